@@ -2,6 +2,8 @@ package main
 
 import (
 	"github.com/gevinzone/basic-go/week9/webook/internal/events"
+	"github.com/gevinzone/basic-go/week9/webook/internal/job"
+	"github.com/gevinzone/basic-go/week9/webook/ioc"
 	"github.com/gin-gonic/gin"
 	"github.com/robfig/cron/v3"
 )
@@ -10,4 +12,8 @@ type App struct {
 	web       *gin.Engine
 	consumers []events.Consumer
 	cron      *cron.Cron
+	// 基于 MySQL 的任务调度，比如说定时发表
+	scheduler *job.Scheduler
+	// 配置变更的时候，由 main 里面唯一的 OnConfigChange 回调调用
+	rankingReloader *ioc.RankingConfigReloader
 }
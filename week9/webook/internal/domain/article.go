@@ -1,6 +1,10 @@
 package domain
 
-import "time"
+import (
+	"github.com/gevinzone/basic-go/week9/webook/pkg/markdownx"
+	"strings"
+	"time"
+)
 
 // Article 可以同时表达线上库和制作库的概念吗？
 // 可以同时表达，作者眼中的 Article 和读者眼中的 Article 吗？
@@ -13,6 +17,21 @@ type Article struct {
 	Status ArticleStatus
 	Ctime  time.Time
 	Utime  time.Time
+	// PublishAt 定时发表的时间，零值代表立刻发表
+	PublishAt time.Time
+	// Tags 帖子的标签，读者可以按照标签来浏览
+	Tags []string
+	// Version 乐观锁的版本号，每次保存都会加一
+	Version int64
+	// DeletedAt 放进回收站的时间，只有 ArticleStatusDeleted 的帖子才有
+	DeletedAt time.Time
+
+	// 下面几个是发表的时候从 Content 渲染出来的，只有线上库的帖子才有
+	// HTML 是过滤过的，可以直接展示
+	HTML        string
+	TOC         []ArticleHeading
+	WordCnt     int
+	ReadMinutes int
 
 	// 做成这样，就应该在 service 或者 repository 里面完成构造
 	// 设计成这个样子，就认为 Interactive 是 Article 的一个属性（值对象）
@@ -20,16 +39,57 @@ type Article struct {
 	//
 }
 
+// Abstract 摘要取去掉 Markdown 标记之后的前几句，
+// 尽量在句子结束的地方截断
 func (a Article) Abstract() string {
-	// 摘要我们取前几句。
-	// 要考虑一个中文问题
-	cs := []rune(a.Content)
-	if len(cs) < 100 {
-		return a.Content
+	return abstract(markdownx.PlainText(a.Content), abstractLen)
+}
+
+// abstractLen 摘要最多多少个字
+const abstractLen = 100
+
+func abstract(text string, limit int) string {
+	// 要考虑一个中文问题，所以按照 rune 来算
+	cs := []rune(strings.Join(strings.Fields(text), " "))
+	if len(cs) <= limit {
+		return string(cs)
+	}
+	cs = cs[:limit]
+	// 从后往前找句子结束的标点，英文的句号后面要跟着空格才算
+	for i := len(cs) - 1; i > 0; i-- {
+		switch cs[i] {
+		case '。', '！', '？', '…', '!', '?':
+			return string(cs[:i+1])
+		case '.':
+			if i+1 < len(cs) && cs[i+1] == ' ' {
+				return string(cs[:i+1])
+			}
+		}
 	}
-	// 英文怎么截取一个完整的单词，我的看法是……不需要纠结，就截断拉到
-	// 词组、介词，往后找标点符号
-	return string(cs[:100])
+	// 一句话都没有结束，只能硬截断了，加上省略号之后也不超过 limit
+	return string(cs[:limit-1]) + "…"
+}
+
+// ArticleHeading 帖子目录里面的一项，Id 是渲染之后标题上的锚点
+type ArticleHeading struct {
+	Level int
+	Text  string
+	Id    string
+}
+
+// Cursor 以这篇帖子作为上一页的最后一篇，得到下一页的游标
+func (a Article) Cursor() ArticleCursor {
+	return ArticleCursor{Utime: a.Utime, Id: a.Id}
+}
+
+// ArticleCursor 帖子列表按照 (utime, id) 倒序翻页的游标，零值代表第一页
+type ArticleCursor struct {
+	Utime time.Time
+	Id    int64
+}
+
+func (c ArticleCursor) IsZero() bool {
+	return c.Id == 0 && c.Utime.IsZero()
 }
 
 type ArticleStatus uint8
@@ -40,6 +100,10 @@ const (
 	ArticleStatusUnpublished
 	ArticleStatusPublished
 	ArticleStatusPrivate
+	// ArticleStatusScheduled 等待定时发表
+	ArticleStatusScheduled
+	// ArticleStatusDeleted 在回收站里面，过了保留期限之后会被彻底删除
+	ArticleStatusDeleted
 )
 
 func (s ArticleStatus) ToUint8() uint8 {
@@ -58,6 +122,10 @@ func (s ArticleStatus) String() string {
 		return "unpublished"
 	case ArticleStatusPublished:
 		return "published"
+	case ArticleStatusScheduled:
+		return "scheduled"
+	case ArticleStatusDeleted:
+		return "deleted"
 	default:
 		return "unknown"
 	}
@@ -0,0 +1,26 @@
+package domain
+
+import (
+	"github.com/gevinzone/basic-go/week9/webook/pkg/diffx"
+	"time"
+)
+
+// ArticleRevision 帖子的历史版本。每一次保存和发表都会生成一个，生成之后就不会再修改
+type ArticleRevision struct {
+	Id        int64
+	ArticleId int64
+	Title     string
+	Content   string
+	Author    Author
+	// 生成这个版本的时候，帖子处于什么状态，用来区分是保存还是发表
+	Status ArticleStatus
+	Ctime  time.Time
+}
+
+// ArticleRevisionDiff 两个版本之间的差异
+type ArticleRevisionDiff struct {
+	From    ArticleRevision
+	To      ArticleRevision
+	Title   []diffx.Line
+	Content []diffx.Line
+}
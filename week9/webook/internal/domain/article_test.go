@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestArticle_Abstract(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{
+			name:    "短内容去掉标记",
+			content: "# 标题\n\n一段 **粗体**",
+			want:    "标题 一段 粗体",
+		},
+		{
+			name:    "在句子结束的地方截断",
+			content: strings.Repeat("字", 60) + "。" + strings.Repeat("字", 60),
+			want:    strings.Repeat("字", 60) + "。",
+		},
+		{
+			name:    "英文句号后面要有空格",
+			content: "Version 1.2 is out. " + strings.Repeat("word ", 30),
+			want:    "Version 1.2 is out.",
+		},
+		{
+			name:    "没有句子结束就硬截断",
+			content: strings.Repeat("字", 120),
+			want:    strings.Repeat("字", 99) + "…",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Article{Content: tc.content}.Abstract())
+		})
+	}
+}
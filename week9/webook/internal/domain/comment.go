@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// Comment 评论，挂在 biz + bizId 表示的资源下面。
+// 一级评论的 RootId 和 ParentId 都是 0，
+// 回复的 RootId 是所在的一级评论，ParentId 是直接回复的那条评论
+type Comment struct {
+	Id    int64
+	Biz   string
+	BizId int64
+	// 评论的人
+	Commentator User
+	Content     string
+
+	RootId   int64
+	ParentId int64
+	// 一级评论下面一共有多少条回复，用来按照热度排序
+	ReplyCnt int64
+
+	Ctime time.Time
+	Utime time.Time
+}
+
+func (c Comment) IsRoot() bool {
+	return c.RootId == 0
+}
+
+// CommentOrder 一级评论的排序方式
+type CommentOrder uint8
+
+const (
+	// CommentOrderTime 最新的在前面
+	CommentOrderTime CommentOrder = iota
+	// CommentOrderHot 回复最多的在前面
+	CommentOrderHot
+)
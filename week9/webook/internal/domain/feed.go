@@ -0,0 +1,24 @@
+package domain
+
+import "time"
+
+// FeedItem 关注的人发表的帖子。
+// Time 是发表的时间，Article 只有在查询 feed 流的时候才会填
+type FeedItem struct {
+	Aid     int64
+	Time    time.Time
+	Article Article
+}
+
+// Cursor 以这一条作为上一页的最后一条，得到下一页的游标
+func (f FeedItem) Cursor() UserBizCursor {
+	return UserBizCursor{Time: f.Time, Id: f.Aid}
+}
+
+// After 按照 (time, aid) 倒序排列的时候，f 是不是排在游标 c 后面
+func (f FeedItem) After(c UserBizCursor) bool {
+	if c.IsZero() {
+		return true
+	}
+	return f.Time.Before(c.Time) || (f.Time.Equal(c.Time) && f.Aid < c.Id)
+}
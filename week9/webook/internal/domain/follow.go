@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// FollowRelation 关注关系，Follower 关注了 Followee。
+// Time 是关注的时间，取消之后重新关注的话是最后一次关注的时间
+type FollowRelation struct {
+	Id       int64
+	Follower int64
+	Followee int64
+	Time     time.Time
+	// Mutual 对方是不是也关注了自己，只有在列表里面才会填
+	Mutual bool
+}
+
+// Cursor 以这条关系作为上一页的最后一条，得到下一页的游标
+func (r FollowRelation) Cursor() UserBizCursor {
+	return UserBizCursor{Time: r.Time, Id: r.Id}
+}
+
+// FollowStatics 用户的关注数和粉丝数
+type FollowStatics struct {
+	// 粉丝数
+	Followers int64
+	// 关注了多少人
+	Followees int64
+}
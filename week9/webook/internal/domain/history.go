@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+// ReadHistory 阅读记录，同一个用户同一个资源只有一条，记录最后一次阅读的时间
+type ReadHistory struct {
+	Uid      int64
+	Biz      string
+	BizId    int64
+	ReadTime time.Time
+}
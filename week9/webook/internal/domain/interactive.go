@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 // Interactive 这个是总体交互的计数
 type Interactive struct {
 	Biz   string
@@ -8,10 +10,77 @@ type Interactive struct {
 	ReadCnt    int64 `json:"read_cnt"`
 	LikeCnt    int64 `json:"like_cnt"`
 	CollectCnt int64 `json:"collect_cnt"`
+	CommentCnt int64 `json:"comment_cnt"`
+	// 点赞之外的表态的计数
+	LoveCnt       int64 `json:"love_cnt"`
+	LaughCnt      int64 `json:"laugh_cnt"`
+	InsightfulCnt int64 `json:"insightful_cnt"`
+	// Uv 去重之后的读者数，ReadCnt 是每次打开都算
+	Uv int64 `json:"uv"`
 	// 这个是当下这个资源，你有没有点赞或者收集
 	// 你也可以考虑把这两个字段分离出去，作为一个单独的结构体
 	Liked     bool `json:"liked"`
 	Collected bool `json:"collected"`
+	// 你的表态，没有表态是空字符串
+	Reaction ReactionType `json:"reaction"`
+}
+
+// Reactions 每一种表态的计数
+func (i Interactive) Reactions() map[ReactionType]int64 {
+	return map[ReactionType]int64{
+		ReactionLike:       i.LikeCnt,
+		ReactionLove:       i.LoveCnt,
+		ReactionLaugh:      i.LaughCnt,
+		ReactionInsightful: i.InsightfulCnt,
+	}
+}
+
+// ReactionType 表态的类型，点赞也是一种表态
+type ReactionType string
+
+const (
+	ReactionLike       ReactionType = "like"
+	ReactionLove       ReactionType = "love"
+	ReactionLaugh      ReactionType = "laugh"
+	ReactionInsightful ReactionType = "insightful"
+)
+
+func (r ReactionType) Valid() bool {
+	switch r {
+	case ReactionLike, ReactionLove, ReactionLaugh, ReactionInsightful:
+		return true
+	default:
+		return false
+	}
+}
+
+// CntDrift 计数和点赞、收藏的记录对不上，Actual 开头的是按照记录重新算出来的
+type CntDrift struct {
+	Biz           string
+	BizId         int64
+	LikeCnt       int64
+	ActualLike    int64
+	CollectCnt    int64
+	ActualCollect int64
+}
+
+// CntReconcileBatch 一批计数的校对结果
+type CntReconcileBatch struct {
+	// 下一批从这个 id 之后开始
+	LastId int64
+	// 这一批检查了多少条，0 说明已经到头了
+	Cnt    int
+	Drifts []CntDrift
+}
+
+// CntReconcileReport 一次完整的校对的结果
+type CntReconcileReport struct {
+	Checked int
+	// 改掉了多少条计数
+	Fixed int
+	// 所有被改掉的计数偏差的绝对值之和
+	LikeDrift    int64
+	CollectDrift int64
 }
 
 type Self struct {
@@ -19,10 +88,62 @@ type Self struct {
 	Collected bool `json:"collected"`
 }
 
+// Collection 收藏夹。Id 为 0 的是默认收藏夹，每个用户都有，不需要创建
 type Collection struct {
+	Id    int64
 	Name  string
 	Uid   int64
-	Items []Resource
+	Ctime time.Time
+	Utime time.Time
+}
+
+// CollectionItem 收藏夹里面的东西，同一个资源一个用户只能收藏到一个收藏夹
+type CollectionItem struct {
+	Cid   int64
+	Biz   string
+	BizId int64
+	Uid   int64
+	Ctime time.Time
+}
+
+// UserBizRecord 用户点赞或者收藏过的资源，不区分 biz。
+// Time 是点赞或者收藏的时间
+type UserBizRecord struct {
+	Id    int64
+	Biz   string
+	BizId int64
+	// 收藏夹 ID，点赞的记录没有这个字段
+	Cid  int64
+	Time time.Time
+}
+
+func (r UserBizRecord) Resource() Resource {
+	return Resource{Biz: r.Biz, BizId: r.BizId}
+}
+
+// Cursor 以这条记录作为上一页的最后一条，得到下一页的游标
+func (r UserBizRecord) Cursor() UserBizCursor {
+	return UserBizCursor{Time: r.Time, Id: r.Id}
+}
+
+// UserBizCursor 按照 (time, id) 倒序翻页的游标，零值代表第一页
+type UserBizCursor struct {
+	Time time.Time
+	Id   int64
+}
+
+func (c UserBizCursor) IsZero() bool {
+	return c.Id == 0 && c.Time.IsZero()
+}
+
+// ResourceSummary 在点赞、收藏之类的列表里面展示的资源摘要，
+// 每一种 biz 自己决定怎么填
+type ResourceSummary struct {
+	Resource
+	Title    string
+	Abstract string
+	Author   Author
+	Utime    time.Time
 }
 
 // max(发送者总速率/单一分区写入速率, 发送者总速率/单一消费者速率) + buffer
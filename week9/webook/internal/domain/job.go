@@ -15,6 +15,8 @@ type Job struct {
 	// 通用的任务的抽象，我们也不知道任务的具体细节，所以就搞一个 Cfg
 	// 具体任务设置具体的值
 	Cfg string
+	// Version 抢占之后的版本，释放的时候用来确认任务还在自己手上
+	Version int
 
 	CancelFunc func() error
 }
@@ -26,6 +28,13 @@ func (j Job) NextTime() time.Time {
 	// 你怎么算？要根据 cron 表达式来算
 	// 可以做成包变量，因为基本不可能变
 
-	s, _ := parser.Parse(j.Cron)
+	// 没有 cron 表达式的是只执行一次的任务，比如说定时发表，执行完就没有下一次了
+	if j.Cron == "" {
+		return time.Time{}
+	}
+	s, err := parser.Parse(j.Cron)
+	if err != nil {
+		return time.Time{}
+	}
 	return s.Next(time.Now())
 }
@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+type NotificationType string
+
+const (
+	NotificationLike    NotificationType = "like"
+	NotificationCollect NotificationType = "collect"
+	NotificationComment NotificationType = "comment"
+	NotificationFollow  NotificationType = "follow"
+)
+
+// NotificationEvent 一次需要通知别人的互动
+type NotificationEvent struct {
+	Type  NotificationType
+	Biz   string
+	BizId int64
+	// Actor 谁点的赞、收的藏、发的评论、关注了别人
+	Actor int64
+	// Receiver 通知谁，为 0 的时候通知资源的作者
+	Receiver int64
+	Time     time.Time
+}
+
+// Notification 同一个资源上同一种互动，没有读之前都聚合在一条通知里面，
+// 也就是 "X 等 13 人赞了你的文章"
+type Notification struct {
+	Id int64
+	// Uid 收到通知的人
+	Uid   int64
+	Type  NotificationType
+	Biz   string
+	BizId int64
+	// Actors 最近的几个人，最新的在前面
+	Actors []int64
+	// ActorCnt 一共多少人
+	ActorCnt int64
+	Read     bool
+	// Time 最后一次互动的时间
+	Time time.Time
+}
+
+// Cursor 以这条通知作为上一页的最后一条，得到下一页的游标
+func (n Notification) Cursor() UserBizCursor {
+	return UserBizCursor{Time: n.Time, Id: n.Id}
+}
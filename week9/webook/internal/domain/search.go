@@ -0,0 +1,17 @@
+package domain
+
+// ArticleSearchHit 一条帖子的搜索结果
+type ArticleSearchHit struct {
+	Article Article
+	// 相关度，越大越相关
+	Score float64
+	// 高亮之后的标题和内容片段，已经做过 HTML 转义
+	TitleHighlight   string
+	ContentHighlight string
+}
+
+type ArticleSearchResult struct {
+	// 命中的总数，用来分页
+	Total int64
+	Hits  []ArticleSearchHit
+}
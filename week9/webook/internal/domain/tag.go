@@ -0,0 +1,8 @@
+package domain
+
+// Tag 标签，用在标签云上
+type Tag struct {
+	Name string
+	// 这个标签下面已经发表了的帖子的数量
+	ArticleCnt int64
+}
@@ -19,6 +19,9 @@ const (
 const (
 	ArticleInvalidInput        = 402001
 	ArticleInternalServerError = 502001
+	// ArticleVersionConflict 保存的时候帖子已经被别的地方修改过了，
+	// 前端要提示作者刷新或者合并
+	ArticleVersionConflict = 402002
 )
 
 var (
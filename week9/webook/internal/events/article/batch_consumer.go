@@ -13,10 +13,14 @@ type InteractiveReadEventBatchConsumer struct {
 	client sarama.Client
 	repo   repository.InteractiveRepository
 	l      logger.LoggerV1
+	store  saramax.IdempotencyStore
 }
 
-func NewInteractiveReadEventBatchConsumer(client sarama.Client, repo repository.InteractiveRepository, l logger.LoggerV1) *InteractiveReadEventBatchConsumer {
-	return &InteractiveReadEventBatchConsumer{client: client, repo: repo, l: l}
+func NewInteractiveReadEventBatchConsumer(client sarama.Client,
+	repo repository.InteractiveRepository,
+	l logger.LoggerV1,
+	store saramax.IdempotencyStore) *InteractiveReadEventBatchConsumer {
+	return &InteractiveReadEventBatchConsumer{client: client, repo: repo, l: l, store: store}
 }
 
 func (r *InteractiveReadEventBatchConsumer) Start() error {
@@ -28,7 +32,8 @@ func (r *InteractiveReadEventBatchConsumer) Start() error {
 	go func() {
 		err := cg.Consume(context.Background(),
 			[]string{"read_article"},
-			saramax.NewBatchHandler[ReadEvent](r.l, r.Consume))
+			saramax.NewBatchHandler[ReadEvent](r.l, r.Consume).
+				WithIdempotency(r.store, readEventKey("interactive")))
 		if err != nil {
 			r.l.Error("退出了消费循环异常", logger.Error(err))
 		}
@@ -36,7 +41,7 @@ func (r *InteractiveReadEventBatchConsumer) Start() error {
 	return err
 }
 
-// Consume 这个不是幂等的
+// Consume 本身不是幂等的，靠 BatchHandler 按照 EventId 去重
 func (r *InteractiveReadEventBatchConsumer) Consume(msg []*sarama.ConsumerMessage, ts []ReadEvent) error {
 	ids := make([]int64, 0, len(ts))
 	bizs := make([]string, 0, len(ts))
@@ -52,5 +57,6 @@ func (r *InteractiveReadEventBatchConsumer) Consume(msg []*sarama.ConsumerMessag
 			logger.Field{Key: "ids", Value: ids},
 			logger.Error(err))
 	}
-	return nil
+	// 返回错误，BatchHandler 才会释放这一批的幂等 key
+	return err
 }
@@ -13,16 +13,19 @@ type InteractiveReadEventConsumer struct {
 	client sarama.Client
 	repo   repository.InteractiveRepository
 	l      logger.LoggerV1
+	store  saramax.IdempotencyStore
 }
 
 func NewInteractiveReadEventConsumer(
 	client sarama.Client,
 	l logger.LoggerV1,
-	repo repository.InteractiveRepository) *InteractiveReadEventConsumer {
+	repo repository.InteractiveRepository,
+	store saramax.IdempotencyStore) *InteractiveReadEventConsumer {
 	return &InteractiveReadEventConsumer{
 		client: client,
 		l:      l,
 		repo:   repo,
+		store:  store,
 	}
 }
 
@@ -36,7 +39,8 @@ func (r *InteractiveReadEventConsumer) Start() error {
 	go func() {
 		err := cg.Consume(context.Background(),
 			[]string{"read_article"},
-			saramax.NewHandler[ReadEvent](r.l, r.Consume))
+			saramax.NewHandler[ReadEvent](r.l, r.Consume).
+				WithIdempotency(r.store, readEventKey("interactive")))
 		if err != nil {
 			r.l.Error("退出了消费循环异常", logger.Error(err))
 		}
@@ -44,7 +48,7 @@ func (r *InteractiveReadEventConsumer) Start() error {
 	return err
 }
 
-// Consume 这个不是幂等的
+// Consume 本身不是幂等的，靠 Handler 按照 EventId 去重
 func (r *InteractiveReadEventConsumer) Consume(msg *sarama.ConsumerMessage, t ReadEvent) error {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
@@ -3,22 +3,25 @@ package article
 import (
 	"context"
 	"github.com/IBM/sarama"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
 	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
 	"github.com/gevinzone/basic-go/week9/webook/pkg/saramax"
 	"time"
 )
 
+// HistoryReadEventConsumer 根据阅读事件记录阅读历史。
+// 和阅读计数是两个独立的消费者组，互不影响进度
 type HistoryReadEventConsumer struct {
 	client sarama.Client
-	repo   repository.InteractiveRepository
+	repo   repository.HistoryRepository
 	l      logger.LoggerV1
 }
 
 func NewHistoryReadEventConsumer(
 	client sarama.Client,
 	l logger.LoggerV1,
-	repo repository.InteractiveRepository) *HistoryReadEventConsumer {
+	repo repository.HistoryRepository) *HistoryReadEventConsumer {
 	return &HistoryReadEventConsumer{
 		client: client,
 		l:      l,
@@ -27,7 +30,7 @@ func NewHistoryReadEventConsumer(
 }
 
 func (r *HistoryReadEventConsumer) Start() error {
-	cg, err := sarama.NewConsumerGroupFromClient("interactive",
+	cg, err := sarama.NewConsumerGroupFromClient("history",
 		r.client)
 	if err != nil {
 		return err
@@ -43,9 +46,22 @@ func (r *HistoryReadEventConsumer) Start() error {
 	return err
 }
 
-// Consume 这个不是幂等的
+// Consume 是幂等的，重复消费只会更新一下阅读时间
 func (r *HistoryReadEventConsumer) Consume(msg *sarama.ConsumerMessage, t ReadEvent) error {
+	if t.Uid <= 0 {
+		// 没有登录的用户没有阅读记录
+		return nil
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
-	return r.repo.AddRecord(ctx, t.Aid, t.Uid)
+	readTime := msg.Timestamp
+	if readTime.IsZero() {
+		readTime = time.Now()
+	}
+	return r.repo.AddRecord(ctx, domain.ReadHistory{
+		Uid:      t.Uid,
+		Biz:      "article",
+		BizId:    t.Aid,
+		ReadTime: readTime,
+	})
 }
@@ -0,0 +1,35 @@
+package article
+
+import (
+	"github.com/IBM/sarama"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	repomocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestHistoryReadEventConsumer_Consume(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := repomocks.NewMockHistoryRepository(ctrl)
+	c := NewHistoryReadEventConsumer(nil, &logger.NopLogger{}, repo)
+
+	readTime := time.UnixMilli(1700000000000)
+	repo.EXPECT().AddRecord(gomock.Any(), domain.ReadHistory{
+		Uid:      123,
+		Biz:      "article",
+		BizId:    1,
+		ReadTime: readTime,
+	}).Return(nil)
+	err := c.Consume(&sarama.ConsumerMessage{Timestamp: readTime},
+		ReadEvent{Uid: 123, Aid: 1})
+	assert.NoError(t, err)
+
+	// 没有登录的用户不记录
+	err = c.Consume(&sarama.ConsumerMessage{Timestamp: readTime},
+		ReadEvent{Aid: 1})
+	assert.NoError(t, err)
+}
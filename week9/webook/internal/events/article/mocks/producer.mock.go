@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: producer.go
+//
+// Generated by this command:
+//
+//	mockgen -source=producer.go -package=evtmocks -destination=mocks/producer.mock.go
+//
+// Package evtmocks is a generated GoMock package.
+package evtmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	article "github.com/gevinzone/basic-go/week9/webook/internal/events/article"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockProducer is a mock of Producer interface.
+type MockProducer struct {
+	ctrl     *gomock.Controller
+	recorder *MockProducerMockRecorder
+}
+
+// MockProducerMockRecorder is the mock recorder for MockProducer.
+type MockProducerMockRecorder struct {
+	mock *MockProducer
+}
+
+// NewMockProducer creates a new mock instance.
+func NewMockProducer(ctrl *gomock.Controller) *MockProducer {
+	mock := &MockProducer{ctrl: ctrl}
+	mock.recorder = &MockProducerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProducer) EXPECT() *MockProducerMockRecorder {
+	return m.recorder
+}
+
+// ProducePublishEvent mocks base method.
+func (m *MockProducer) ProducePublishEvent(ctx context.Context, evt article.PublishEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProducePublishEvent", ctx, evt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProducePublishEvent indicates an expected call of ProducePublishEvent.
+func (mr *MockProducerMockRecorder) ProducePublishEvent(ctx, evt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProducePublishEvent", reflect.TypeOf((*MockProducer)(nil).ProducePublishEvent), ctx, evt)
+}
+
+// ProduceReadEvent mocks base method.
+func (m *MockProducer) ProduceReadEvent(ctx context.Context, evt article.ReadEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProduceReadEvent", ctx, evt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProduceReadEvent indicates an expected call of ProduceReadEvent.
+func (mr *MockProducerMockRecorder) ProduceReadEvent(ctx, evt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProduceReadEvent", reflect.TypeOf((*MockProducer)(nil).ProduceReadEvent), ctx, evt)
+}
+
+// ProduceReadEventV1 mocks base method.
+func (m *MockProducer) ProduceReadEventV1(ctx context.Context, v1 article.ReadEventV1) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ProduceReadEventV1", ctx, v1)
+}
+
+// ProduceReadEventV1 indicates an expected call of ProduceReadEventV1.
+func (mr *MockProducerMockRecorder) ProduceReadEventV1(ctx, v1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProduceReadEventV1", reflect.TypeOf((*MockProducer)(nil).ProduceReadEventV1), ctx, v1)
+}
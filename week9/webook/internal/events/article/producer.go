@@ -4,11 +4,20 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/IBM/sarama"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/saramax"
+	"github.com/google/uuid"
+	"strconv"
+	"time"
 )
 
+const topicPublishArticle = "publish_article"
+
+//go:generate mockgen -source=./producer.go -package=evtmocks -destination=mocks/producer.mock.go Producer
 type Producer interface {
 	ProduceReadEvent(ctx context.Context, evt ReadEvent) error
 	ProduceReadEventV1(ctx context.Context, v1 ReadEventV1)
+	// ProducePublishEvent 发表和撤回帖子
+	ProducePublishEvent(ctx context.Context, evt PublishEvent) error
 }
 
 type KafkaProducer struct {
@@ -23,6 +32,13 @@ func (k *KafkaProducer) ProduceReadEventV1(ctx context.Context, v1 ReadEventV1)
 // ProduceReadEvent 如果你有复杂的重试逻辑，就用装饰器
 // 你认为你的重试逻辑很简单，你就放这里
 func (k *KafkaProducer) ProduceReadEvent(ctx context.Context, evt ReadEvent) error {
+	if evt.EventId == "" {
+		// 消费者靠这个去重
+		evt.EventId = uuid.New().String()
+	}
+	if evt.Ctime == 0 {
+		evt.Ctime = time.Now().UnixMilli()
+	}
 	data, err := json.Marshal(evt)
 	if err != nil {
 		return err
@@ -34,6 +50,23 @@ func (k *KafkaProducer) ProduceReadEvent(ctx context.Context, evt ReadEvent) err
 	return err
 }
 
+// ProducePublishEvent 用帖子 ID 做 key，同一篇帖子的发表和撤回是有序的
+func (k *KafkaProducer) ProducePublishEvent(ctx context.Context, evt PublishEvent) error {
+	if evt.EventId == "" {
+		evt.EventId = uuid.New().String()
+	}
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	_, _, err = k.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: topicPublishArticle,
+		Key:   sarama.StringEncoder(strconv.FormatInt(evt.Aid, 10)),
+		Value: sarama.ByteEncoder(data),
+	})
+	return err
+}
+
 func NewKafkaProducer(pc sarama.SyncProducer) Producer {
 	return &KafkaProducer{
 		producer: pc,
@@ -41,11 +74,42 @@ func NewKafkaProducer(pc sarama.SyncProducer) Producer {
 }
 
 type ReadEvent struct {
+	// EventId 每一个事件唯一，重复投递的时候不变，
+	// 发送的时候没有设置就由 KafkaProducer 生成
+	EventId string
+	Uid     int64
+	Aid     int64
+	// Ctime 阅读的时间，毫秒数，消息积压了也按照这个算 UV。
+	// 发送的时候没有设置就由 KafkaProducer 填上当前时间
+	Ctime int64
+}
+
+// readEventKey 按照 EventId 去重，不同的消费者组要区分开
+func readEventKey(group string) saramax.KeyFunc[ReadEvent] {
+	return func(msg *sarama.ConsumerMessage, t ReadEvent) string {
+		if t.EventId == "" {
+			// 老版本的生产者发的消息没有 EventId，没办法去重
+			return ""
+		}
+		return group + ":" + t.EventId
+	}
+}
+
+// PublishEvent 帖子发表了，或者被作者撤回了
+type PublishEvent struct {
+	EventId string
+	Aid     int64
+	// 作者
 	Uid int64
-	Aid int64
+	// Publish 为 true 是发表，false 是撤回
+	Publish bool
+	// 发表的时候是第一次发表的时间，重新发表也不变，毫秒数
+	Ctime int64
 }
 
 type ReadEventV1 struct {
-	Uids []int64
-	Aids []int64
+	// EventId 和 ReadEvent 的一样，消费者靠这个去重
+	EventId string
+	Uids    []int64
+	Aids    []int64
 }
@@ -0,0 +1,79 @@
+package article
+
+import (
+	"context"
+	"github.com/IBM/sarama"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/saramax"
+	"time"
+)
+
+// InteractiveUVEventBatchConsumer 用阅读事件里面的 Uid 算 UV。
+// 和阅读数分开消费，HyperLogLog 重复添加没有影响，所以不需要去重
+type InteractiveUVEventBatchConsumer struct {
+	client sarama.Client
+	repo   repository.InteractiveRepository
+	l      logger.LoggerV1
+}
+
+func NewInteractiveUVEventBatchConsumer(client sarama.Client,
+	repo repository.InteractiveRepository,
+	l logger.LoggerV1) *InteractiveUVEventBatchConsumer {
+	return &InteractiveUVEventBatchConsumer{client: client, repo: repo, l: l}
+}
+
+func (r *InteractiveUVEventBatchConsumer) Start() error {
+	cg, err := sarama.NewConsumerGroupFromClient("interactive_uv",
+		r.client)
+	if err != nil {
+		return err
+	}
+	go func() {
+		err := cg.Consume(context.Background(),
+			[]string{"read_article"},
+			saramax.NewBatchHandler[ReadEvent](r.l, r.Consume))
+		if err != nil {
+			r.l.Error("退出了消费循环异常", logger.Error(err))
+		}
+	}()
+	return err
+}
+
+func (r *InteractiveUVEventBatchConsumer) Consume(msg []*sarama.ConsumerMessage, ts []ReadEvent) error {
+	bizs := make([]string, 0, len(ts))
+	ids := make([]int64, 0, len(ts))
+	uids := make([]int64, 0, len(ts))
+	times := make([]time.Time, 0, len(ts))
+	for i, evt := range ts {
+		// 没有登录的读者不算
+		if evt.Uid <= 0 {
+			continue
+		}
+		bizs = append(bizs, "article")
+		ids = append(ids, evt.Aid)
+		uids = append(uids, evt.Uid)
+		times = append(times, readTime(msg[i], evt))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := r.repo.AddUV(ctx, bizs, ids, uids, times)
+	if err != nil {
+		r.l.Error("批量记录读者失败",
+			logger.Field{Key: "ids", Value: ids},
+			logger.Error(err))
+	}
+	return err
+}
+
+// readTime 按照阅读的时间算 UV，消息积压到第二天也算在前一天。
+// 老版本的生产者没有带 Ctime，用 Kafka 消息的时间
+func readTime(msg *sarama.ConsumerMessage, evt ReadEvent) time.Time {
+	if evt.Ctime > 0 {
+		return time.UnixMilli(evt.Ctime)
+	}
+	if !msg.Timestamp.IsZero() {
+		return msg.Timestamp
+	}
+	return time.Now()
+}
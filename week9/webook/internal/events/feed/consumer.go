@@ -0,0 +1,55 @@
+package feed
+
+import (
+	"context"
+	"github.com/IBM/sarama"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/article"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/saramax"
+	"time"
+)
+
+// PublishEventConsumer 根据帖子的发表和撤回更新关注者的 feed 流。
+// 推到收件箱和从收件箱删除都是幂等的，所以不需要去重
+type PublishEventConsumer struct {
+	client sarama.Client
+	svc    service.FeedService
+	l      logger.LoggerV1
+}
+
+func NewPublishEventConsumer(client sarama.Client,
+	svc service.FeedService,
+	l logger.LoggerV1) *PublishEventConsumer {
+	return &PublishEventConsumer{
+		client: client,
+		svc:    svc,
+		l:      l,
+	}
+}
+
+func (c *PublishEventConsumer) Start() error {
+	cg, err := sarama.NewConsumerGroupFromClient("feed", c.client)
+	if err != nil {
+		return err
+	}
+	go func() {
+		err := cg.Consume(context.Background(),
+			[]string{"publish_article"},
+			saramax.NewHandler[article.PublishEvent](c.l, c.Consume))
+		if err != nil {
+			c.l.Error("退出了消费循环异常", logger.Error(err))
+		}
+	}()
+	return err
+}
+
+func (c *PublishEventConsumer) Consume(msg *sarama.ConsumerMessage, evt article.PublishEvent) error {
+	// 粉丝多的时候要推很多个收件箱，超时时间长一点
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	if evt.Publish {
+		return c.svc.Publish(ctx, evt.Uid, evt.Aid, time.UnixMilli(evt.Ctime))
+	}
+	return c.svc.Withdraw(ctx, evt.Uid, evt.Aid)
+}
@@ -0,0 +1,57 @@
+package follow
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+	"strconv"
+)
+
+const topicFollowEvent = "follow_event"
+
+//go:generate mockgen -source=./producer.go -package=evtmocks -destination=mocks/producer.mock.go Producer
+type Producer interface {
+	ProduceFollowEvent(ctx context.Context, evt FollowEvent) error
+}
+
+type KafkaProducer struct {
+	producer sarama.SyncProducer
+}
+
+func NewKafkaProducer(pc sarama.SyncProducer) Producer {
+	return &KafkaProducer{
+		producer: pc,
+	}
+}
+
+// ProduceFollowEvent 用 followee 做 key，同一个人收到的关注事件是有序的
+func (k *KafkaProducer) ProduceFollowEvent(ctx context.Context, evt FollowEvent) error {
+	if evt.EventId == "" {
+		// 消费者靠这个去重
+		evt.EventId = uuid.New().String()
+	}
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	_, _, err = k.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: topicFollowEvent,
+		Key:   sarama.StringEncoder(strconv.FormatInt(evt.Followee, 10)),
+		Value: sarama.ByteEncoder(data),
+	})
+	return err
+}
+
+// FollowEvent 关注或者取消关注，只有关系真的变了才会发
+type FollowEvent struct {
+	// EventId 每一个事件唯一，重复投递的时候不变，
+	// 发送的时候没有设置就由 KafkaProducer 生成
+	EventId  string
+	Follower int64
+	Followee int64
+	// Follow 为 true 是关注，false 是取消关注
+	Follow bool
+	// 毫秒数
+	Ctime int64
+}
@@ -0,0 +1,54 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: producer.go
+//
+// Generated by this command:
+//
+//	mockgen -source=producer.go -package=evtmocks -destination=mocks/producer.mock.go
+//
+// Package evtmocks is a generated GoMock package.
+package evtmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	interactive "github.com/gevinzone/basic-go/week9/webook/internal/events/interactive"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockProducer is a mock of Producer interface.
+type MockProducer struct {
+	ctrl     *gomock.Controller
+	recorder *MockProducerMockRecorder
+}
+
+// MockProducerMockRecorder is the mock recorder for MockProducer.
+type MockProducerMockRecorder struct {
+	mock *MockProducer
+}
+
+// NewMockProducer creates a new mock instance.
+func NewMockProducer(ctrl *gomock.Controller) *MockProducer {
+	mock := &MockProducer{ctrl: ctrl}
+	mock.recorder = &MockProducerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProducer) EXPECT() *MockProducerMockRecorder {
+	return m.recorder
+}
+
+// ProduceInteractiveEvent mocks base method.
+func (m *MockProducer) ProduceInteractiveEvent(ctx context.Context, evt interactive.InteractiveEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProduceInteractiveEvent", ctx, evt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProduceInteractiveEvent indicates an expected call of ProduceInteractiveEvent.
+func (mr *MockProducerMockRecorder) ProduceInteractiveEvent(ctx, evt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProduceInteractiveEvent", reflect.TypeOf((*MockProducer)(nil).ProduceInteractiveEvent), ctx, evt)
+}
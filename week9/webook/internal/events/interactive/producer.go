@@ -0,0 +1,68 @@
+package interactive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/IBM/sarama"
+	"github.com/google/uuid"
+)
+
+const topicInteractiveEvent = "interactive_event"
+
+const (
+	TypeLike    = "like"
+	TypeCollect = "collect"
+	TypeComment = "comment"
+)
+
+//go:generate mockgen -source=./producer.go -package=evtmocks -destination=mocks/producer.mock.go Producer
+type Producer interface {
+	ProduceInteractiveEvent(ctx context.Context, evt InteractiveEvent) error
+}
+
+type KafkaProducer struct {
+	producer sarama.SyncProducer
+}
+
+func NewKafkaProducer(pc sarama.SyncProducer) Producer {
+	return &KafkaProducer{
+		producer: pc,
+	}
+}
+
+// ProduceInteractiveEvent 用 biz 和 bizId 做 key，同一个资源上的互动是有序的
+func (k *KafkaProducer) ProduceInteractiveEvent(ctx context.Context, evt InteractiveEvent) error {
+	if evt.EventId == "" {
+		// 消费者靠这个去重
+		evt.EventId = uuid.New().String()
+	}
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	_, _, err = k.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: topicInteractiveEvent,
+		Key:   sarama.StringEncoder(fmt.Sprintf("%s:%d", evt.Biz, evt.BizId)),
+		Value: sarama.ByteEncoder(data),
+	})
+	return err
+}
+
+// InteractiveEvent 点赞、收藏和评论，取消的时候不发
+type InteractiveEvent struct {
+	// EventId 每一个事件唯一，重复投递的时候不变，
+	// 发送的时候没有设置就由 KafkaProducer 生成
+	EventId string
+	// Type 是 TypeLike、TypeCollect 或者 TypeComment
+	Type  string
+	Biz   string
+	BizId int64
+	// Uid 谁点的赞、收的藏、发的评论
+	Uid int64
+	// Receiver 评论的时候是资源的作者或者被回复的人。
+	// 点赞和收藏的时候是 0，由消费者去找资源的作者
+	Receiver int64
+	// 毫秒数
+	Ctime int64
+}
@@ -0,0 +1,131 @@
+package notification
+
+import (
+	"context"
+	"github.com/IBM/sarama"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/follow"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/interactive"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/saramax"
+	"time"
+)
+
+// InteractiveEventConsumer 点赞、收藏和评论之后通知对方
+type InteractiveEventConsumer struct {
+	client sarama.Client
+	svc    service.NotificationService
+	l      logger.LoggerV1
+	store  saramax.IdempotencyStore
+}
+
+func NewInteractiveEventConsumer(client sarama.Client,
+	svc service.NotificationService,
+	l logger.LoggerV1,
+	store saramax.IdempotencyStore) *InteractiveEventConsumer {
+	return &InteractiveEventConsumer{
+		client: client,
+		svc:    svc,
+		l:      l,
+		store:  store,
+	}
+}
+
+func (c *InteractiveEventConsumer) Start() error {
+	cg, err := sarama.NewConsumerGroupFromClient("notification_interactive", c.client)
+	if err != nil {
+		return err
+	}
+	go func() {
+		err := cg.Consume(context.Background(),
+			[]string{"interactive_event"},
+			saramax.NewHandler[interactive.InteractiveEvent](c.l, c.Consume).
+				WithIdempotency(c.store, func(msg *sarama.ConsumerMessage, evt interactive.InteractiveEvent) string {
+					return eventKey("notification_interactive", evt.EventId)
+				}))
+		if err != nil {
+			c.l.Error("退出了消费循环异常", logger.Error(err))
+		}
+	}()
+	return err
+}
+
+// Consume 同一个人重复的互动只算一次，Handler 按照 EventId 去重，处理成功之后才算消费过了
+func (c *InteractiveEventConsumer) Consume(msg *sarama.ConsumerMessage, evt interactive.InteractiveEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return c.svc.Notify(ctx, domain.NotificationEvent{
+		Type:     domain.NotificationType(evt.Type),
+		Biz:      evt.Biz,
+		BizId:    evt.BizId,
+		Actor:    evt.Uid,
+		Receiver: evt.Receiver,
+		Time:     time.UnixMilli(evt.Ctime),
+	})
+}
+
+// FollowEventConsumer 被关注了之后通知对方，取消关注不通知
+type FollowEventConsumer struct {
+	client sarama.Client
+	svc    service.NotificationService
+	l      logger.LoggerV1
+	store  saramax.IdempotencyStore
+}
+
+func NewFollowEventConsumer(client sarama.Client,
+	svc service.NotificationService,
+	l logger.LoggerV1,
+	store saramax.IdempotencyStore) *FollowEventConsumer {
+	return &FollowEventConsumer{
+		client: client,
+		svc:    svc,
+		l:      l,
+		store:  store,
+	}
+}
+
+func (c *FollowEventConsumer) Start() error {
+	cg, err := sarama.NewConsumerGroupFromClient("notification_follow", c.client)
+	if err != nil {
+		return err
+	}
+	go func() {
+		err := cg.Consume(context.Background(),
+			[]string{"follow_event"},
+			saramax.NewHandler[follow.FollowEvent](c.l, c.Consume).
+				WithIdempotency(c.store, func(msg *sarama.ConsumerMessage, evt follow.FollowEvent) string {
+					return eventKey("notification_follow", evt.EventId)
+				}))
+		if err != nil {
+			c.l.Error("退出了消费循环异常", logger.Error(err))
+		}
+	}()
+	return err
+}
+
+func (c *FollowEventConsumer) Consume(msg *sarama.ConsumerMessage, evt follow.FollowEvent) error {
+	if !evt.Follow {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	// 同一个人的新粉丝聚合在一起，"X 等 13 人关注了你"
+	return c.svc.Notify(ctx, domain.NotificationEvent{
+		Type:     domain.NotificationFollow,
+		Biz:      "user",
+		BizId:    evt.Followee,
+		Actor:    evt.Follower,
+		Receiver: evt.Followee,
+		Time:     time.UnixMilli(evt.Ctime),
+	})
+}
+
+// eventKey 按照 EventId 去重，不同的消费者组要区分开
+func eventKey(group, eventId string) string {
+	if eventId == "" {
+		// 老版本的生产者发的消息没有 EventId，没办法去重
+		return ""
+	}
+	return group + ":" + eventId
+}
@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/errs"
 	"github.com/gevinzone/basic-go/week9/webook/internal/integration/startup"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository/dao/article"
 	ijwt "github.com/gevinzone/basic-go/week9/webook/internal/web/jwt"
@@ -31,6 +32,10 @@ func (s *ArticleGORMHandlerTestSuite) SetupSuite() {
 		context.Set("claims", &ijwt.UserClaims{
 			Id: 123,
 		})
+		// ginx.WrapToken 从 users 里面取
+		context.Set("users", ijwt.UserClaims{
+			Id: 123,
+		})
 		context.Next()
 	})
 	s.db = startup.InitTestDB()
@@ -80,6 +85,7 @@ func (s *ArticleGORMHandlerTestSuite) TestArticleHandler_Edit() {
 					Content:  "随便试试",
 					AuthorId: 123,
 					Status:   domain.ArticleStatusUnpublished.ToUint8(),
+					Version:  1,
 				}, art)
 			},
 			req: Article{
@@ -120,6 +126,8 @@ func (s *ArticleGORMHandlerTestSuite) TestArticleHandler_Edit() {
 					// 创建时间没变
 					Ctime:  456,
 					Status: domain.ArticleStatusUnpublished.ToUint8(),
+					// 老数据没有版本号，更新之后也会加一
+					Version: 1,
 				}, art)
 			},
 			req: Article{
@@ -173,6 +181,48 @@ func (s *ArticleGORMHandlerTestSuite) TestArticleHandler_Edit() {
 				Msg:  "系统错误",
 			},
 		},
+		{
+			name: "版本冲突",
+			before: func(t *testing.T) {
+				// 在别的页面里面已经保存过一次了
+				s.db.Create(&article.Article{
+					Id:       4,
+					Title:    "我的标题",
+					Content:  "我的内容",
+					Ctime:    456,
+					Utime:    234,
+					AuthorId: 123,
+					Status:   domain.ArticleStatusUnpublished.ToUint8(),
+					Version:  2,
+				})
+			},
+			after: func(t *testing.T) {
+				// 数据没有发生变化
+				var art article.Article
+				s.db.Where("id = ?", 4).First(&art)
+				assert.Equal(t, article.Article{
+					Id:       4,
+					Title:    "我的标题",
+					Content:  "我的内容",
+					Ctime:    456,
+					Utime:    234,
+					AuthorId: 123,
+					Status:   domain.ArticleStatusUnpublished.ToUint8(),
+					Version:  2,
+				}, art)
+			},
+			req: Article{
+				Id:      4,
+				Title:   "新的标题",
+				Content: "新的内容",
+				Version: 1,
+			},
+			wantCode: 200,
+			wantResult: Result[int64]{
+				Code: errs.ArticleVersionConflict,
+				Msg:  "帖子已经被修改过，请刷新之后再编辑",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -427,6 +477,75 @@ func (s *ArticleGORMHandlerTestSuite) TestArticle_Publish() {
 	}
 }
 
+func (s *ArticleGORMHandlerTestSuite) TestArticle_DeleteAndRestore() {
+	t := s.T()
+	s.db.Create(&article.Article{
+		Id:       1,
+		Title:    "我的标题",
+		Content:  "我的内容",
+		AuthorId: 123,
+		Status:   domain.ArticleStatusPublished.ToUint8(),
+		Ctime:    456,
+		Utime:    234,
+	})
+	s.db.Create(&article.PublishedArticle{
+		Id:       1,
+		Title:    "我的标题",
+		Content:  "我的内容",
+		AuthorId: 123,
+		Status:   domain.ArticleStatusPublished.ToUint8(),
+		Ctime:    456,
+		Utime:    234,
+	})
+	// 别人的帖子删不掉
+	s.db.Create(&article.Article{
+		Id:       2,
+		Title:    "别人的标题",
+		Content:  "别人的内容",
+		AuthorId: 234,
+		Status:   domain.ArticleStatusUnpublished.ToUint8(),
+	})
+
+	post := func(path string, id int64) Result[any] {
+		data, err := json.Marshal(map[string]int64{"id": id})
+		assert.NoError(t, err)
+		req, err := http.NewRequest(http.MethodPost, path, bytes.NewReader(data))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		s.server.ServeHTTP(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		var result Result[any]
+		err = json.Unmarshal(recorder.Body.Bytes(), &result)
+		assert.NoError(t, err)
+		return result
+	}
+
+	assert.Equal(t, Result[any]{Msg: "OK"}, post("/articles/delete", 1))
+	var art article.Article
+	s.db.Where("id = ?", 1).First(&art)
+	assert.Equal(t, domain.ArticleStatusDeleted.ToUint8(), art.Status)
+	assert.True(t, art.Dtime > 0)
+	var publishedArt article.PublishedArticle
+	s.db.Where("id = ?", 1).First(&publishedArt)
+	assert.Equal(t, domain.ArticleStatusDeleted.ToUint8(), publishedArt.Status)
+
+	assert.Equal(t, Result[any]{Code: 4, Msg: "帖子不存在"}, post("/articles/delete", 2))
+
+	assert.Equal(t, Result[any]{Msg: "OK"}, post("/articles/trash/restore", 1))
+	art = article.Article{}
+	s.db.Where("id = ?", 1).First(&art)
+	assert.Equal(t, domain.ArticleStatusUnpublished.ToUint8(), art.Status)
+	assert.Equal(t, int64(0), art.Dtime)
+	publishedArt = article.PublishedArticle{}
+	s.db.Where("id = ?", 1).First(&publishedArt)
+	// 恢复之后读者看不到，要重新发表
+	assert.Equal(t, domain.ArticleStatusPrivate.ToUint8(), publishedArt.Status)
+
+	assert.Equal(t, Result[any]{Code: 4, Msg: "帖子不在回收站里面"},
+		post("/articles/trash/restore", 1))
+}
+
 func TestGORMArticle(t *testing.T) {
 	suite.Run(t, new(ArticleGORMHandlerTestSuite))
 }
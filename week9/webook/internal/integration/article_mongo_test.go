@@ -6,10 +6,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/bwmarrin/snowflake"
 	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/errs"
 	"github.com/gevinzone/basic-go/week9/webook/internal/integration/startup"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository/dao/article"
+	"github.com/gevinzone/basic-go/week9/webook/internal/web"
 	ijwt "github.com/gevinzone/basic-go/week9/webook/internal/web/jwt"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -37,6 +40,10 @@ func (s *ArticleMongoHandlerTestSuite) SetupSuite() {
 		context.Set("claims", &ijwt.UserClaims{
 			Id: 123,
 		})
+		// ginx.WrapToken 从 users 里面取
+		context.Set("users", ijwt.UserClaims{
+			Id: 123,
+		})
 		context.Next()
 	})
 	s.mdb = startup.InitMongoDB()
@@ -48,7 +55,9 @@ func (s *ArticleMongoHandlerTestSuite) SetupSuite() {
 	}
 	s.col = s.mdb.Collection("articles")
 	s.liveCol = s.mdb.Collection("published_articles")
-	hdl := startup.InitArticleHandler(article.NewMongoDBDAO(s.mdb, node))
+	hdl := startup.InitArticleHandler(article.NewMongoDBDAOV1(s.mdb, func() int64 {
+		return node.Generate().Int64()
+	}))
 	hdl.RegisterRoutes(s.server)
 }
 
@@ -61,6 +70,9 @@ func (s *ArticleMongoHandlerTestSuite) TearDownTest() {
 	_, err = s.mdb.Collection("published_articles").
 		DeleteMany(ctx, bson.D{})
 	assert.NoError(s.T(), err)
+	_, err = s.mdb.Collection("article_revisions").
+		DeleteMany(ctx, bson.D{})
+	assert.NoError(s.T(), err)
 }
 
 func (s *ArticleMongoHandlerTestSuite) TestCleanMongo() {
@@ -115,6 +127,7 @@ func (s *ArticleMongoHandlerTestSuite) TestArticleHandler_Edit() {
 					Content:  "随便试试",
 					AuthorId: 123,
 					Status:   domain.ArticleStatusUnpublished.ToUint8(),
+					Version:  1,
 				}, art)
 			},
 			req: Article{
@@ -162,6 +175,8 @@ func (s *ArticleMongoHandlerTestSuite) TestArticleHandler_Edit() {
 					// 创建时间没变
 					Ctime:  456,
 					Status: domain.ArticleStatusUnpublished.ToUint8(),
+					// 老数据没有版本号，$inc 之后就是 1
+					Version: 1,
 				}, art)
 			},
 			req: Article{
@@ -221,6 +236,54 @@ func (s *ArticleMongoHandlerTestSuite) TestArticleHandler_Edit() {
 				Msg:  "系统错误",
 			},
 		},
+		{
+			name: "版本冲突",
+			before: func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+				defer cancel()
+				// 在别的页面里面已经保存过一次了
+				_, err := s.col.InsertOne(ctx, &article.Article{
+					Id:       4,
+					Title:    "我的标题",
+					Content:  "我的内容",
+					Ctime:    456,
+					Utime:    234,
+					AuthorId: 123,
+					Status:   domain.ArticleStatusUnpublished.ToUint8(),
+					Version:  2,
+				})
+				assert.NoError(t, err)
+			},
+			after: func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+				defer cancel()
+				// 数据没有发生变化
+				var art article.Article
+				err := s.col.FindOne(ctx, bson.D{bson.E{Key: "id", Value: 4}}).Decode(&art)
+				assert.NoError(t, err)
+				assert.Equal(t, article.Article{
+					Id:       4,
+					Title:    "我的标题",
+					Content:  "我的内容",
+					Ctime:    456,
+					Utime:    234,
+					AuthorId: 123,
+					Status:   domain.ArticleStatusUnpublished.ToUint8(),
+					Version:  2,
+				}, art)
+			},
+			req: Article{
+				Id:      4,
+				Title:   "新的标题",
+				Content: "新的内容",
+				Version: 1,
+			},
+			wantCode: 200,
+			wantResult: Result[int64]{
+				Code: errs.ArticleVersionConflict,
+				Msg:  "帖子已经被修改过，请刷新之后再编辑",
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -378,15 +441,23 @@ func (s *ArticleMongoHandlerTestSuite) TestArticle_Publish() {
 				_, err := s.col.InsertOne(ctx, &art)
 				assert.NoError(t, err)
 				part := article.PublishedArticle(art)
+				// 上一次发表的时候有标签
+				part.Tags = []string{"go"}
 				_, err = s.liveCol.InsertOne(ctx, &part)
 				assert.NoError(t, err)
 			},
 			after: func(t *testing.T) {
 				ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
 				defer cancel()
+				// 重新发表的时候去掉了标签，线上库也要去掉
+				var live article.PublishedArticle
+				err := s.liveCol.FindOne(ctx, bson.D{bson.E{Key: "id", Value: 3}}).Decode(&live)
+				assert.NoError(t, err)
+				assert.Empty(t, live.Tags)
+				assert.Equal(t, "新的标题", live.Title)
 				// 验证一下数据
 				var art article.Article
-				err := s.col.FindOne(ctx, bson.D{bson.E{Key: "id", Value: 3}}).Decode(&art)
+				err = s.col.FindOne(ctx, bson.D{bson.E{Key: "id", Value: 3}}).Decode(&art)
 				assert.NoError(t, err)
 				assert.Equal(t, int64(3), art.Id)
 				assert.Equal(t, "新的标题", art.Title)
@@ -516,6 +587,219 @@ func (s *ArticleMongoHandlerTestSuite) TestArticle_Publish() {
 	}
 }
 
+func (s *ArticleMongoHandlerTestSuite) TestArticle_Withdraw() {
+	t := s.T()
+	testCases := []struct {
+		name   string
+		before func(t *testing.T)
+		after  func(t *testing.T)
+		id     int64
+
+		wantCode   int
+		wantResult Result[int64]
+	}{
+		{
+			name: "撤回帖子",
+			before: func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+				defer cancel()
+				art := article.Article{
+					Id:       1,
+					Title:    "我的标题",
+					Content:  "我的内容",
+					Ctime:    456,
+					Utime:    234,
+					AuthorId: 123,
+					Status:   domain.ArticleStatusPublished.ToUint8(),
+				}
+				_, err := s.col.InsertOne(ctx, art)
+				assert.NoError(t, err)
+				_, err = s.liveCol.InsertOne(ctx, article.PublishedArticle(art))
+				assert.NoError(t, err)
+			},
+			after: func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+				defer cancel()
+				var art article.Article
+				err := s.col.FindOne(ctx, bson.M{"id": 1}).Decode(&art)
+				assert.NoError(t, err)
+				assert.Equal(t, domain.ArticleStatusPrivate.ToUint8(), art.Status)
+				var part article.PublishedArticle
+				err = s.liveCol.FindOne(ctx, bson.M{"id": 1}).Decode(&part)
+				assert.NoError(t, err)
+				assert.Equal(t, domain.ArticleStatusPrivate.ToUint8(), part.Status)
+			},
+			id:       1,
+			wantCode: 200,
+			wantResult: Result[int64]{
+				Msg: "OK",
+			},
+		},
+		{
+			name: "撤回别人的帖子",
+			before: func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+				defer cancel()
+				art := article.Article{
+					Id:       2,
+					Title:    "我的标题",
+					Content:  "我的内容",
+					Ctime:    456,
+					Utime:    234,
+					AuthorId: 789,
+					Status:   domain.ArticleStatusPublished.ToUint8(),
+				}
+				_, err := s.col.InsertOne(ctx, art)
+				assert.NoError(t, err)
+				_, err = s.liveCol.InsertOne(ctx, article.PublishedArticle(art))
+				assert.NoError(t, err)
+			},
+			after: func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+				defer cancel()
+				// 状态没有变化
+				var art article.Article
+				err := s.col.FindOne(ctx, bson.M{"id": 2}).Decode(&art)
+				assert.NoError(t, err)
+				assert.Equal(t, domain.ArticleStatusPublished.ToUint8(), art.Status)
+				var part article.PublishedArticle
+				err = s.liveCol.FindOne(ctx, bson.M{"id": 2}).Decode(&part)
+				assert.NoError(t, err)
+				assert.Equal(t, domain.ArticleStatusPublished.ToUint8(), part.Status)
+			},
+			id:       2,
+			wantCode: 200,
+			wantResult: Result[int64]{
+				Code: 5,
+				Msg:  "系统错误",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.before(t)
+			data, err := json.Marshal(Article{Id: tc.id})
+			assert.NoError(t, err)
+			req, err := http.NewRequest(http.MethodPost,
+				"/articles/withdraw", bytes.NewReader(data))
+			assert.NoError(t, err)
+			req.Header.Set("Content-Type",
+				"application/json")
+			recorder := httptest.NewRecorder()
+
+			s.server.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			if recorder.Code != http.StatusOK {
+				return
+			}
+			var result Result[int64]
+			err = json.Unmarshal(recorder.Body.Bytes(), &result)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantResult, result)
+			tc.after(t)
+		})
+	}
+}
+
+func (s *ArticleMongoHandlerTestSuite) TestArticleHandler_Detail() {
+	t := s.T()
+	testCases := []struct {
+		name   string
+		before func(t *testing.T)
+		id     int64
+
+		wantCode   int
+		wantResult Result[web.ArticleVO]
+	}{
+		{
+			name: "查询成功",
+			before: func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+				defer cancel()
+				_, err := s.col.InsertOne(ctx, article.Article{
+					Id:       1,
+					Title:    "我的标题",
+					Content:  "我的内容",
+					Ctime:    456,
+					Utime:    234,
+					AuthorId: 123,
+					Status:   domain.ArticleStatusUnpublished.ToUint8(),
+					Tags:     []string{"go"},
+					Version:  3,
+				})
+				assert.NoError(t, err)
+			},
+			id:       1,
+			wantCode: 200,
+			wantResult: Result[web.ArticleVO]{
+				Data: web.ArticleVO{
+					Id:      1,
+					Title:   "我的标题",
+					Content: "我的内容",
+					Status:  domain.ArticleStatusUnpublished.ToUint8(),
+					Tags:    []string{"go"},
+					Version: 3,
+					Ctime:   time.UnixMilli(456).Format(time.DateTime),
+					Utime:   time.UnixMilli(234).Format(time.DateTime),
+				},
+			},
+		},
+		{
+			name: "别人的帖子",
+			before: func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+				defer cancel()
+				_, err := s.col.InsertOne(ctx, article.Article{
+					Id:       2,
+					Title:    "我的标题",
+					Content:  "我的内容",
+					Ctime:    456,
+					Utime:    234,
+					AuthorId: 789,
+				})
+				assert.NoError(t, err)
+			},
+			id:       2,
+			wantCode: 200,
+			wantResult: Result[web.ArticleVO]{
+				Code: 4,
+				Msg:  "输入有误",
+			},
+		},
+		{
+			name:     "帖子不存在",
+			before:   func(t *testing.T) {},
+			id:       3,
+			wantCode: 200,
+			wantResult: Result[web.ArticleVO]{
+				Code: 5,
+				Msg:  "系统错误",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.before(t)
+			req, err := http.NewRequest(http.MethodGet,
+				fmt.Sprintf("/articles/detail/%d", tc.id), nil)
+			assert.NoError(t, err)
+			recorder := httptest.NewRecorder()
+
+			s.server.ServeHTTP(recorder, req)
+			assert.Equal(t, tc.wantCode, recorder.Code)
+			if recorder.Code != http.StatusOK {
+				return
+			}
+			var result Result[web.ArticleVO]
+			err = json.Unmarshal(recorder.Body.Bytes(), &result)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantResult, result)
+		})
+	}
+}
+
 func TestMongoArticle(t *testing.T) {
 	suite.Run(t, new(ArticleMongoHandlerTestSuite))
 }
@@ -524,4 +808,5 @@ type Article struct {
 	Id      int64  `json:"id"`
 	Title   string `json:"title"`
 	Content string `json:"content"`
+	Version int64  `json:"version"`
 }
@@ -0,0 +1,20 @@
+package startup
+
+import (
+	"github.com/IBM/sarama"
+)
+
+var kafkaClient sarama.Client
+
+func InitKafka() sarama.Client {
+	if kafkaClient == nil {
+		saramaCfg := sarama.NewConfig()
+		saramaCfg.Producer.Return.Successes = true
+		client, err := sarama.NewClient([]string{"localhost:9094"}, saramaCfg)
+		if err != nil {
+			panic(err)
+		}
+		kafkaClient = client
+	}
+	return kafkaClient
+}
@@ -3,6 +3,9 @@
 package startup
 
 import (
+	events "github.com/gevinzone/basic-go/week9/webook/internal/events/article"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/follow"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/interactive"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
 	article2 "github.com/gevinzone/basic-go/week9/webook/internal/repository/article"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
@@ -17,6 +20,9 @@ import (
 )
 
 var thirdProvider = wire.NewSet(InitRedis, InitTestDB, InitLog)
+var kafkaProvider = wire.NewSet(InitKafka,
+	ioc.NewSyncProducer,
+	events.NewKafkaProducer)
 var userSvcProvider = wire.NewSet(
 	dao.NewUserDAO,
 	cache.NewUserCache,
@@ -24,20 +30,77 @@ var userSvcProvider = wire.NewSet(
 	service.NewUserService)
 var articlSvcProvider = wire.NewSet(
 	article.NewGORMArticleDAO,
+	cache.NewRedisArticleCache,
 	article2.NewArticleRepository,
 	service.NewArticleService)
+var jobSvcProvider = wire.NewSet(
+	dao.NewGORMJobDAO,
+	repository.NewPreemptCronJobRepository,
+	service.NewCronJobService)
+var searchSvcProvider = wire.NewSet(
+	repository.NewInMemorySearchRepository,
+	service.NewSearchService)
+
+// 评论数的缓存用的是 interactiveSvcProvider 里面的 InteractiveCache
+var commentSvcProvider = wire.NewSet(
+	dao.NewGORMCommentDAO,
+	repository.NewCachedCommentRepository,
+	ioc.InitCommentService)
+var historySvcProvider = wire.NewSet(
+	dao.NewGORMHistoryDAO,
+	repository.NewHistoryDBRepository,
+	service.NewHistoryService)
 var interactiveSvcProvider = wire.NewSet(
 	service.NewInteractiveService,
 	repository.NewCachedInteractiveRepository,
 	dao.NewGORMInteractiveDAO,
 	cache.NewRedisInteractiveCache,
+	repository.NewCollectionDBRepository,
+	dao.NewGORMCollectionDAO,
+	interactive.NewKafkaProducer,
+)
+var interactiveLiveSvcProvider = wire.NewSet(
+	ioc.InitRedisPubSubClient,
+	cache.NewRedisInteractiveChangeSubscriber,
+	repository.NewCachedInteractiveChangeRepository,
+	service.NewLiveInteractiveService,
+)
+var followSvcProvider = wire.NewSet(
+	dao.NewGORMFollowDAO,
+	cache.NewRedisFollowCache,
+	repository.NewCachedFollowRepository,
+	follow.NewKafkaProducer,
+	service.NewFollowService,
+)
+var feedSvcProvider = wire.NewSet(
+	cache.NewRedisFeedCache,
+	repository.NewCachedFeedRepository,
+	service.NewFeedService,
+)
+var notificationSvcProvider = wire.NewSet(
+	dao.NewGORMNotificationDAO,
+	cache.NewRedisNotificationCache,
+	repository.NewCachedNotificationRepository,
+	ioc.InitNotificationService,
 )
 
 func InitWebServer() *gin.Engine {
 	wire.Build(
 		thirdProvider,
+		kafkaProvider,
 		userSvcProvider,
 		articlSvcProvider,
+		jobSvcProvider,
+		searchSvcProvider,
+		commentSvcProvider,
+		historySvcProvider,
+		interactiveSvcProvider,
+		interactiveLiveSvcProvider,
+		followSvcProvider,
+		feedSvcProvider,
+		notificationSvcProvider,
+		service.NewCollectionService,
+		ioc.InitResourceService,
 		cache.NewCodeCache,
 		repository.NewCodeRepository,
 		// service 部分
@@ -51,6 +114,14 @@ func InitWebServer() *gin.Engine {
 		web.NewUserHandler,
 		web.NewOAuth2WechatHandler,
 		web.NewArticleHandler,
+		web.NewSearchHandler,
+		web.NewCommentHandler,
+		web.NewHistoryHandler,
+		web.NewCollectionHandler,
+		web.NewUserInteractiveHandler,
+		web.NewFollowHandler,
+		web.NewFeedHandler,
+		web.NewNotificationHandler,
 		ijwt.NewRedisJWTHandler,
 
 		// gin 的中间件
@@ -65,11 +136,18 @@ func InitWebServer() *gin.Engine {
 
 func InitArticleHandler(dao article.ArticleDAO) *web.ArticleHandler {
 	wire.Build(thirdProvider,
-		//userSvcProvider,
-		//cache.NewRedisArticleCache,
+		kafkaProvider,
+		dao.NewUserDAO,
+		cache.NewUserCache,
+		repository.NewUserRepository,
+		cache.NewRedisArticleCache,
 		//wire.InterfaceValue(new(article.ArticleDAO), dao),
 		article2.NewArticleRepository,
+		jobSvcProvider,
 		service.NewArticleService,
+		interactiveSvcProvider,
+		interactiveLiveSvcProvider,
+		followSvcProvider,
 		web.NewArticleHandler)
 	return new(web.ArticleHandler)
 }
@@ -85,6 +163,6 @@ func InitJwtHdl() ijwt.Handler {
 }
 
 func InitInteractiveService() service.InteractiveService {
-	wire.Build(thirdProvider, interactiveSvcProvider)
-	return service.NewInteractiveService(nil, nil)
+	wire.Build(thirdProvider, kafkaProvider, interactiveSvcProvider)
+	return service.NewInteractiveService(nil, nil, nil, nil)
 }
@@ -7,6 +7,9 @@
 package startup
 
 import (
+	article3 "github.com/gevinzone/basic-go/week9/webook/internal/events/article"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/follow"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/interactive"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
 	article2 "github.com/gevinzone/basic-go/week9/webook/internal/repository/article"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
@@ -36,22 +39,98 @@ func InitWebServer() *gin.Engine {
 	codeRepository := repository.NewCodeRepository(codeCache)
 	smsService := ioc.InitSMSService(cmdable)
 	codeService := service.NewCodeService(codeRepository, smsService)
-	userHandler := web.NewUserHandler(userService, codeService, handler)
+	followDAO := dao.NewGORMFollowDAO(gormDB)
+	followCache := cache.NewRedisFollowCache(cmdable)
+	followRepository := repository.NewCachedFollowRepository(followDAO, followCache, loggerV1)
+	client := InitKafka()
+	syncProducer := ioc.NewSyncProducer(client)
+	followProducer := follow.NewKafkaProducer(syncProducer)
+	followService := service.NewFollowService(followRepository, userRepository, followProducer, loggerV1)
+	userHandler := web.NewUserHandler(userService, codeService, followService, handler)
 	wechatService := InitPhantomWechatService(loggerV1)
 	oAuth2WechatHandler := web.NewOAuth2WechatHandler(wechatService, userService, handler)
 	articleDAO := article.NewGORMArticleDAO(gormDB)
-	articleRepository := article2.NewArticleRepository(articleDAO, loggerV1)
-	articleService := service.NewArticleService(articleRepository)
-	articleHandler := web.NewArticleHandler(articleService, loggerV1)
-	engine := ioc.InitWebServer(v, userHandler, oAuth2WechatHandler, articleHandler)
+	articleCache := cache.NewRedisArticleCache(cmdable)
+	articleRepository := article2.NewArticleRepository(articleDAO, userRepository, articleCache, loggerV1)
+	producer := article3.NewKafkaProducer(syncProducer)
+	jobDAO := dao.NewGORMJobDAO(gormDB)
+	jobRepository := repository.NewPreemptCronJobRepository(jobDAO)
+	jobService := service.NewCronJobService(jobRepository, loggerV1)
+	articleService := service.NewArticleService(articleRepository, loggerV1, producer, jobService)
+	searchRepository := repository.NewInMemorySearchRepository()
+	searchService := service.NewSearchService(articleRepository, searchRepository, loggerV1)
+	searchHandler := web.NewSearchHandler(searchService)
+	commentDAO := dao.NewGORMCommentDAO(gormDB)
+	interactiveCache := cache.NewRedisInteractiveCache(cmdable)
+	commentRepository := repository.NewCachedCommentRepository(commentDAO, interactiveCache, loggerV1)
+	interactiveProducer := interactive.NewKafkaProducer(syncProducer)
+	commentService := ioc.InitCommentService(commentRepository, articleService, interactiveProducer, loggerV1)
+	commentHandler := web.NewCommentHandler(commentService)
+	historyDAO := dao.NewGORMHistoryDAO(gormDB)
+	historyRepository := repository.NewHistoryDBRepository(historyDAO)
+	historyService := service.NewHistoryService(historyRepository)
+	historyHandler := web.NewHistoryHandler(historyService)
+	collectionDAO := dao.NewGORMCollectionDAO(gormDB)
+	collectionRepository := repository.NewCollectionDBRepository(collectionDAO)
+	collectionService := service.NewCollectionService(collectionRepository)
+	interactiveDAO := dao.NewGORMInteractiveDAO(gormDB)
+	interactiveRepository := repository.NewCachedInteractiveRepository(interactiveDAO, interactiveCache, loggerV1)
+	interactiveService := service.NewInteractiveService(interactiveRepository, collectionRepository, interactiveProducer, loggerV1)
+	universalClient := ioc.InitRedisPubSubClient(cmdable)
+	interactiveChangeSubscriber := cache.NewRedisInteractiveChangeSubscriber(universalClient)
+	interactiveChangeRepository := repository.NewCachedInteractiveChangeRepository(interactiveChangeSubscriber)
+	interactiveLiveService := service.NewLiveInteractiveService(interactiveRepository, interactiveChangeRepository, loggerV1)
+	articleHandler := web.NewArticleHandler(articleService, interactiveService, followService, interactiveLiveService, loggerV1)
+	collectionHandler := web.NewCollectionHandler(collectionService, interactiveService, articleService)
+	resourceService := ioc.InitResourceService(articleService, loggerV1)
+	userInteractiveHandler := web.NewUserInteractiveHandler(interactiveService, collectionService, resourceService)
+	followHandler := web.NewFollowHandler(followService)
+	feedCache := cache.NewRedisFeedCache(cmdable)
+	feedRepository := repository.NewCachedFeedRepository(feedCache)
+	feedService := service.NewFeedService(feedRepository, followRepository, articleService, loggerV1)
+	feedHandler := web.NewFeedHandler(feedService)
+	notificationDAO := dao.NewGORMNotificationDAO(gormDB)
+	notificationCache := cache.NewRedisNotificationCache(cmdable)
+	notificationRepository := repository.NewCachedNotificationRepository(notificationDAO, notificationCache, loggerV1)
+	notificationService := ioc.InitNotificationService(notificationRepository, articleService, loggerV1)
+	notificationHandler := web.NewNotificationHandler(notificationService)
+	engine := ioc.InitWebServer(v, userHandler, oAuth2WechatHandler, articleHandler, searchHandler, commentHandler, historyHandler, collectionHandler, userInteractiveHandler, followHandler, feedHandler, notificationHandler)
 	return engine
 }
 
 func InitArticleHandler(dao2 article.ArticleDAO) *web.ArticleHandler {
+	gormDB := InitTestDB()
+	userDAO := dao.NewUserDAO(gormDB)
+	cmdable := InitRedis()
+	userCache := cache.NewUserCache(cmdable)
+	userRepository := repository.NewUserRepository(userDAO, userCache)
+	articleCache := cache.NewRedisArticleCache(cmdable)
 	loggerV1 := InitLog()
-	articleRepository := article2.NewArticleRepository(dao2, loggerV1)
-	articleService := service.NewArticleService(articleRepository)
-	articleHandler := web.NewArticleHandler(articleService, loggerV1)
+	articleRepository := article2.NewArticleRepository(dao2, userRepository, articleCache, loggerV1)
+	client := InitKafka()
+	syncProducer := ioc.NewSyncProducer(client)
+	producer := article3.NewKafkaProducer(syncProducer)
+	jobDAO := dao.NewGORMJobDAO(gormDB)
+	jobRepository := repository.NewPreemptCronJobRepository(jobDAO)
+	jobService := service.NewCronJobService(jobRepository, loggerV1)
+	articleService := service.NewArticleService(articleRepository, loggerV1, producer, jobService)
+	interactiveDAO := dao.NewGORMInteractiveDAO(gormDB)
+	interactiveCache := cache.NewRedisInteractiveCache(cmdable)
+	interactiveRepository := repository.NewCachedInteractiveRepository(interactiveDAO, interactiveCache, loggerV1)
+	collectionDAO := dao.NewGORMCollectionDAO(gormDB)
+	collectionRepository := repository.NewCollectionDBRepository(collectionDAO)
+	interactiveProducer := interactive.NewKafkaProducer(syncProducer)
+	interactiveService := service.NewInteractiveService(interactiveRepository, collectionRepository, interactiveProducer, loggerV1)
+	followDAO := dao.NewGORMFollowDAO(gormDB)
+	followCache := cache.NewRedisFollowCache(cmdable)
+	followRepository := repository.NewCachedFollowRepository(followDAO, followCache, loggerV1)
+	followProducer := follow.NewKafkaProducer(syncProducer)
+	followService := service.NewFollowService(followRepository, userRepository, followProducer, loggerV1)
+	universalClient := ioc.InitRedisPubSubClient(cmdable)
+	interactiveChangeSubscriber := cache.NewRedisInteractiveChangeSubscriber(universalClient)
+	interactiveChangeRepository := repository.NewCachedInteractiveChangeRepository(interactiveChangeSubscriber)
+	interactiveLiveService := service.NewLiveInteractiveService(interactiveRepository, interactiveChangeRepository, loggerV1)
+	articleHandler := web.NewArticleHandler(articleService, interactiveService, followService, interactiveLiveService, loggerV1)
 	return articleHandler
 }
 
@@ -79,7 +158,12 @@ func InitInteractiveService() service.InteractiveService {
 	interactiveCache := cache.NewRedisInteractiveCache(cmdable)
 	loggerV1 := InitLog()
 	interactiveRepository := repository.NewCachedInteractiveRepository(interactiveDAO, interactiveCache, loggerV1)
-	interactiveService := service.NewInteractiveService(interactiveRepository, loggerV1)
+	collectionDAO := dao.NewGORMCollectionDAO(gormDB)
+	collectionRepository := repository.NewCollectionDBRepository(collectionDAO)
+	client := InitKafka()
+	syncProducer := ioc.NewSyncProducer(client)
+	interactiveProducer := interactive.NewKafkaProducer(syncProducer)
+	interactiveService := service.NewInteractiveService(interactiveRepository, collectionRepository, interactiveProducer, loggerV1)
 	return interactiveService
 }
 
@@ -87,8 +171,27 @@ func InitInteractiveService() service.InteractiveService {
 
 var thirdProvider = wire.NewSet(InitRedis, InitTestDB, InitLog)
 
+var kafkaProvider = wire.NewSet(InitKafka, ioc.NewSyncProducer, article3.NewKafkaProducer)
+
 var userSvcProvider = wire.NewSet(dao.NewUserDAO, cache.NewUserCache, repository.NewUserRepository, service.NewUserService)
 
-var articlSvcProvider = wire.NewSet(article.NewGORMArticleDAO, article2.NewArticleRepository, service.NewArticleService)
+var articlSvcProvider = wire.NewSet(article.NewGORMArticleDAO, cache.NewRedisArticleCache, article2.NewArticleRepository, service.NewArticleService)
+
+var jobSvcProvider = wire.NewSet(dao.NewGORMJobDAO, repository.NewPreemptCronJobRepository, service.NewCronJobService)
+
+var searchSvcProvider = wire.NewSet(repository.NewInMemorySearchRepository, service.NewSearchService)
+
+// 评论数的缓存用的是 interactiveSvcProvider 里面的 InteractiveCache
+var commentSvcProvider = wire.NewSet(dao.NewGORMCommentDAO, repository.NewCachedCommentRepository, ioc.InitCommentService)
+
+var historySvcProvider = wire.NewSet(dao.NewGORMHistoryDAO, repository.NewHistoryDBRepository, service.NewHistoryService)
+
+var interactiveSvcProvider = wire.NewSet(service.NewInteractiveService, repository.NewCachedInteractiveRepository, dao.NewGORMInteractiveDAO, cache.NewRedisInteractiveCache, repository.NewCollectionDBRepository, dao.NewGORMCollectionDAO, interactive.NewKafkaProducer)
+
+var interactiveLiveSvcProvider = wire.NewSet(ioc.InitRedisPubSubClient, cache.NewRedisInteractiveChangeSubscriber, repository.NewCachedInteractiveChangeRepository, service.NewLiveInteractiveService)
+
+var followSvcProvider = wire.NewSet(dao.NewGORMFollowDAO, cache.NewRedisFollowCache, repository.NewCachedFollowRepository, follow.NewKafkaProducer, service.NewFollowService)
+
+var feedSvcProvider = wire.NewSet(cache.NewRedisFeedCache, repository.NewCachedFeedRepository, service.NewFeedService)
 
-var interactiveSvcProvider = wire.NewSet(service.NewInteractiveService, repository.NewCachedInteractiveRepository, dao.NewGORMInteractiveDAO, cache.NewRedisInteractiveCache)
+var notificationSvcProvider = wire.NewSet(dao.NewGORMNotificationDAO, cache.NewRedisNotificationCache, repository.NewCachedNotificationRepository, ioc.InitNotificationService)
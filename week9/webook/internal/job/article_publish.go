@@ -0,0 +1,34 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	"time"
+)
+
+// ArticlePublishExecutor 执行定时发表的任务
+type ArticlePublishExecutor struct {
+	svc     service.ArticleService
+	timeout time.Duration
+}
+
+func NewArticlePublishExecutor(svc service.ArticleService) *ArticlePublishExecutor {
+	return &ArticlePublishExecutor{svc: svc, timeout: time.Second * 10}
+}
+
+func (a *ArticlePublishExecutor) Name() string {
+	return service.ScheduledPublishExecutor
+}
+
+func (a *ArticlePublishExecutor) Exec(ctx context.Context, j domain.Job) error {
+	var cfg service.ScheduledPublishCfg
+	err := json.Unmarshal([]byte(j.Cfg), &cfg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	return a.svc.PublishScheduled(ctx, cfg.Uid, cfg.Aid)
+}
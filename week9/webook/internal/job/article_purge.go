@@ -0,0 +1,73 @@
+package job
+
+import (
+	"context"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"time"
+)
+
+// ArticlePurgeExecutor 清理回收站，彻底删除超过保留期限的帖子，
+// 连同它们的阅读、点赞和收藏数据
+type ArticlePurgeExecutor struct {
+	artSvc  service.ArticleService
+	intrSvc service.InteractiveService
+	l       logger.LoggerV1
+	// 每一批处理多少篇
+	batchSize int
+	timeout   time.Duration
+}
+
+func NewArticlePurgeExecutor(artSvc service.ArticleService,
+	intrSvc service.InteractiveService,
+	l logger.LoggerV1) *ArticlePurgeExecutor {
+	return &ArticlePurgeExecutor{
+		artSvc:    artSvc,
+		intrSvc:   intrSvc,
+		l:         l,
+		batchSize: 100,
+		timeout:   time.Minute,
+	}
+}
+
+func (a *ArticlePurgeExecutor) Name() string {
+	return "article_purge"
+}
+
+// Job 清理回收站的任务，每天凌晨三点跑一次
+func (a *ArticlePurgeExecutor) Job() domain.Job {
+	return domain.Job{
+		Name:     "article_purge",
+		Executor: a.Name(),
+		Cron:     "0 3 * * *",
+	}
+}
+
+func (a *ArticlePurgeExecutor) Exec(ctx context.Context, j domain.Job) error {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	for {
+		arts, err := a.artSvc.ListExpiredTrash(ctx, a.batchSize)
+		if err != nil {
+			return err
+		}
+		for _, art := range arts {
+			// 先删互动数据，中途失败了帖子还在回收站里面，下一次还会再来删
+			err = a.intrSvc.Delete(ctx, "article", art.Id)
+			if err != nil {
+				return err
+			}
+			err = a.artSvc.Purge(ctx, art.Id)
+			if err != nil {
+				return err
+			}
+		}
+		if len(arts) > 0 {
+			a.l.Info("清理回收站", logger.Int32("cnt", int32(len(arts))))
+		}
+		if len(arts) < a.batchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CntReconcileExecutor 点赞数和收藏数是在不同的事务里面增减的，
+// 缓存也只是尽量更新，时间长了总会有偏差，定期按照点赞和收藏的记录校对一遍
+type CntReconcileExecutor struct {
+	svc service.InteractiveService
+	l   logger.LoggerV1
+	// 最近一次校对发现的偏差，偏差一直很大说明计数的逻辑有问题
+	drift *prometheus.GaugeVec
+	// 最近一次校对改掉了多少条计数
+	fixed prometheus.Gauge
+}
+
+func NewCntReconcileExecutor(svc service.InteractiveService,
+	l logger.LoggerV1) *CntReconcileExecutor {
+	drift := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "geekbang_daming",
+		Subsystem: "webook",
+		Name:      "interactive_cnt_drift",
+		Help:      "最近一次校对点赞数和收藏数发现的偏差",
+	}, []string{"type"})
+	err := prometheus.Register(drift)
+	if err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			panic(err)
+		}
+		drift = are.ExistingCollector.(*prometheus.GaugeVec)
+	}
+	fixed := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "geekbang_daming",
+		Subsystem: "webook",
+		Name:      "interactive_cnt_fixed",
+		Help:      "最近一次校对改掉的点赞数和收藏数的条数",
+	})
+	err = prometheus.Register(fixed)
+	if err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if !errors.As(err, &are) {
+			panic(err)
+		}
+		fixed = are.ExistingCollector.(prometheus.Gauge)
+	}
+	return &CntReconcileExecutor{
+		svc:   svc,
+		l:     l,
+		drift: drift,
+		fixed: fixed,
+	}
+}
+
+func (r *CntReconcileExecutor) Name() string {
+	return "interactive_cnt_reconcile"
+}
+
+// Job 每天凌晨校对一次，要扫全表。
+// 不设超时，表大了也要扫到最后，执行期间调度器会一直续约
+func (r *CntReconcileExecutor) Job() domain.Job {
+	return domain.Job{
+		Name:     "interactive_cnt_reconcile",
+		Executor: r.Name(),
+		Cron:     "0 4 * * *",
+	}
+}
+
+func (r *CntReconcileExecutor) Exec(ctx context.Context, j domain.Job) error {
+	report, err := r.svc.ReconcileCnt(ctx)
+	// 中途出错了也把已经校对的部分报上去
+	r.drift.WithLabelValues("like").Set(float64(report.LikeDrift))
+	r.drift.WithLabelValues("collect").Set(float64(report.CollectDrift))
+	r.fixed.Set(float64(report.Fixed))
+	r.l.Info("校对点赞数和收藏数",
+		logger.Int64("checked", int64(report.Checked)),
+		logger.Int64("fixed", int64(report.Fixed)),
+		logger.Int64("likeDrift", report.LikeDrift),
+		logger.Int64("collectDrift", report.CollectDrift))
+	return err
+}
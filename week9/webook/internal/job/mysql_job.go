@@ -81,7 +81,11 @@ func (s *Scheduler) Schedule(ctx context.Context) error {
 		if err != nil {
 			// 你不能 return
 			// 你要继续下一轮
+			// 大部分时候是没有可以执行的任务，所以歇一下再抢
+			s.limiter.Release(1)
 			s.l.Error("抢占任务失败", logger.Error(err))
+			s.sleep(ctx)
+			continue
 		}
 
 		exec, ok := s.execs[j.Executor]
@@ -90,6 +94,12 @@ func (s *Scheduler) Schedule(ctx context.Context) error {
 			// 线上就继续
 			s.l.Error("未找到对应的执行器",
 				logger.String("executor", j.Executor))
+			s.limiter.Release(1)
+			if err1 := j.CancelFunc(); err1 != nil {
+				s.l.Error("释放任务失败",
+					logger.Error(err1),
+					logger.Int64("jid", j.Id))
+			}
 			continue
 		}
 
@@ -123,3 +133,11 @@ func (s *Scheduler) Schedule(ctx context.Context) error {
 		}()
 	}
 }
+
+// sleep 等待下一轮调度，ctx 结束的时候立刻返回
+func (s *Scheduler) sleep(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+	}
+}
@@ -10,7 +10,8 @@ import (
 )
 
 type RankingJob struct {
-	svc       service.RankingService
+	name      string
+	run       func(ctx context.Context) error
 	timeout   time.Duration
 	client    *rlock.Client
 	key       string
@@ -24,7 +25,8 @@ func NewRankingJob(svc service.RankingService,
 	l logger.LoggerV1,
 	timeout time.Duration) *RankingJob {
 	// 根据你的数据量来，如果要是七天内的帖子数量很多，你就要设置长一点
-	return &RankingJob{svc: svc,
+	return &RankingJob{name: "ranking",
+		run:       svc.TopN,
 		timeout:   timeout,
 		client:    client,
 		key:       "rlock:cron_job:ranking",
@@ -33,8 +35,24 @@ func NewRankingJob(svc service.RankingService,
 	}
 }
 
+// NewAllTimeRankingJob 总榜要扫全部帖子，和热榜分开跑，用自己的锁，
+// 不然总榜算得慢会拖着热榜、周榜一起超时
+func NewAllTimeRankingJob(svc service.RankingService,
+	client *rlock.Client,
+	l logger.LoggerV1,
+	timeout time.Duration) *RankingJob {
+	return &RankingJob{name: "ranking_all_time",
+		run:       svc.TopNAllTime,
+		timeout:   timeout,
+		client:    client,
+		key:       "rlock:cron_job:ranking_all_time",
+		l:         l,
+		localLock: &sync.Mutex{},
+	}
+}
+
 func (r *RankingJob) Name() string {
-	return "ranking"
+	return r.name
 }
 
 // 按时间调度的，三分钟一次
@@ -83,7 +101,7 @@ func (r *RankingJob) Run() error {
 
 	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
 	defer cancel()
-	return r.svc.TopN(ctx)
+	return r.run(ctx)
 }
 
 func (r *RankingJob) Close() error {
@@ -0,0 +1,51 @@
+package job
+
+import (
+	"context"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"time"
+)
+
+// ReadCntFlushExecutor 把 Redis 里面累加的阅读数合并到数据库
+type ReadCntFlushExecutor struct {
+	svc     service.InteractiveService
+	l       logger.LoggerV1
+	timeout time.Duration
+}
+
+func NewReadCntFlushExecutor(svc service.InteractiveService,
+	l logger.LoggerV1) *ReadCntFlushExecutor {
+	return &ReadCntFlushExecutor{
+		svc:     svc,
+		l:       l,
+		timeout: time.Second * 30,
+	}
+}
+
+func (r *ReadCntFlushExecutor) Name() string {
+	return "interactive_read_cnt_flush"
+}
+
+// Job 每分钟合并一次，数据库里面的阅读数最多落后一分钟
+func (r *ReadCntFlushExecutor) Job() domain.Job {
+	return domain.Job{
+		Name:     "interactive_read_cnt_flush",
+		Executor: r.Name(),
+		Cron:     "* * * * *",
+	}
+}
+
+func (r *ReadCntFlushExecutor) Exec(ctx context.Context, j domain.Job) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	cnt, err := r.svc.FlushReadCnt(ctx)
+	if err != nil {
+		return err
+	}
+	if cnt > 0 {
+		r.l.Debug("合并阅读数", logger.Int32("cnt", int32(cnt)))
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package job
+
+import (
+	"context"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"time"
+)
+
+// UVRollupExecutor 把 Redis 里面按天的 UV 汇总到数据库
+type UVRollupExecutor struct {
+	svc     service.InteractiveService
+	l       logger.LoggerV1
+	timeout time.Duration
+}
+
+func NewUVRollupExecutor(svc service.InteractiveService,
+	l logger.LoggerV1) *UVRollupExecutor {
+	return &UVRollupExecutor{
+		svc:     svc,
+		l:       l,
+		timeout: time.Minute * 5,
+	}
+}
+
+func (r *UVRollupExecutor) Name() string {
+	return "interactive_uv_rollup"
+}
+
+// Job 每小时汇总一次，数据库里面当天的 UV 最多落后一个小时
+func (r *UVRollupExecutor) Job() domain.Job {
+	return domain.Job{
+		Name:     "interactive_uv_rollup",
+		Executor: r.Name(),
+		Cron:     "5 * * * *",
+	}
+}
+
+func (r *UVRollupExecutor) Exec(ctx context.Context, j domain.Job) error {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	now := time.Now()
+	// 昨天最后一个小时的数据要在今天才能汇总进去
+	for _, day := range []time.Time{now.AddDate(0, 0, -1), now} {
+		cnt, err := r.svc.RollupUV(ctx, day)
+		if err != nil {
+			return err
+		}
+		r.l.Debug("汇总 UV",
+			logger.String("day", day.Format(time.DateOnly)),
+			logger.Int32("cnt", int32(cnt)))
+	}
+	return nil
+}
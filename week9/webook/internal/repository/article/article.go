@@ -2,6 +2,7 @@ package article
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/ecodeclub/ekit/slice"
 	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
@@ -12,6 +13,19 @@ import (
 	"time"
 )
 
+var (
+	// ErrVersionConflict 保存的时候帖子已经被修改过了
+	ErrVersionConflict = dao.ErrVersionConflict
+	// ErrPossibleIncorrectAuthor 帖子不存在，或者不是这个作者的
+	ErrPossibleIncorrectAuthor = dao.ErrPossibleIncorrectAuthor
+	// ErrRevisionNotFound 历史版本不存在，或者不是这个作者的
+	ErrRevisionNotFound = dao.ErrRevisionNotFound
+	// ErrArticleNotFound 帖子不存在
+	ErrArticleNotFound = dao.ErrArticleNotFound
+	// ErrArticleDeleted 帖子在回收站里面，不能修改、发表或者撤回
+	ErrArticleDeleted = dao.ErrArticleDeleted
+)
+
 // repository 还是要用来操作缓存和DAO
 // 事务概念应该在 DAO 这一层
 
@@ -22,10 +36,38 @@ type ArticleRepository interface {
 	Sync(ctx context.Context, art domain.Article) (int64, error)
 	SyncStatus(ctx context.Context, id int64, author int64, status domain.ArticleStatus) error
 	List(ctx context.Context, uid int64, offset int, limit int) ([]domain.Article, error)
+	// ListByCursor 作者的帖子列表，用游标翻页
+	ListByCursor(ctx context.Context, uid int64, cursor domain.ArticleCursor, limit int) ([]domain.Article, error)
 	GetByID(ctx context.Context, id int64) (domain.Article, error)
 	GetPublishedById(ctx context.Context, id int64) (domain.Article, error)
 	ListPub(ctx context.Context, start time.Time, offset int, limit int) ([]domain.Article, error)
+	// ListPubByCursor 已发表的帖子，用游标翻页
+	ListPubByCursor(ctx context.Context, cursor domain.ArticleCursor, limit int) ([]domain.Article, error)
 	//FindById(ctx context.Context, id int64) domain.Article
+
+	ListRevisions(ctx context.Context, author, id int64, offset, limit int) ([]domain.ArticleRevision, error)
+	GetRevision(ctx context.Context, author, revId int64) (domain.ArticleRevision, error)
+
+	// ListScheduled 按照定时发表的时间先后，列出作者等待发表的帖子
+	ListScheduled(ctx context.Context, author int64, offset, limit int) ([]domain.Article, error)
+	CancelSchedule(ctx context.Context, author, id int64) error
+
+	ListPubByTag(ctx context.Context, tag string, offset, limit int) ([]domain.Article, error)
+	TagCloud(ctx context.Context, limit int) ([]domain.Tag, error)
+	// ListPubAfterId 按照 ID 遍历已发表的帖子，带上作者的名字
+	ListPubAfterId(ctx context.Context, id int64, limit int) ([]domain.Article, error)
+	// ListPubByIds 批量查询已经发表的帖子，不保证顺序
+	ListPubByIds(ctx context.Context, ids []int64) ([]domain.Article, error)
+
+	// SoftDelete 把帖子挪到回收站，制作库和线上库都会改
+	SoftDelete(ctx context.Context, author, id int64) error
+	// Restore 从回收站恢复，恢复之后是未发表的状态
+	Restore(ctx context.Context, author, id int64) error
+	ListDeleted(ctx context.Context, author int64, offset, limit int) ([]domain.Article, error)
+	// ListDeletedBefore 在 before 之前被删除的帖子，清理回收站用
+	ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]domain.Article, error)
+	// Purge 彻底删除帖子，不可恢复
+	Purge(ctx context.Context, id int64) error
 }
 
 type CachedArticleRepository struct {
@@ -74,8 +116,13 @@ func (repo *CachedArticleRepository) GetPublishedById(
 			Id:   usr.Id,
 			Name: usr.Nickname,
 		},
-		Ctime: time.UnixMilli(art.Ctime),
-		Utime: time.UnixMilli(art.Utime),
+		Tags:        art.Tags,
+		HTML:        art.Html,
+		TOC:         tocToDomain(art.Toc),
+		WordCnt:     art.WordCnt,
+		ReadMinutes: art.ReadMinutes,
+		Ctime:       time.UnixMilli(art.Ctime),
+		Utime:       time.UnixMilli(art.Utime),
 	}
 	return res, nil
 }
@@ -117,6 +164,59 @@ func (c *CachedArticleRepository) List(ctx context.Context, uid int64, offset in
 	return data, nil
 }
 
+func (c *CachedArticleRepository) ListByCursor(ctx context.Context,
+	uid int64, cursor domain.ArticleCursor, limit int) ([]domain.Article, error) {
+	// 游标翻页不依赖于每一页的大小，所以第一页的缓存只要够长，都可以直接用
+	if cursor.IsZero() && limit <= 100 {
+		data, err := c.cache.GetFirstPage(ctx, uid)
+		// 缓存的第一页比 limit 短的话，调用者会以为已经到底了，所以要查数据库
+		if err == nil && len(data) >= limit {
+			if len(data) > limit {
+				data = data[:limit]
+			}
+			go func() {
+				c.preCache(ctx, data)
+			}()
+			return data, nil
+		}
+	}
+	res, err := c.dao.GetByAuthorCursor(ctx, uid, toDAOCursor(cursor), limit)
+	if err != nil {
+		return nil, err
+	}
+	data := slice.Map(res, func(idx int, src dao.Article) domain.Article {
+		return c.toDomain(src)
+	})
+	if cursor.IsZero() {
+		go func() {
+			err := c.cache.SetFirstPage(ctx, uid, data)
+			if err != nil {
+				c.l.Error("回写缓存失败", logger.Error(err))
+			}
+			c.preCache(ctx, data)
+		}()
+	}
+	return data, nil
+}
+
+func (c *CachedArticleRepository) ListPubByCursor(ctx context.Context,
+	cursor domain.ArticleCursor, limit int) ([]domain.Article, error) {
+	res, err := c.dao.ListPubCursor(ctx, toDAOCursor(cursor), limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.PublishedArticle) domain.Article {
+		return c.toDomain(dao.Article(src))
+	}), nil
+}
+
+func toDAOCursor(cursor domain.ArticleCursor) dao.Cursor {
+	if cursor.IsZero() {
+		return dao.Cursor{}
+	}
+	return dao.Cursor{Utime: cursor.Utime.UnixMilli(), Id: cursor.Id}
+}
+
 func (repo *CachedArticleRepository) toDomain(art dao.Article) domain.Article {
 	return domain.Article{
 		Id:      art.Id,
@@ -126,13 +226,173 @@ func (repo *CachedArticleRepository) toDomain(art dao.Article) domain.Article {
 		Author: domain.Author{
 			Id: art.AuthorId,
 		},
-		Ctime: time.UnixMilli(art.Ctime),
-		Utime: time.UnixMilli(art.Utime),
+		PublishAt: optionalTimeToDomain(art.PublishAt),
+		Tags:      art.Tags,
+		Version:   art.Version,
+		DeletedAt: optionalTimeToDomain(art.Dtime),
+		Ctime:     time.UnixMilli(art.Ctime),
+		Utime:     time.UnixMilli(art.Utime),
+	}
+}
+
+func (c *CachedArticleRepository) ListScheduled(ctx context.Context,
+	author int64, offset, limit int) ([]domain.Article, error) {
+	res, err := c.dao.ListByStatus(ctx, author,
+		domain.ArticleStatusScheduled.ToUint8(), offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.Article) domain.Article {
+		return c.toDomain(src)
+	}), nil
+}
+
+func (c *CachedArticleRepository) CancelSchedule(ctx context.Context, author, id int64) error {
+	defer func() {
+		// 状态变了，第一页缓存也要清空
+		c.cache.DelFirstPage(ctx, author)
+	}()
+	return c.dao.CancelSchedule(ctx, author, id)
+}
+
+func (c *CachedArticleRepository) SoftDelete(ctx context.Context, author, id int64) error {
+	err := c.dao.SoftDelete(ctx, author, id)
+	if err == nil {
+		c.cache.DelFirstPage(ctx, author)
+	}
+	return err
+}
+
+func (c *CachedArticleRepository) Restore(ctx context.Context, author, id int64) error {
+	err := c.dao.Restore(ctx, author, id)
+	if err == nil {
+		c.cache.DelFirstPage(ctx, author)
+	}
+	return err
+}
+
+func (c *CachedArticleRepository) ListDeleted(ctx context.Context,
+	author int64, offset, limit int) ([]domain.Article, error) {
+	res, err := c.dao.ListDeleted(ctx, author, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.Article) domain.Article {
+		return c.toDomain(src)
+	}), nil
+}
+
+func (c *CachedArticleRepository) ListDeletedBefore(ctx context.Context,
+	before time.Time, limit int) ([]domain.Article, error) {
+	res, err := c.dao.ListDeletedBefore(ctx, before.UnixMilli(), limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.Article) domain.Article {
+		return c.toDomain(src)
+	}), nil
+}
+
+func (c *CachedArticleRepository) Purge(ctx context.Context, id int64) error {
+	return c.dao.Purge(ctx, id)
+}
+
+func (c *CachedArticleRepository) ListPubByTag(ctx context.Context,
+	tag string, offset, limit int) ([]domain.Article, error) {
+	res, err := c.dao.ListPubByTag(ctx, tag, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.PublishedArticle) domain.Article {
+		return c.toDomain(dao.Article(src))
+	}), nil
+}
+
+func (c *CachedArticleRepository) ListPubByIds(ctx context.Context,
+	ids []int64) ([]domain.Article, error) {
+	res, err := c.dao.ListPubByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.PublishedArticle) domain.Article {
+		return c.toDomain(dao.Article(src))
+	}), nil
+}
+
+func (c *CachedArticleRepository) TagCloud(ctx context.Context, limit int) ([]domain.Tag, error) {
+	res, err := c.dao.TagCloud(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.TagCount) domain.Tag {
+		return domain.Tag{Name: src.Name, ArticleCnt: src.Cnt}
+	}), nil
+}
+
+func (c *CachedArticleRepository) ListPubAfterId(ctx context.Context,
+	id int64, limit int) ([]domain.Article, error) {
+	res, err := c.dao.ListPubAfterId(ctx, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	// 同一个作者往往有很多篇，一批里面只查一次
+	names := make(map[int64]string, len(res))
+	return slice.Map(res, func(idx int, src dao.PublishedArticle) domain.Article {
+		art := c.toDomain(dao.Article(src))
+		name, ok := names[src.AuthorId]
+		if !ok {
+			usr, er := c.userRepo.FindById(ctx, src.AuthorId)
+			if er != nil {
+				c.l.Error("查询作者失败",
+					logger.Int64("uid", src.AuthorId),
+					logger.Error(er))
+			}
+			name = usr.Nickname
+			names[src.AuthorId] = name
+		}
+		art.Author.Name = name
+		return art
+	}), nil
+}
+
+func (c *CachedArticleRepository) ListRevisions(ctx context.Context,
+	author, id int64, offset, limit int) ([]domain.ArticleRevision, error) {
+	// 历史版本访问频率很低，不需要缓存
+	res, err := c.dao.ListRevisions(ctx, author, id, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.ArticleRevision) domain.ArticleRevision {
+		return c.toRevisionDomain(src)
+	}), nil
+}
+
+func (c *CachedArticleRepository) GetRevision(ctx context.Context,
+	author, revId int64) (domain.ArticleRevision, error) {
+	res, err := c.dao.GetRevision(ctx, author, revId)
+	if err != nil {
+		return domain.ArticleRevision{}, err
+	}
+	return c.toRevisionDomain(res), nil
+}
+
+func (c *CachedArticleRepository) toRevisionDomain(rev dao.ArticleRevision) domain.ArticleRevision {
+	return domain.ArticleRevision{
+		Id:        rev.Id,
+		ArticleId: rev.ArticleId,
+		Title:     rev.Title,
+		Content:   rev.Content,
+		Author: domain.Author{
+			Id: rev.AuthorId,
+		},
+		Status: domain.ArticleStatus(rev.Status),
+		Ctime:  time.UnixMilli(rev.Ctime),
 	}
 }
 
 func (c *CachedArticleRepository) SyncStatus(ctx context.Context, id int64, author int64, status domain.ArticleStatus) error {
-	return c.dao.SyncStatus(ctx, id, author, uint8(status))
+	// 注意 DAO 上参数的顺序是 author 在前
+	return c.dao.SyncStatus(ctx, author, id, uint8(status))
 }
 
 func (c *CachedArticleRepository) Sync(ctx context.Context, art domain.Article) (int64, error) {
@@ -224,12 +484,7 @@ func (c *CachedArticleRepository) Create(ctx context.Context, art domain.Article
 		// 清空缓存
 		c.cache.DelFirstPage(ctx, art.Author.Id)
 	}()
-	return c.dao.Insert(ctx, dao.Article{
-		Title:    art.Title,
-		Content:  art.Content,
-		AuthorId: art.Author.Id,
-		Status:   uint8(art.Status),
-	})
+	return c.dao.Insert(ctx, c.toEntity(art))
 }
 
 func (c *CachedArticleRepository) Update(ctx context.Context, art domain.Article) error {
@@ -237,13 +492,7 @@ func (c *CachedArticleRepository) Update(ctx context.Context, art domain.Article
 		// 清空缓存
 		c.cache.DelFirstPage(ctx, art.Author.Id)
 	}()
-	return c.dao.UpdateById(ctx, dao.Article{
-		Id:       art.Id,
-		Title:    art.Title,
-		Content:  art.Content,
-		AuthorId: art.Author.Id,
-		Status:   uint8(art.Status),
-	})
+	return c.dao.UpdateById(ctx, c.toEntity(art))
 }
 
 func (c *CachedArticleRepository) toEntity(art domain.Article) dao.Article {
@@ -253,7 +502,49 @@ func (c *CachedArticleRepository) toEntity(art domain.Article) dao.Article {
 		Content:  art.Content,
 		AuthorId: art.Author.Id,
 		Status:   uint8(art.Status),
+		// 零值的时间，UnixMilli 不是 0
+		PublishAt:   optionalTimeToEntity(art.PublishAt),
+		Tags:        art.Tags,
+		Version:     art.Version,
+		Html:        art.HTML,
+		Toc:         tocToEntity(art.TOC),
+		WordCnt:     art.WordCnt,
+		ReadMinutes: art.ReadMinutes,
+	}
+}
+
+func tocToEntity(toc []domain.ArticleHeading) string {
+	if len(toc) == 0 {
+		return ""
+	}
+	// 只有基本类型，不会出错
+	val, _ := json.Marshal(toc)
+	return string(val)
+}
+
+func tocToDomain(toc string) []domain.ArticleHeading {
+	if toc == "" {
+		return nil
+	}
+	var res []domain.ArticleHeading
+	// 解析不了就当作没有目录，不影响读帖子
+	_ = json.Unmarshal([]byte(toc), &res)
+	return res
+}
+
+// optionalTimeToEntity 定时发表、删除这种可能没有的时间，数据库里面用 0 表示没有
+func optionalTimeToEntity(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
+
+func optionalTimeToDomain(t int64) time.Time {
+	if t == 0 {
+		return time.Time{}
 	}
+	return time.UnixMilli(t)
 }
 
 func (c *CachedArticleRepository) preCache(ctx context.Context, data []domain.Article) {
@@ -265,9 +556,14 @@ func (c *CachedArticleRepository) preCache(ctx context.Context, data []domain.Ar
 	}
 }
 
-func NewArticleRepository(dao dao.ArticleDAO, l logger.LoggerV1) ArticleRepository {
+func NewArticleRepository(dao dao.ArticleDAO,
+	userRepo repository.UserRepository,
+	cache cache.ArticleCache,
+	l logger.LoggerV1) ArticleRepository {
 	return &CachedArticleRepository{
-		dao: dao,
-		l:   l,
+		dao:      dao,
+		userRepo: userRepo,
+		cache:    cache,
+		l:        l,
 	}
 }
@@ -0,0 +1,90 @@
+package article
+
+import (
+	"context"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+)
+
+// SearchableArticleRepository 装饰器，在线上库变化之后增量更新搜索索引。
+// 索引更新失败不影响主流程，定时全量重建会兜底
+type SearchableArticleRepository struct {
+	ArticleRepository
+	search repository.SearchRepository
+	l      logger.LoggerV1
+}
+
+func NewSearchableArticleRepository(repo ArticleRepository,
+	search repository.SearchRepository,
+	l logger.LoggerV1) ArticleRepository {
+	return &SearchableArticleRepository{
+		ArticleRepository: repo,
+		search:            search,
+		l:                 l,
+	}
+}
+
+func (s *SearchableArticleRepository) Sync(ctx context.Context, art domain.Article) (int64, error) {
+	id, err := s.ArticleRepository.Sync(ctx, art)
+	if err != nil {
+		return id, err
+	}
+	s.reindex(ctx, id)
+	return id, nil
+}
+
+func (s *SearchableArticleRepository) SyncStatus(ctx context.Context,
+	id int64, author int64, status domain.ArticleStatus) error {
+	err := s.ArticleRepository.SyncStatus(ctx, id, author, status)
+	if err != nil {
+		return err
+	}
+	s.reindex(ctx, id)
+	return nil
+}
+
+func (s *SearchableArticleRepository) SoftDelete(ctx context.Context, author, id int64) error {
+	err := s.ArticleRepository.SoftDelete(ctx, author, id)
+	if err != nil {
+		return err
+	}
+	s.deleteIndex(ctx, id)
+	return nil
+}
+
+func (s *SearchableArticleRepository) Purge(ctx context.Context, id int64) error {
+	err := s.ArticleRepository.Purge(ctx, id)
+	if err != nil {
+		return err
+	}
+	s.deleteIndex(ctx, id)
+	return nil
+}
+
+func (s *SearchableArticleRepository) deleteIndex(ctx context.Context, id int64) {
+	err := s.search.DeleteArticle(ctx, id)
+	if err != nil {
+		s.l.Error("删除搜索索引失败",
+			logger.Int64("aid", id),
+			logger.Error(err))
+	}
+}
+
+// reindex 以线上库为准更新索引，只有已发表的帖子能被搜到
+func (s *SearchableArticleRepository) reindex(ctx context.Context, id int64) {
+	// 从线上库读出来，顺便带上了作者的名字
+	art, err := s.ArticleRepository.GetPublishedById(ctx, id)
+	if err == nil {
+		if art.Status == domain.ArticleStatusPublished {
+			err = s.search.InputArticle(ctx, art)
+		} else {
+			err = s.search.DeleteArticle(ctx, id)
+		}
+	}
+	if err != nil {
+		s.l.Error("更新搜索索引失败",
+			logger.Int64("aid", id),
+			logger.Error(err))
+	}
+}
@@ -0,0 +1,179 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+const (
+	// 大 V 的集合，读的时候要去拉他们的发件箱
+	keyFeedBigAuthors = "feed:big_authors"
+	// 同一毫秒发表的帖子要靠 aid 区分，多取一点，过滤掉游标之前的之后还能凑够一页
+	feedTieSlack = 16
+)
+
+//go:generate mockgen -source=./feed.go -package=cachemocks -destination=mocks/feed.mock.go FeedCache
+type FeedCache interface {
+	// PushToInboxes 推到 uids 每个人的收件箱里面，收件箱只保留最新的那些
+	PushToInboxes(ctx context.Context, uids []int64, item domain.FeedItem) error
+	// RemoveFromInboxes 从 uids 每个人的收件箱里面删掉 aid
+	RemoveFromInboxes(ctx context.Context, uids []int64, aid int64) error
+	// ListInbox 按照发表时间倒序，返回游标之后的 limit 条
+	ListInbox(ctx context.Context, uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FeedItem, error)
+	// AddToOutbox 作者自己的发件箱，不管是不是大 V 都会放
+	AddToOutbox(ctx context.Context, uid int64, item domain.FeedItem) error
+	RemoveFromOutbox(ctx context.Context, uid int64, aid int64) error
+	ListOutbox(ctx context.Context, uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FeedItem, error)
+	// AddBigAuthor 记录大 V，一旦成为大 V 就不会再移除，
+	// 不然他之前只放在发件箱的帖子就拉不到了
+	AddBigAuthor(ctx context.Context, uid int64) error
+	BigAuthors(ctx context.Context) ([]int64, error)
+}
+
+// RedisFeedCache 收件箱和发件箱都是 sorted set，member 是帖子 ID，score 是发表时间的毫秒数
+type RedisFeedCache struct {
+	client redis.Cmdable
+	// 收件箱最多保留多少条，再往前的就看不到了
+	inboxSize int64
+	// 发件箱最多保留多少条，大 V 的粉丝只能拉到这么多
+	outboxSize int64
+	// 很久没有新内容的收件箱就让它过期
+	inboxExpiration time.Duration
+}
+
+func NewRedisFeedCache(client redis.Cmdable) FeedCache {
+	return &RedisFeedCache{
+		client:          client,
+		inboxSize:       1000,
+		outboxSize:      200,
+		inboxExpiration: time.Hour * 24 * 30,
+	}
+}
+
+func (r *RedisFeedCache) PushToInboxes(ctx context.Context,
+	uids []int64, item domain.FeedItem) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	z := redis.Z{
+		Score:  float64(item.Time.UnixMilli()),
+		Member: item.Aid,
+	}
+	pipe := r.client.Pipeline()
+	for _, uid := range uids {
+		key := r.inboxKey(uid)
+		pipe.ZAdd(ctx, key, z)
+		// 只保留分数最高的 inboxSize 条
+		pipe.ZRemRangeByRank(ctx, key, 0, -r.inboxSize-1)
+		pipe.Expire(ctx, key, r.inboxExpiration)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisFeedCache) RemoveFromInboxes(ctx context.Context, uids []int64, aid int64) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	pipe := r.client.Pipeline()
+	for _, uid := range uids {
+		pipe.ZRem(ctx, r.inboxKey(uid), aid)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisFeedCache) ListInbox(ctx context.Context,
+	uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FeedItem, error) {
+	return r.list(ctx, r.inboxKey(uid), cursor, limit)
+}
+
+func (r *RedisFeedCache) AddToOutbox(ctx context.Context, uid int64, item domain.FeedItem) error {
+	key := r.outboxKey(uid)
+	pipe := r.client.Pipeline()
+	pipe.ZAdd(ctx, key, redis.Z{
+		Score:  float64(item.Time.UnixMilli()),
+		Member: item.Aid,
+	})
+	pipe.ZRemRangeByRank(ctx, key, 0, -r.outboxSize-1)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisFeedCache) RemoveFromOutbox(ctx context.Context, uid int64, aid int64) error {
+	return r.client.ZRem(ctx, r.outboxKey(uid), aid).Err()
+}
+
+func (r *RedisFeedCache) ListOutbox(ctx context.Context,
+	uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FeedItem, error) {
+	return r.list(ctx, r.outboxKey(uid), cursor, limit)
+}
+
+// list 从游标的时间开始往前取，同一毫秒的再按照 aid 过滤
+func (r *RedisFeedCache) list(ctx context.Context, key string,
+	cursor domain.UserBizCursor, limit int) ([]domain.FeedItem, error) {
+	maxScore := "+inf"
+	if !cursor.IsZero() {
+		maxScore = strconv.FormatInt(cursor.Time.UnixMilli(), 10)
+	}
+	zs, err := r.client.ZRevRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   maxScore,
+		Count: int64(limit + feedTieSlack),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]domain.FeedItem, 0, limit)
+	for _, z := range zs {
+		aid, err := strconv.ParseInt(z.Member.(string), 10, 64)
+		if err != nil {
+			// 不可能出现
+			continue
+		}
+		item := domain.FeedItem{
+			Aid:  aid,
+			Time: time.UnixMilli(int64(z.Score)),
+		}
+		if !item.After(cursor) {
+			continue
+		}
+		res = append(res, item)
+		if len(res) == limit {
+			break
+		}
+	}
+	return res, nil
+}
+
+func (r *RedisFeedCache) AddBigAuthor(ctx context.Context, uid int64) error {
+	return r.client.SAdd(ctx, keyFeedBigAuthors, uid).Err()
+}
+
+func (r *RedisFeedCache) BigAuthors(ctx context.Context) ([]int64, error) {
+	vals, err := r.client.SMembers(ctx, keyFeedBigAuthors).Result()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]int64, 0, len(vals))
+	for _, val := range vals {
+		uid, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			continue
+		}
+		res = append(res, uid)
+	}
+	return res, nil
+}
+
+func (r *RedisFeedCache) inboxKey(uid int64) string {
+	return fmt.Sprintf("feed:inbox:%d", uid)
+}
+
+func (r *RedisFeedCache) outboxKey(uid int64) string {
+	return fmt.Sprintf("feed:outbox:%d", uid)
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+const (
+	fieldFollowers = "followers"
+	fieldFollowees = "followees"
+)
+
+//go:generate mockgen -source=./follow.go -package=cachemocks -destination=mocks/follow.mock.go FollowCache
+type FollowCache interface {
+	// Follow follower 的关注数和 followee 的粉丝数一起加一，
+	// 缓存里面没有的不管，下次查询的时候从数据库加载
+	Follow(ctx context.Context, follower, followee int64) error
+	// Unfollow 和 Follow 相反，一起减一
+	Unfollow(ctx context.Context, follower, followee int64) error
+	// GetStatics 缓存里面没有的时候返回 ErrKeyNotExist
+	GetStatics(ctx context.Context, uid int64) (domain.FollowStatics, error)
+	SetStatics(ctx context.Context, uid int64, statics domain.FollowStatics) error
+}
+
+// RedisFollowCache 和 RedisInteractiveCache 一样，一个用户一个 hash，
+// 计数只在 key 存在的时候增减
+type RedisFollowCache struct {
+	client     redis.Cmdable
+	expiration time.Duration
+}
+
+func NewRedisFollowCache(client redis.Cmdable) FollowCache {
+	return &RedisFollowCache{
+		client:     client,
+		expiration: time.Minute * 15,
+	}
+}
+
+func (r *RedisFollowCache) Follow(ctx context.Context, follower, followee int64) error {
+	return r.incr(ctx, follower, followee, 1)
+}
+
+func (r *RedisFollowCache) Unfollow(ctx context.Context, follower, followee int64) error {
+	return r.incr(ctx, follower, followee, -1)
+}
+
+func (r *RedisFollowCache) incr(ctx context.Context,
+	follower, followee int64, delta int64) error {
+	pipe := r.client.Pipeline()
+	pipe.Eval(ctx, luaIncrCnt, []string{r.key(follower)}, fieldFollowees, delta)
+	pipe.Eval(ctx, luaIncrCnt, []string{r.key(followee)}, fieldFollowers, delta)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisFollowCache) GetStatics(ctx context.Context, uid int64) (domain.FollowStatics, error) {
+	data, err := r.client.HGetAll(ctx, r.key(uid)).Result()
+	if err != nil {
+		return domain.FollowStatics{}, err
+	}
+	if len(data) == 0 {
+		return domain.FollowStatics{}, ErrKeyNotExist
+	}
+	// 理论上来说，这里不可能有 error
+	followers, _ := strconv.ParseInt(data[fieldFollowers], 10, 64)
+	followees, _ := strconv.ParseInt(data[fieldFollowees], 10, 64)
+	return domain.FollowStatics{
+		Followers: followers,
+		Followees: followees,
+	}, nil
+}
+
+func (r *RedisFollowCache) SetStatics(ctx context.Context,
+	uid int64, statics domain.FollowStatics) error {
+	key := r.key(uid)
+	pipe := r.client.Pipeline()
+	pipe.HMSet(ctx, key,
+		fieldFollowers, statics.Followers,
+		fieldFollowees, statics.Followees)
+	pipe.Expire(ctx, key, r.expiration)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisFollowCache) key(uid int64) string {
+	return fmt.Sprintf("follow:statics:%d", uid)
+}
@@ -3,39 +3,141 @@ package cache
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
 	"github.com/redis/go-redis/v9"
 	"strconv"
+	"strings"
 	"time"
 )
 
 var (
 	//go:embed lua/interative_incr_cnt.lua
 	luaIncrCnt string
+	//go:embed lua/interactive_read_pending_incr.lua
+	luaIncrReadPending string
+	//go:embed lua/interactive_read_pending_snapshot.lua
+	luaSnapshotReadPending string
+	//go:embed lua/interactive_read_pending_ack.lua
+	luaAckReadPending string
+	//go:embed lua/interactive_read_pending_release.lua
+	luaReleaseReadPending string
+	//go:embed lua/interactive_uv_restore.lua
+	luaRestoreUV string
 )
 
 const (
-	fieldReadCnt    = "read_cnt"
-	fieldCollectCnt = "collect_cnt"
-	fieldLikeCnt    = "like_cnt"
+	fieldReadCnt       = "read_cnt"
+	fieldCollectCnt    = "collect_cnt"
+	fieldLikeCnt       = "like_cnt"
+	fieldCommentCnt    = "comment_cnt"
+	fieldLoveCnt       = "love_cnt"
+	fieldLaughCnt      = "laugh_cnt"
+	fieldInsightfulCnt = "insightful_cnt"
+	// 数据库里面的 UV，只在 Redis 的 HyperLogLog 丢了的时候兜底
+	fieldUv = "uv"
+
+	keyPrefix = "interactive:"
+	// 阅读数先累加在这里，定时合并到数据库。
+	// 合并相关的 key 都带上 {read_cnt}，在 Redis Cluster 里面落在同一个 slot 上，才能放进同一个脚本
+	keyReadPending = "interactive:{read_cnt}:pending"
+	// 合并的时候把 pending 改名成这个，合并完成之后删掉
+	keyReadFlushing      = "interactive:{read_cnt}:flushing"
+	keyReadFlushingBatch = "interactive:{read_cnt}:flushing:batch"
+	// 每确认一批就加一
+	keyReadGen = "interactive:{read_cnt}:gen"
+	// 每个资源一个 HyperLogLog，后面跟 biz:bizId，按天的再跟上 :日期
+	keyUvPrefix = "interactive:uv:"
+	// 某一天有人看过的资源，汇总 UV 的时候只扫这些，后面跟日期
+	keyUvDirtyPrefix = "interactive:uv:dirty:"
+	// 按天的 UV 汇总到数据库之后就没用了，留几天给汇总任务重试
+	uvDailyExpiration = time.Hour * 72
+	// 总的 UV 每次有人看都续期，很久没人看的资源过期了就用数据库里面汇总过的，
+	// 不然每一篇看过的帖子都要在 Redis 里面留一个 HyperLogLog。
+	// 过期了又有人看的时候，先用 RestoreUV 把数据库里面存的 HyperLogLog 合并回来
+	uvTotalExpiration = time.Hour * 24 * 30
 )
 
+// UVCnt 某个资源在某一天的 UV 和总的 UV
+type UVCnt struct {
+	Biz     string
+	BizId   int64
+	DayUV   int64
+	TotalUV int64
+	// TotalSketch 总的 UV 的 HyperLogLog 本身，汇总的时候存到数据库，过期了之后用 RestoreUV 恢复
+	TotalSketch []byte
+}
+
+// reactionFields 每一种表态的计数在缓存里面的 field
+var reactionFields = map[domain.ReactionType]string{
+	domain.ReactionLike:       fieldLikeCnt,
+	domain.ReactionLove:       fieldLoveCnt,
+	domain.ReactionLaugh:      fieldLaughCnt,
+	domain.ReactionInsightful: fieldInsightfulCnt,
+}
+
+// ReadCntDelta 还没有合并到数据库的阅读数
+type ReadCntDelta struct {
+	Biz   string
+	BizId int64
+	Delta int64
+}
+
 //go:generate mockgen -source=./interactive.go -package=cachemocks -destination=mocks/interactive.mock.go InteractiveCache
 type InteractiveCache interface {
 
-	// IncrReadCntIfPresent 如果在缓存中有对应的数据，就 +1
-	IncrReadCntIfPresent(ctx context.Context,
-		biz string, bizId int64) error
-	IncrLikeCntIfPresent(ctx context.Context,
-		biz string, bizId int64) error
-	DecrLikeCntIfPresent(ctx context.Context,
-		biz string, bizId int64) error
+	// IncrReadCntPending 阅读数不直接写数据库，先在 Redis 里面累加，
+	// bizs 和 bizIds 一一对应
+	IncrReadCntPending(ctx context.Context, bizs []string, bizIds []int64) error
+	// GetPendingReadCnt 还没有合并到数据库的阅读数，没有的不会出现在结果里面
+	GetPendingReadCnt(ctx context.Context, biz string, bizIds []int64) (map[int64]int64, error)
+	// SnapshotPendingReadCnt 合并的第一阶段，把待合并的阅读数冻结下来。
+	// 如果上一次合并没有 Ack，返回的还是上一次的批次和数据，batch 会被忽略
+	SnapshotPendingReadCnt(ctx context.Context, batch string) (string, []ReadCntDelta, error)
+	// AckPendingReadCnt 合并的第二阶段，数据库已经更新好了，
+	// 删掉这些资源的缓存，以及冻结的数据
+	AckPendingReadCnt(ctx context.Context, batch string) error
+	// ReadCntGen 合并的代数，每确认一批就加一。
+	// 回写缓存之前先查一次，传给 Set 和 SetByIds
+	ReadCntGen(ctx context.Context) (int64, error)
+	// SwitchReactionIfPresent from 的计数减一，to 的计数加一，都是空字符串的时候什么都不做。
+	// 只有一个的时候就是表态或者取消表态
+	SwitchReactionIfPresent(ctx context.Context, biz string, bizId int64,
+		from, to domain.ReactionType) error
 	IncrCollectCntIfPresent(ctx context.Context, biz string, bizId int64) error
+	DecrCollectCntIfPresent(ctx context.Context, biz string, bizId int64) error
+	// IncrCommentCntIfPresent 删除评论的时候会连带删除回复，所以 delta 可能是负数
+	IncrCommentCntIfPresent(ctx context.Context, biz string, bizId int64, delta int64) error
 	// Get 查询缓存中数据
 	// 事实上，这里 liked 和 collected 是不需要缓存的
 	Get(ctx context.Context, biz string, bizId int64) (domain.Interactive, error)
-	Set(ctx context.Context, biz string, bizId int64, intr domain.Interactive) error
+	// Set 回写缓存，gen 是查询数据库之前的 ReadCntGen。
+	// 写完之后代数变了，说明查到的可能是合并之前的阅读数，刚写进去的会被删掉
+	Set(ctx context.Context, biz string, bizId int64, intr domain.Interactive, gen int64) error
+	// GetByIds 批量查询，只返回缓存中有的，调用者自己处理没有命中的部分
+	GetByIds(ctx context.Context, biz string, bizIds []int64) (map[int64]domain.Interactive, error)
+	// SetByIds 批量回写，gen 和 Set 的一样
+	SetByIds(ctx context.Context, biz string, intrs map[int64]domain.Interactive, gen int64) error
+	Del(ctx context.Context, biz string, bizId int64) error
+
+	// AddUV 记录读者，用 HyperLogLog 同时算按天的和总的 UV。
+	// bizs、bizIds 和 uids 一一对应，day 的格式是 20060102
+	AddUV(ctx context.Context, bizs []string, bizIds []int64, uids []int64, day string) error
+	// GetUV 总的 UV，HyperLogLog 不存在或者过期了的就是 0，调用者用数据库里面的兜底
+	GetUV(ctx context.Context, biz string, bizIds []int64) (map[int64]int64, error)
+	// MissingUV 总的 UV 的 HyperLogLog 是不是不在 Redis 里面，返回的和 bizIds 一一对应
+	MissingUV(ctx context.Context, bizs []string, bizIds []int64) ([]bool, error)
+	// RestoreUV 把 ScanDailyUV 返回的 TotalSketch 合并回总的 UV，
+	// 和过期之后新加的读者取并集
+	RestoreUV(ctx context.Context, biz string, bizId int64, sketch []byte) error
+	// ScanDailyUV 用 SSCAN 遍历某一天有人看过的资源，cursor 为 0 代表开始，
+	// 返回的 cursor 为 0 说明遍历完了
+	ScanDailyUV(ctx context.Context, day string, cursor uint64, count int64) (uint64, []UVCnt, error)
+
+	// PublishChange 通知所有实例这些资源的计数变了，只通知不带值，
+	// 订阅的一方用 InteractiveChangeSubscriber
+	PublishChange(ctx context.Context, biz string, bizIds ...int64) error
 }
 
 // 方案1
@@ -53,43 +155,144 @@ type RedisInteractiveCache struct {
 
 func (r *RedisInteractiveCache) IncrCollectCntIfPresent(ctx context.Context,
 	biz string, bizId int64) error {
-	return r.client.Eval(ctx, luaIncrCnt, []string{r.key(biz, bizId),
-		fieldCollectCnt}, 1).Err()
+	return r.client.Eval(ctx, luaIncrCnt, []string{r.key(biz, bizId)},
+		fieldCollectCnt, 1).Err()
 }
 
-func (r *RedisInteractiveCache) IncrReadCntIfPresent(ctx context.Context,
+func (r *RedisInteractiveCache) DecrCollectCntIfPresent(ctx context.Context,
 	biz string, bizId int64) error {
-	// 拿到的结果，可能自增成功了，可能不需要自增（key不存在）
-	// 你要不要返回一个 error 表达 key 不存在？
-	//res, err := r.client.Eval(ctx, luaIncrCnt,
-	//	[]string{r.key(biz, bizId)},
-	//	// read_cnt +1
-	//	"read_cnt", 1).Int()
-	//if err != nil {
-	//	return err
-	//}
-	//if res == 0 {
-	// 这边一般是缓存过期了
-	//	return errors.New("缓存中 key 不存在")
-	//}
-	return r.client.Eval(ctx, luaIncrCnt,
-		[]string{r.key(biz, bizId)},
-		// read_cnt +1
-		fieldReadCnt, 1).Err()
+	return r.client.Eval(ctx, luaIncrCnt, []string{r.key(biz, bizId)},
+		fieldCollectCnt, -1).Err()
 }
 
-func (r *RedisInteractiveCache) IncrLikeCntIfPresent(ctx context.Context,
-	biz string, bizId int64) error {
-	return r.client.Eval(ctx, luaIncrCnt,
-		[]string{r.key(biz, bizId)},
-		fieldLikeCnt, 1).Err()
+func (r *RedisInteractiveCache) IncrCommentCntIfPresent(ctx context.Context,
+	biz string, bizId int64, delta int64) error {
+	return r.client.Eval(ctx, luaIncrCnt, []string{r.key(biz, bizId)},
+		fieldCommentCnt, delta).Err()
 }
 
-func (r *RedisInteractiveCache) DecrLikeCntIfPresent(ctx context.Context,
-	biz string, bizId int64) error {
+func (r *RedisInteractiveCache) IncrReadCntPending(ctx context.Context,
+	bizs []string, bizIds []int64) error {
+	if len(bizs) != len(bizIds) {
+		return errors.New("biz 和 bizId 的数量不一致")
+	}
+	if len(bizs) == 0 {
+		return nil
+	}
+	fields := make([]any, 0, len(bizs))
+	for i := range bizs {
+		fields = append(fields, r.field(bizs[i], bizIds[i]))
+	}
+	// 缓存里面的 read_cnt 是数据库里面的值，这里不用动
+	return r.client.Eval(ctx, luaIncrReadPending,
+		[]string{keyReadPending}, fields...).Err()
+}
+
+func (r *RedisInteractiveCache) GetPendingReadCnt(ctx context.Context,
+	biz string, bizIds []int64) (map[int64]int64, error) {
+	res := make(map[int64]int64, len(bizIds))
+	if len(bizIds) == 0 {
+		return res, nil
+	}
+	fields := make([]string, 0, len(bizIds))
+	for _, id := range bizIds {
+		fields = append(fields, r.field(biz, id))
+	}
+	pipe := r.client.Pipeline()
+	pending := pipe.HMGet(ctx, keyReadPending, fields...)
+	// 正在合并的也要算上，不然合并的过程中阅读数会变少
+	flushing := pipe.HMGet(ctx, keyReadFlushing, fields...)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range bizIds {
+		delta := r.parseDelta(pending.Val(), i) + r.parseDelta(flushing.Val(), i)
+		if delta != 0 {
+			res[id] = delta
+		}
+	}
+	return res, nil
+}
+
+func (r *RedisInteractiveCache) SnapshotPendingReadCnt(ctx context.Context,
+	batch string) (string, []ReadCntDelta, error) {
+	data, err := r.client.Eval(ctx, luaSnapshotReadPending,
+		[]string{keyReadPending, keyReadFlushing, keyReadFlushingBatch},
+		batch).StringSlice()
+	if err != nil || len(data) == 0 {
+		return "", nil, err
+	}
+	// 第一个是批次号，后面是 field value 交替
+	batch, data = data[0], data[1:]
+	res := make([]ReadCntDelta, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		biz, bizId, ok := r.parseField(data[i])
+		if !ok {
+			// 不认识的 field，不会出现，除非有人手动改了 Redis
+			continue
+		}
+		delta, _ := strconv.ParseInt(data[i+1], 10, 64)
+		res = append(res, ReadCntDelta{Biz: biz, BizId: bizId, Delta: delta})
+	}
+	return batch, res, nil
+}
+
+func (r *RedisInteractiveCache) AckPendingReadCnt(ctx context.Context, batch string) error {
+	fields, err := r.client.Eval(ctx, luaAckReadPending,
+		[]string{keyReadFlushing, keyReadFlushingBatch, keyReadGen},
+		batch).StringSlice()
+	if err == redis.Nil {
+		// 不是这一批
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// 一个个删，不然 Redis Cluster 会报 CROSSSLOT
+	if err = r.del(ctx, fields); err != nil {
+		return err
+	}
+	// 在这之前，读者都会加上冻结的阅读数，多算一点，但是不会少算
+	return r.client.Eval(ctx, luaReleaseReadPending,
+		[]string{keyReadFlushing, keyReadFlushingBatch}, batch).Err()
+}
+
+// del 删除资源的缓存，fields 是 biz:bizId
+func (r *RedisInteractiveCache) del(ctx context.Context, fields []string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	pipe := r.client.Pipeline()
+	for _, field := range fields {
+		pipe.Del(ctx, keyPrefix+field)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisInteractiveCache) ReadCntGen(ctx context.Context) (int64, error) {
+	res, err := r.client.Get(ctx, keyReadGen).Int64()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	return res, err
+}
+
+func (r *RedisInteractiveCache) SwitchReactionIfPresent(ctx context.Context,
+	biz string, bizId int64, from, to domain.ReactionType) error {
+	args := make([]any, 0, 4)
+	if from != "" {
+		args = append(args, reactionFields[from], -1)
+	}
+	if to != "" {
+		args = append(args, reactionFields[to], 1)
+	}
+	if len(args) == 0 {
+		return nil
+	}
 	return r.client.Eval(ctx, luaIncrCnt,
-		[]string{r.key(biz, bizId)},
-		fieldLikeCnt, -1).Err()
+		[]string{r.key(biz, bizId)}, args...).Err()
 }
 
 //func (r *RedisInteractiveCache) GetV1(ctx context.Context,
@@ -112,42 +315,300 @@ func (r *RedisInteractiveCache) Get(ctx context.Context,
 	// 所以你没有办法判定，缓存里面是有这个key，但是对应 cnt 都是0，还是说没有这个 key
 
 	// 拿到 key 对应的值里面的所有的 field
-	data, err := r.client.HGetAll(ctx, r.key(biz, bizId)).Result()
+	res, err := r.GetByIds(ctx, biz, []int64{bizId})
 	if err != nil {
 		return domain.Interactive{}, err
 	}
-
-	if len(data) == 0 {
+	intr, ok := res[bizId]
+	if !ok {
 		// 缓存不存在，系统错误，比如说你的同事，手贱设置了缓存，但是忘记任何 fields
 		return domain.Interactive{}, ErrKeyNotExist
 	}
+	return intr, nil
+}
+
+func (r *RedisInteractiveCache) GetByIds(ctx context.Context,
+	biz string, bizIds []int64) (map[int64]domain.Interactive, error) {
+	if len(bizIds) == 0 {
+		return map[int64]domain.Interactive{}, nil
+	}
+	// 用 pipeline 一次性发过去，省掉 N 次网络往返
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, 0, len(bizIds))
+	uvs := make([]*redis.IntCmd, 0, len(bizIds))
+	fields := make([]string, 0, len(bizIds))
+	for _, id := range bizIds {
+		cmds = append(cmds, pipe.HGetAll(ctx, r.key(biz, id)))
+		uvs = append(uvs, pipe.PFCount(ctx, r.uvKey(biz, id)))
+		fields = append(fields, r.field(biz, id))
+	}
+	// 缓存里面的阅读数是数据库的值，还要加上没有合并的
+	pending := pipe.HMGet(ctx, keyReadPending, fields...)
+	flushing := pipe.HMGet(ctx, keyReadFlushing, fields...)
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[int64]domain.Interactive, len(bizIds))
+	for i, cmd := range cmds {
+		data := cmd.Val()
+		if len(data) == 0 {
+			continue
+		}
+		intr := r.toDomain(data)
+		intr.ReadCnt += r.parseDelta(pending.Val(), i) + r.parseDelta(flushing.Val(), i)
+		// HyperLogLog 丢了的时候，用数据库里面汇总过的兜底
+		if uv := uvs[i].Val(); uv > intr.Uv {
+			intr.Uv = uv
+		}
+		res[bizIds[i]] = intr
+	}
+	return res, nil
+}
+
+func (r *RedisInteractiveCache) parseDelta(vals []any, i int) int64 {
+	if i >= len(vals) {
+		return 0
+	}
+	// 没有的时候是 nil
+	str, ok := vals[i].(string)
+	if !ok {
+		return 0
+	}
+	delta, _ := strconv.ParseInt(str, 10, 64)
+	return delta
+}
 
+func (r *RedisInteractiveCache) toDomain(data map[string]string) domain.Interactive {
 	// 理论上来说，这里不可能有 error
 	collectCnt, _ := strconv.ParseInt(data[fieldCollectCnt], 10, 64)
 	likeCnt, _ := strconv.ParseInt(data[fieldLikeCnt], 10, 64)
 	readCnt, _ := strconv.ParseInt(data[fieldReadCnt], 10, 64)
+	commentCnt, _ := strconv.ParseInt(data[fieldCommentCnt], 10, 64)
+	uv, _ := strconv.ParseInt(data[fieldUv], 10, 64)
+	loveCnt, _ := strconv.ParseInt(data[fieldLoveCnt], 10, 64)
+	laughCnt, _ := strconv.ParseInt(data[fieldLaughCnt], 10, 64)
+	insightfulCnt, _ := strconv.ParseInt(data[fieldInsightfulCnt], 10, 64)
 
 	return domain.Interactive{
-		CollectCnt: collectCnt,
-		LikeCnt:    likeCnt,
-		ReadCnt:    readCnt,
-	}, err
+		CollectCnt:    collectCnt,
+		LikeCnt:       likeCnt,
+		ReadCnt:       readCnt,
+		CommentCnt:    commentCnt,
+		LoveCnt:       loveCnt,
+		LaughCnt:      laughCnt,
+		InsightfulCnt: insightfulCnt,
+		Uv:            uv,
+	}
+}
+
+func (r *RedisInteractiveCache) Set(ctx context.Context,
+	biz string, bizId int64, intr domain.Interactive, gen int64) error {
+	return r.SetByIds(ctx, biz, map[int64]domain.Interactive{bizId: intr}, gen)
 }
 
-func (r *RedisInteractiveCache) Set(ctx context.Context, biz string, bizId int64, intr domain.Interactive) error {
-	key := r.key(biz, bizId)
-	err := r.client.HMSet(ctx, key,
+func (r *RedisInteractiveCache) SetByIds(ctx context.Context,
+	biz string, intrs map[int64]domain.Interactive, gen int64) error {
+	if len(intrs) == 0 {
+		return nil
+	}
+	pipe := r.client.Pipeline()
+	fields := make([]string, 0, len(intrs))
+	for id, intr := range intrs {
+		key := r.key(biz, id)
+		pipe.HMSet(ctx, key, r.fields(intr)...)
+		pipe.Expire(ctx, key, time.Minute*15)
+		fields = append(fields, r.field(biz, id))
+	}
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return err
+	}
+	// 写完之后再查代数。查数据库到写缓存之间确认过一批的话，
+	// 写进去的可能是合并之前的阅读数，而冻结的阅读数已经删掉了，会一直少算
+	cur, err := r.ReadCntGen(ctx)
+	if err != nil || cur == gen {
+		return err
+	}
+	return r.del(ctx, fields)
+}
+
+func (r *RedisInteractiveCache) fields(intr domain.Interactive) []any {
+	return []any{
 		fieldLikeCnt, intr.LikeCnt,
 		fieldCollectCnt, intr.CollectCnt,
-		fieldReadCnt, intr.ReadCnt).Err()
+		fieldReadCnt, intr.ReadCnt,
+		fieldCommentCnt, intr.CommentCnt,
+		fieldLoveCnt, intr.LoveCnt,
+		fieldLaughCnt, intr.LaughCnt,
+		fieldInsightfulCnt, intr.InsightfulCnt,
+		fieldUv, intr.Uv,
+	}
+}
+
+func (r *RedisInteractiveCache) Del(ctx context.Context, biz string, bizId int64) error {
+	return r.client.Del(ctx, r.key(biz, bizId)).Err()
+}
+
+func (r *RedisInteractiveCache) AddUV(ctx context.Context,
+	bizs []string, bizIds []int64, uids []int64, day string) error {
+	if len(bizIds) == 0 {
+		return nil
+	}
+	pipe := r.client.Pipeline()
+	dirty := keyUvDirtyPrefix + day
+	for i := range bizIds {
+		uid := strconv.FormatInt(uids[i], 10)
+		daily := r.dailyUvKey(bizs[i], bizIds[i], day)
+		total := r.uvKey(bizs[i], bizIds[i])
+		pipe.PFAdd(ctx, total, uid)
+		pipe.Expire(ctx, total, uvTotalExpiration)
+		pipe.PFAdd(ctx, daily, uid)
+		pipe.Expire(ctx, daily, uvDailyExpiration)
+		pipe.SAdd(ctx, dirty, r.field(bizs[i], bizIds[i]))
+	}
+	pipe.Expire(ctx, dirty, uvDailyExpiration)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisInteractiveCache) GetUV(ctx context.Context,
+	biz string, bizIds []int64) (map[int64]int64, error) {
+	if len(bizIds) == 0 {
+		return map[int64]int64{}, nil
+	}
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.IntCmd, 0, len(bizIds))
+	for _, id := range bizIds {
+		cmds = append(cmds, pipe.PFCount(ctx, r.uvKey(biz, id)))
+	}
+	_, err := pipe.Exec(ctx)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	res := make(map[int64]int64, len(bizIds))
+	for i, cmd := range cmds {
+		res[bizIds[i]] = cmd.Val()
+	}
+	return res, nil
+}
+
+func (r *RedisInteractiveCache) MissingUV(ctx context.Context,
+	bizs []string, bizIds []int64) ([]bool, error) {
+	if len(bizIds) == 0 {
+		return nil, nil
+	}
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.IntCmd, 0, len(bizIds))
+	for i, id := range bizIds {
+		cmds = append(cmds, pipe.Exists(ctx, r.uvKey(bizs[i], id)))
+	}
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return nil, err
 	}
-	return r.client.Expire(ctx, key, time.Minute*15).Err()
+	res := make([]bool, len(cmds))
+	for i, cmd := range cmds {
+		res[i] = cmd.Val() == 0
+	}
+	return res, nil
+}
+
+func (r *RedisInteractiveCache) RestoreUV(ctx context.Context,
+	biz string, bizId int64, sketch []byte) error {
+	total := r.uvKey(biz, bizId)
+	// 用 total 整个作为 hash tag，Redis Cluster 上两个 key 才在同一个 slot
+	saved := "{" + total + "}:restore"
+	return r.client.Eval(ctx, luaRestoreUV, []string{total, saved},
+		sketch, int64(uvTotalExpiration/time.Second)).Err()
+}
+
+func (r *RedisInteractiveCache) ScanDailyUV(ctx context.Context,
+	day string, cursor uint64, count int64) (uint64, []UVCnt, error) {
+	members, next, err := r.client.SScan(ctx, keyUvDirtyPrefix+day, cursor, "", count).Result()
+	if err != nil || len(members) == 0 {
+		return next, nil, err
+	}
+	res := make([]UVCnt, 0, len(members))
+	pipe := r.client.Pipeline()
+	daily := make([]*redis.IntCmd, 0, len(members))
+	total := make([]*redis.IntCmd, 0, len(members))
+	sketches := make([]*redis.StringCmd, 0, len(members))
+	for _, m := range members {
+		biz, bizId, ok := r.parseField(m)
+		if !ok {
+			continue
+		}
+		res = append(res, UVCnt{Biz: biz, BizId: bizId})
+		daily = append(daily, pipe.PFCount(ctx, r.dailyUvKey(biz, bizId, day)))
+		total = append(total, pipe.PFCount(ctx, r.uvKey(biz, bizId)))
+		// HyperLogLog 就是一个字符串，原样读出来，SET 回去就可以接着用
+		sketches = append(sketches, pipe.Get(ctx, r.uvKey(biz, bizId)))
+	}
+	if len(res) == 0 {
+		return next, nil, nil
+	}
+	_, err = pipe.Exec(ctx)
+	// 总的 UV 刚好过期了的话 GET 返回 redis.Nil，这个资源就不保存 HyperLogLog
+	if err != nil && err != redis.Nil {
+		return 0, nil, err
+	}
+	for i := range res {
+		res[i].DayUV = daily[i].Val()
+		res[i].TotalUV = total[i].Val()
+		if sketch, er := sketches[i].Bytes(); er == nil {
+			res[i].TotalSketch = sketch
+		}
+	}
+	return next, res, nil
+}
+
+func (r *RedisInteractiveCache) PublishChange(ctx context.Context,
+	biz string, bizIds ...int64) error {
+	if len(bizIds) == 0 {
+		return nil
+	}
+	// 一批阅读事件里面同一个资源会出现很多次，通知一次就够了
+	seen := make(map[int64]struct{}, len(bizIds))
+	pipe := r.client.Pipeline()
+	for _, bizId := range bizIds {
+		if _, ok := seen[bizId]; ok {
+			continue
+		}
+		seen[bizId] = struct{}{}
+		pipe.Publish(ctx, changeChannel(biz, bizId), "")
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisInteractiveCache) uvKey(biz string, bizId int64) string {
+	return keyUvPrefix + r.field(biz, bizId)
+}
+
+func (r *RedisInteractiveCache) dailyUvKey(biz string, bizId int64, day string) string {
+	return r.uvKey(biz, bizId) + ":" + day
 }
 
 func (r *RedisInteractiveCache) key(biz string, bizId int64) string {
-	return fmt.Sprintf("interactive:%s:%d", biz, bizId)
+	return keyPrefix + r.field(biz, bizId)
+}
+
+// field 待合并的阅读数里面的 field，加上 keyPrefix 就是资源的缓存 key
+func (r *RedisInteractiveCache) field(biz string, bizId int64) string {
+	return fmt.Sprintf("%s:%d", biz, bizId)
+}
+
+func (r *RedisInteractiveCache) parseField(field string) (string, int64, bool) {
+	idx := strings.LastIndexByte(field, ':')
+	if idx <= 0 {
+		return "", 0, false
+	}
+	bizId, err := strconv.ParseInt(field[idx+1:], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return field[:idx], bizId, true
 }
 
 //func (r *RedisInteractiveCache) keyPersonal(biz string, bizId int64) string {
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"strings"
+)
+
+// 一个资源一个 channel，只有订阅了这个资源的实例才会收到，后面跟 biz:bizId
+const changeChannelPrefix = "interactive:changed:"
+
+// InteractiveChange 计数变了的资源
+type InteractiveChange struct {
+	Biz   string
+	BizId int64
+}
+
+//go:generate mockgen -source=./interactive_change.go -package=cachemocks -destination=mocks/interactive_change.mock.go InteractiveChangeSubscriber InteractiveSubscription
+type InteractiveChangeSubscriber interface {
+	// Subscribe 返回的订阅一开始什么都不监听，用 Watch 和 Unwatch 增减
+	Subscribe(ctx context.Context) InteractiveSubscription
+}
+
+type InteractiveSubscription interface {
+	Watch(ctx context.Context, biz string, bizId int64) error
+	Unwatch(ctx context.Context, biz string, bizId int64) error
+	// Changes 收到的计数变化，Close 之后会被关掉
+	Changes() <-chan InteractiveChange
+	Close() error
+}
+
+// RedisInteractiveChangeSubscriber 订阅 RedisInteractiveCache.PublishChange 发出来的消息。
+// Cmdable 里面没有 Subscribe，所以要的是 UniversalClient
+type RedisInteractiveChangeSubscriber struct {
+	client redis.UniversalClient
+}
+
+func NewRedisInteractiveChangeSubscriber(client redis.UniversalClient) InteractiveChangeSubscriber {
+	return &RedisInteractiveChangeSubscriber{client: client}
+}
+
+func (r *RedisInteractiveChangeSubscriber) Subscribe(ctx context.Context) InteractiveSubscription {
+	ps := r.client.Subscribe(ctx)
+	sub := &redisInteractiveSubscription{
+		ps:      ps,
+		changes: make(chan InteractiveChange, 1024),
+	}
+	go sub.loop()
+	return sub
+}
+
+type redisInteractiveSubscription struct {
+	ps      *redis.PubSub
+	changes chan InteractiveChange
+}
+
+func (r *redisInteractiveSubscription) loop() {
+	defer close(r.changes)
+	// Close 之后 Channel 会被关掉
+	for msg := range r.ps.Channel() {
+		biz, bizId, ok := parseChangeChannel(msg.Channel)
+		if !ok {
+			continue
+		}
+		r.changes <- InteractiveChange{Biz: biz, BizId: bizId}
+	}
+}
+
+func (r *redisInteractiveSubscription) Watch(ctx context.Context, biz string, bizId int64) error {
+	return r.ps.Subscribe(ctx, changeChannel(biz, bizId))
+}
+
+func (r *redisInteractiveSubscription) Unwatch(ctx context.Context, biz string, bizId int64) error {
+	return r.ps.Unsubscribe(ctx, changeChannel(biz, bizId))
+}
+
+func (r *redisInteractiveSubscription) Changes() <-chan InteractiveChange {
+	return r.changes
+}
+
+func (r *redisInteractiveSubscription) Close() error {
+	return r.ps.Close()
+}
+
+func changeChannel(biz string, bizId int64) string {
+	return fmt.Sprintf("%s%s:%d", changeChannelPrefix, biz, bizId)
+}
+
+func parseChangeChannel(channel string) (string, int64, bool) {
+	field, ok := strings.CutPrefix(channel, changeChannelPrefix)
+	if !ok {
+		return "", 0, false
+	}
+	idx := strings.LastIndexByte(field, ':')
+	if idx <= 0 {
+		return "", 0, false
+	}
+	bizId, err := strconv.ParseInt(field[idx+1:], 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return field[:idx], bizId, true
+}
@@ -3,7 +3,7 @@ package cache
 import (
 	"context"
 	"errors"
-	"github.com/ecodeclub/ekit/syncx/atomicx"
+	"github.com/ecodeclub/ekit/syncx"
 	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
 	"time"
 )
@@ -11,17 +11,13 @@ import (
 type RankingLocalCache struct {
 	// 我用我的泛型封装
 	// 你可以考虑直接使用 uber 的，或者 SDK 自带的
-	topN       *atomicx.Value[[]domain.Article]
-	ddl        *atomicx.Value[time.Time]
-	expiration time.Duration
+	// 榜单名字 => 这个榜单的数据
+	boards *syncx.Map[string, item]
 }
 
 func NewRankingLocalCache() *RankingLocalCache {
 	return &RankingLocalCache{
-		topN: atomicx.NewValue[[]domain.Article](),
-		ddl:  atomicx.NewValueOf(time.Now()),
-		// 永不过期，或者非常长，或者对齐到 redis 的过期时间，都行
-		expiration: time.Minute * 10,
+		boards: &syncx.Map[string, item]{},
 	}
 }
 
@@ -29,25 +25,28 @@ func NewRankingLocalCache() *RankingLocalCache {
 //
 //}
 
-func (r *RankingLocalCache) Set(ctx context.Context, arts []domain.Article) error {
+// Set 永不过期，或者非常长，或者对齐到 redis 的过期时间，都行，由调用者决定
+func (r *RankingLocalCache) Set(ctx context.Context, board string,
+	arts []domain.Article, expiration time.Duration) error {
 	// 也可以按照 id => Article 缓存
-	r.topN.Store(arts)
-	ddl := time.Now().Add(r.expiration)
-	r.ddl.Store(ddl)
+	r.boards.Store(board, item{
+		arts: arts,
+		ddl:  time.Now().Add(expiration),
+	})
 	return nil
 }
 
-func (r *RankingLocalCache) Get(ctx context.Context) ([]domain.Article, error) {
-	ddl := r.ddl.Load()
-	arts := r.topN.Load()
-	if len(arts) == 0 || ddl.Before(time.Now()) {
+func (r *RankingLocalCache) Get(ctx context.Context, board string) ([]domain.Article, error) {
+	val, _ := r.boards.Load(board)
+	if len(val.arts) == 0 || val.ddl.Before(time.Now()) {
 		return nil, errors.New("本地缓存未命中")
 	}
-	return arts, nil
+	return val.arts, nil
 }
-func (r *RankingLocalCache) ForceGet(ctx context.Context) ([]domain.Article, error) {
-	arts := r.topN.Load()
-	return arts, nil
+
+func (r *RankingLocalCache) ForceGet(ctx context.Context, board string) ([]domain.Article, error) {
+	val, _ := r.boards.Load(board)
+	return val.arts, nil
 }
 
 type item struct {
@@ -0,0 +1,15 @@
+-- 正在合并的阅读数
+local flushing = KEYS[1]
+local batchKey = KEYS[2]
+-- 合并的代数，回写缓存的时候用来判断读到的是不是合并之前的值
+local genKey = KEYS[3]
+-- 要确认的批次号
+local batch = ARGV[1]
+if redis.call("GET", batchKey) ~= batch then
+    -- 不是这一批，可能已经确认过了
+    return false
+end
+redis.call("INCR", genKey)
+-- 这些资源的缓存要删掉重新加载。
+-- 它们分散在不同的 slot 上，Redis Cluster 不允许在脚本里面操作没有声明的 key，所以交给调用者删
+return redis.call("HKEYS", flushing)
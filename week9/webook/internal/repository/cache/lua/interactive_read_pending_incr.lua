@@ -0,0 +1,7 @@
+-- 待合并的阅读数，field 是 biz:bizId
+local key = KEYS[1]
+-- 每一个 ARGV 都是一个 field，各自 +1
+for i = 1, #ARGV do
+    redis.call("HINCRBY", key, ARGV[i], 1)
+end
+return #ARGV
@@ -0,0 +1,10 @@
+-- 正在合并的阅读数
+local flushing = KEYS[1]
+local batchKey = KEYS[2]
+-- 要释放的批次号
+local batch = ARGV[1]
+if redis.call("GET", batchKey) ~= batch then
+    return 0
+end
+-- 资源的缓存已经删掉了，读者不会再重复计算这一批
+return redis.call("DEL", flushing, batchKey)
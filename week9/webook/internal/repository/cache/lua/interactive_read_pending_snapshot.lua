@@ -0,0 +1,28 @@
+-- 待合并的阅读数
+local pending = KEYS[1]
+-- 正在合并的阅读数
+local flushing = KEYS[2]
+-- 正在合并的这一批的批次号
+local batchKey = KEYS[3]
+-- 新的批次号
+local batch = ARGV[1]
+if redis.call("EXISTS", flushing) == 0 then
+    if redis.call("EXISTS", pending) == 0 then
+        -- 没有需要合并的
+        return {}
+    end
+    -- 之后的阅读数会写到新的 pending 里面
+    redis.call("RENAME", pending, flushing)
+    redis.call("SET", batchKey, batch)
+else
+    -- 上一次合并没有确认，可能是中途崩溃了，用原来的批次号重来
+    local old = redis.call("GET", batchKey)
+    if old then
+        batch = old
+    else
+        redis.call("SET", batchKey, batch)
+    end
+end
+local res = redis.call("HGETALL", flushing)
+table.insert(res, 1, batch)
+return res
@@ -0,0 +1,12 @@
+-- 总的 UV 的 HyperLogLog
+local total = KEYS[1]
+-- 临时放数据库里面保存的 HyperLogLog，和 total 在同一个 slot 上
+local saved = KEYS[2]
+local sketch = ARGV[1]
+local ttl = tonumber(ARGV[2])
+redis.call("SET", saved, sketch)
+-- 取并集，过期之后新来的读者和之前的读者都算上，重复恢复也没有影响
+redis.call("PFMERGE", total, total, saved)
+redis.call("DEL", saved)
+redis.call("EXPIRE", total, ttl)
+return 1
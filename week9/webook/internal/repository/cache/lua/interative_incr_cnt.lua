@@ -1,14 +1,15 @@
 local key = KEYS[1]
--- 对应到的是 hincrby 中的 field
-local cntKey = ARGV[1]
--- +1 或者 -1
-local delta = tonumber(ARGV[2])
+-- 后面是一对一对的 field 和 delta，对应到的是 hincrby 中的 field
+-- 换一种表态的时候，一个减一，一个加一，要一起改
 local exists = redis.call("EXISTS", key)
 if exists == 1 then
-    redis.call("HINCRBY", key, cntKey, delta)
+    for i = 1, #ARGV, 2 do
+        -- +1 或者 -1
+        redis.call("HINCRBY", key, ARGV[i], tonumber(ARGV[i + 1]))
+    end
     -- 说明自增成功了
     return 1
 else
     -- 自增不成功
     return 0
-end
\ No newline at end of file
+end
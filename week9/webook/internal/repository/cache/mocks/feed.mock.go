@@ -0,0 +1,155 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: feed.go
+//
+// Generated by this command:
+//
+//	mockgen -source=feed.go -package=cachemocks -destination=mocks/feed.mock.go
+//
+// Package cachemocks is a generated GoMock package.
+package cachemocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFeedCache is a mock of FeedCache interface.
+type MockFeedCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockFeedCacheMockRecorder
+}
+
+// MockFeedCacheMockRecorder is the mock recorder for MockFeedCache.
+type MockFeedCacheMockRecorder struct {
+	mock *MockFeedCache
+}
+
+// NewMockFeedCache creates a new mock instance.
+func NewMockFeedCache(ctrl *gomock.Controller) *MockFeedCache {
+	mock := &MockFeedCache{ctrl: ctrl}
+	mock.recorder = &MockFeedCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFeedCache) EXPECT() *MockFeedCacheMockRecorder {
+	return m.recorder
+}
+
+// AddBigAuthor mocks base method.
+func (m *MockFeedCache) AddBigAuthor(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddBigAuthor", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddBigAuthor indicates an expected call of AddBigAuthor.
+func (mr *MockFeedCacheMockRecorder) AddBigAuthor(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBigAuthor", reflect.TypeOf((*MockFeedCache)(nil).AddBigAuthor), ctx, uid)
+}
+
+// AddToOutbox mocks base method.
+func (m *MockFeedCache) AddToOutbox(ctx context.Context, uid int64, item domain.FeedItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddToOutbox", ctx, uid, item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddToOutbox indicates an expected call of AddToOutbox.
+func (mr *MockFeedCacheMockRecorder) AddToOutbox(ctx, uid, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToOutbox", reflect.TypeOf((*MockFeedCache)(nil).AddToOutbox), ctx, uid, item)
+}
+
+// BigAuthors mocks base method.
+func (m *MockFeedCache) BigAuthors(ctx context.Context) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BigAuthors", ctx)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BigAuthors indicates an expected call of BigAuthors.
+func (mr *MockFeedCacheMockRecorder) BigAuthors(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BigAuthors", reflect.TypeOf((*MockFeedCache)(nil).BigAuthors), ctx)
+}
+
+// ListInbox mocks base method.
+func (m *MockFeedCache) ListInbox(ctx context.Context, uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FeedItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInbox", ctx, uid, cursor, limit)
+	ret0, _ := ret[0].([]domain.FeedItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInbox indicates an expected call of ListInbox.
+func (mr *MockFeedCacheMockRecorder) ListInbox(ctx, uid, cursor, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInbox", reflect.TypeOf((*MockFeedCache)(nil).ListInbox), ctx, uid, cursor, limit)
+}
+
+// ListOutbox mocks base method.
+func (m *MockFeedCache) ListOutbox(ctx context.Context, uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FeedItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOutbox", ctx, uid, cursor, limit)
+	ret0, _ := ret[0].([]domain.FeedItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOutbox indicates an expected call of ListOutbox.
+func (mr *MockFeedCacheMockRecorder) ListOutbox(ctx, uid, cursor, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOutbox", reflect.TypeOf((*MockFeedCache)(nil).ListOutbox), ctx, uid, cursor, limit)
+}
+
+// PushToInboxes mocks base method.
+func (m *MockFeedCache) PushToInboxes(ctx context.Context, uids []int64, item domain.FeedItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PushToInboxes", ctx, uids, item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PushToInboxes indicates an expected call of PushToInboxes.
+func (mr *MockFeedCacheMockRecorder) PushToInboxes(ctx, uids, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PushToInboxes", reflect.TypeOf((*MockFeedCache)(nil).PushToInboxes), ctx, uids, item)
+}
+
+// RemoveFromInboxes mocks base method.
+func (m *MockFeedCache) RemoveFromInboxes(ctx context.Context, uids []int64, aid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveFromInboxes", ctx, uids, aid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveFromInboxes indicates an expected call of RemoveFromInboxes.
+func (mr *MockFeedCacheMockRecorder) RemoveFromInboxes(ctx, uids, aid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFromInboxes", reflect.TypeOf((*MockFeedCache)(nil).RemoveFromInboxes), ctx, uids, aid)
+}
+
+// RemoveFromOutbox mocks base method.
+func (m *MockFeedCache) RemoveFromOutbox(ctx context.Context, uid, aid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveFromOutbox", ctx, uid, aid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveFromOutbox indicates an expected call of RemoveFromOutbox.
+func (mr *MockFeedCacheMockRecorder) RemoveFromOutbox(ctx, uid, aid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFromOutbox", reflect.TypeOf((*MockFeedCache)(nil).RemoveFromOutbox), ctx, uid, aid)
+}
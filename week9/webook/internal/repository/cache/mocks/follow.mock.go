@@ -0,0 +1,97 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: follow.go
+//
+// Generated by this command:
+//
+//	mockgen -source=follow.go -package=cachemocks -destination=mocks/follow.mock.go
+//
+// Package cachemocks is a generated GoMock package.
+package cachemocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFollowCache is a mock of FollowCache interface.
+type MockFollowCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockFollowCacheMockRecorder
+}
+
+// MockFollowCacheMockRecorder is the mock recorder for MockFollowCache.
+type MockFollowCacheMockRecorder struct {
+	mock *MockFollowCache
+}
+
+// NewMockFollowCache creates a new mock instance.
+func NewMockFollowCache(ctrl *gomock.Controller) *MockFollowCache {
+	mock := &MockFollowCache{ctrl: ctrl}
+	mock.recorder = &MockFollowCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFollowCache) EXPECT() *MockFollowCacheMockRecorder {
+	return m.recorder
+}
+
+// Follow mocks base method.
+func (m *MockFollowCache) Follow(ctx context.Context, follower, followee int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Follow", ctx, follower, followee)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Follow indicates an expected call of Follow.
+func (mr *MockFollowCacheMockRecorder) Follow(ctx, follower, followee any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Follow", reflect.TypeOf((*MockFollowCache)(nil).Follow), ctx, follower, followee)
+}
+
+// GetStatics mocks base method.
+func (m *MockFollowCache) GetStatics(ctx context.Context, uid int64) (domain.FollowStatics, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatics", ctx, uid)
+	ret0, _ := ret[0].(domain.FollowStatics)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatics indicates an expected call of GetStatics.
+func (mr *MockFollowCacheMockRecorder) GetStatics(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatics", reflect.TypeOf((*MockFollowCache)(nil).GetStatics), ctx, uid)
+}
+
+// SetStatics mocks base method.
+func (m *MockFollowCache) SetStatics(ctx context.Context, uid int64, statics domain.FollowStatics) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStatics", ctx, uid, statics)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStatics indicates an expected call of SetStatics.
+func (mr *MockFollowCacheMockRecorder) SetStatics(ctx, uid, statics any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStatics", reflect.TypeOf((*MockFollowCache)(nil).SetStatics), ctx, uid, statics)
+}
+
+// Unfollow mocks base method.
+func (m *MockFollowCache) Unfollow(ctx context.Context, follower, followee int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unfollow", ctx, follower, followee)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unfollow indicates an expected call of Unfollow.
+func (mr *MockFollowCacheMockRecorder) Unfollow(ctx, follower, followee any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unfollow", reflect.TypeOf((*MockFollowCache)(nil).Unfollow), ctx, follower, followee)
+}
@@ -0,0 +1,336 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./interactive.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./interactive.go -package=cachemocks -destination=mocks/interactive.mock.go
+//
+// Package cachemocks is a generated GoMock package.
+package cachemocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	cache "github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockInteractiveCache is a mock of InteractiveCache interface.
+type MockInteractiveCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockInteractiveCacheMockRecorder
+}
+
+// MockInteractiveCacheMockRecorder is the mock recorder for MockInteractiveCache.
+type MockInteractiveCacheMockRecorder struct {
+	mock *MockInteractiveCache
+}
+
+// NewMockInteractiveCache creates a new mock instance.
+func NewMockInteractiveCache(ctrl *gomock.Controller) *MockInteractiveCache {
+	mock := &MockInteractiveCache{ctrl: ctrl}
+	mock.recorder = &MockInteractiveCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInteractiveCache) EXPECT() *MockInteractiveCacheMockRecorder {
+	return m.recorder
+}
+
+// AckPendingReadCnt mocks base method.
+func (m *MockInteractiveCache) AckPendingReadCnt(ctx context.Context, batch string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AckPendingReadCnt", ctx, batch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AckPendingReadCnt indicates an expected call of AckPendingReadCnt.
+func (mr *MockInteractiveCacheMockRecorder) AckPendingReadCnt(ctx, batch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AckPendingReadCnt", reflect.TypeOf((*MockInteractiveCache)(nil).AckPendingReadCnt), ctx, batch)
+}
+
+// AddUV mocks base method.
+func (m *MockInteractiveCache) AddUV(ctx context.Context, bizs []string, bizIds, uids []int64, day string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUV", ctx, bizs, bizIds, uids, day)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddUV indicates an expected call of AddUV.
+func (mr *MockInteractiveCacheMockRecorder) AddUV(ctx, bizs, bizIds, uids, day any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUV", reflect.TypeOf((*MockInteractiveCache)(nil).AddUV), ctx, bizs, bizIds, uids, day)
+}
+
+// DecrCollectCntIfPresent mocks base method.
+func (m *MockInteractiveCache) DecrCollectCntIfPresent(ctx context.Context, biz string, bizId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecrCollectCntIfPresent", ctx, biz, bizId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DecrCollectCntIfPresent indicates an expected call of DecrCollectCntIfPresent.
+func (mr *MockInteractiveCacheMockRecorder) DecrCollectCntIfPresent(ctx, biz, bizId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecrCollectCntIfPresent", reflect.TypeOf((*MockInteractiveCache)(nil).DecrCollectCntIfPresent), ctx, biz, bizId)
+}
+
+// Del mocks base method.
+func (m *MockInteractiveCache) Del(ctx context.Context, biz string, bizId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Del", ctx, biz, bizId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Del indicates an expected call of Del.
+func (mr *MockInteractiveCacheMockRecorder) Del(ctx, biz, bizId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Del", reflect.TypeOf((*MockInteractiveCache)(nil).Del), ctx, biz, bizId)
+}
+
+// Get mocks base method.
+func (m *MockInteractiveCache) Get(ctx context.Context, biz string, bizId int64) (domain.Interactive, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, biz, bizId)
+	ret0, _ := ret[0].(domain.Interactive)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockInteractiveCacheMockRecorder) Get(ctx, biz, bizId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockInteractiveCache)(nil).Get), ctx, biz, bizId)
+}
+
+// GetByIds mocks base method.
+func (m *MockInteractiveCache) GetByIds(ctx context.Context, biz string, bizIds []int64) (map[int64]domain.Interactive, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIds", ctx, biz, bizIds)
+	ret0, _ := ret[0].(map[int64]domain.Interactive)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIds indicates an expected call of GetByIds.
+func (mr *MockInteractiveCacheMockRecorder) GetByIds(ctx, biz, bizIds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIds", reflect.TypeOf((*MockInteractiveCache)(nil).GetByIds), ctx, biz, bizIds)
+}
+
+// GetPendingReadCnt mocks base method.
+func (m *MockInteractiveCache) GetPendingReadCnt(ctx context.Context, biz string, bizIds []int64) (map[int64]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingReadCnt", ctx, biz, bizIds)
+	ret0, _ := ret[0].(map[int64]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingReadCnt indicates an expected call of GetPendingReadCnt.
+func (mr *MockInteractiveCacheMockRecorder) GetPendingReadCnt(ctx, biz, bizIds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingReadCnt", reflect.TypeOf((*MockInteractiveCache)(nil).GetPendingReadCnt), ctx, biz, bizIds)
+}
+
+// GetUV mocks base method.
+func (m *MockInteractiveCache) GetUV(ctx context.Context, biz string, bizIds []int64) (map[int64]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUV", ctx, biz, bizIds)
+	ret0, _ := ret[0].(map[int64]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUV indicates an expected call of GetUV.
+func (mr *MockInteractiveCacheMockRecorder) GetUV(ctx, biz, bizIds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUV", reflect.TypeOf((*MockInteractiveCache)(nil).GetUV), ctx, biz, bizIds)
+}
+
+// IncrCollectCntIfPresent mocks base method.
+func (m *MockInteractiveCache) IncrCollectCntIfPresent(ctx context.Context, biz string, bizId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrCollectCntIfPresent", ctx, biz, bizId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrCollectCntIfPresent indicates an expected call of IncrCollectCntIfPresent.
+func (mr *MockInteractiveCacheMockRecorder) IncrCollectCntIfPresent(ctx, biz, bizId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrCollectCntIfPresent", reflect.TypeOf((*MockInteractiveCache)(nil).IncrCollectCntIfPresent), ctx, biz, bizId)
+}
+
+// IncrCommentCntIfPresent mocks base method.
+func (m *MockInteractiveCache) IncrCommentCntIfPresent(ctx context.Context, biz string, bizId, delta int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrCommentCntIfPresent", ctx, biz, bizId, delta)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrCommentCntIfPresent indicates an expected call of IncrCommentCntIfPresent.
+func (mr *MockInteractiveCacheMockRecorder) IncrCommentCntIfPresent(ctx, biz, bizId, delta any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrCommentCntIfPresent", reflect.TypeOf((*MockInteractiveCache)(nil).IncrCommentCntIfPresent), ctx, biz, bizId, delta)
+}
+
+// IncrReadCntPending mocks base method.
+func (m *MockInteractiveCache) IncrReadCntPending(ctx context.Context, bizs []string, bizIds []int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrReadCntPending", ctx, bizs, bizIds)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrReadCntPending indicates an expected call of IncrReadCntPending.
+func (mr *MockInteractiveCacheMockRecorder) IncrReadCntPending(ctx, bizs, bizIds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrReadCntPending", reflect.TypeOf((*MockInteractiveCache)(nil).IncrReadCntPending), ctx, bizs, bizIds)
+}
+
+// MissingUV mocks base method.
+func (m *MockInteractiveCache) MissingUV(ctx context.Context, bizs []string, bizIds []int64) ([]bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MissingUV", ctx, bizs, bizIds)
+	ret0, _ := ret[0].([]bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MissingUV indicates an expected call of MissingUV.
+func (mr *MockInteractiveCacheMockRecorder) MissingUV(ctx, bizs, bizIds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MissingUV", reflect.TypeOf((*MockInteractiveCache)(nil).MissingUV), ctx, bizs, bizIds)
+}
+
+// PublishChange mocks base method.
+func (m *MockInteractiveCache) PublishChange(ctx context.Context, biz string, bizIds ...int64) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, biz}
+	for _, a := range bizIds {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PublishChange", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PublishChange indicates an expected call of PublishChange.
+func (mr *MockInteractiveCacheMockRecorder) PublishChange(ctx, biz any, bizIds ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, biz}, bizIds...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishChange", reflect.TypeOf((*MockInteractiveCache)(nil).PublishChange), varargs...)
+}
+
+// ReadCntGen mocks base method.
+func (m *MockInteractiveCache) ReadCntGen(ctx context.Context) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadCntGen", ctx)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReadCntGen indicates an expected call of ReadCntGen.
+func (mr *MockInteractiveCacheMockRecorder) ReadCntGen(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadCntGen", reflect.TypeOf((*MockInteractiveCache)(nil).ReadCntGen), ctx)
+}
+
+// RestoreUV mocks base method.
+func (m *MockInteractiveCache) RestoreUV(ctx context.Context, biz string, bizId int64, sketch []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUV", ctx, biz, bizId, sketch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreUV indicates an expected call of RestoreUV.
+func (mr *MockInteractiveCacheMockRecorder) RestoreUV(ctx, biz, bizId, sketch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUV", reflect.TypeOf((*MockInteractiveCache)(nil).RestoreUV), ctx, biz, bizId, sketch)
+}
+
+// ScanDailyUV mocks base method.
+func (m *MockInteractiveCache) ScanDailyUV(ctx context.Context, day string, cursor uint64, count int64) (uint64, []cache.UVCnt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScanDailyUV", ctx, day, cursor, count)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].([]cache.UVCnt)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ScanDailyUV indicates an expected call of ScanDailyUV.
+func (mr *MockInteractiveCacheMockRecorder) ScanDailyUV(ctx, day, cursor, count any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScanDailyUV", reflect.TypeOf((*MockInteractiveCache)(nil).ScanDailyUV), ctx, day, cursor, count)
+}
+
+// Set mocks base method.
+func (m *MockInteractiveCache) Set(ctx context.Context, biz string, bizId int64, intr domain.Interactive, gen int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, biz, bizId, intr, gen)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockInteractiveCacheMockRecorder) Set(ctx, biz, bizId, intr, gen any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockInteractiveCache)(nil).Set), ctx, biz, bizId, intr, gen)
+}
+
+// SetByIds mocks base method.
+func (m *MockInteractiveCache) SetByIds(ctx context.Context, biz string, intrs map[int64]domain.Interactive, gen int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetByIds", ctx, biz, intrs, gen)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetByIds indicates an expected call of SetByIds.
+func (mr *MockInteractiveCacheMockRecorder) SetByIds(ctx, biz, intrs, gen any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetByIds", reflect.TypeOf((*MockInteractiveCache)(nil).SetByIds), ctx, biz, intrs, gen)
+}
+
+// SnapshotPendingReadCnt mocks base method.
+func (m *MockInteractiveCache) SnapshotPendingReadCnt(ctx context.Context, batch string) (string, []cache.ReadCntDelta, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SnapshotPendingReadCnt", ctx, batch)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].([]cache.ReadCntDelta)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SnapshotPendingReadCnt indicates an expected call of SnapshotPendingReadCnt.
+func (mr *MockInteractiveCacheMockRecorder) SnapshotPendingReadCnt(ctx, batch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnapshotPendingReadCnt", reflect.TypeOf((*MockInteractiveCache)(nil).SnapshotPendingReadCnt), ctx, batch)
+}
+
+// SwitchReactionIfPresent mocks base method.
+func (m *MockInteractiveCache) SwitchReactionIfPresent(ctx context.Context, biz string, bizId int64, from, to domain.ReactionType) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SwitchReactionIfPresent", ctx, biz, bizId, from, to)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SwitchReactionIfPresent indicates an expected call of SwitchReactionIfPresent.
+func (mr *MockInteractiveCacheMockRecorder) SwitchReactionIfPresent(ctx, biz, bizId, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SwitchReactionIfPresent", reflect.TypeOf((*MockInteractiveCache)(nil).SwitchReactionIfPresent), ctx, biz, bizId, from, to)
+}
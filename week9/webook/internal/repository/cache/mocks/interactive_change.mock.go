@@ -0,0 +1,133 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interactive_change.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interactive_change.go -package=cachemocks -destination=mocks/interactive_change.mock.go
+//
+// Package cachemocks is a generated GoMock package.
+package cachemocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	cache "github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockInteractiveChangeSubscriber is a mock of InteractiveChangeSubscriber interface.
+type MockInteractiveChangeSubscriber struct {
+	ctrl     *gomock.Controller
+	recorder *MockInteractiveChangeSubscriberMockRecorder
+}
+
+// MockInteractiveChangeSubscriberMockRecorder is the mock recorder for MockInteractiveChangeSubscriber.
+type MockInteractiveChangeSubscriberMockRecorder struct {
+	mock *MockInteractiveChangeSubscriber
+}
+
+// NewMockInteractiveChangeSubscriber creates a new mock instance.
+func NewMockInteractiveChangeSubscriber(ctrl *gomock.Controller) *MockInteractiveChangeSubscriber {
+	mock := &MockInteractiveChangeSubscriber{ctrl: ctrl}
+	mock.recorder = &MockInteractiveChangeSubscriberMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInteractiveChangeSubscriber) EXPECT() *MockInteractiveChangeSubscriberMockRecorder {
+	return m.recorder
+}
+
+// Subscribe mocks base method.
+func (m *MockInteractiveChangeSubscriber) Subscribe(ctx context.Context) cache.InteractiveSubscription {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx)
+	ret0, _ := ret[0].(cache.InteractiveSubscription)
+	return ret0
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockInteractiveChangeSubscriberMockRecorder) Subscribe(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockInteractiveChangeSubscriber)(nil).Subscribe), ctx)
+}
+
+// MockInteractiveSubscription is a mock of InteractiveSubscription interface.
+type MockInteractiveSubscription struct {
+	ctrl     *gomock.Controller
+	recorder *MockInteractiveSubscriptionMockRecorder
+}
+
+// MockInteractiveSubscriptionMockRecorder is the mock recorder for MockInteractiveSubscription.
+type MockInteractiveSubscriptionMockRecorder struct {
+	mock *MockInteractiveSubscription
+}
+
+// NewMockInteractiveSubscription creates a new mock instance.
+func NewMockInteractiveSubscription(ctrl *gomock.Controller) *MockInteractiveSubscription {
+	mock := &MockInteractiveSubscription{ctrl: ctrl}
+	mock.recorder = &MockInteractiveSubscriptionMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInteractiveSubscription) EXPECT() *MockInteractiveSubscriptionMockRecorder {
+	return m.recorder
+}
+
+// Changes mocks base method.
+func (m *MockInteractiveSubscription) Changes() <-chan cache.InteractiveChange {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Changes")
+	ret0, _ := ret[0].(<-chan cache.InteractiveChange)
+	return ret0
+}
+
+// Changes indicates an expected call of Changes.
+func (mr *MockInteractiveSubscriptionMockRecorder) Changes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Changes", reflect.TypeOf((*MockInteractiveSubscription)(nil).Changes))
+}
+
+// Close mocks base method.
+func (m *MockInteractiveSubscription) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockInteractiveSubscriptionMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockInteractiveSubscription)(nil).Close))
+}
+
+// Unwatch mocks base method.
+func (m *MockInteractiveSubscription) Unwatch(ctx context.Context, biz string, bizId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unwatch", ctx, biz, bizId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unwatch indicates an expected call of Unwatch.
+func (mr *MockInteractiveSubscriptionMockRecorder) Unwatch(ctx, biz, bizId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unwatch", reflect.TypeOf((*MockInteractiveSubscription)(nil).Unwatch), ctx, biz, bizId)
+}
+
+// Watch mocks base method.
+func (m *MockInteractiveSubscription) Watch(ctx context.Context, biz string, bizId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Watch", ctx, biz, bizId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Watch indicates an expected call of Watch.
+func (mr *MockInteractiveSubscriptionMockRecorder) Watch(ctx, biz, bizId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockInteractiveSubscription)(nil).Watch), ctx, biz, bizId)
+}
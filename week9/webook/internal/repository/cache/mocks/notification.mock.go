@@ -0,0 +1,82 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: notification.go
+//
+// Generated by this command:
+//
+//	mockgen -source=notification.go -package=cachemocks -destination=mocks/notification.mock.go
+//
+// Package cachemocks is a generated GoMock package.
+package cachemocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockNotificationCache is a mock of NotificationCache interface.
+type MockNotificationCache struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationCacheMockRecorder
+}
+
+// MockNotificationCacheMockRecorder is the mock recorder for MockNotificationCache.
+type MockNotificationCacheMockRecorder struct {
+	mock *MockNotificationCache
+}
+
+// NewMockNotificationCache creates a new mock instance.
+func NewMockNotificationCache(ctrl *gomock.Controller) *MockNotificationCache {
+	mock := &MockNotificationCache{ctrl: ctrl}
+	mock.recorder = &MockNotificationCacheMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationCache) EXPECT() *MockNotificationCacheMockRecorder {
+	return m.recorder
+}
+
+// DelUnreadCnt mocks base method.
+func (m *MockNotificationCache) DelUnreadCnt(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DelUnreadCnt", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DelUnreadCnt indicates an expected call of DelUnreadCnt.
+func (mr *MockNotificationCacheMockRecorder) DelUnreadCnt(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DelUnreadCnt", reflect.TypeOf((*MockNotificationCache)(nil).DelUnreadCnt), ctx, uid)
+}
+
+// GetUnreadCnt mocks base method.
+func (m *MockNotificationCache) GetUnreadCnt(ctx context.Context, uid int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnreadCnt", ctx, uid)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnreadCnt indicates an expected call of GetUnreadCnt.
+func (mr *MockNotificationCacheMockRecorder) GetUnreadCnt(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnreadCnt", reflect.TypeOf((*MockNotificationCache)(nil).GetUnreadCnt), ctx, uid)
+}
+
+// SetUnreadCnt mocks base method.
+func (m *MockNotificationCache) SetUnreadCnt(ctx context.Context, uid, cnt int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUnreadCnt", ctx, uid, cnt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUnreadCnt indicates an expected call of SetUnreadCnt.
+func (mr *MockNotificationCacheMockRecorder) SetUnreadCnt(ctx, uid, cnt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUnreadCnt", reflect.TypeOf((*MockNotificationCache)(nil).SetUnreadCnt), ctx, uid, cnt)
+}
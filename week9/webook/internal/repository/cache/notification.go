@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+//go:generate mockgen -source=./notification.go -package=cachemocks -destination=mocks/notification.mock.go NotificationCache
+type NotificationCache interface {
+	// GetUnreadCnt 缓存里面没有的时候返回 ErrKeyNotExist
+	GetUnreadCnt(ctx context.Context, uid int64) (int64, error)
+	SetUnreadCnt(ctx context.Context, uid int64, cnt int64) error
+	// DelUnreadCnt 新建了通知或者标记了已读之后删掉，下次查询的时候从数据库加载
+	DelUnreadCnt(ctx context.Context, uid int64) error
+}
+
+// RedisNotificationCache 前端会轮询未读数，所以缓存起来
+type RedisNotificationCache struct {
+	client     redis.Cmdable
+	expiration time.Duration
+}
+
+func NewRedisNotificationCache(client redis.Cmdable) NotificationCache {
+	return &RedisNotificationCache{
+		client:     client,
+		expiration: time.Minute * 15,
+	}
+}
+
+func (r *RedisNotificationCache) GetUnreadCnt(ctx context.Context, uid int64) (int64, error) {
+	return r.client.Get(ctx, r.key(uid)).Int64()
+}
+
+func (r *RedisNotificationCache) SetUnreadCnt(ctx context.Context, uid int64, cnt int64) error {
+	return r.client.Set(ctx, r.key(uid), cnt, r.expiration).Err()
+}
+
+func (r *RedisNotificationCache) DelUnreadCnt(ctx context.Context, uid int64) error {
+	return r.client.Del(ctx, r.key(uid)).Err()
+}
+
+func (r *RedisNotificationCache) key(uid int64) string {
+	return fmt.Sprintf("notification:unread:%d", uid)
+}
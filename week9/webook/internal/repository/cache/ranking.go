@@ -9,24 +9,27 @@ import (
 )
 
 type RankingCache interface {
-	Set(ctx context.Context, arts []domain.Article) error
-	Get(ctx context.Context) ([]domain.Article, error)
+	// Set expiration 要比这个榜单计算的间隔长，不然两次计算之间会有一段时间没有榜单
+	Set(ctx context.Context, board string, arts []domain.Article, expiration time.Duration) error
+	Get(ctx context.Context, board string) ([]domain.Article, error)
 }
 
 type RankingRedisCache struct {
 	client redis.Cmdable
-	key    string
+	// 每个榜单一个 key，ranking:hot-24h 这种
+	keyPrefix string
 }
 
 func NewRankingRedisCache(client redis.Cmdable) *RankingRedisCache {
 	return &RankingRedisCache{
-		client: client,
-		key:    "ranking",
+		client:    client,
+		keyPrefix: "ranking:",
 	}
 
 }
 
-func (r *RankingRedisCache) Set(ctx context.Context, arts []domain.Article) error {
+func (r *RankingRedisCache) Set(ctx context.Context, board string,
+	arts []domain.Article, expiration time.Duration) error {
 	// 你可以趁机，把 article 写到缓存里面 id => article
 	for i := 0; i < len(arts); i++ {
 		arts[i].Content = ""
@@ -36,12 +39,12 @@ func (r *RankingRedisCache) Set(ctx context.Context, arts []domain.Article) erro
 		return err
 	}
 	// 这个过期时间要稍微长一点，最好是超过计算热榜的时间（包含重试在内的时间）
-	// 你甚至可以直接永不过期
-	return r.client.Set(ctx, r.key, val, time.Minute*10).Err()
+	// 每个榜单算的频率不一样，所以由调用者按照榜单来传
+	return r.client.Set(ctx, r.key(board), val, expiration).Err()
 }
 
-func (r *RankingRedisCache) Get(ctx context.Context) ([]domain.Article, error) {
-	data, err := r.client.Get(ctx, r.key).Bytes()
+func (r *RankingRedisCache) Get(ctx context.Context, board string) ([]domain.Article, error) {
+	data, err := r.client.Get(ctx, r.key(board)).Bytes()
 	if err != nil {
 		return nil, err
 	}
@@ -50,3 +53,7 @@ func (r *RankingRedisCache) Get(ctx context.Context) ([]domain.Article, error) {
 	err = json.Unmarshal(data, &res)
 	return res, err
 }
+
+func (r *RankingRedisCache) key(board string) string {
+	return r.keyPrefix + board
+}
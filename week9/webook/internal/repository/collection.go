@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/dao"
+	"time"
+)
+
+var ErrCollectionNotFound = dao.ErrRecordNotFound
+
+//go:generate mockgen -source=./collection.go -package=repomocks -destination=mocks/collection.mock.go CollectionRepository
+type CollectionRepository interface {
+	Create(ctx context.Context, c domain.Collection) (int64, error)
+	GetById(ctx context.Context, id int64) (domain.Collection, error)
+	Rename(ctx context.Context, uid, id int64, name string) error
+	// Delete 删除收藏夹，里面的东西会挪到默认收藏夹
+	Delete(ctx context.Context, uid, id int64) error
+	List(ctx context.Context, uid int64) ([]domain.Collection, error)
+	ListItems(ctx context.Context, uid, cid int64, offset, limit int) ([]domain.CollectionItem, error)
+	// ListAllItems 不分收藏夹，用户收藏过的所有东西，最近收藏的在前面
+	ListAllItems(ctx context.Context, uid int64,
+		cursor domain.UserBizCursor, limit int) ([]domain.UserBizRecord, error)
+	MoveItem(ctx context.Context, uid int64, biz string, bizId, cid int64) error
+}
+
+// CollectionDBRepository 收藏夹只有自己会看，访问量不大，所以不缓存
+type CollectionDBRepository struct {
+	dao dao.CollectionDAO
+}
+
+func NewCollectionDBRepository(dao dao.CollectionDAO) CollectionRepository {
+	return &CollectionDBRepository{dao: dao}
+}
+
+func (c *CollectionDBRepository) Create(ctx context.Context, col domain.Collection) (int64, error) {
+	return c.dao.Insert(ctx, dao.Collection{
+		Name: col.Name,
+		Uid:  col.Uid,
+	})
+}
+
+func (c *CollectionDBRepository) GetById(ctx context.Context, id int64) (domain.Collection, error) {
+	res, err := c.dao.GetById(ctx, id)
+	if err != nil {
+		return domain.Collection{}, err
+	}
+	return c.toDomain(res), nil
+}
+
+func (c *CollectionDBRepository) Rename(ctx context.Context, uid, id int64, name string) error {
+	return c.dao.UpdateName(ctx, uid, id, name)
+}
+
+func (c *CollectionDBRepository) Delete(ctx context.Context, uid, id int64) error {
+	return c.dao.Delete(ctx, uid, id)
+}
+
+func (c *CollectionDBRepository) List(ctx context.Context, uid int64) ([]domain.Collection, error) {
+	res, err := c.dao.ListByUid(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.Collection) domain.Collection {
+		return c.toDomain(src)
+	}), nil
+}
+
+func (c *CollectionDBRepository) ListItems(ctx context.Context,
+	uid, cid int64, offset, limit int) ([]domain.CollectionItem, error) {
+	res, err := c.dao.ListItems(ctx, uid, cid, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.UserCollectionBiz) domain.CollectionItem {
+		return domain.CollectionItem{
+			Cid:   src.Cid,
+			Biz:   src.Biz,
+			BizId: src.BizId,
+			Uid:   src.Uid,
+			Ctime: time.UnixMilli(src.Ctime),
+		}
+	}), nil
+}
+
+func (c *CollectionDBRepository) ListAllItems(ctx context.Context,
+	uid int64, cursor domain.UserBizCursor, limit int) ([]domain.UserBizRecord, error) {
+	res, err := c.dao.ListAllItems(ctx, uid, toTimeCursor(cursor), limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.UserCollectionBiz) domain.UserBizRecord {
+		return domain.UserBizRecord{
+			Id:    src.Id,
+			Biz:   src.Biz,
+			BizId: src.BizId,
+			Cid:   src.Cid,
+			Time:  time.UnixMilli(src.Ctime),
+		}
+	}), nil
+}
+
+func (c *CollectionDBRepository) MoveItem(ctx context.Context,
+	uid int64, biz string, bizId, cid int64) error {
+	return c.dao.MoveItem(ctx, uid, biz, bizId, cid)
+}
+
+func (c *CollectionDBRepository) toDomain(col dao.Collection) domain.Collection {
+	return domain.Collection{
+		Id:    col.Id,
+		Name:  col.Name,
+		Uid:   col.Uid,
+		Ctime: time.UnixMilli(col.Ctime),
+		Utime: time.UnixMilli(col.Utime),
+	}
+}
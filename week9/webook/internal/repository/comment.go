@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/dao"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"time"
+)
+
+var ErrCommentNotFound = dao.ErrRecordNotFound
+
+//go:generate mockgen -source=./comment.go -package=repomocks -destination=mocks/comment.mock.go CommentRepository
+type CommentRepository interface {
+	Create(ctx context.Context, c domain.Comment) (int64, error)
+	GetById(ctx context.Context, id int64) (domain.Comment, error)
+	ListRoot(ctx context.Context, biz string, bizId int64,
+		order domain.CommentOrder, offset, limit int) ([]domain.Comment, error)
+	ListReplies(ctx context.Context, rootId int64, offset, limit int) ([]domain.Comment, error)
+	// Delete 删除评论和它下面的所有回复
+	Delete(ctx context.Context, c domain.Comment) error
+}
+
+// CachedCommentRepository 评论本身不缓存，
+// 但是评论数在互动的缓存里面，所以要顺便更新
+type CachedCommentRepository struct {
+	dao       dao.CommentDAO
+	intrCache cache.InteractiveCache
+	l         logger.LoggerV1
+}
+
+func NewCachedCommentRepository(dao dao.CommentDAO,
+	intrCache cache.InteractiveCache,
+	l logger.LoggerV1) CommentRepository {
+	return &CachedCommentRepository{
+		dao:       dao,
+		intrCache: intrCache,
+		l:         l,
+	}
+}
+
+func (c *CachedCommentRepository) Create(ctx context.Context, cmt domain.Comment) (int64, error) {
+	id, err := c.dao.Insert(ctx, c.toEntity(cmt))
+	if err != nil {
+		return 0, err
+	}
+	c.incrCommentCnt(ctx, cmt, 1)
+	return id, nil
+}
+
+func (c *CachedCommentRepository) GetById(ctx context.Context, id int64) (domain.Comment, error) {
+	res, err := c.dao.GetById(ctx, id)
+	if err != nil {
+		return domain.Comment{}, err
+	}
+	return c.toDomain(res), nil
+}
+
+func (c *CachedCommentRepository) ListRoot(ctx context.Context,
+	biz string, bizId int64, order domain.CommentOrder,
+	offset, limit int) ([]domain.Comment, error) {
+	res, err := c.dao.ListRoot(ctx, biz, bizId,
+		order == domain.CommentOrderHot, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.Comment) domain.Comment {
+		return c.toDomain(src)
+	}), nil
+}
+
+func (c *CachedCommentRepository) ListReplies(ctx context.Context,
+	rootId int64, offset, limit int) ([]domain.Comment, error) {
+	res, err := c.dao.ListReplies(ctx, rootId, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.Comment) domain.Comment {
+		return c.toDomain(src)
+	}), nil
+}
+
+func (c *CachedCommentRepository) Delete(ctx context.Context, cmt domain.Comment) error {
+	cnt, err := c.dao.Delete(ctx, c.toEntity(cmt))
+	if err != nil || cnt == 0 {
+		return err
+	}
+	c.incrCommentCnt(ctx, cmt, -cnt)
+	return nil
+}
+
+// incrCommentCnt 数据库已经改好了，缓存更新失败只是计数暂时不准
+func (c *CachedCommentRepository) incrCommentCnt(ctx context.Context,
+	cmt domain.Comment, delta int64) {
+	err := c.intrCache.IncrCommentCntIfPresent(ctx, cmt.Biz, cmt.BizId, delta)
+	if err != nil {
+		c.l.Error("更新缓存中的评论数失败",
+			logger.String("biz", cmt.Biz),
+			logger.Int64("bizId", cmt.BizId),
+			logger.Error(err))
+	}
+}
+
+func (c *CachedCommentRepository) toEntity(cmt domain.Comment) dao.Comment {
+	return dao.Comment{
+		Id:       cmt.Id,
+		Uid:      cmt.Commentator.Id,
+		Biz:      cmt.Biz,
+		BizId:    cmt.BizId,
+		Content:  cmt.Content,
+		RootId:   cmt.RootId,
+		Pid:      cmt.ParentId,
+		ReplyCnt: cmt.ReplyCnt,
+	}
+}
+
+func (c *CachedCommentRepository) toDomain(cmt dao.Comment) domain.Comment {
+	return domain.Comment{
+		Id:          cmt.Id,
+		Biz:         cmt.Biz,
+		BizId:       cmt.BizId,
+		Commentator: domain.User{Id: cmt.Uid},
+		Content:     cmt.Content,
+		RootId:      cmt.RootId,
+		ParentId:    cmt.Pid,
+		ReplyCnt:    cmt.ReplyCnt,
+		Ctime:       time.UnixMilli(cmt.Ctime),
+		Utime:       time.UnixMilli(cmt.Utime),
+	}
+}
@@ -8,10 +8,36 @@ type Article struct {
 	Title   string `gorm:"type=varchar(4096)" bson:"title,omitempty"`
 	Content string `gorm:"type=BLOB" bson:"content,omitempty"`
 	// 作者
-	AuthorId int64 `gorm:"index" bson:"author_id,omitempty"`
+	// 作者查看自己的帖子列表，是按照 (utime, id) 倒序翻页的，
+	// 所以建 author_id, utime 的联合索引，InnoDB 的二级索引里面本身就带了 id
+	AuthorId int64 `gorm:"index:author_utime,priority:1" bson:"author_id,omitempty"`
 	Status   uint8 `bson:"status,omitempty"`
-	Ctime    int64 `bson:"ctime,omitempty"`
-	Utime    int64 `bson:"utime,omitempty"`
+	// 定时发表的时间，毫秒数。0 代表没有定时
+	PublishAt int64 `bson:"publish_at,omitempty"`
+	Ctime     int64 `bson:"ctime,omitempty"`
+	// 读者列表按照 utime 翻页
+	Utime int64 `gorm:"index:author_utime,priority:2;index" bson:"utime,omitempty"`
+	// 在 MySQL 里面标签存在关联表里面，MongoDB 就直接内嵌
+	Tags []string `gorm:"-" bson:"tags,omitempty"`
+	// Version 乐观锁，每次 UpdateById 都加一
+	Version int64 `bson:"version,omitempty"`
+	// Dtime 放进回收站的时间，0 代表没有删除。
+	// 清理任务按照它找出过期的帖子
+	Dtime int64 `gorm:"index" bson:"dtime,omitempty"`
+
+	// 发表的时候渲染出来的结果，读者直接用，不需要每次都渲染
+	Html string `gorm:"type=BLOB" bson:"html,omitempty"`
+	// 目录，JSON 格式
+	Toc         string `gorm:"type=BLOB" bson:"toc,omitempty"`
+	WordCnt     int    `bson:"word_cnt,omitempty"`
+	ReadMinutes int    `bson:"read_minutes,omitempty"`
+}
+
+// draft 制作库不需要渲染的结果，保存之前去掉
+func (a Article) draft() Article {
+	a.Html, a.Toc = "", ""
+	a.WordCnt, a.ReadMinutes = 0, 0
+	return a
 }
 
 // PublishedArticle 衍生类型，偷个懒
@@ -24,8 +50,57 @@ type PublishedArticleV1 struct {
 	Title    string `gorm:"type=varchar(4096)" bson:"title,omitempty"`
 	AuthorId int64  `gorm:"index" bson:"author_id,omitempty"`
 	Status   uint8  `bson:"status,omitempty"`
-	Ctime    int64  `bson:"ctime,omitempty"`
-	Utime    int64  `bson:"utime,omitempty"`
+	// ContentKey 内容在对象存储里面的 key。每次发表都换一个新的 key，
+	// 所以这一行在提交之前，一直指向上一次发表的、完整的内容
+	ContentKey string `gorm:"type=varchar(256)" bson:"content_key,omitempty"`
+	Dtime      int64  `bson:"dtime,omitempty"`
+	Ctime      int64  `bson:"ctime,omitempty"`
+	Utime      int64  `bson:"utime,omitempty"`
+
+	// 渲染的结果和 published_articles 一样放在数据库里面，读者看详情的时候直接用
+	Html        string `gorm:"type=BLOB" bson:"html,omitempty"`
+	Toc         string `gorm:"type=BLOB" bson:"toc,omitempty"`
+	WordCnt     int    `bson:"word_cnt,omitempty"`
+	ReadMinutes int    `bson:"read_minutes,omitempty"`
+}
+
+// ArticleRevision 帖子的历史版本，只插入，不更新
+type ArticleRevision struct {
+	Id int64 `gorm:"primaryKey,autoIncrement" bson:"id,omitempty"`
+	// 按照帖子查询历史版本，并且校验作者
+	// WHERE article_id = ? AND author_id = ? ORDER BY id DESC
+	ArticleId int64  `gorm:"index:aid_author" bson:"article_id,omitempty"`
+	AuthorId  int64  `gorm:"index:aid_author" bson:"author_id,omitempty"`
+	Title     string `gorm:"type=varchar(4096)" bson:"title,omitempty"`
+	Content   string `gorm:"type=BLOB" bson:"content,omitempty"`
+	Status    uint8  `bson:"status,omitempty"`
+	Ctime     int64  `bson:"ctime,omitempty"`
+}
+
+// Tag 标签本身，name 上有唯一索引
+type Tag struct {
+	Id    int64  `gorm:"primaryKey,autoIncrement"`
+	Name  string `gorm:"type:varchar(64);uniqueIndex"`
+	Ctime int64
+}
+
+// ArticleTag 制作库里面帖子和标签的多对多关系
+type ArticleTag struct {
+	Id int64 `gorm:"primaryKey,autoIncrement"`
+	// 按照帖子找标签
+	ArticleId int64 `gorm:"uniqueIndex:aid_tid"`
+	// 按照标签找帖子
+	TagId int64 `gorm:"uniqueIndex:aid_tid;index"`
+	Ctime int64
+}
+
+// PublishedArticleTag 线上库的关联关系，读者按照标签浏览用的是这个
+type PublishedArticleTag ArticleTag
+
+// TagCount 标签云的查询结果，不是表
+type TagCount struct {
+	Name string `bson:"_id"`
+	Cnt  int64  `bson:"cnt"`
 }
 
 //func (u *Article) BeforeCreate(tx *gorm.DB) (err error) {
@@ -3,6 +3,7 @@ package article
 import (
 	"context"
 	"errors"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"time"
@@ -29,7 +30,8 @@ func (dao *GORMArticleDAO) GetByAuthor(ctx context.Context, author int64, offset
 	// 你的工作就是优化了这个查询，加进去了索引
 	// author_id => author_id, utime 的联合索引
 	err := dao.db.WithContext(ctx).Model(&Article{}).
-		Where("author_id = ?", author).
+		// 回收站里面的不算
+		Where("author_id = ? AND status <> ?", author, statusDeleted).
 		Offset(offset).
 		Limit(limit).
 		// 升序排序。 utime ASC
@@ -44,11 +46,54 @@ func (dao *GORMArticleDAO) GetByAuthor(ctx context.Context, author int64, offset
 	return arts, err
 }
 
+func (dao *GORMArticleDAO) GetByAuthorCursor(ctx context.Context,
+	author int64, cursor Cursor, limit int) ([]Article, error) {
+	var arts []Article
+	db := dao.db.WithContext(ctx).Model(&Article{}).
+		Where("author_id = ? AND status <> ?", author, statusDeleted)
+	err := afterCursor(db, cursor).
+		Order("utime DESC, id DESC").
+		Limit(limit).
+		Find(&arts).Error
+	return arts, err
+}
+
+func (dao *GORMArticleDAO) ListPubCursor(ctx context.Context,
+	cursor Cursor, limit int) ([]PublishedArticle, error) {
+	var res []PublishedArticle
+	err := listPubCursor(dao.db.WithContext(ctx), publishedArticleTable, cursor, limit, &res)
+	return res, err
+}
+
+// listPubCursor live 是线上库的表，结果放进 dst
+func listPubCursor(db *gorm.DB, live string, cursor Cursor, limit int, dst any) error {
+	db = db.Table(live).
+		Where("status = ?", domain.ArticleStatusPublished.ToUint8())
+	return afterCursor(db, cursor).
+		Order("utime DESC, id DESC").
+		Limit(limit).
+		Find(dst).Error
+}
+
+// afterCursor 只保留排在游标之后的数据。
+// utime 相同的时候要靠 id 来区分，不然同一毫秒更新的数据会被跳过或者重复
+func afterCursor(db *gorm.DB, cursor Cursor) *gorm.DB {
+	if cursor == (Cursor{}) {
+		return db
+	}
+	return db.Where("utime < ? OR (utime = ? AND id < ?)",
+		cursor.Utime, cursor.Utime, cursor.Id)
+}
+
 func (dao *GORMArticleDAO) GetPubById(ctx context.Context, id int64) (PublishedArticle, error) {
 	var pub PublishedArticle
 	err := dao.db.WithContext(ctx).
 		Where("id = ?", id).
 		First(&pub).Error
+	if err != nil {
+		return pub, err
+	}
+	pub.Tags, err = dao.findTags(ctx, publishedArticleTagTable, id)
 	return pub, err
 }
 
@@ -57,6 +102,10 @@ func (dao *GORMArticleDAO) GetById(ctx context.Context, id int64) (Article, erro
 	err := dao.db.WithContext(ctx).Model(&Article{}).
 		Where("id = ?", id).
 		First(&art).Error
+	if err != nil {
+		return art, err
+	}
+	art.Tags, err = dao.findTags(ctx, articleTagTable, id)
 	return art, err
 }
 
@@ -68,18 +117,21 @@ func NewGORMArticleDAO(db *gorm.DB) ArticleDAO {
 
 func (dao *GORMArticleDAO) SyncStatus(ctx context.Context, author, id int64, status uint8) error {
 	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UnixMilli()
+		// 回收站里面的帖子不能撤回，不然就从回收站里面出来了，dtime 却还在
 		res := tx.Model(&Article{}).
-			Where("id=? AND author_id = ?", id, author).
-			Update("status", status)
+			Where("id=? AND author_id = ? AND status <> ?", id, author, statusDeleted).
+			Updates(map[string]any{"status": status, "utime": now})
 		if res.Error != nil {
 			return res.Error
 		}
 		if res.RowsAffected != 1 {
-			return ErrPossibleIncorrectAuthor
+			return notUpdated(tx, author, id)
 		}
 
 		res = tx.Model(&PublishedArticle{}).
-			Where("id=? AND author_id = ?", id, author).Update("status", status)
+			Where("id=? AND author_id = ?", id, author).
+			Updates(map[string]any{"status": status, "utime": now})
 		if res.Error != nil {
 			return res.Error
 		}
@@ -101,7 +153,7 @@ func (dao *GORMArticleDAO) Sync(ctx context.Context,
 		err error
 	)
 	if id == 0 {
-		id, err = txDAO.Insert(ctx, art)
+		id, err = txDAO.Insert(ctx, art.draft())
 	} else {
 		err = txDAO.UpdateById(ctx, art)
 	}
@@ -116,15 +168,23 @@ func (dao *GORMArticleDAO) Sync(ctx context.Context,
 		// ID 冲突的时候。实际上，在 MYSQL 里面你写不写都可以
 		Columns: []clause.Column{{Name: "id"}},
 		DoUpdates: clause.Assignments(map[string]interface{}{
-			"title":   art.Title,
-			"content": art.Content,
-			"status":  art.Status,
-			"utime":   now,
+			"title":        art.Title,
+			"content":      art.Content,
+			"status":       art.Status,
+			"html":         art.Html,
+			"toc":          art.Toc,
+			"word_cnt":     art.WordCnt,
+			"read_minutes": art.ReadMinutes,
+			"utime":        now,
 		}),
 	}).Create(&publishArt).Error
 	if err != nil {
 		return 0, err
 	}
+	err = replaceTags(tx, publishedArticleTagTable, id, art.Tags, now)
+	if err != nil {
+		return 0, err
+	}
 	tx.Commit()
 	return id, tx.Error
 }
@@ -168,29 +228,388 @@ func (dao *GORMArticleDAO) Insert(ctx context.Context,
 	now := time.Now().UnixMilli()
 	art.Ctime = now
 	art.Utime = now
-	err := dao.db.WithContext(ctx).Create(&art).Error
+	art.Version = 1
+	// 在 Sync 里面调用的时候，dao.db 本身就是一个事务，这里会变成 SAVEPOINT
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&art).Error
+		if err != nil {
+			return err
+		}
+		err = replaceTags(tx, articleTagTable, art.Id, art.Tags, now)
+		if err != nil {
+			return err
+		}
+		return tx.Create(newRevision(art, now)).Error
+	})
 	// 返回自增主键
 	return art.Id, err
 }
 
-// UpdateById 只更新标题、内容和状态
+// UpdateById 只更新标题、内容、状态和定时发表的时间。
+// art.Version 大于 0 的时候会校验版本号，版本号对不上返回 ErrVersionConflict
 func (dao *GORMArticleDAO) UpdateById(ctx context.Context,
 	art Article) error {
 	now := time.Now().UnixMilli()
-	res := dao.db.Model(&Article{}).WithContext(ctx).
-		Where("id=? AND author_id = ? ", art.Id, art.AuthorId).
-		Updates(map[string]any{
-			"title":   art.Title,
-			"content": art.Content,
-			"status":  art.Status,
-			"utime":   now,
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Model(&Article{}).
+			Where("id=? AND author_id = ? AND status <> ?", art.Id, art.AuthorId, statusDeleted)
+		if art.Version > 0 {
+			query = query.Where("version = ?", art.Version)
+		}
+		res := query.Updates(map[string]any{
+			"title":      art.Title,
+			"content":    art.Content,
+			"status":     art.Status,
+			"publish_at": art.PublishAt,
+			"version":    gorm.Expr("version + 1"),
+			"utime":      now,
 		})
-	err := res.Error
+		err := res.Error
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected == 0 {
+			return dao.updateFailed(tx, art)
+		}
+		// 标签和标题、内容一样，都是整体覆盖
+		err = replaceTags(tx, articleTagTable, art.Id, art.Tags, now)
+		if err != nil {
+			return err
+		}
+		// 每一次修改都留下一个版本，作者可以回退
+		return tx.Create(newRevision(art, now)).Error
+	})
+}
+
+// updateFailed 一行都没有更新的时候，区分是在回收站里面，是版本冲突，还是帖子不属于这个作者
+func (dao *GORMArticleDAO) updateFailed(tx *gorm.DB, art Article) error {
+	deleted, err := isDeleted(tx, art.AuthorId, art.Id)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		return ErrArticleDeleted
+	}
+	if art.Version > 0 {
+		var cnt int64
+		err := tx.Model(&Article{}).
+			Where("id = ? AND author_id = ?", art.Id, art.AuthorId).
+			Count(&cnt).Error
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			return ErrVersionConflict
+		}
+	}
+	return errors.New("更新数据失败")
+}
+
+// notUpdated 改状态的时候一行都没有更新，区分是在回收站里面，还是帖子不属于这个作者
+func notUpdated(tx *gorm.DB, author, id int64) error {
+	deleted, err := isDeleted(tx, author, id)
 	if err != nil {
 		return err
 	}
+	if deleted {
+		return ErrArticleDeleted
+	}
+	return ErrPossibleIncorrectAuthor
+}
+
+func isDeleted(tx *gorm.DB, author, id int64) (bool, error) {
+	var cnt int64
+	err := tx.Model(&Article{}).
+		Where("id = ? AND author_id = ? AND status = ?", id, author, statusDeleted).
+		Count(&cnt).Error
+	return cnt > 0, err
+}
+
+func (dao *GORMArticleDAO) ListByStatus(ctx context.Context,
+	author int64, status uint8, offset, limit int) ([]Article, error) {
+	var res []Article
+	err := dao.db.WithContext(ctx).
+		Where("author_id = ? AND status = ?", author, status).
+		Order("publish_at ASC").
+		Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMArticleDAO) CancelSchedule(ctx context.Context, author, id int64) error {
+	res := dao.db.WithContext(ctx).Model(&Article{}).
+		// 带上 status，避免把已经发表了的帖子改回去
+		Where("id = ? AND author_id = ? AND status = ?", id, author,
+			domain.ArticleStatusScheduled.ToUint8()).
+		Updates(map[string]any{
+			"status":     domain.ArticleStatusUnpublished.ToUint8(),
+			"publish_at": 0,
+			"utime":      time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
 	if res.RowsAffected == 0 {
-		return errors.New("更新数据失败")
+		return ErrPossibleIncorrectAuthor
 	}
 	return nil
 }
+
+func (dao *GORMArticleDAO) SoftDelete(ctx context.Context, author, id int64) error {
+	return dao.softDelete(ctx, author, id, &PublishedArticle{})
+}
+
+// softDelete 把帖子挪到回收站，live 是线上库的表
+func (dao *GORMArticleDAO) softDelete(ctx context.Context, author, id int64, live any) error {
+	now := time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Article{}).
+			Where("id = ? AND author_id = ? AND status <> ?", id, author, statusDeleted).
+			Updates(map[string]any{
+				"status": statusDeleted,
+				"dtime":  now,
+				"utime":  now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != 1 {
+			return ErrPossibleIncorrectAuthor
+		}
+		// 没有发表过的帖子，线上库里面没有数据
+		return tx.Model(live).
+			Where("id = ? AND author_id = ?", id, author).
+			Updates(map[string]any{
+				"status": statusDeleted,
+				"dtime":  now,
+				"utime":  now,
+			}).Error
+	})
+}
+
+func (dao *GORMArticleDAO) Restore(ctx context.Context, author, id int64) error {
+	return dao.restore(ctx, author, id, &PublishedArticle{})
+}
+
+func (dao *GORMArticleDAO) restore(ctx context.Context, author, id int64, live any) error {
+	now := time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Article{}).
+			Where("id = ? AND author_id = ? AND status = ?", id, author, statusDeleted).
+			Updates(map[string]any{
+				"status": domain.ArticleStatusUnpublished.ToUint8(),
+				"dtime":  0,
+				"utime":  now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != 1 {
+			return ErrPossibleIncorrectAuthor
+		}
+		// 读者那边不会自动重新出现，作者要重新发表
+		return tx.Model(live).
+			Where("id = ? AND author_id = ?", id, author).
+			Updates(map[string]any{
+				"status": statusPrivate,
+				"dtime":  0,
+				"utime":  now,
+			}).Error
+	})
+}
+
+func (dao *GORMArticleDAO) ListDeleted(ctx context.Context,
+	author int64, offset, limit int) ([]Article, error) {
+	var res []Article
+	err := dao.db.WithContext(ctx).
+		Where("author_id = ? AND status = ?", author, statusDeleted).
+		Order("dtime DESC").
+		Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMArticleDAO) ListDeletedBefore(ctx context.Context,
+	dtime int64, limit int) ([]Article, error) {
+	var res []Article
+	// 命中 dtime 上的索引
+	err := dao.db.WithContext(ctx).
+		Where("dtime > 0 AND dtime < ? AND status = ?", dtime, statusDeleted).
+		Order("dtime ASC").
+		Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMArticleDAO) Purge(ctx context.Context, id int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return purge(tx, id, &PublishedArticle{})
+	})
+}
+
+// purge 删除帖子在数据库里面的所有数据，live 是线上库的表
+func purge(tx *gorm.DB, id int64, live any) error {
+	err := tx.Where("id = ?", id).Delete(&Article{}).Error
+	if err != nil {
+		return err
+	}
+	err = tx.Where("id = ?", id).Delete(live).Error
+	if err != nil {
+		return err
+	}
+	for _, table := range []string{articleTagTable, publishedArticleTagTable} {
+		err = tx.Table(table).Where("article_id = ?", id).Delete(&ArticleTag{}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Where("article_id = ?", id).Delete(&ArticleRevision{}).Error
+}
+
+func (dao *GORMArticleDAO) ListPubByTag(ctx context.Context,
+	tag string, offset, limit int) ([]PublishedArticle, error) {
+	var res []PublishedArticle
+	err := listPubByTag(dao.db.WithContext(ctx), publishedArticleTable, tag, offset, limit, &res)
+	return res, err
+}
+
+func listPubByTag(db *gorm.DB, live string, tag string, offset, limit int, dst any) error {
+	return db.Table(live).
+		Select(live+".*").
+		Joins("JOIN published_article_tags ON published_article_tags.article_id = "+live+".id").
+		Joins("JOIN tags ON tags.id = published_article_tags.tag_id").
+		Where("tags.name = ? AND "+live+".status = ?",
+			tag, domain.ArticleStatusPublished.ToUint8()).
+		Order(live + ".utime DESC").
+		Offset(offset).Limit(limit).
+		Find(dst).Error
+}
+
+func (dao *GORMArticleDAO) TagCloud(ctx context.Context, limit int) ([]TagCount, error) {
+	return tagCloud(dao.db.WithContext(ctx), publishedArticleTable, limit)
+}
+
+func tagCloud(db *gorm.DB, live string, limit int) ([]TagCount, error) {
+	var res []TagCount
+	// 仅作者可见的帖子不能算进去
+	err := db.Model(&PublishedArticleTag{}).
+		Select("tags.name AS name, COUNT(*) AS cnt").
+		Joins("JOIN tags ON tags.id = published_article_tags.tag_id").
+		Joins("JOIN "+live+" ON "+live+".id = published_article_tags.article_id").
+		Where(live+".status = ?", domain.ArticleStatusPublished.ToUint8()).
+		Group("tags.name").
+		Order("cnt DESC").
+		Limit(limit).
+		Scan(&res).Error
+	return res, err
+}
+
+func (dao *GORMArticleDAO) ListPubAfterId(ctx context.Context,
+	id int64, limit int) ([]PublishedArticle, error) {
+	var res []PublishedArticle
+	err := listPubAfterId(dao.db.WithContext(ctx), publishedArticleTable, id, limit, &res)
+	return res, err
+}
+
+func listPubAfterId(db *gorm.DB, live string, id int64, limit int, dst any) error {
+	// 按照主键遍历，不管翻到多后面都不会变慢
+	return db.Table(live).
+		Where("id > ?", id).
+		Order("id ASC").
+		Limit(limit).
+		Find(dst).Error
+}
+
+func (dao *GORMArticleDAO) ListPubByIds(ctx context.Context,
+	ids []int64) ([]PublishedArticle, error) {
+	var res []PublishedArticle
+	err := listPubByIds(dao.db.WithContext(ctx), publishedArticleTable, ids, &res)
+	return res, err
+}
+
+func listPubByIds(db *gorm.DB, live string, ids []int64, dst any) error {
+	return db.Table(live).
+		Where("id IN ? AND status = ?", ids, domain.ArticleStatusPublished.ToUint8()).
+		Find(dst).Error
+}
+
+func (dao *GORMArticleDAO) findTags(ctx context.Context, table string, aid int64) ([]string, error) {
+	var res []string
+	err := dao.db.WithContext(ctx).Table(table).
+		Joins("JOIN tags ON tags.id = "+table+".tag_id").
+		Where(table+".article_id = ?", aid).
+		Order(table+".id ASC").
+		Pluck("tags.name", &res).Error
+	return res, err
+}
+
+func (dao *GORMArticleDAO) ListRevisions(ctx context.Context,
+	author, id int64, offset, limit int) ([]ArticleRevision, error) {
+	var res []ArticleRevision
+	// 自增主键就代表了版本的先后
+	err := dao.db.WithContext(ctx).
+		Where("article_id = ? AND author_id = ?", id, author).
+		Order("id DESC").
+		Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMArticleDAO) GetRevision(ctx context.Context,
+	author, revId int64) (ArticleRevision, error) {
+	var res ArticleRevision
+	err := dao.db.WithContext(ctx).
+		Where("id = ? AND author_id = ?", revId, author).
+		First(&res).Error
+	if err == gorm.ErrRecordNotFound {
+		return res, ErrRevisionNotFound
+	}
+	return res, err
+}
+
+func newRevision(art Article, now int64) *ArticleRevision {
+	return &ArticleRevision{
+		ArticleId: art.Id,
+		AuthorId:  art.AuthorId,
+		Title:     art.Title,
+		Content:   art.Content,
+		Status:    art.Status,
+		Ctime:     now,
+	}
+}
+
+const (
+	articleTagTable          = "article_tags"
+	publishedArticleTagTable = "published_article_tags"
+	// 线上库的表，S3DAO 用的是 published_article_v1
+	publishedArticleTable   = "published_articles"
+	publishedArticleV1Table = "published_article_v1"
+)
+
+// replaceTags 用 tags 整体覆盖帖子原本的标签，table 决定了是制作库还是线上库。
+// 需要在事务里面调用
+func replaceTags(tx *gorm.DB, table string, aid int64, tags []string, now int64) error {
+	err := tx.Table(table).Where("article_id = ?", aid).Delete(&ArticleTag{}).Error
+	if err != nil || len(tags) == 0 {
+		return err
+	}
+	ts := make([]Tag, 0, len(tags))
+	for _, name := range tags {
+		ts = append(ts, Tag{Name: name, Ctime: now})
+	}
+	// 标签已经存在就什么都不做
+	err = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&ts).Error
+	if err != nil {
+		return err
+	}
+	var tids []int64
+	err = tx.Model(&Tag{}).Where("name IN ?", tags).Pluck("id", &tids).Error
+	if err != nil {
+		return err
+	}
+	rels := make([]ArticleTag, 0, len(tids))
+	for _, tid := range tids {
+		rels = append(rels, ArticleTag{ArticleId: aid, TagId: tid, Ctime: now})
+	}
+	return tx.Table(table).Create(&rels).Error
+}
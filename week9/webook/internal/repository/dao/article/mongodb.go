@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"github.com/bwmarrin/snowflake"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -18,65 +19,323 @@ type MongoDBDAO struct {
 	col *mongo.Collection
 	// 代表的是线上库
 	liveCol *mongo.Collection
-	node    *snowflake.Node
+	// 历史版本
+	revCol *mongo.Collection
+	node   *snowflake.Node
 
 	idGen IDGenerator
 }
 
+// ListPub 按照更新时间倒序，列出 start 之前发表的帖子
 func (m *MongoDBDAO) ListPub(ctx context.Context, start time.Time, offset int, limit int) ([]Article, error) {
-	//TODO implement me
-	panic("implement me")
+	filter := bson.M{
+		"status": domain.ArticleStatusPublished.ToUint8(),
+		"utime":  bson.M{"$lt": start.UnixMilli()},
+	}
+	opts := options.Find().
+		SetSort(bson.D{bson.E{Key: "utime", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := m.liveCol.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var res []Article
+	err = cursor.All(ctx, &res)
+	return res, err
 }
 
 func (m *MongoDBDAO) GetPubById(ctx context.Context, id int64) (PublishedArticle, error) {
-	//TODO implement me
-	panic("implement me")
+	var res PublishedArticle
+	err := m.liveCol.FindOne(ctx, bson.M{"id": id}).Decode(&res)
+	if err == mongo.ErrNoDocuments {
+		return res, ErrArticleNotFound
+	}
+	return res, err
 }
 
 func (m *MongoDBDAO) GetByAuthor(ctx context.Context, author int64, offset, limit int) ([]Article, error) {
-	//TODO implement me
-	panic("implement me")
+	// 命中 author_id, utime 的联合索引
+	opts := options.Find().
+		SetSort(bson.D{bson.E{Key: "utime", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := m.col.Find(ctx, notDeleted(bson.M{"author_id": author}), opts)
+	if err != nil {
+		return nil, err
+	}
+	var res []Article
+	err = cursor.All(ctx, &res)
+	return res, err
+}
+
+func (m *MongoDBDAO) GetByAuthorCursor(ctx context.Context,
+	author int64, cursor Cursor, limit int) ([]Article, error) {
+	filter := afterCursorFilter(notDeleted(bson.M{"author_id": author}), cursor)
+	cur, err := m.col.Find(ctx, filter, cursorFindOptions(limit))
+	if err != nil {
+		return nil, err
+	}
+	var res []Article
+	err = cur.All(ctx, &res)
+	return res, err
+}
+
+func (m *MongoDBDAO) ListPubCursor(ctx context.Context,
+	cursor Cursor, limit int) ([]PublishedArticle, error) {
+	filter := afterCursorFilter(bson.M{
+		"status": domain.ArticleStatusPublished.ToUint8()}, cursor)
+	cur, err := m.liveCol.Find(ctx, filter, cursorFindOptions(limit))
+	if err != nil {
+		return nil, err
+	}
+	var res []PublishedArticle
+	err = cur.All(ctx, &res)
+	return res, err
+}
+
+// notDeleted 过滤掉回收站里面的帖子
+func notDeleted(filter bson.M) bson.M {
+	filter["status"] = bson.M{"$ne": statusDeleted}
+	return filter
+}
+
+func afterCursorFilter(filter bson.M, cursor Cursor) bson.M {
+	if cursor == (Cursor{}) {
+		return filter
+	}
+	filter["$or"] = bson.A{
+		bson.M{"utime": bson.M{"$lt": cursor.Utime}},
+		bson.M{"utime": cursor.Utime, "id": bson.M{"$lt": cursor.Id}},
+	}
+	return filter
+}
+
+func cursorFindOptions(limit int) *options.FindOptions {
+	return options.Find().
+		SetSort(bson.D{bson.E{Key: "utime", Value: -1}, bson.E{Key: "id", Value: -1}}).
+		SetLimit(int64(limit))
 }
 
 func (m *MongoDBDAO) GetById(ctx context.Context, id int64) (Article, error) {
-	//TODO implement me
-	panic("implement me")
+	var res Article
+	err := m.col.FindOne(ctx, bson.M{"id": id}).Decode(&res)
+	if err == mongo.ErrNoDocuments {
+		return res, ErrArticleNotFound
+	}
+	return res, err
 }
 
 func (m *MongoDBDAO) Insert(ctx context.Context, art Article) (int64, error) {
 	now := time.Now().UnixMilli()
 	art.Ctime = now
 	art.Utime = now
-	//id := m.idGen()
-	id := m.node.Generate().Int64()
-	art.Id = id
-	_, err := m.col.InsertOne(ctx, art)
+	art.Version = 1
 	// 你没有自增主键
 	// GLOBAL UNIFY ID (GUID，全局唯一ID）
-	return id, err
+	id := m.genId()
+	art.Id = id
+	_, err := m.col.InsertOne(ctx, art)
+	if err != nil {
+		return 0, err
+	}
+	return id, m.insertRevision(ctx, art, now)
 }
 
 func (m *MongoDBDAO) UpdateById(ctx context.Context, art Article) error {
 	// 操作制作库
-	filter := bson.M{"id": art.Id, "author_id": art.AuthorId}
-	update := bson.D{bson.E{"$set", bson.M{
-		"title":   art.Title,
-		"content": art.Content,
-		"utime":   time.Now().UnixMilli(),
-		"status":  art.Status,
-	}}}
+	filter := bson.M{"id": art.Id, "author_id": art.AuthorId,
+		"status": bson.M{"$ne": statusDeleted}}
+	if art.Version > 0 {
+		// 乐观锁
+		filter["version"] = art.Version
+	}
+	now := time.Now().UnixMilli()
+	update := bson.D{
+		bson.E{Key: "$set", Value: bson.M{
+			"title":      art.Title,
+			"content":    art.Content,
+			"utime":      now,
+			"status":     art.Status,
+			"publish_at": art.PublishAt,
+			"tags":       art.Tags,
+		}},
+		bson.E{Key: "$inc", Value: bson.M{"version": 1}},
+	}
 	res, err := m.col.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
 
-	// 这边就是校验了 author_id 是不是正确的 ID
+	// 这边就是校验了 author_id 是不是正确的 ID，以及版本号对不对
+	if res.ModifiedCount == 0 {
+		return m.updateFailed(ctx, art)
+	}
+	// 没有事务，只能是尽量保证版本被记录下来
+	return m.insertRevision(ctx, art, now)
+}
+
+// updateFailed 一条都没有更新的时候，区分是在回收站里面，是版本冲突，还是帖子不属于这个作者
+func (m *MongoDBDAO) updateFailed(ctx context.Context, art Article) error {
+	deleted, err := m.isDeleted(ctx, art.AuthorId, art.Id)
+	if err != nil {
+		return err
+	}
+	if deleted {
+		return ErrArticleDeleted
+	}
+	if art.Version > 0 {
+		cnt, err := m.col.CountDocuments(ctx,
+			bson.M{"id": art.Id, "author_id": art.AuthorId})
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			return ErrVersionConflict
+		}
+	}
+	return errors.New("更新数据失败")
+}
+
+func (m *MongoDBDAO) isDeleted(ctx context.Context, author, id int64) (bool, error) {
+	cnt, err := m.col.CountDocuments(ctx,
+		bson.M{"id": id, "author_id": author, "status": statusDeleted})
+	return cnt > 0, err
+}
+
+func (m *MongoDBDAO) ListByStatus(ctx context.Context,
+	author int64, status uint8, offset, limit int) ([]Article, error) {
+	filter := bson.M{"author_id": author, "status": status}
+	opts := options.Find().
+		SetSort(bson.D{bson.E{Key: "publish_at", Value: 1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := m.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var res []Article
+	err = cursor.All(ctx, &res)
+	return res, err
+}
+
+func (m *MongoDBDAO) CancelSchedule(ctx context.Context, author, id int64) error {
+	filter := bson.M{"id": id, "author_id": author,
+		"status": domain.ArticleStatusScheduled.ToUint8()}
+	update := bson.D{bson.E{Key: "$set", Value: bson.M{
+		"status":     domain.ArticleStatusUnpublished.ToUint8(),
+		"publish_at": 0,
+		"utime":      time.Now().UnixMilli(),
+	}}}
+	res, err := m.col.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
 	if res.ModifiedCount == 0 {
-		return errors.New("更新数据失败")
+		return ErrPossibleIncorrectAuthor
 	}
 	return nil
 }
 
+func (m *MongoDBDAO) ListPubByTag(ctx context.Context,
+	tag string, offset, limit int) ([]PublishedArticle, error) {
+	// 数组字段上的查询，命中 tags 上的多键索引
+	filter := bson.M{"tags": tag, "status": domain.ArticleStatusPublished.ToUint8()}
+	opts := options.Find().
+		SetSort(bson.D{bson.E{Key: "utime", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := m.liveCol.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var res []PublishedArticle
+	err = cursor.All(ctx, &res)
+	return res, err
+}
+
+func (m *MongoDBDAO) TagCloud(ctx context.Context, limit int) ([]TagCount, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": domain.ArticleStatusPublished.ToUint8()}}},
+		{{Key: "$unwind", Value: "$tags"}},
+		{{Key: "$group", Value: bson.M{"_id": "$tags", "cnt": bson.M{"$sum": 1}}}},
+		{{Key: "$sort", Value: bson.D{bson.E{Key: "cnt", Value: -1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := m.liveCol.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var res []TagCount
+	err = cursor.All(ctx, &res)
+	return res, err
+}
+
+func (m *MongoDBDAO) ListPubAfterId(ctx context.Context,
+	id int64, limit int) ([]PublishedArticle, error) {
+	filter := bson.M{"id": bson.M{"$gt": id}}
+	opts := options.Find().
+		SetSort(bson.D{bson.E{Key: "id", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := m.liveCol.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var res []PublishedArticle
+	err = cursor.All(ctx, &res)
+	return res, err
+}
+
+func (m *MongoDBDAO) ListPubByIds(ctx context.Context,
+	ids []int64) ([]PublishedArticle, error) {
+	filter := bson.M{
+		"id":     bson.M{"$in": ids},
+		"status": domain.ArticleStatusPublished.ToUint8(),
+	}
+	cursor, err := m.liveCol.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var res []PublishedArticle
+	err = cursor.All(ctx, &res)
+	return res, err
+}
+
+func (m *MongoDBDAO) ListRevisions(ctx context.Context,
+	author, id int64, offset, limit int) ([]ArticleRevision, error) {
+	filter := bson.M{"article_id": id, "author_id": author}
+	// 雪花算法的 ID 是递增的，可以直接用来排序
+	opts := options.Find().
+		SetSort(bson.D{bson.E{Key: "id", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := m.revCol.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var res []ArticleRevision
+	err = cursor.All(ctx, &res)
+	return res, err
+}
+
+func (m *MongoDBDAO) GetRevision(ctx context.Context,
+	author, revId int64) (ArticleRevision, error) {
+	var res ArticleRevision
+	err := m.revCol.FindOne(ctx, bson.M{"id": revId, "author_id": author}).
+		Decode(&res)
+	if err == mongo.ErrNoDocuments {
+		return res, ErrRevisionNotFound
+	}
+	return res, err
+}
+
+func (m *MongoDBDAO) insertRevision(ctx context.Context, art Article, now int64) error {
+	rev := newRevision(art, now)
+	rev.Id = m.genId()
+	_, err := m.revCol.InsertOne(ctx, rev)
+	return err
+}
+
 func (m *MongoDBDAO) Sync(ctx context.Context, art Article) (int64, error) {
 	// 没法子引入事务的概念
 	// 首先第一步，保存制作库
@@ -87,7 +346,7 @@ func (m *MongoDBDAO) Sync(ctx context.Context, art Article) (int64, error) {
 	if id > 0 {
 		err = m.UpdateById(ctx, art)
 	} else {
-		id, err = m.Insert(ctx, art)
+		id, err = m.Insert(ctx, art.draft())
 	}
 	if err != nil {
 		return 0, err
@@ -97,10 +356,22 @@ func (m *MongoDBDAO) Sync(ctx context.Context, art Article) (int64, error) {
 	now := time.Now().UnixMilli()
 	//update := bson.E{"$set", art}
 	//upsert := bson.E{"$setOnInsert", bson.D{bson.E{"ctime", now}}}
-	art.Utime = now
 	updateV1 := bson.M{
-		// 更新，如果不存在，就是插入，
-		"$set": PublishedArticle(art),
+		// 更新，如果不存在，就是插入。
+		// 不能直接 $set 整个结构体，字段上都有 omitempty，
+		// 重新发表的时候清空了标签、目录这些，线上库还会留着旧的
+		"$set": bson.M{
+			"author_id":    art.AuthorId,
+			"title":        art.Title,
+			"content":      art.Content,
+			"status":       art.Status,
+			"tags":         art.Tags,
+			"html":         art.Html,
+			"toc":          art.Toc,
+			"word_cnt":     art.WordCnt,
+			"read_minutes": art.ReadMinutes,
+			"utime":        now,
+		},
 		// 在插入的时候，要插入 ctime
 		"$setOnInsert": bson.M{"ctime": now},
 	}
@@ -113,7 +384,136 @@ func (m *MongoDBDAO) Sync(ctx context.Context, art Article) (int64, error) {
 }
 
 func (m *MongoDBDAO) SyncStatus(ctx context.Context, author, id int64, status uint8) error {
-	panic("implement me")
+	// 没有事务，先改制作库，再改线上库
+	filter := bson.M{"id": id, "author_id": author}
+	update := bson.D{bson.E{Key: "$set", Value: bson.M{
+		"status": status,
+		"utime":  time.Now().UnixMilli(),
+	}}}
+	// 回收站里面的帖子不能撤回
+	res, err := m.col.UpdateOne(ctx, bson.M{"id": id, "author_id": author,
+		"status": bson.M{"$ne": statusDeleted}}, update)
+	if err != nil {
+		return err
+	}
+	// 状态本来就一样的时候 ModifiedCount 是 0，所以看 MatchedCount
+	if res.MatchedCount != 1 {
+		deleted, err := m.isDeleted(ctx, author, id)
+		if err != nil {
+			return err
+		}
+		if deleted {
+			return ErrArticleDeleted
+		}
+		return ErrPossibleIncorrectAuthor
+	}
+	res, err = m.liveCol.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount != 1 {
+		return ErrPossibleIncorrectAuthor
+	}
+	return nil
+}
+
+func (m *MongoDBDAO) SoftDelete(ctx context.Context, author, id int64) error {
+	now := time.Now().UnixMilli()
+	filter := bson.M{"id": id, "author_id": author, "status": bson.M{"$ne": statusDeleted}}
+	update := bson.D{bson.E{Key: "$set", Value: bson.M{
+		"status": statusDeleted,
+		"dtime":  now,
+		"utime":  now,
+	}}}
+	res, err := m.col.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount != 1 {
+		return ErrPossibleIncorrectAuthor
+	}
+	// 没有发表过的帖子，线上库里面没有数据
+	_, err = m.liveCol.UpdateOne(ctx, bson.M{"id": id, "author_id": author}, update)
+	return err
+}
+
+func (m *MongoDBDAO) Restore(ctx context.Context, author, id int64) error {
+	now := time.Now().UnixMilli()
+	filter := bson.M{"id": id, "author_id": author, "status": statusDeleted}
+	res, err := m.col.UpdateOne(ctx, filter, bson.D{bson.E{Key: "$set", Value: bson.M{
+		"status": domain.ArticleStatusUnpublished.ToUint8(),
+		"dtime":  0,
+		"utime":  now,
+	}}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount != 1 {
+		return ErrPossibleIncorrectAuthor
+	}
+	// 读者那边不会自动重新出现，作者要重新发表
+	_, err = m.liveCol.UpdateOne(ctx, bson.M{"id": id, "author_id": author},
+		bson.D{bson.E{Key: "$set", Value: bson.M{
+			"status": statusPrivate,
+			"dtime":  0,
+			"utime":  now,
+		}}})
+	return err
+}
+
+func (m *MongoDBDAO) ListDeleted(ctx context.Context,
+	author int64, offset, limit int) ([]Article, error) {
+	opts := options.Find().
+		SetSort(bson.D{bson.E{Key: "dtime", Value: -1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+	cursor, err := m.col.Find(ctx, bson.M{"author_id": author, "status": statusDeleted}, opts)
+	if err != nil {
+		return nil, err
+	}
+	var res []Article
+	err = cursor.All(ctx, &res)
+	return res, err
+}
+
+func (m *MongoDBDAO) ListDeletedBefore(ctx context.Context,
+	dtime int64, limit int) ([]Article, error) {
+	filter := bson.M{
+		"status": statusDeleted,
+		"dtime":  bson.M{"$gt": 0, "$lt": dtime},
+	}
+	opts := options.Find().
+		SetSort(bson.D{bson.E{Key: "dtime", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := m.col.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var res []Article
+	err = cursor.All(ctx, &res)
+	return res, err
+}
+
+func (m *MongoDBDAO) Purge(ctx context.Context, id int64) error {
+	// 先删线上库，中途失败了下一轮还能从制作库找到它
+	_, err := m.liveCol.DeleteOne(ctx, bson.M{"id": id})
+	if err != nil {
+		return err
+	}
+	_, err = m.revCol.DeleteMany(ctx, bson.M{"article_id": id})
+	if err != nil {
+		return err
+	}
+	_, err = m.col.DeleteOne(ctx, bson.M{"id": id})
+	return err
+}
+
+// genId 优先用注入的 IDGenerator，没有的话用雪花算法的节点
+func (m *MongoDBDAO) genId() int64 {
+	if m.idGen != nil {
+		return m.idGen()
+	}
+	return m.node.Generate().Int64()
 }
 
 func InitCollections(db *mongo.Database) error {
@@ -130,14 +530,62 @@ func InitCollections(db *mongo.Database) error {
 			},
 			Options: options.Index(),
 		},
+		// 作者列表，按照 utime 翻页和按照游标翻页都用这个
+		{
+			Keys: bson.D{bson.E{Key: "author_id", Value: 1},
+				bson.E{Key: "utime", Value: -1},
+				bson.E{Key: "id", Value: -1},
+			},
+			Options: options.Index(),
+		},
+		{
+			Keys: bson.D{bson.E{Key: "utime", Value: -1},
+				bson.E{Key: "id", Value: -1},
+			},
+			Options: options.Index(),
+		},
 	}
 	_, err := db.Collection("articles").Indexes().
-		CreateMany(ctx, index)
+		CreateMany(ctx, append(index,
+			// 清理回收站用
+			mongo.IndexModel{
+				Keys:    bson.D{bson.E{Key: "dtime", Value: 1}},
+				Options: options.Index(),
+			}))
 	if err != nil {
 		return err
 	}
 	_, err = db.Collection("published_articles").Indexes().
-		CreateMany(ctx, index)
+		CreateMany(ctx, append(index,
+			mongo.IndexModel{
+				Keys: bson.D{bson.E{Key: "tags", Value: 1},
+					bson.E{Key: "utime", Value: -1},
+				},
+				Options: options.Index(),
+			},
+			// 读者按照发表时间浏览，WHERE status = ? AND utime < ? ORDER BY utime DESC
+			mongo.IndexModel{
+				Keys: bson.D{bson.E{Key: "status", Value: 1},
+					bson.E{Key: "utime", Value: -1},
+				},
+				Options: options.Index(),
+			}))
+	if err != nil {
+		return err
+	}
+	_, err = db.Collection("article_revisions").Indexes().
+		CreateMany(ctx, []mongo.IndexModel{
+			{
+				Keys:    bson.D{bson.E{Key: "id", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys: bson.D{bson.E{Key: "article_id", Value: 1},
+					bson.E{Key: "author_id", Value: 1},
+				},
+				Options: options.Index(),
+			},
+		})
 	return err
 }
 
@@ -147,8 +595,8 @@ func NewMongoDBDAOV1(db *mongo.Database, idGen IDGenerator) ArticleDAO {
 	return &MongoDBDAO{
 		col:     db.Collection("articles"),
 		liveCol: db.Collection("published_articles"),
-		//node:    node,
-		idGen: idGen,
+		revCol:  db.Collection("article_revisions"),
+		idGen:   idGen,
 	}
 }
 
@@ -156,6 +604,7 @@ func NewMongoDBDAO(db *mongo.Database, node *snowflake.Node) ArticleDAO {
 	return &MongoDBDAO{
 		col:     db.Collection("articles"),
 		liveCol: db.Collection("published_articles"),
+		revCol:  db.Collection("article_revisions"),
 		node:    node,
 	}
 }
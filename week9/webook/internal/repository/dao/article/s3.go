@@ -1,60 +1,65 @@
 package article
 
 import (
-	"bytes"
 	"context"
-	_ "github.com/aws/aws-sdk-go"
-	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/ecodeclub/ekit"
+	"errors"
+	"fmt"
 	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/objstore"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
-	"strconv"
 	"time"
 )
 
-var statusPrivate = domain.ArticleStatusPrivate.ToUint8()
+var (
+	statusPrivate = domain.ArticleStatusPrivate.ToUint8()
+	statusDeleted = domain.ArticleStatusDeleted.ToUint8()
+)
+
+const contentType = "text/plain;charset=utf-8"
 
 type S3DAO struct {
-	oss *s3.S3
+	store objstore.ObjectStore
 	// 通过组合 GORMArticleDAO 来简化操作
 	// 当然在实践中，你是不太会有组合的机会
 	// 你操作制作库总是一样的
 	// 你就是操作线上库的时候不一样
 	GORMArticleDAO
-	bucket *string
+	// 上传内容失败之后的重试次数，以及第一次重试的间隔，之后每次翻倍
+	retries  int
+	interval time.Duration
 }
 
 // NewOssDAO 因为组合 GORMArticleDAO 是一个内部实现细节
 // 所以这里要直接传入 DB
-func NewOssDAO(oss *s3.S3, db *gorm.DB) ArticleDAO {
+func NewOssDAO(store objstore.ObjectStore, db *gorm.DB) ArticleDAO {
 	return &S3DAO{
-		oss: oss,
-		// 你也可以考虑利用依赖注入来传入。
-		// 但是事实上这个很少变，所以你可以延迟到必要的时候再注入
-		bucket: ekit.ToPtr[string]("webook-1314583317"),
+		store: store,
 		GORMArticleDAO: GORMArticleDAO{
 			db: db,
 		},
+		retries:  3,
+		interval: time.Millisecond * 100,
 	}
 }
 
 func (o *S3DAO) Sync(ctx context.Context, art Article) (int64, error) {
 	// 保存制作库
 	// 保存线上库，并且把 content 上传到 OSS
-	//
 	var (
 		id = art.Id
+		// 这一次上传的内容，和线上库原本指向的内容
+		newKey, oldKey string
 	)
 	// 制作库流量不大，并发不高，你就保存到数据库就可以
 	// 当然，有钱或者体量大，就还是考虑 OSS
-	err := o.db.Transaction(func(tx *gorm.DB) error {
+	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var err error
 		now := time.Now().UnixMilli()
 		// 制作库
 		txDAO := NewGORMArticleDAO(tx)
 		if id == 0 {
-			id, err = txDAO.Insert(ctx, art)
+			id, err = txDAO.Insert(ctx, art.draft())
 		} else {
 			err = txDAO.UpdateById(ctx, art)
 		}
@@ -62,41 +67,256 @@ func (o *S3DAO) Sync(ctx context.Context, art Article) (int64, error) {
 			return err
 		}
 		art.Id = id
+		// 锁住线上库的这一行，避免并发发表的时候删错了旧的内容
+		var old PublishedArticleV1
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", id).Limit(1).Find(&old).Error
+		if err != nil {
+			return err
+		}
+		oldKey = old.ContentKey
+		// 先上传，再让线上库指向它。上传失败整个事务回滚，线上库还是指向旧的内容
+		// 代价是上传期间事务一直开着
+		newKey = contentKey(id, now)
+		err = o.putWithRetry(ctx, newKey, []byte(art.Content))
+		if err != nil {
+			return err
+		}
 		publishArt := PublishedArticleV1{
-			Id:       art.Id,
-			Title:    art.Title,
-			AuthorId: art.AuthorId,
-			Status:   art.Status,
-			Ctime:    now,
-			Utime:    now,
+			Id:          art.Id,
+			Title:       art.Title,
+			AuthorId:    art.AuthorId,
+			Status:      art.Status,
+			ContentKey:  newKey,
+			Ctime:       now,
+			Utime:       now,
+			Html:        art.Html,
+			Toc:         art.Toc,
+			WordCnt:     art.WordCnt,
+			ReadMinutes: art.ReadMinutes,
 		}
-		// 线上库不保存 Content,要准备上传到 OSS 里面
-		return tx.Clauses(clause.OnConflict{
+		// 线上库不保存 Content，只保存 key
+		err = tx.Clauses(clause.OnConflict{
 			// ID 冲突的时候。实际上，在 MYSQL 里面你写不写都可以
 			Columns: []clause.Column{{Name: "id"}},
 			DoUpdates: clause.Assignments(map[string]interface{}{
-				"title":  art.Title,
-				"utime":  now,
-				"status": art.Status,
+				"title":        art.Title,
+				"utime":        now,
+				"status":       art.Status,
+				"content_key":  newKey,
+				"html":         art.Html,
+				"toc":          art.Toc,
+				"word_cnt":     art.WordCnt,
+				"read_minutes": art.ReadMinutes,
 				// 要参与 SQL 运算的
 			}),
 		}).Create(&publishArt).Error
+		if err != nil {
+			return err
+		}
+		// 标签还是放在数据库里面，不然没法按照标签查询
+		return replaceTags(tx, publishedArticleTagTable, id, art.Tags, now)
 	})
-	// 说明保存到数据库的时候失败了
 	if err != nil {
+		// 补偿：内容可能已经上传了，但是没有任何一行指向它
+		if newKey != "" {
+			o.deleteQuietly(newKey)
+		}
 		return 0, err
 	}
-	// 接下来就是保存到 OSS 里面
-	// 你要有监控，你要有重试，你要有补偿机制
-	_, err = o.oss.PutObjectWithContext(ctx, &s3.PutObjectInput{
-		Bucket:      o.bucket,
-		Key:         ekit.ToPtr[string](strconv.FormatInt(art.Id, 10)),
-		Body:        bytes.NewReader([]byte(art.Content)),
-		ContentType: ekit.ToPtr[string]("text/plain;charset=utf-8"),
-	})
-	return id, err
+	// 旧的内容已经没有人引用了
+	if oldKey != "" && oldKey != newKey {
+		o.deleteQuietly(oldKey)
+	}
+	return id, nil
+}
+
+// GetPubById 线上库里面只有 key，内容要从对象存储里面读出来
+func (o *S3DAO) GetPubById(ctx context.Context, id int64) (PublishedArticle, error) {
+	var pub PublishedArticleV1
+	err := o.db.WithContext(ctx).Where("id = ?", id).First(&pub).Error
+	if err != nil {
+		return PublishedArticle{}, err
+	}
+	content, err := o.store.Get(ctx, pub.ContentKey)
+	if err != nil {
+		return PublishedArticle{}, fmt.Errorf("读取帖子内容失败 %d: %w", id, err)
+	}
+	tags, err := o.findTags(ctx, publishedArticleTagTable, id)
+	if err != nil {
+		return PublishedArticle{}, err
+	}
+	res := toPublished(pub, content)
+	res.Tags = tags
+	return res, nil
+}
+
+// ListPubCursor 线上库是 published_article_v1，不能用 GORMArticleDAO 的实现
+func (o *S3DAO) ListPubCursor(ctx context.Context,
+	cursor Cursor, limit int) ([]PublishedArticle, error) {
+	var pubs []PublishedArticleV1
+	err := listPubCursor(o.db.WithContext(ctx), publishedArticleV1Table, cursor, limit, &pubs)
+	if err != nil {
+		return nil, err
+	}
+	return o.withContent(ctx, pubs)
+}
+
+func (o *S3DAO) ListPubByTag(ctx context.Context,
+	tag string, offset, limit int) ([]PublishedArticle, error) {
+	var pubs []PublishedArticleV1
+	err := listPubByTag(o.db.WithContext(ctx), publishedArticleV1Table, tag, offset, limit, &pubs)
+	if err != nil {
+		return nil, err
+	}
+	return o.withContent(ctx, pubs)
+}
+
+func (o *S3DAO) TagCloud(ctx context.Context, limit int) ([]TagCount, error) {
+	return tagCloud(o.db.WithContext(ctx), publishedArticleV1Table, limit)
+}
+
+func (o *S3DAO) ListPubAfterId(ctx context.Context,
+	id int64, limit int) ([]PublishedArticle, error) {
+	var pubs []PublishedArticleV1
+	err := listPubAfterId(o.db.WithContext(ctx), publishedArticleV1Table, id, limit, &pubs)
+	if err != nil {
+		return nil, err
+	}
+	return o.withContent(ctx, pubs)
+}
+
+func (o *S3DAO) ListPubByIds(ctx context.Context,
+	ids []int64) ([]PublishedArticle, error) {
+	var pubs []PublishedArticleV1
+	err := listPubByIds(o.db.WithContext(ctx), publishedArticleV1Table, ids, &pubs)
+	if err != nil {
+		return nil, err
+	}
+	return o.withContent(ctx, pubs)
+}
+
+// withContent 列表里面要用内容生成摘要、建索引，所以每一篇都要去对象存储里面读一次。
+// 内容丢了的帖子不影响整个列表，只是没有内容
+func (o *S3DAO) withContent(ctx context.Context, pubs []PublishedArticleV1) ([]PublishedArticle, error) {
+	res := make([]PublishedArticle, 0, len(pubs))
+	for _, pub := range pubs {
+		var content []byte
+		if pub.ContentKey != "" {
+			var err error
+			content, err = o.store.Get(ctx, pub.ContentKey)
+			if err != nil && !errors.Is(err, objstore.ErrObjectNotFound) {
+				return nil, fmt.Errorf("读取帖子内容失败 %d: %w", pub.Id, err)
+			}
+		}
+		res = append(res, toPublished(pub, content))
+	}
+	return res, nil
 }
 
+func toPublished(pub PublishedArticleV1, content []byte) PublishedArticle {
+	return PublishedArticle{
+		Id:       pub.Id,
+		Title:    pub.Title,
+		Content:  string(content),
+		AuthorId: pub.AuthorId,
+		Status:   pub.Status,
+		Dtime:    pub.Dtime,
+		Ctime:    pub.Ctime,
+		Utime:    pub.Utime,
+
+		Html:        pub.Html,
+		Toc:         pub.Toc,
+		WordCnt:     pub.WordCnt,
+		ReadMinutes: pub.ReadMinutes,
+	}
+}
+
+// SyncStatus 撤回之类的只改状态，内容还留在对象存储里面，重新发表的时候会被替换
 func (o *S3DAO) SyncStatus(ctx context.Context, author, id int64, status uint8) error {
-	panic("implement me")
+	return o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now().UnixMilli()
+		res := tx.Model(&Article{}).
+			Where("id = ? AND author_id = ? AND status <> ?", id, author, statusDeleted).
+			Updates(map[string]any{"status": status, "utime": now})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != 1 {
+			return notUpdated(tx, author, id)
+		}
+		res = tx.Model(&PublishedArticleV1{}).
+			Where("id = ? AND author_id = ?", id, author).
+			Updates(map[string]any{"status": status, "utime": now})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected != 1 {
+			return ErrPossibleIncorrectAuthor
+		}
+		return nil
+	})
+}
+
+func (o *S3DAO) SoftDelete(ctx context.Context, author, id int64) error {
+	return o.softDelete(ctx, author, id, &PublishedArticleV1{})
+}
+
+func (o *S3DAO) Restore(ctx context.Context, author, id int64) error {
+	return o.restore(ctx, author, id, &PublishedArticleV1{})
+}
+
+// Purge 彻底删除帖子，包括制作库、线上库和对象存储里面的内容。
+// 先删除数据库里面的记录，再删除内容，所以不会有记录指向已经不存在的内容
+func (o *S3DAO) Purge(ctx context.Context, id int64) error {
+	var key string
+	err := o.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var pub PublishedArticleV1
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", id).Limit(1).Find(&pub).Error
+		if err != nil {
+			return err
+		}
+		key = pub.ContentKey
+		return purge(tx, id, &PublishedArticleV1{})
+	})
+	if err != nil || key == "" {
+		return err
+	}
+	// 记录已经删掉了，内容删除失败只是多占一点空间
+	o.deleteQuietly(key)
+	return nil
+}
+
+func (o *S3DAO) putWithRetry(ctx context.Context, key string, data []byte) error {
+	var err error
+	interval := o.interval
+	for i := 0; i < o.retries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(interval):
+			}
+			interval *= 2
+		}
+		err = o.store.Put(ctx, key, data, contentType)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// deleteQuietly 删除不再被引用的内容。用独立的 context，
+// 避免调用者的 context 已经超时导致补偿做不了；删除失败了也只是留下一个孤儿对象
+func (o *S3DAO) deleteQuietly(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+	_ = o.store.Delete(ctx, key)
+}
+
+// contentKey 每次发表都生成一个新的 key，形如 帖子ID/发表时间
+func contentKey(id int64, now int64) string {
+	return fmt.Sprintf("%d/%d", id, now)
 }
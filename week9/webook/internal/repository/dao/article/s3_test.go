@@ -3,12 +3,19 @@ package article
 import (
 	"bytes"
 	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/ecodeclub/ekit"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/objstore"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gormMysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
 	"io"
 	"os"
 	"testing"
@@ -53,3 +60,219 @@ func TestS3(t *testing.T) {
 	assert.NoError(t, err)
 	t.Log(string(data))
 }
+
+func TestS3DAO_putWithRetry(t *testing.T) {
+	testCases := []struct {
+		name     string
+		failures int
+		wantErr  bool
+		wantCall int
+	}{
+		{name: "一次成功", failures: 0, wantCall: 1},
+		{name: "重试之后成功", failures: 2, wantCall: 3},
+		{name: "重试耗尽", failures: 3, wantErr: true, wantCall: 3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &flakyStore{failures: tc.failures}
+			dao := &S3DAO{store: store, retries: 3, interval: time.Millisecond}
+			err := dao.putWithRetry(context.Background(), "1/1", []byte("内容"))
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.wantCall, store.calls)
+		})
+	}
+}
+
+// flakyStore 前 failures 次 Put 都失败
+type flakyStore struct {
+	objstore.ObjectStore
+	failures int
+	calls    int
+}
+
+func (f *flakyStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	f.calls++
+	if f.calls <= f.failures {
+		return errors.New("mock error")
+	}
+	return nil
+}
+
+func TestS3DAO_Sync(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(mock sqlmock.Sqlmock)
+		// 上传内容是否失败
+		putErr error
+
+		wantErr bool
+		// 删除的是旧的内容还是新上传的内容
+		wantDeleteOld bool
+		wantDeleteNew bool
+	}{
+		{
+			name: "发表成功，删除旧的内容",
+			mock: func(mock sqlmock.Sqlmock) {
+				mockUpdateDraft(mock)
+				mockLockPub(mock, "1/100")
+				mock.ExpectExec("INSERT INTO `published_article_v1` .*").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("DELETE FROM `published_article_tags` .*").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+			wantDeleteOld: true,
+		},
+		{
+			name: "第一次发表，没有旧的内容",
+			mock: func(mock sqlmock.Sqlmock) {
+				mockUpdateDraft(mock)
+				mockLockPub(mock, "")
+				mock.ExpectExec("INSERT INTO `published_article_v1` .*").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("DELETE FROM `published_article_tags` .*").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "上传失败，线上库还是指向旧的内容",
+			mock: func(mock sqlmock.Sqlmock) {
+				mockUpdateDraft(mock)
+				mockLockPub(mock, "1/100")
+				mock.ExpectRollback()
+			},
+			putErr:  errors.New("mock error"),
+			wantErr: true,
+			// 可能上传了一部分，也要删掉
+			wantDeleteNew: true,
+		},
+		{
+			name: "写线上库失败，删除新上传的内容",
+			mock: func(mock sqlmock.Sqlmock) {
+				mockUpdateDraft(mock)
+				mockLockPub(mock, "1/100")
+				mock.ExpectExec("INSERT INTO `published_article_v1` .*").
+					WillReturnError(errors.New("mock db error"))
+				mock.ExpectRollback()
+			},
+			wantErr:       true,
+			wantDeleteNew: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDB, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			tc.mock(mock)
+			db, err := gorm.Open(gormMysql.New(gormMysql.Config{
+				Conn:                      mockDB,
+				SkipInitializeWithVersion: true,
+			}), &gorm.Config{
+				DisableAutomaticPing:   true,
+				SkipDefaultTransaction: true,
+			})
+			require.NoError(t, err)
+			store := &recordStore{putErr: tc.putErr}
+			dao := NewOssDAO(store, db).(*S3DAO)
+			dao.retries = 1
+
+			id, err := dao.Sync(context.Background(), Article{
+				Id: 1, Title: "标题", Content: "内容", AuthorId: 123,
+				Status: domain.ArticleStatusPublished.ToUint8(),
+			})
+			assert.Equal(t, tc.wantErr, err != nil)
+			require.NoError(t, mock.ExpectationsWereMet())
+			require.Len(t, store.puts, 1)
+			newKey := store.puts[0]
+			var wantDeletes []string
+			if tc.wantDeleteNew {
+				wantDeletes = append(wantDeletes, newKey)
+			}
+			if tc.wantDeleteOld {
+				wantDeletes = append(wantDeletes, "1/100")
+			}
+			assert.Equal(t, wantDeletes, store.deletes)
+			if !tc.wantErr {
+				assert.Equal(t, int64(1), id)
+			}
+		})
+	}
+}
+
+func TestS3DAO_GetPubById(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	mock.ExpectQuery("SELECT \\* FROM `published_article_v1` .*").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author_id", "content_key",
+			"html", "toc", "word_cnt", "read_minutes"}).
+			AddRow(1, "标题", 123, "1/100", "<p>内容</p>", `[{"id":"a"}]`, 2, 1))
+	mock.ExpectQuery("SELECT .* FROM `published_article_tags` .*").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}))
+	db, err := gorm.Open(gormMysql.New(gormMysql.Config{
+		Conn:                      mockDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	store := &recordStore{content: map[string]string{"1/100": "内容"}}
+	dao := NewOssDAO(store, db)
+
+	// 渲染的结果也要读出来，不然读者看不到 HTML 和目录
+	art, err := dao.GetPubById(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, PublishedArticle{
+		Id: 1, Title: "标题", Content: "内容", AuthorId: 123,
+		Tags: []string{},
+		Html: "<p>内容</p>", Toc: `[{"id":"a"}]`, WordCnt: 2, ReadMinutes: 1,
+	}, art)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// mockUpdateDraft 更新制作库，没有标签
+func mockUpdateDraft(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT .*").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE `articles` .*").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM `article_tags` .*").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO `article_revisions` .*").WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// mockLockPub 锁住线上库，key 为空代表还没有发表过
+func mockLockPub(mock sqlmock.Sqlmock, key string) {
+	rows := sqlmock.NewRows([]string{"id", "content_key"})
+	if key != "" {
+		rows.AddRow(1, key)
+	}
+	mock.ExpectQuery("SELECT \\* FROM `published_article_v1` .* FOR UPDATE").
+		WillReturnRows(rows)
+}
+
+// recordStore 记录上传和删除了哪些 key
+type recordStore struct {
+	objstore.ObjectStore
+	putErr  error
+	puts    []string
+	deletes []string
+	content map[string]string
+}
+
+func (r *recordStore) Get(ctx context.Context, key string) ([]byte, error) {
+	val, ok := r.content[key]
+	if !ok {
+		return nil, objstore.ErrObjectNotFound
+	}
+	return []byte(val), nil
+}
+
+func (r *recordStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	r.puts = append(r.puts, key)
+	return r.putErr
+}
+
+func (r *recordStore) Delete(ctx context.Context, key string) error {
+	r.deletes = append(r.deletes, key)
+	return nil
+}
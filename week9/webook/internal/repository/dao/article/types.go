@@ -3,18 +3,72 @@ package article
 import (
 	"context"
 	"errors"
+	"gorm.io/gorm"
 	"time"
 )
 
-var ErrPossibleIncorrectAuthor = errors.New("用户在尝试操作非本人数据")
+var (
+	ErrPossibleIncorrectAuthor = errors.New("用户在尝试操作非本人数据")
+	// ErrVersionConflict 帖子在加载之后已经被修改过了，比如说在另外一个页面里面保存过
+	ErrVersionConflict = errors.New("帖子版本冲突")
+	// ErrRevisionNotFound 历史版本不存在，或者不是这个作者的
+	ErrRevisionNotFound = errors.New("历史版本不存在")
+	// ErrArticleNotFound 帖子不存在
+	ErrArticleNotFound = gorm.ErrRecordNotFound
+	// ErrArticleDeleted 帖子在回收站里面，不能修改、发表或者撤回，要先恢复
+	ErrArticleDeleted = errors.New("帖子在回收站里面")
+)
+
+// Cursor 按照 (utime, id) 倒序翻页的游标，也就是上一页最后一条的 utime 和 id。
+// 零值代表第一页
+type Cursor struct {
+	Utime int64
+	Id    int64
+}
 
 type ArticleDAO interface {
 	Insert(ctx context.Context, art Article) (int64, error)
+	// UpdateById 回收站里面的帖子返回 ErrArticleDeleted
 	UpdateById(ctx context.Context, art Article) error
 	GetByAuthor(ctx context.Context, author int64, offset, limit int) ([]Article, error)
+	// GetByAuthorCursor 和 GetByAuthor 一样，但是用游标翻页，翻多少页性能都不会下降
+	GetByAuthorCursor(ctx context.Context, author int64, cursor Cursor, limit int) ([]Article, error)
+	// GetById 帖子不存在返回 ErrArticleNotFound
 	GetById(ctx context.Context, id int64) (Article, error)
+	// GetPubById 线上库没有返回 ErrArticleNotFound
 	GetPubById(ctx context.Context, id int64) (PublishedArticle, error)
 	Sync(ctx context.Context, art Article) (int64, error)
+	// SyncStatus 回收站里面的帖子返回 ErrArticleDeleted
 	SyncStatus(ctx context.Context, author, id int64, status uint8) error
 	ListPub(ctx context.Context, start time.Time, offset int, limit int) ([]Article, error)
+	// ListPubCursor 用游标翻页，按照 (utime, id) 倒序返回已经发表的帖子
+	ListPubCursor(ctx context.Context, cursor Cursor, limit int) ([]PublishedArticle, error)
+	// ListByStatus 按照定时发表的时间，列出作者处于某个状态的帖子
+	ListByStatus(ctx context.Context, author int64, status uint8, offset, limit int) ([]Article, error)
+	// CancelSchedule 把定时发表的帖子改回未发表
+	CancelSchedule(ctx context.Context, author, id int64) error
+
+	// ListPubByTag 按照更新时间，列出某个标签下已经发表的帖子
+	ListPubByTag(ctx context.Context, tag string, offset, limit int) ([]PublishedArticle, error)
+	// TagCloud 按照已发表帖子的数量从多到少返回标签
+	TagCloud(ctx context.Context, limit int) ([]TagCount, error)
+	// ListPubAfterId 按照 ID 从小到大遍历线上库，用来全量重建索引之类的
+	ListPubAfterId(ctx context.Context, id int64, limit int) ([]PublishedArticle, error)
+	// ListPubByIds 批量查询已经发表的帖子，不保证顺序，不存在或者不可见的不会返回
+	ListPubByIds(ctx context.Context, ids []int64) ([]PublishedArticle, error)
+
+	// SoftDelete 把帖子放进回收站，制作库和线上库都改成删除状态
+	SoftDelete(ctx context.Context, author, id int64) error
+	// Restore 从回收站恢复，制作库回到未发表，线上库回到仅自己可见
+	Restore(ctx context.Context, author, id int64) error
+	// ListDeleted 按照删除时间从新到旧，列出作者回收站里面的帖子
+	ListDeleted(ctx context.Context, author int64, offset, limit int) ([]Article, error)
+	// ListDeletedBefore 列出在 dtime 之前被删除的帖子，不区分作者
+	ListDeletedBefore(ctx context.Context, dtime int64, limit int) ([]Article, error)
+	// Purge 彻底删除帖子，包括线上库、标签和历史版本
+	Purge(ctx context.Context, id int64) error
+
+	// ListRevisions 按照从新到旧的顺序，返回某个作者的某篇帖子的历史版本
+	ListRevisions(ctx context.Context, author, id int64, offset, limit int) ([]ArticleRevision, error)
+	GetRevision(ctx context.Context, author, revId int64) (ArticleRevision, error)
 }
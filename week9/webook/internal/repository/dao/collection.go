@@ -0,0 +1,135 @@
+package dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+// CollectionDAO 收藏夹的增删改查。
+// 收藏和取消收藏会影响计数，所以放在 InteractiveDAO 里面
+type CollectionDAO interface {
+	Insert(ctx context.Context, c Collection) (int64, error)
+	GetById(ctx context.Context, id int64) (Collection, error)
+	// UpdateName 只能改自己的收藏夹，没有改到返回 ErrRecordNotFound
+	UpdateName(ctx context.Context, uid, id int64, name string) error
+	// Delete 删除收藏夹，里面的东西挪到默认收藏夹
+	Delete(ctx context.Context, uid, id int64) error
+	ListByUid(ctx context.Context, uid int64) ([]Collection, error)
+	// ListItems 收藏夹里面的东西，最近收藏的在前面
+	ListItems(ctx context.Context, uid, cid int64, offset, limit int) ([]UserCollectionBiz, error)
+	// ListAllItems 不分收藏夹，用户收藏过的所有东西，按照收藏时间倒序
+	ListAllItems(ctx context.Context, uid int64, cursor TimeCursor, limit int) ([]UserCollectionBiz, error)
+	// MoveItem 把收藏的东西挪到另外一个收藏夹，没有收藏返回 ErrRecordNotFound
+	MoveItem(ctx context.Context, uid int64, biz string, bizId, cid int64) error
+}
+
+type GORMCollectionDAO struct {
+	db *gorm.DB
+}
+
+func NewGORMCollectionDAO(db *gorm.DB) CollectionDAO {
+	return &GORMCollectionDAO{db: db}
+}
+
+func (dao *GORMCollectionDAO) Insert(ctx context.Context, c Collection) (int64, error) {
+	now := time.Now().UnixMilli()
+	c.Ctime = now
+	c.Utime = now
+	err := dao.db.WithContext(ctx).Create(&c).Error
+	return c.Id, err
+}
+
+func (dao *GORMCollectionDAO) GetById(ctx context.Context, id int64) (Collection, error) {
+	var res Collection
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&res).Error
+	return res, err
+}
+
+func (dao *GORMCollectionDAO) UpdateName(ctx context.Context,
+	uid, id int64, name string) error {
+	res := dao.db.WithContext(ctx).Model(&Collection{}).
+		Where("id = ? AND uid = ?", id, uid).
+		Updates(map[string]any{
+			"name":  name,
+			"utime": time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		// 名字没变的时候 utime 也会变，所以这里一定是没有这个收藏夹
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (dao *GORMCollectionDAO) Delete(ctx context.Context, uid, id int64) error {
+	now := time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("id = ? AND uid = ?", id, uid).Delete(&Collection{})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrRecordNotFound
+		}
+		// 收藏的东西不跟着删，不然还要一个个去减收藏数
+		return tx.Model(&UserCollectionBiz{}).
+			Where("uid = ? AND cid = ?", uid, id).
+			Updates(map[string]any{
+				"cid":   0,
+				"utime": now,
+			}).Error
+	})
+}
+
+func (dao *GORMCollectionDAO) ListByUid(ctx context.Context, uid int64) ([]Collection, error) {
+	var res []Collection
+	err := dao.db.WithContext(ctx).
+		Where("uid = ?", uid).
+		Order("id ASC").
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMCollectionDAO) ListItems(ctx context.Context,
+	uid, cid int64, offset, limit int) ([]UserCollectionBiz, error) {
+	var res []UserCollectionBiz
+	// 命中 uid_cid 索引
+	err := dao.db.WithContext(ctx).
+		Where("uid = ? AND cid = ?", uid, cid).
+		Order("id DESC").
+		Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMCollectionDAO) ListAllItems(ctx context.Context,
+	uid int64, cursor TimeCursor, limit int) ([]UserCollectionBiz, error) {
+	var res []UserCollectionBiz
+	// 命中 uid_ctime 索引。挪收藏夹只改 utime，不影响顺序
+	db := dao.db.WithContext(ctx).Where("uid = ?", uid)
+	err := afterTimeCursor(db, "ctime", cursor).
+		Order("ctime DESC, id DESC").
+		Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMCollectionDAO) MoveItem(ctx context.Context,
+	uid int64, biz string, bizId, cid int64) error {
+	res := dao.db.WithContext(ctx).Model(&UserCollectionBiz{}).
+		Where("biz = ? AND biz_id = ? AND uid = ?", biz, bizId, uid).
+		Updates(map[string]any{
+			"cid":   cid,
+			"utime": time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type CommentDAO interface {
+	// Insert 插入评论，同时更新资源的评论数和一级评论的回复数
+	Insert(ctx context.Context, c Comment) (int64, error)
+	GetById(ctx context.Context, id int64) (Comment, error)
+	// ListRoot 一级评论，hot 为 true 的时候按照回复数排序
+	ListRoot(ctx context.Context, biz string, bizId int64,
+		hot bool, offset, limit int) ([]Comment, error)
+	// ListReplies 一级评论下面的所有回复，按照时间先后排序
+	ListReplies(ctx context.Context, rootId int64, offset, limit int) ([]Comment, error)
+	// Delete 删除评论和它下面的所有回复，返回一共删除了多少条
+	Delete(ctx context.Context, c Comment) (int64, error)
+}
+
+type GORMCommentDAO struct {
+	db *gorm.DB
+}
+
+func NewGORMCommentDAO(db *gorm.DB) CommentDAO {
+	return &GORMCommentDAO{db: db}
+}
+
+func (dao *GORMCommentDAO) Insert(ctx context.Context, c Comment) (int64, error) {
+	now := time.Now().UnixMilli()
+	c.Ctime = now
+	c.Utime = now
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(&c).Error
+		if err != nil {
+			return err
+		}
+		if c.RootId > 0 {
+			err = tx.Model(&Comment{}).Where("id = ?", c.RootId).
+				Updates(map[string]any{
+					"reply_cnt": gorm.Expr("reply_cnt + 1"),
+					"utime":     now,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return incrCommentCnt(tx, c.Biz, c.BizId, 1, now)
+	})
+	return c.Id, err
+}
+
+// incrCommentCnt 和 IncrReadCnt 一样是 upsert 的语义
+func incrCommentCnt(tx *gorm.DB, biz string, bizId int64, delta int64, now int64) error {
+	return tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]any{
+			"comment_cnt": gorm.Expr("comment_cnt + ?", delta),
+			"utime":       now,
+		}),
+	}).Create(&Interactive{
+		Biz:        biz,
+		BizId:      bizId,
+		CommentCnt: delta,
+		Ctime:      now,
+		Utime:      now,
+	}).Error
+}
+
+func (dao *GORMCommentDAO) GetById(ctx context.Context, id int64) (Comment, error) {
+	var res Comment
+	err := dao.db.WithContext(ctx).Where("id = ?", id).First(&res).Error
+	return res, err
+}
+
+func (dao *GORMCommentDAO) ListRoot(ctx context.Context,
+	biz string, bizId int64, hot bool, offset, limit int) ([]Comment, error) {
+	order := "id DESC"
+	if hot {
+		order = "reply_cnt DESC, id DESC"
+	}
+	var res []Comment
+	err := dao.db.WithContext(ctx).
+		Where("biz = ? AND biz_id = ? AND root_id = ?", biz, bizId, 0).
+		Order(order).
+		Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMCommentDAO) ListReplies(ctx context.Context,
+	rootId int64, offset, limit int) ([]Comment, error) {
+	var res []Comment
+	err := dao.db.WithContext(ctx).
+		Where("root_id = ?", rootId).
+		Order("id ASC").
+		Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMCommentDAO) Delete(ctx context.Context, c Comment) (int64, error) {
+	now := time.Now().UnixMilli()
+	var cnt int64
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		ids, err := dao.subtree(tx, c)
+		if err != nil {
+			return err
+		}
+		res := tx.Where("id IN ?", ids).Delete(&Comment{})
+		if res.Error != nil {
+			return res.Error
+		}
+		// 并发删除的时候，以真正删掉的为准
+		cnt = res.RowsAffected
+		if cnt == 0 {
+			return nil
+		}
+		if c.RootId > 0 {
+			err = tx.Model(&Comment{}).Where("id = ?", c.RootId).
+				Updates(map[string]any{
+					"reply_cnt": gorm.Expr("reply_cnt - ?", cnt),
+					"utime":     now,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return incrCommentCnt(tx, c.Biz, c.BizId, -cnt, now)
+	})
+	return cnt, err
+}
+
+// subtree 找出 c 和它下面所有回复的 ID
+func (dao *GORMCommentDAO) subtree(tx *gorm.DB, c Comment) ([]int64, error) {
+	if c.RootId == 0 {
+		var ids []int64
+		err := tx.Model(&Comment{}).Where("root_id = ?", c.Id).
+			Pluck("id", &ids).Error
+		return append(ids, c.Id), err
+	}
+	// 同一个一级评论下面的回复不会太多，一次捞出来在内存里面找
+	var replies []Comment
+	err := tx.Select("id", "pid").Where("root_id = ?", c.RootId).
+		Find(&replies).Error
+	if err != nil {
+		return nil, err
+	}
+	children := make(map[int64][]int64, len(replies))
+	for _, r := range replies {
+		children[r.Pid] = append(children[r.Pid], r.Id)
+	}
+	ids := []int64{c.Id}
+	for i := 0; i < len(ids); i++ {
+		ids = append(ids, children[ids[i]]...)
+	}
+	return ids, nil
+}
+
+type Comment struct {
+	Id int64 `gorm:"primaryKey,autoIncrement"`
+	// 评论的人
+	Uid int64
+	// 一级评论按照资源查询，按照时间或者回复数排序
+	Biz     string `gorm:"type:varchar(128);index:biz_type_id_root"`
+	BizId   int64  `gorm:"index:biz_type_id_root"`
+	Content string `gorm:"type:text"`
+	// 一级评论是 0
+	RootId int64 `gorm:"index:biz_type_id_root;index"`
+	// 直接回复的评论，一级评论是 0
+	Pid      int64
+	ReplyCnt int64
+	Ctime    int64
+	Utime    int64
+}
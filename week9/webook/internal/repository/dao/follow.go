@@ -0,0 +1,208 @@
+package dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+//go:generate mockgen -source=./follow.go -package=daomocks -destination=mocks/follow.mock.go FollowDAO
+
+// FollowDAO 关注关系，关注和取消关注的时候在同一个事务里面更新双方的计数
+type FollowDAO interface {
+	// Follow 关注，已经关注了的时候返回 false
+	Follow(ctx context.Context, follower, followee int64) (bool, error)
+	// Unfollow 取消关注，本来就没有关注的时候返回 false
+	Unfollow(ctx context.Context, follower, followee int64) (bool, error)
+	// ListFollowers followee 的粉丝，按照关注时间倒序
+	ListFollowers(ctx context.Context, followee int64, cursor TimeCursor, limit int) ([]FollowRelation, error)
+	// ListFollowees follower 关注的人，按照关注时间倒序
+	ListFollowees(ctx context.Context, follower int64, cursor TimeCursor, limit int) ([]FollowRelation, error)
+	// FindFollowees uids 里面 follower 关注了的那些
+	FindFollowees(ctx context.Context, follower int64, uids []int64) ([]int64, error)
+	// FindFollowers uids 里面关注了 followee 的那些
+	FindFollowers(ctx context.Context, followee int64, uids []int64) ([]int64, error)
+	// GetStatics 没有关注过别人也没有粉丝的用户返回 ErrRecordNotFound
+	GetStatics(ctx context.Context, uid int64) (FollowStatics, error)
+}
+
+type GORMFollowDAO struct {
+	db *gorm.DB
+}
+
+func NewGORMFollowDAO(db *gorm.DB) FollowDAO {
+	return &GORMFollowDAO{db: db}
+}
+
+func (dao *GORMFollowDAO) Follow(ctx context.Context, follower, followee int64) (bool, error) {
+	now := time.Now().UnixMilli()
+	changed := false
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 先保证有这条记录，然后锁住它，并发的关注和取消关注就只能一个个来
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&FollowRelation{
+				Follower: follower,
+				Followee: followee,
+				Ctime:    now,
+				Utime:    now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		var fr FollowRelation
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("follower = ? AND followee = ?", follower, followee).
+			First(&fr).Error
+		if err != nil {
+			return err
+		}
+		if fr.Status == 1 {
+			// 重复关注，计数不能加
+			return nil
+		}
+		err = tx.Model(&FollowRelation{}).Where("id = ?", fr.Id).
+			Updates(map[string]any{
+				"status": 1,
+				"utime":  now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		changed = true
+		return dao.incrStatics(tx, follower, followee, 1, now)
+	})
+	return changed, err
+}
+
+func (dao *GORMFollowDAO) Unfollow(ctx context.Context, follower, followee int64) (bool, error) {
+	now := time.Now().UnixMilli()
+	changed := false
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 软删除，只有真的改到了才减计数
+		res := tx.Model(&FollowRelation{}).
+			Where("follower = ? AND followee = ? AND status = ?", follower, followee, 1).
+			Updates(map[string]any{
+				"status": 0,
+				"utime":  now,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		changed = true
+		return dao.incrStatics(tx, follower, followee, -1, now)
+	})
+	return changed, err
+}
+
+// incrStatics follower 的关注数和 followee 的粉丝数一起加上 delta
+func (dao *GORMFollowDAO) incrStatics(tx *gorm.DB,
+	follower, followee int64, delta int64, now int64) error {
+	err := tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]any{
+			"followees": gorm.Expr("`followees` + ?", delta),
+			"utime":     now,
+		}),
+	}).Create(&FollowStatics{
+		Uid:       follower,
+		Followees: delta,
+		Ctime:     now,
+		Utime:     now,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]any{
+			"followers": gorm.Expr("`followers` + ?", delta),
+			"utime":     now,
+		}),
+	}).Create(&FollowStatics{
+		Uid:       followee,
+		Followers: delta,
+		Ctime:     now,
+		Utime:     now,
+	}).Error
+}
+
+func (dao *GORMFollowDAO) ListFollowers(ctx context.Context,
+	followee int64, cursor TimeCursor, limit int) ([]FollowRelation, error) {
+	var res []FollowRelation
+	// 命中 followee_utime 索引
+	db := dao.db.WithContext(ctx).
+		Where("followee = ? AND status = ?", followee, 1)
+	err := afterTimeCursor(db, "utime", cursor).
+		Order("utime DESC, id DESC").
+		Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMFollowDAO) ListFollowees(ctx context.Context,
+	follower int64, cursor TimeCursor, limit int) ([]FollowRelation, error) {
+	var res []FollowRelation
+	// 命中 follower_utime 索引
+	db := dao.db.WithContext(ctx).
+		Where("follower = ? AND status = ?", follower, 1)
+	err := afterTimeCursor(db, "utime", cursor).
+		Order("utime DESC, id DESC").
+		Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMFollowDAO) FindFollowees(ctx context.Context,
+	follower int64, uids []int64) ([]int64, error) {
+	var res []int64
+	if len(uids) == 0 {
+		return res, nil
+	}
+	err := dao.db.WithContext(ctx).Model(&FollowRelation{}).
+		Where("follower = ? AND followee IN ? AND status = ?", follower, uids, 1).
+		Pluck("followee", &res).Error
+	return res, err
+}
+
+func (dao *GORMFollowDAO) FindFollowers(ctx context.Context,
+	followee int64, uids []int64) ([]int64, error) {
+	var res []int64
+	if len(uids) == 0 {
+		return res, nil
+	}
+	err := dao.db.WithContext(ctx).Model(&FollowRelation{}).
+		Where("followee = ? AND follower IN ? AND status = ?", followee, uids, 1).
+		Pluck("follower", &res).Error
+	return res, err
+}
+
+func (dao *GORMFollowDAO) GetStatics(ctx context.Context, uid int64) (FollowStatics, error) {
+	var res FollowStatics
+	err := dao.db.WithContext(ctx).Where("uid = ?", uid).First(&res).Error
+	return res, err
+}
+
+// FollowRelation 关注关系，取消关注只是把 status 改成 0
+type FollowRelation struct {
+	Id int64 `gorm:"primaryKey,autoIncrement"`
+	// 查我关注了谁，用 follower_utime；查谁关注了我，用 followee_utime
+	Follower int64 `gorm:"uniqueIndex:follower_followee;index:follower_utime,priority:1"`
+	Followee int64 `gorm:"uniqueIndex:follower_followee;index:followee_utime,priority:1"`
+	// 1 是关注，0 是取消关注
+	Status uint8
+	Ctime  int64
+	// 重新关注会更新 utime，所以 utime 才是关注的时间
+	Utime int64 `gorm:"index:follower_utime,priority:2;index:followee_utime,priority:2"`
+}
+
+// FollowStatics 关注数和粉丝数，和关注关系在同一个事务里面更新
+type FollowStatics struct {
+	Id        int64 `gorm:"primaryKey,autoIncrement"`
+	Uid       int64 `gorm:"unique"`
+	Followers int64
+	Followees int64
+	Ctime     int64
+	Utime     int64
+}
@@ -0,0 +1,74 @@
+package dao
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type HistoryDAO interface {
+	// Upsert 没有记录就插入，有记录就更新最后阅读的时间
+	Upsert(ctx context.Context, h ReadHistory) error
+	// List 最近读过的在前面
+	List(ctx context.Context, uid int64, offset, limit int) ([]ReadHistory, error)
+	Delete(ctx context.Context, uid int64, biz string, bizId int64) error
+	DeleteAll(ctx context.Context, uid int64) error
+}
+
+type GORMHistoryDAO struct {
+	db *gorm.DB
+}
+
+func NewGORMHistoryDAO(db *gorm.DB) HistoryDAO {
+	return &GORMHistoryDAO{db: db}
+}
+
+func (dao *GORMHistoryDAO) Upsert(ctx context.Context, h ReadHistory) error {
+	now := time.Now().UnixMilli()
+	h.Ctime = now
+	h.Utime = now
+	return dao.db.WithContext(ctx).Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]any{
+			// 消息可能乱序，或者重复消费，阅读时间只能往后走
+			"read_time": gorm.Expr("GREATEST(read_time, ?)", h.ReadTime),
+			"utime":     now,
+		}),
+	}).Create(&h).Error
+}
+
+func (dao *GORMHistoryDAO) List(ctx context.Context,
+	uid int64, offset, limit int) ([]ReadHistory, error) {
+	var res []ReadHistory
+	err := dao.db.WithContext(ctx).
+		Where("uid = ?", uid).
+		Order("read_time DESC").
+		Offset(offset).Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMHistoryDAO) Delete(ctx context.Context,
+	uid int64, biz string, bizId int64) error {
+	return dao.db.WithContext(ctx).
+		Where("uid = ? AND biz = ? AND biz_id = ?", uid, biz, bizId).
+		Delete(&ReadHistory{}).Error
+}
+
+func (dao *GORMHistoryDAO) DeleteAll(ctx context.Context, uid int64) error {
+	return dao.db.WithContext(ctx).
+		Where("uid = ?", uid).
+		Delete(&ReadHistory{}).Error
+}
+
+type ReadHistory struct {
+	Id int64 `gorm:"primaryKey,autoIncrement"`
+	// 一个用户一个资源只有一条记录
+	Uid   int64  `gorm:"uniqueIndex:uid_biz_type_id;index:uid_read_time"`
+	Biz   string `gorm:"type:varchar(128);uniqueIndex:uid_biz_type_id"`
+	BizId int64  `gorm:"uniqueIndex:uid_biz_type_id"`
+	// 最后一次阅读的时间，按照它来列出阅读记录
+	ReadTime int64 `gorm:"index:uid_read_time"`
+	Ctime    int64
+	Utime    int64
+}
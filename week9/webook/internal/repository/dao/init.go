@@ -9,10 +9,25 @@ func InitTable(db *gorm.DB) error {
 	return db.AutoMigrate(&User{},
 		&article.Article{},
 		&article.PublishedArticle{},
+		// 内容放在对象存储里面的时候用这个
+		&article.PublishedArticleV1{},
+		&article.ArticleRevision{},
+		&article.Tag{},
+		&article.ArticleTag{},
+		&article.PublishedArticleTag{},
 		&Interactive{},
+		&InteractiveFlushLog{},
+		&InteractiveDailyUV{},
+		&InteractiveUVSketch{},
 		&UserLikeBiz{},
 		&Collection{},
 		&UserCollectionBiz{},
+		&FollowRelation{},
+		&FollowStatics{},
+		&Notification{},
+		&NotificationActor{},
+		&Comment{},
+		&ReadHistory{},
 		&Job{},
 	)
 }
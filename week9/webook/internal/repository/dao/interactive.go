@@ -2,23 +2,62 @@ package dao
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"time"
 )
 
-var ErrRecordNotFound = gorm.ErrRecordNotFound
+var (
+	ErrRecordNotFound  = gorm.ErrRecordNotFound
+	ErrUnknownReaction = errors.New("不支持的表态")
+)
+
+// reactionCntColumns 每一种表态的计数放在哪一列，点赞就是 like_cnt
+var reactionCntColumns = map[string]string{
+	"like":       "like_cnt",
+	"love":       "love_cnt",
+	"laugh":      "laugh_cnt",
+	"insightful": "insightful_cnt",
+}
 
 //go:generate mockgen -source=./interactive.go -package=daomocks -destination=mocks/interactive.mock.go InteractiveDAO
 type InteractiveDAO interface {
 	IncrReadCnt(ctx context.Context, biz string, bizId int64) error
-	InsertLikeInfo(ctx context.Context, biz string, bizId, uid int64) error
+	// SetReaction 表态，点赞也是一种表态。返回之前的表态，没有的话是空字符串
+	SetReaction(ctx context.Context, biz string, bizId, uid int64, reaction string) (string, error)
 	GetLikeInfo(ctx context.Context, biz string, bizId, uid int64) (UserLikeBiz, error)
-	DeleteLikeInfo(ctx context.Context, biz string, bizId, uid int64) error
+	// DeleteLikeInfo 取消表态，并且更新计数，返回取消掉的表态。
+	// 本来就没有表态的时候返回空字符串
+	DeleteLikeInfo(ctx context.Context, biz string, bizId, uid int64) (string, error)
+	// ListLikesByUid 用户点赞过的所有资源，按照点赞时间倒序
+	ListLikesByUid(ctx context.Context, uid int64, cursor TimeCursor, limit int) ([]UserLikeBiz, error)
 	Get(ctx context.Context, biz string, bizId int64) (Interactive, error)
+	// GetByIds 没有互动数据的资源不会出现在结果里面
+	GetByIds(ctx context.Context, biz string, bizIds []int64) ([]Interactive, error)
 	InsertCollectionBiz(ctx context.Context, cb UserCollectionBiz) error
 	GetCollectionInfo(ctx context.Context, biz string, bizId, uid int64) (UserCollectionBiz, error)
+	// DeleteCollectionBiz 取消收藏，并且更新计数。
+	// 本来就没有收藏的时候返回 false
+	DeleteCollectionBiz(ctx context.Context, biz string, bizId, uid int64) (bool, error)
 	BatchIncrReadCnt(ctx context.Context, bizs []string, ids []int64) error
+	// MergeReadCnt 把 Redis 里面累加的阅读数合并进来。
+	// 同一个 batch 只会合并一次，重复调用直接返回
+	MergeReadCnt(ctx context.Context, batch string, deltas []ReadCntDelta) error
+	// SaveUV 保存某一天汇总的 UV，同时更新总的 UV 和它的 HyperLogLog。
+	// 同一天可以反复汇总，后面的覆盖前面的
+	SaveUV(ctx context.Context, day string, uvs []UVCnt) error
+	// GetUVSketches 查询汇总的时候保存的 HyperLogLog，bizs 和 bizIds 一一对应，没有保存过的不返回
+	GetUVSketches(ctx context.Context, bizs []string, bizIds []int64) ([]InteractiveUVSketch, error)
+	// DeleteFlushLogsBefore 合并记录只在合并没有确认的时候有用，过一段时间就可以删了
+	DeleteFlushLogsBefore(ctx context.Context, ctime int64) error
+	// ReconcileCnt 按照 id 的顺序取出 id 之后的 limit 条计数，
+	// 用点赞和收藏的记录重新算一遍，不一致的直接改掉
+	ReconcileCnt(ctx context.Context, id int64, limit int) (domain.CntReconcileBatch, error)
+	// DeleteByBiz 删除某个资源的计数、点赞、收藏和评论
+	DeleteByBiz(ctx context.Context, biz string, bizId int64) error
 }
 
 type GORMInteractiveDAO struct {
@@ -56,6 +95,128 @@ func (dao *GORMInteractiveDAO) BatchIncrReadCnt(ctx context.Context, bizs []stri
 	})
 }
 
+func (dao *GORMInteractiveDAO) MergeReadCnt(ctx context.Context,
+	batch string, deltas []ReadCntDelta) error {
+	if len(deltas) == 0 {
+		return nil
+	}
+	now := time.Now().UnixMilli()
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 先占住批次号，和更新阅读数在同一个事务里面，
+		// 所以要么都成功，要么都失败
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&InteractiveFlushLog{Batch: batch, Ctime: now})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			// 这一批已经合并过了，上一次是在 Ack 之前崩溃的
+			return nil
+		}
+		intrs := make([]Interactive, 0, len(deltas))
+		for _, d := range deltas {
+			intrs = append(intrs, Interactive{
+				Biz:     d.Biz,
+				BizId:   d.BizId,
+				ReadCnt: d.Delta,
+				Ctime:   now,
+				Utime:   now,
+			})
+		}
+		// 一条 INSERT ... ON DUPLICATE KEY UPDATE 搞定一整批
+		return tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]any{
+				"read_cnt": gorm.Expr("`read_cnt` + VALUES(`read_cnt`)"),
+				"utime":    now,
+			}),
+		}).Create(&intrs).Error
+	})
+}
+
+func (dao *GORMInteractiveDAO) SaveUV(ctx context.Context, day string, uvs []UVCnt) error {
+	if len(uvs) == 0 {
+		return nil
+	}
+	now := time.Now().UnixMilli()
+	dailys := make([]InteractiveDailyUV, 0, len(uvs))
+	intrs := make([]Interactive, 0, len(uvs))
+	sketches := make([]InteractiveUVSketch, 0, len(uvs))
+	for _, uv := range uvs {
+		if len(uv.TotalSketch) > 0 {
+			sketches = append(sketches, InteractiveUVSketch{
+				Biz:    uv.Biz,
+				BizId:  uv.BizId,
+				Sketch: uv.TotalSketch,
+				Ctime:  now,
+				Utime:  now,
+			})
+		}
+		dailys = append(dailys, InteractiveDailyUV{
+			Biz:   uv.Biz,
+			BizId: uv.BizId,
+			Day:   day,
+			Uv:    uv.DayUV,
+			Ctime: now,
+			Utime: now,
+		})
+		intrs = append(intrs, Interactive{
+			Biz:   uv.Biz,
+			BizId: uv.BizId,
+			Uv:    uv.TotalUV,
+			Ctime: now,
+			Utime: now,
+		})
+	}
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]any{
+				"uv":    gorm.Expr("VALUES(`uv`)"),
+				"utime": now,
+			}),
+		}).Create(&dailys).Error
+		if err != nil {
+			return err
+		}
+		// HyperLogLog 丢了之后会从头算，总的 UV 不能变小
+		err = tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]any{
+				"uv":    gorm.Expr("GREATEST(`uv`, VALUES(`uv`))"),
+				"utime": now,
+			}),
+		}).Create(&intrs).Error
+		if err != nil || len(sketches) == 0 {
+			return err
+		}
+		// Redis 里面的 HyperLogLog 过期之前会先恢复再记录，所以新的总是包含了旧的
+		return tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]any{
+				"sketch": gorm.Expr("VALUES(`sketch`)"),
+				"utime":  now,
+			}),
+		}).Create(&sketches).Error
+	})
+}
+
+func (dao *GORMInteractiveDAO) GetUVSketches(ctx context.Context,
+	bizs []string, bizIds []int64) ([]InteractiveUVSketch, error) {
+	if len(bizIds) == 0 {
+		return nil, nil
+	}
+	conds := make([][]any, 0, len(bizIds))
+	for i, id := range bizIds {
+		conds = append(conds, []any{bizs[i], id})
+	}
+	var res []InteractiveUVSketch
+	err := dao.db.WithContext(ctx).
+		Where("(biz, biz_id) IN ?", conds).Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMInteractiveDAO) DeleteFlushLogsBefore(ctx context.Context, ctime int64) error {
+	return dao.db.WithContext(ctx).Where("ctime < ?", ctime).
+		Delete(&InteractiveFlushLog{}).Error
+}
+
 func (dao *GORMInteractiveDAO) GetLikeInfo(ctx context.Context, biz string, bizId, uid int64) (UserLikeBiz, error) {
 	var res UserLikeBiz
 	err := dao.db.WithContext(ctx).
@@ -71,7 +232,7 @@ func (dao *GORMInteractiveDAO) GetCollectionInfo(ctx context.Context, biz string
 	return res, err
 }
 
-// InsertCollectionBiz 和 InsertLikeInfo 能不能抽取出来，
+// InsertCollectionBiz 和 SetReaction 能不能抽取出来，
 // 适当的重复（复制-粘贴）要比强行抽象要更加好一点
 //func (dao *GORMInteractiveDAO) common(ctx context.Context,
 //	biz any, column string, intr Interactive) error {
@@ -109,7 +270,7 @@ func (dao *GORMInteractiveDAO) InsertCollectionBiz(ctx context.Context,
 	cb.Ctime = now
 	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		// 插入收藏项目
-		err := dao.db.WithContext(ctx).Create(&cb).Error
+		err := tx.Create(&cb).Error
 		if err != nil {
 			return err
 		}
@@ -129,59 +290,122 @@ func (dao *GORMInteractiveDAO) InsertCollectionBiz(ctx context.Context,
 	})
 }
 
-func (dao *GORMInteractiveDAO) InsertLikeInfo(ctx context.Context,
-	biz string, bizId, uid int64) error {
-	// 一把梭
-	// 同时记录点赞，以及更新点赞计数
-	// 首先你需要一张表来记录，谁点给什么资源点了赞
+// SetReaction 点赞是一种表态，一个用户对一个资源只能有一种表态，
+// 换一种表态的时候，旧的计数减一，新的计数加一，都在同一个事务里面
+func (dao *GORMInteractiveDAO) SetReaction(ctx context.Context,
+	biz string, bizId, uid int64, reaction string) (string, error) {
+	col, ok := reactionCntColumns[reaction]
+	if !ok {
+		return "", ErrUnknownReaction
+	}
 	now := time.Now().UnixMilli()
-	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 先准备插入点赞记录
-		// 有没有可能已经点赞过了？
-		// 我要不要校验一下，这里必须是没有点赞过
-		err := tx.Clauses(clause.OnConflict{
-			DoUpdates: clause.Assignments(map[string]any{
-				"utime":  now,
-				"statue": 1,
-			}),
-		}).Create(&UserLikeBiz{
-			Biz:    biz,
-			BizId:  bizId,
-			Uid:    uid,
-			Status: 1,
-			Ctime:  now,
-			Utime:  now,
-		}).Error
+	var old string
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 先保证记录存在，这样后面的 FOR UPDATE 一定能锁住这一行，
+		// 同一个用户并发表态的时候只会一个一个来
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&UserLikeBiz{
+				Biz:      biz,
+				BizId:    bizId,
+				Uid:      uid,
+				Reaction: reaction,
+				Ctime:    now,
+				Utime:    now,
+			}).Error
 		if err != nil {
 			return err
 		}
-
+		var ub UserLikeBiz
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("biz = ? AND biz_id = ? AND uid = ?", biz, bizId, uid).
+			First(&ub).Error
+		if err != nil {
+			return err
+		}
+		if ub.Status == 1 {
+			old = ub.Reaction
+		}
+		// 重复表态，计数不能加
+		if old == reaction {
+			return nil
+		}
+		err = tx.Model(&UserLikeBiz{}).Where("id = ?", ub.Id).
+			Updates(map[string]any{
+				"reaction": reaction,
+				"status":   1,
+				"utime":    now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		updates := map[string]any{
+			col:     gorm.Expr(fmt.Sprintf("`%s` + 1", col)),
+			"utime": now,
+		}
+		if oldCol, ok := reactionCntColumns[old]; ok {
+			updates[oldCol] = gorm.Expr(fmt.Sprintf("`%s` - 1", oldCol))
+		}
+		intr := Interactive{
+			Biz:   biz,
+			BizId: bizId,
+			Ctime: now,
+			Utime: now,
+		}
+		intr.setReactionCnt(reaction, 1)
 		return tx.Clauses(clause.OnConflict{
-			// MySQL 不写
-			//Columns:
-			DoUpdates: clause.Assignments(map[string]any{
-				"like_cnt": gorm.Expr("like_cnt + 1"),
-				"utime":    time.Now().UnixMilli(),
-			}),
-		}).Create(&Interactive{
-			Biz:     biz,
-			BizId:   bizId,
-			LikeCnt: 1,
-			Ctime:   now,
-			Utime:   now,
-		}).Error
+			DoUpdates: clause.Assignments(updates),
+		}).Create(&intr).Error
+	})
+	return old, err
+}
+
+func (dao *GORMInteractiveDAO) DeleteCollectionBiz(ctx context.Context,
+	biz string, bizId, uid int64) (bool, error) {
+	now := time.Now().UnixMilli()
+	var deleted bool
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Where("biz = ? AND biz_id = ? AND uid = ?", biz, bizId, uid).
+			Delete(&UserCollectionBiz{})
+		if res.Error != nil {
+			return res.Error
+		}
+		// 重复取消收藏，计数不能减
+		deleted = res.RowsAffected > 0
+		if !deleted {
+			return nil
+		}
+		return tx.Model(&Interactive{}).
+			Where("biz = ? AND biz_id = ?", biz, bizId).
+			Updates(map[string]any{
+				"collect_cnt": gorm.Expr("`collect_cnt` - 1"),
+				"utime":       now,
+			}).Error
 	})
+	return deleted, err
 }
 
-func (dao *GORMInteractiveDAO) DeleteLikeInfo(ctx context.Context, biz string, bizId, uid int64) error {
+func (dao *GORMInteractiveDAO) DeleteLikeInfo(ctx context.Context,
+	biz string, bizId, uid int64) (string, error) {
 	now := time.Now().UnixMilli()
+	var old string
 	// 控制事务超时
-	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 要知道取消的是哪一种表态，才知道减哪个计数
+		var ub UserLikeBiz
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("biz=? AND biz_id = ? AND uid = ? AND status = ?", biz, bizId, uid, 1).
+			First(&ub).Error
+		if err == ErrRecordNotFound {
+			// 没有表态过，或者重复取消，计数不能减
+			return nil
+		}
+		if err != nil {
+			return err
+		}
 		// 两个操作
 		// 一个是软删除点赞记录
 		// 一个是减点赞数量
-		err := tx.Model(&UserLikeBiz{}).
-			Where("biz=? AND biz_id = ? AND uid = ?", biz, bizId, uid).
+		err = tx.Model(&UserLikeBiz{}).Where("id = ?", ub.Id).
 			Updates(map[string]any{
 				"utime":  now,
 				"status": 0,
@@ -189,14 +413,142 @@ func (dao *GORMInteractiveDAO) DeleteLikeInfo(ctx context.Context, biz string, b
 		if err != nil {
 			return err
 		}
+		old = ub.Reaction
+		if _, ok := reactionCntColumns[old]; !ok {
+			// 老数据没有 reaction，都是点赞
+			old = "like"
+		}
+		col := reactionCntColumns[old]
 		return tx.Model(&Interactive{}).
 			// 这边命中了索引，然后没找到，所以不会加锁
 			Where("biz=? AND biz_id = ?", biz, bizId).
 			Updates(map[string]any{
-				"utime":    now,
-				"like_cnt": gorm.Expr("like_cnt-1"),
+				"utime": now,
+				col:     gorm.Expr(fmt.Sprintf("`%s` - 1", col)),
 			}).Error
 	})
+	return old, err
+}
+
+func (dao *GORMInteractiveDAO) ListLikesByUid(ctx context.Context,
+	uid int64, cursor TimeCursor, limit int) ([]UserLikeBiz, error) {
+	var res []UserLikeBiz
+	// 命中 uid_utime 索引，取消点赞的记录还在，其它表态也不算点赞，都要过滤掉
+	db := dao.db.WithContext(ctx).
+		Where("uid = ? AND status = ? AND reaction = ?", uid, 1, "like")
+	err := afterTimeCursor(db, "utime", cursor).
+		Order("utime DESC, id DESC").
+		Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+// afterTimeCursor 只保留按照 (col, id) 倒序排在游标之后的数据
+func afterTimeCursor(db *gorm.DB, col string, cursor TimeCursor) *gorm.DB {
+	if cursor == (TimeCursor{}) {
+		return db
+	}
+	return db.Where(col+" < ? OR ("+col+" = ? AND id < ?)",
+		cursor.Time, cursor.Time, cursor.Id)
+}
+
+func (dao *GORMInteractiveDAO) ReconcileCnt(ctx context.Context,
+	id int64, limit int) (domain.CntReconcileBatch, error) {
+	res := domain.CntReconcileBatch{LastId: id}
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 先锁住计数，并发的点赞和收藏要等这一批改完才能更新计数，
+		// 而已经提交的点赞和收藏在后面的 COUNT 里面都能看到，所以不会改错
+		var intrs []Interactive
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id > ?", id).
+			Order("id ASC").
+			Limit(limit).
+			Find(&intrs).Error
+		if err != nil || len(intrs) == 0 {
+			return err
+		}
+		res.Cnt = len(intrs)
+		res.LastId = intrs[len(intrs)-1].Id
+		keys := make([][]any, 0, len(intrs))
+		for _, intr := range intrs {
+			keys = append(keys, []any{intr.Biz, intr.BizId})
+		}
+		// 都能命中 biz 和 biz_id 在前的唯一索引
+		// 其它表态的计数不校对，只校对点赞数
+		likeCnts, err := countByBiz(tx.Model(&UserLikeBiz{}).
+			Where("status = ? AND reaction = ?", 1, "like"), keys)
+		if err != nil {
+			return err
+		}
+		collectCnts, err := countByBiz(tx.Model(&UserCollectionBiz{}), keys)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UnixMilli()
+		for _, intr := range intrs {
+			drift := domain.CntDrift{
+				Biz:           intr.Biz,
+				BizId:         intr.BizId,
+				LikeCnt:       intr.LikeCnt,
+				ActualLike:    likeCnts[bizKey{Biz: intr.Biz, BizId: intr.BizId}],
+				CollectCnt:    intr.CollectCnt,
+				ActualCollect: collectCnts[bizKey{Biz: intr.Biz, BizId: intr.BizId}],
+			}
+			if drift.LikeCnt == drift.ActualLike && drift.CollectCnt == drift.ActualCollect {
+				continue
+			}
+			err = tx.Model(&Interactive{}).Where("id = ?", intr.Id).
+				Updates(map[string]any{
+					"like_cnt":    drift.ActualLike,
+					"collect_cnt": drift.ActualCollect,
+					"utime":       now,
+				}).Error
+			if err != nil {
+				return err
+			}
+			res.Drifts = append(res.Drifts, drift)
+		}
+		return nil
+	})
+	return res, err
+}
+
+type bizKey struct {
+	Biz   string
+	BizId int64
+}
+
+// countByBiz 按照 (biz, biz_id) 分组计数，keys 里面每一个元素是 []any{biz, bizId}
+func countByBiz(db *gorm.DB, keys [][]any) (map[bizKey]int64, error) {
+	var rows []struct {
+		Biz   string
+		BizId int64
+		Cnt   int64
+	}
+	err := db.Select("biz, biz_id, COUNT(*) AS cnt").
+		Where("(biz, biz_id) IN ?", keys).
+		Group("biz, biz_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[bizKey]int64, len(rows))
+	for _, row := range rows {
+		res[bizKey{Biz: row.Biz, BizId: row.BizId}] = row.Cnt
+	}
+	return res, nil
+}
+
+func (dao *GORMInteractiveDAO) DeleteByBiz(ctx context.Context, biz string, bizId int64) error {
+	return dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, model := range []any{&Interactive{}, &UserLikeBiz{}, &UserCollectionBiz{}, &Comment{}} {
+			err := tx.Where("biz = ? AND biz_id = ?", biz, bizId).Delete(model).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func NewGORMInteractiveDAO(db *gorm.DB) InteractiveDAO {
@@ -253,6 +605,15 @@ func (dao *GORMInteractiveDAO) Get(ctx context.Context, biz string, bizId int64)
 	return res, err
 }
 
+func (dao *GORMInteractiveDAO) GetByIds(ctx context.Context,
+	biz string, bizIds []int64) ([]Interactive, error) {
+	var res []Interactive
+	err := dao.db.WithContext(ctx).
+		Where("biz = ? AND biz_id IN ?", biz, bizIds).
+		Find(&res).Error
+	return res, err
+}
+
 // Interactive 正常来说，一张主表和与它有关联关系的表会共用一个DAO，
 // 所以我们就用一个 DAO 来操作
 // 假如说我要查找点赞数量前 100 的，
@@ -284,11 +645,77 @@ type Interactive struct {
 	// 默认是 BLOB/TEXT 类型
 	Biz string `gorm:"uniqueIndex:biz_id_type;type:varchar(128)"`
 	// 这个是阅读计数
-	ReadCnt    int64
-	LikeCnt    int64
-	CollectCnt int64
-	Ctime      int64
-	Utime      int64
+	ReadCnt int64
+	// 每一种表态一个计数，LikeCnt 就是点赞数。加新的表态要加一列
+	LikeCnt       int64
+	LoveCnt       int64
+	LaughCnt      int64
+	InsightfulCnt int64
+	CollectCnt    int64
+	CommentCnt    int64
+	// 去重之后的读者数，Redis 里面的 HyperLogLog 定时汇总过来
+	Uv    int64
+	Ctime int64
+	Utime int64
+}
+
+func (i *Interactive) setReactionCnt(reaction string, cnt int64) {
+	switch reaction {
+	case "like":
+		i.LikeCnt = cnt
+	case "love":
+		i.LoveCnt = cnt
+	case "laugh":
+		i.LaughCnt = cnt
+	case "insightful":
+		i.InsightfulCnt = cnt
+	}
+}
+
+// UVCnt 一个资源某一天的 UV 和总的 UV
+type UVCnt struct {
+	Biz         string
+	BizId       int64
+	DayUV       int64
+	TotalUV     int64
+	TotalSketch []byte
+}
+
+// InteractiveDailyUV 按天汇总的 UV
+type InteractiveDailyUV struct {
+	Id    int64  `gorm:"primaryKey,autoIncrement"`
+	Biz   string `gorm:"type:varchar(128);uniqueIndex:biz_type_id_day"`
+	BizId int64  `gorm:"uniqueIndex:biz_type_id_day"`
+	// 20060102 这种格式
+	Day   string `gorm:"type:char(8);uniqueIndex:biz_type_id_day"`
+	Uv    int64
+	Ctime int64
+	Utime int64
+}
+
+// InteractiveUVSketch 总的 UV 的 HyperLogLog。
+// Redis 里面的过期了之后用它恢复，不然只能拿过期之后的读者和旧的 UV 比大小
+type InteractiveUVSketch struct {
+	Id     int64  `gorm:"primaryKey,autoIncrement"`
+	Biz    string `gorm:"type:varchar(128);uniqueIndex:biz_type_id"`
+	BizId  int64  `gorm:"uniqueIndex:biz_type_id"`
+	Sketch []byte `gorm:"type:BLOB"`
+	Ctime  int64
+	Utime  int64
+}
+
+// ReadCntDelta 一个资源要加上去的阅读数
+type ReadCntDelta struct {
+	Biz   string
+	BizId int64
+	Delta int64
+}
+
+// InteractiveFlushLog 合并阅读数的记录，保证同一批只合并一次
+type InteractiveFlushLog struct {
+	Id    int64  `gorm:"primaryKey,autoIncrement"`
+	Batch string `gorm:"type:varchar(128);uniqueIndex"`
+	Ctime int64  `gorm:"index"`
 }
 
 // InteractiveV1 对写更友好
@@ -323,17 +750,21 @@ type UserLikeBiz struct {
 	Biz   string `gorm:"uniqueIndex:uid_biz_id_type;type:varchar(128)"`
 	BizId int64  `gorm:"uniqueIndex:uid_biz_id_type"`
 
-	// 谁的操作
-	Uid int64 `gorm:"uniqueIndex:uid_biz_id_type"`
+	// 谁的操作。
+	// 用户看自己点赞过什么，按照点赞时间倒序，所以 uid_utime 是 Uid 在前
+	Uid int64 `gorm:"uniqueIndex:uid_biz_id_type;index:uid_utime,priority:1"`
 
 	Ctime int64
-	Utime int64
+	Utime int64 `gorm:"index:uid_utime,priority:2"`
 	// 如果这样设计，那么，取消点赞的时候，怎么办？
 	// 我删了这个数据
 	// 你就软删除
 	// 这个状态是存储状态，纯纯用于软删除的，业务层面上没有感知
 	// 0-代表删除，1 代表有效
 	Status uint8
+	// 表态的类型，一个用户对一个资源只有一种表态。
+	// 加这一列之前的记录都是点赞
+	Reaction string `gorm:"type:varchar(32);default:like"`
 
 	// 有效/无效
 	//Type string
@@ -342,8 +773,9 @@ type UserLikeBiz struct {
 // Collection 收藏夹
 type Collection struct {
 	Id   int64  `gorm:"primaryKey,autoIncrement"`
-	Name string `gorm:"type=varchar(1024)"`
-	Uid  int64  `gorm:""`
+	Name string `gorm:"type:varchar(1024)"`
+	// 查询用户的所有收藏夹
+	Uid int64 `gorm:"index"`
 
 	Ctime int64
 	Utime int64
@@ -353,33 +785,22 @@ type Collection struct {
 type UserCollectionBiz struct {
 	Id int64 `gorm:"primaryKey,autoIncrement"`
 	// 收藏夹 ID
-	// 作为关联关系中的外键，我们这里需要索引
-	Cid   int64  `gorm:"index"`
+	// 作为关联关系中的外键，我们这里需要索引。
+	// 默认收藏夹的 cid 都是 0，所以要和 uid 一起建索引
+	Cid   int64  `gorm:"index:uid_cid,priority:2"`
 	BizId int64  `gorm:"uniqueIndex:biz_type_id_uid"`
 	Biz   string `gorm:"type:varchar(128);uniqueIndex:biz_type_id_uid"`
 	// 这算是一个冗余，因为正常来说，
 	// 只需要在 Collection 中维持住 Uid 就可以
-	Uid   int64 `gorm:"uniqueIndex:biz_type_id_uid"`
-	Ctime int64
+	Uid int64 `gorm:"uniqueIndex:biz_type_id_uid;index:uid_cid,priority:1;index:uid_ctime,priority:1"`
+	// 不分收藏夹看用户收藏过的所有东西，按照收藏时间倒序
+	Ctime int64 `gorm:"index:uid_ctime,priority:2"`
 	Utime int64
 }
 
-// 假如说我有一个需求，需要查询到收藏夹的信息，和收藏夹里面的资源
-// SELECT c.id as cid , c.name as cname, uc.biz_id as biz_id, uc.biz as biz
-// FROM `collection` as c JOIN `user_collection_biz` as uc
-// ON c.id = uc.cid
-// WHERE c.id IN (1,2,3)
-
-type CollectionItem struct {
-	Cid   int64
-	Cname string
-	BizId int64
-	Biz   string
-}
-
-func (dao *GORMInteractiveDAO) GetItems() ([]CollectionItem, error) {
-	// 不记得构造 JOIN 查询
-	var items []CollectionItem
-	err := dao.db.Raw("", 1, 2, 3).Find(&items).Error
-	return items, err
+// TimeCursor 按照 (时间, id) 倒序翻页的游标，时间是毫秒数。
+// 零值代表第一页
+type TimeCursor struct {
+	Time int64
+	Id   int64
 }
@@ -3,21 +3,70 @@ package dao
 import (
 	"context"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"time"
 )
 
 type JobDAO interface {
 	Preempt(ctx context.Context, refreshInterval time.Duration) (Job, error)
-	Release(ctx context.Context, id int64) error
+	// Release 只释放自己还占着的任务，version 是抢占之后的版本
+	Release(ctx context.Context, id int64, version int) error
 	UpdateUtime(ctx context.Context, id int64) error
 	UpdateNextTime(ctx context.Context, id int64, next time.Time) error
 	Stop(ctx context.Context, id int64) error
+	// Upsert 按照 name 插入或者更新任务，更新的时候会把任务重新置为等待调度
+	Upsert(ctx context.Context, j Job) error
+	// InsertIfAbsent 同名的任务不存在的时候才插入，已经存在的任务保持原样
+	InsertIfAbsent(ctx context.Context, j Job) error
+	StopByName(ctx context.Context, name string) error
 }
 
 type GORMJobDAO struct {
 	db *gorm.DB
 }
 
+func NewGORMJobDAO(db *gorm.DB) JobDAO {
+	return &GORMJobDAO{db: db}
+}
+
+func (g *GORMJobDAO) Upsert(ctx context.Context, j Job) error {
+	now := time.Now().UnixMilli()
+	j.Status = jobStatusWaiting
+	j.Ctime = now
+	j.Utime = now
+	return g.db.WithContext(ctx).Clauses(clause.OnConflict{
+		// name 上有唯一索引
+		Columns: []clause.Column{{Name: "name"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"cfg":       j.Cfg,
+			"executor":  j.Executor,
+			"cron":      j.Cron,
+			"next_time": j.NextTime,
+			"status":    jobStatusWaiting,
+			"utime":     now,
+		}),
+	}).Create(&j).Error
+}
+
+func (g *GORMJobDAO) InsertIfAbsent(ctx context.Context, j Job) error {
+	now := time.Now().UnixMilli()
+	j.Status = jobStatusWaiting
+	j.Ctime = now
+	j.Utime = now
+	return g.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoNothing: true,
+	}).Create(&j).Error
+}
+
+func (g *GORMJobDAO) StopByName(ctx context.Context, name string) error {
+	return g.db.WithContext(ctx).Model(&Job{}).
+		Where("name = ?", name).Updates(map[string]any{
+		"status": jobStatusPaused,
+		"utime":  time.Now().UnixMilli(),
+	}).Error
+}
+
 func (g *GORMJobDAO) UpdateUtime(ctx context.Context, id int64) error {
 	return g.db.WithContext(ctx).Model(&Job{}).
 		Where("id =?", id).Updates(map[string]any{
@@ -33,18 +82,18 @@ func (g *GORMJobDAO) UpdateNextTime(ctx context.Context, id int64, next time.Tim
 }
 
 func (g *GORMJobDAO) Stop(ctx context.Context, id int64) error {
-	return g.db.WithContext(ctx).
+	return g.db.WithContext(ctx).Model(&Job{}).
 		Where("id = ?", id).Updates(map[string]any{
 		"status": jobStatusPaused,
 		"utime":  time.Now().UnixMilli(),
 	}).Error
 }
 
-func (g *GORMJobDAO) Release(ctx context.Context, id int64) error {
-	// 这里有一个问题。你要不要检测 status 或者 version?
-	// WHERE version = ?
-	// 要。你们的作业记得修改
-	return g.db.WithContext(ctx).Model(&Job{}).Where("id =?", id).
+func (g *GORMJobDAO) Release(ctx context.Context, id int64, version int) error {
+	// 要检测 status 和 version。执行完之后任务可能已经被停掉了，比如说只执行一次的任务，
+	// 或者执行期间被取消了；续约失败的话也可能已经被别人抢走了。这些情况都不能改回等待调度
+	return g.db.WithContext(ctx).Model(&Job{}).
+		Where("id = ? AND status = ? AND version = ?", id, jobStatusRunning, version).
 		Updates(map[string]any{
 			"status": jobStatusWaiting,
 			"utime":  time.Now().UnixMilli(),
@@ -65,7 +114,7 @@ func (g *GORMJobDAO) Preempt(ctx context.Context, refreshInterval time.Duration)
 		// 1. 一次拉一批，我一次性取出 100 条来，然后，我随机从某一条开始，向后开始抢占
 		// 2. 我搞个随机偏移量，0-100 生成一个随机偏移量。兜底：第一轮没查到，偏移量回归到 0
 		// 3. 我搞一个 id 取余分配，status = ? AND next_time <=? AND id%10 = ? 兜底：不加余数条件，取next_time 最老的
-		err := db.WithContext(ctx).Where("status = ? AND next_time <=?", jobStatusWaiting, now).
+		err := db.WithContext(ctx).Where("status = ? AND next_time <=?", jobStatusWaiting, now.UnixMilli()).
 			First(&j).Error
 		// 你找到了，可以被抢占的
 		// 找到之后你要干嘛？你要抢占
@@ -86,16 +135,19 @@ func (g *GORMJobDAO) Preempt(ctx context.Context, refreshInterval time.Duration)
 			j.Id, j.Version).Model(&Job{}).
 			Updates(map[string]any{
 				"status":  jobStatusRunning,
-				"utime":   now,
+				"utime":   now.UnixMilli(),
 				"version": j.Version + 1,
 			})
 		if res.Error != nil {
-			return Job{}, err
+			return Job{}, res.Error
 		}
 		if res.RowsAffected == 0 {
 			// 抢占失败，你只能说，我要继续下一轮
 			continue
 		}
+		// 释放的时候要用抢占之后的版本
+		j.Version++
+		j.Status = jobStatusRunning
 		return j, nil
 	}
 }
@@ -0,0 +1,32 @@
+package dao
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gormMysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestGORMJobDAO_Release(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	// 只有还在运行、而且版本没变的时候才改回等待调度，
+	// 执行完被停掉了的任务（比如说只执行一次的任务）更新不到
+	mock.ExpectExec("UPDATE `jobs` SET `status`=\\?,`utime`=\\? WHERE id = \\? AND status = \\? AND version = \\?").
+		WithArgs(jobStatusWaiting, sqlmock.AnyArg(), int64(1), jobStatusRunning, 3).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	db, err := gorm.Open(gormMysql.New(gormMysql.Config{
+		Conn:                      mockDB,
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	require.NoError(t, err)
+	err = NewGORMJobDAO(db).Release(context.Background(), 1, 3)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,145 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: follow.go
+//
+// Generated by this command:
+//
+//	mockgen -source=follow.go -package=daomocks -destination=mocks/follow.mock.go
+//
+// Package daomocks is a generated GoMock package.
+package daomocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	dao "github.com/gevinzone/basic-go/week9/webook/internal/repository/dao"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFollowDAO is a mock of FollowDAO interface.
+type MockFollowDAO struct {
+	ctrl     *gomock.Controller
+	recorder *MockFollowDAOMockRecorder
+}
+
+// MockFollowDAOMockRecorder is the mock recorder for MockFollowDAO.
+type MockFollowDAOMockRecorder struct {
+	mock *MockFollowDAO
+}
+
+// NewMockFollowDAO creates a new mock instance.
+func NewMockFollowDAO(ctrl *gomock.Controller) *MockFollowDAO {
+	mock := &MockFollowDAO{ctrl: ctrl}
+	mock.recorder = &MockFollowDAOMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFollowDAO) EXPECT() *MockFollowDAOMockRecorder {
+	return m.recorder
+}
+
+// FindFollowees mocks base method.
+func (m *MockFollowDAO) FindFollowees(ctx context.Context, follower int64, uids []int64) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindFollowees", ctx, follower, uids)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindFollowees indicates an expected call of FindFollowees.
+func (mr *MockFollowDAOMockRecorder) FindFollowees(ctx, follower, uids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindFollowees", reflect.TypeOf((*MockFollowDAO)(nil).FindFollowees), ctx, follower, uids)
+}
+
+// FindFollowers mocks base method.
+func (m *MockFollowDAO) FindFollowers(ctx context.Context, followee int64, uids []int64) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindFollowers", ctx, followee, uids)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindFollowers indicates an expected call of FindFollowers.
+func (mr *MockFollowDAOMockRecorder) FindFollowers(ctx, followee, uids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindFollowers", reflect.TypeOf((*MockFollowDAO)(nil).FindFollowers), ctx, followee, uids)
+}
+
+// Follow mocks base method.
+func (m *MockFollowDAO) Follow(ctx context.Context, follower, followee int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Follow", ctx, follower, followee)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Follow indicates an expected call of Follow.
+func (mr *MockFollowDAOMockRecorder) Follow(ctx, follower, followee any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Follow", reflect.TypeOf((*MockFollowDAO)(nil).Follow), ctx, follower, followee)
+}
+
+// GetStatics mocks base method.
+func (m *MockFollowDAO) GetStatics(ctx context.Context, uid int64) (dao.FollowStatics, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatics", ctx, uid)
+	ret0, _ := ret[0].(dao.FollowStatics)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatics indicates an expected call of GetStatics.
+func (mr *MockFollowDAOMockRecorder) GetStatics(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatics", reflect.TypeOf((*MockFollowDAO)(nil).GetStatics), ctx, uid)
+}
+
+// ListFollowees mocks base method.
+func (m *MockFollowDAO) ListFollowees(ctx context.Context, follower int64, cursor dao.TimeCursor, limit int) ([]dao.FollowRelation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFollowees", ctx, follower, cursor, limit)
+	ret0, _ := ret[0].([]dao.FollowRelation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFollowees indicates an expected call of ListFollowees.
+func (mr *MockFollowDAOMockRecorder) ListFollowees(ctx, follower, cursor, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFollowees", reflect.TypeOf((*MockFollowDAO)(nil).ListFollowees), ctx, follower, cursor, limit)
+}
+
+// ListFollowers mocks base method.
+func (m *MockFollowDAO) ListFollowers(ctx context.Context, followee int64, cursor dao.TimeCursor, limit int) ([]dao.FollowRelation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFollowers", ctx, followee, cursor, limit)
+	ret0, _ := ret[0].([]dao.FollowRelation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFollowers indicates an expected call of ListFollowers.
+func (mr *MockFollowDAOMockRecorder) ListFollowers(ctx, followee, cursor, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFollowers", reflect.TypeOf((*MockFollowDAO)(nil).ListFollowers), ctx, followee, cursor, limit)
+}
+
+// Unfollow mocks base method.
+func (m *MockFollowDAO) Unfollow(ctx context.Context, follower, followee int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unfollow", ctx, follower, followee)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Unfollow indicates an expected call of Unfollow.
+func (mr *MockFollowDAOMockRecorder) Unfollow(ctx, follower, followee any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unfollow", reflect.TypeOf((*MockFollowDAO)(nil).Unfollow), ctx, follower, followee)
+}
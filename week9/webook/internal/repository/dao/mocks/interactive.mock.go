@@ -0,0 +1,289 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interactive.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interactive.go -package=daomocks -destination=mocks/interactive.mock.go
+//
+// Package daomocks is a generated GoMock package.
+package daomocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	dao "github.com/gevinzone/basic-go/week9/webook/internal/repository/dao"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockInteractiveDAO is a mock of InteractiveDAO interface.
+type MockInteractiveDAO struct {
+	ctrl     *gomock.Controller
+	recorder *MockInteractiveDAOMockRecorder
+}
+
+// MockInteractiveDAOMockRecorder is the mock recorder for MockInteractiveDAO.
+type MockInteractiveDAOMockRecorder struct {
+	mock *MockInteractiveDAO
+}
+
+// NewMockInteractiveDAO creates a new mock instance.
+func NewMockInteractiveDAO(ctrl *gomock.Controller) *MockInteractiveDAO {
+	mock := &MockInteractiveDAO{ctrl: ctrl}
+	mock.recorder = &MockInteractiveDAOMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInteractiveDAO) EXPECT() *MockInteractiveDAOMockRecorder {
+	return m.recorder
+}
+
+// BatchIncrReadCnt mocks base method.
+func (m *MockInteractiveDAO) BatchIncrReadCnt(ctx context.Context, bizs []string, ids []int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchIncrReadCnt", ctx, bizs, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BatchIncrReadCnt indicates an expected call of BatchIncrReadCnt.
+func (mr *MockInteractiveDAOMockRecorder) BatchIncrReadCnt(ctx, bizs, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchIncrReadCnt", reflect.TypeOf((*MockInteractiveDAO)(nil).BatchIncrReadCnt), ctx, bizs, ids)
+}
+
+// DeleteByBiz mocks base method.
+func (m *MockInteractiveDAO) DeleteByBiz(ctx context.Context, biz string, bizId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByBiz", ctx, biz, bizId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByBiz indicates an expected call of DeleteByBiz.
+func (mr *MockInteractiveDAOMockRecorder) DeleteByBiz(ctx, biz, bizId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByBiz", reflect.TypeOf((*MockInteractiveDAO)(nil).DeleteByBiz), ctx, biz, bizId)
+}
+
+// DeleteCollectionBiz mocks base method.
+func (m *MockInteractiveDAO) DeleteCollectionBiz(ctx context.Context, biz string, bizId, uid int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCollectionBiz", ctx, biz, bizId, uid)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteCollectionBiz indicates an expected call of DeleteCollectionBiz.
+func (mr *MockInteractiveDAOMockRecorder) DeleteCollectionBiz(ctx, biz, bizId, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCollectionBiz", reflect.TypeOf((*MockInteractiveDAO)(nil).DeleteCollectionBiz), ctx, biz, bizId, uid)
+}
+
+// DeleteFlushLogsBefore mocks base method.
+func (m *MockInteractiveDAO) DeleteFlushLogsBefore(ctx context.Context, ctime int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteFlushLogsBefore", ctx, ctime)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteFlushLogsBefore indicates an expected call of DeleteFlushLogsBefore.
+func (mr *MockInteractiveDAOMockRecorder) DeleteFlushLogsBefore(ctx, ctime any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteFlushLogsBefore", reflect.TypeOf((*MockInteractiveDAO)(nil).DeleteFlushLogsBefore), ctx, ctime)
+}
+
+// DeleteLikeInfo mocks base method.
+func (m *MockInteractiveDAO) DeleteLikeInfo(ctx context.Context, biz string, bizId, uid int64) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLikeInfo", ctx, biz, bizId, uid)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteLikeInfo indicates an expected call of DeleteLikeInfo.
+func (mr *MockInteractiveDAOMockRecorder) DeleteLikeInfo(ctx, biz, bizId, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLikeInfo", reflect.TypeOf((*MockInteractiveDAO)(nil).DeleteLikeInfo), ctx, biz, bizId, uid)
+}
+
+// Get mocks base method.
+func (m *MockInteractiveDAO) Get(ctx context.Context, biz string, bizId int64) (dao.Interactive, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, biz, bizId)
+	ret0, _ := ret[0].(dao.Interactive)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockInteractiveDAOMockRecorder) Get(ctx, biz, bizId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockInteractiveDAO)(nil).Get), ctx, biz, bizId)
+}
+
+// GetByIds mocks base method.
+func (m *MockInteractiveDAO) GetByIds(ctx context.Context, biz string, bizIds []int64) ([]dao.Interactive, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIds", ctx, biz, bizIds)
+	ret0, _ := ret[0].([]dao.Interactive)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIds indicates an expected call of GetByIds.
+func (mr *MockInteractiveDAOMockRecorder) GetByIds(ctx, biz, bizIds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIds", reflect.TypeOf((*MockInteractiveDAO)(nil).GetByIds), ctx, biz, bizIds)
+}
+
+// GetCollectionInfo mocks base method.
+func (m *MockInteractiveDAO) GetCollectionInfo(ctx context.Context, biz string, bizId, uid int64) (dao.UserCollectionBiz, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCollectionInfo", ctx, biz, bizId, uid)
+	ret0, _ := ret[0].(dao.UserCollectionBiz)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCollectionInfo indicates an expected call of GetCollectionInfo.
+func (mr *MockInteractiveDAOMockRecorder) GetCollectionInfo(ctx, biz, bizId, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCollectionInfo", reflect.TypeOf((*MockInteractiveDAO)(nil).GetCollectionInfo), ctx, biz, bizId, uid)
+}
+
+// GetLikeInfo mocks base method.
+func (m *MockInteractiveDAO) GetLikeInfo(ctx context.Context, biz string, bizId, uid int64) (dao.UserLikeBiz, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLikeInfo", ctx, biz, bizId, uid)
+	ret0, _ := ret[0].(dao.UserLikeBiz)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLikeInfo indicates an expected call of GetLikeInfo.
+func (mr *MockInteractiveDAOMockRecorder) GetLikeInfo(ctx, biz, bizId, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLikeInfo", reflect.TypeOf((*MockInteractiveDAO)(nil).GetLikeInfo), ctx, biz, bizId, uid)
+}
+
+// GetUVSketches mocks base method.
+func (m *MockInteractiveDAO) GetUVSketches(ctx context.Context, bizs []string, bizIds []int64) ([]dao.InteractiveUVSketch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUVSketches", ctx, bizs, bizIds)
+	ret0, _ := ret[0].([]dao.InteractiveUVSketch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUVSketches indicates an expected call of GetUVSketches.
+func (mr *MockInteractiveDAOMockRecorder) GetUVSketches(ctx, bizs, bizIds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUVSketches", reflect.TypeOf((*MockInteractiveDAO)(nil).GetUVSketches), ctx, bizs, bizIds)
+}
+
+// IncrReadCnt mocks base method.
+func (m *MockInteractiveDAO) IncrReadCnt(ctx context.Context, biz string, bizId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrReadCnt", ctx, biz, bizId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrReadCnt indicates an expected call of IncrReadCnt.
+func (mr *MockInteractiveDAOMockRecorder) IncrReadCnt(ctx, biz, bizId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrReadCnt", reflect.TypeOf((*MockInteractiveDAO)(nil).IncrReadCnt), ctx, biz, bizId)
+}
+
+// InsertCollectionBiz mocks base method.
+func (m *MockInteractiveDAO) InsertCollectionBiz(ctx context.Context, cb dao.UserCollectionBiz) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertCollectionBiz", ctx, cb)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertCollectionBiz indicates an expected call of InsertCollectionBiz.
+func (mr *MockInteractiveDAOMockRecorder) InsertCollectionBiz(ctx, cb any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertCollectionBiz", reflect.TypeOf((*MockInteractiveDAO)(nil).InsertCollectionBiz), ctx, cb)
+}
+
+// ListLikesByUid mocks base method.
+func (m *MockInteractiveDAO) ListLikesByUid(ctx context.Context, uid int64, cursor dao.TimeCursor, limit int) ([]dao.UserLikeBiz, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLikesByUid", ctx, uid, cursor, limit)
+	ret0, _ := ret[0].([]dao.UserLikeBiz)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLikesByUid indicates an expected call of ListLikesByUid.
+func (mr *MockInteractiveDAOMockRecorder) ListLikesByUid(ctx, uid, cursor, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLikesByUid", reflect.TypeOf((*MockInteractiveDAO)(nil).ListLikesByUid), ctx, uid, cursor, limit)
+}
+
+// MergeReadCnt mocks base method.
+func (m *MockInteractiveDAO) MergeReadCnt(ctx context.Context, batch string, deltas []dao.ReadCntDelta) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeReadCnt", ctx, batch, deltas)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MergeReadCnt indicates an expected call of MergeReadCnt.
+func (mr *MockInteractiveDAOMockRecorder) MergeReadCnt(ctx, batch, deltas any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeReadCnt", reflect.TypeOf((*MockInteractiveDAO)(nil).MergeReadCnt), ctx, batch, deltas)
+}
+
+// ReconcileCnt mocks base method.
+func (m *MockInteractiveDAO) ReconcileCnt(ctx context.Context, id int64, limit int) (domain.CntReconcileBatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileCnt", ctx, id, limit)
+	ret0, _ := ret[0].(domain.CntReconcileBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReconcileCnt indicates an expected call of ReconcileCnt.
+func (mr *MockInteractiveDAOMockRecorder) ReconcileCnt(ctx, id, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileCnt", reflect.TypeOf((*MockInteractiveDAO)(nil).ReconcileCnt), ctx, id, limit)
+}
+
+// SaveUV mocks base method.
+func (m *MockInteractiveDAO) SaveUV(ctx context.Context, day string, uvs []dao.UVCnt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveUV", ctx, day, uvs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveUV indicates an expected call of SaveUV.
+func (mr *MockInteractiveDAOMockRecorder) SaveUV(ctx, day, uvs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveUV", reflect.TypeOf((*MockInteractiveDAO)(nil).SaveUV), ctx, day, uvs)
+}
+
+// SetReaction mocks base method.
+func (m *MockInteractiveDAO) SetReaction(ctx context.Context, biz string, bizId, uid int64, reaction string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReaction", ctx, biz, bizId, uid, reaction)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetReaction indicates an expected call of SetReaction.
+func (mr *MockInteractiveDAOMockRecorder) SetReaction(ctx, biz, bizId, uid, reaction any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReaction", reflect.TypeOf((*MockInteractiveDAO)(nil).SetReaction), ctx, biz, bizId, uid, reaction)
+}
@@ -0,0 +1,99 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: notification.go
+//
+// Generated by this command:
+//
+//	mockgen -source=notification.go -package=daomocks -destination=mocks/notification.mock.go
+//
+// Package daomocks is a generated GoMock package.
+package daomocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	dao "github.com/gevinzone/basic-go/week9/webook/internal/repository/dao"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockNotificationDAO is a mock of NotificationDAO interface.
+type MockNotificationDAO struct {
+	ctrl     *gomock.Controller
+	recorder *MockNotificationDAOMockRecorder
+}
+
+// MockNotificationDAOMockRecorder is the mock recorder for MockNotificationDAO.
+type MockNotificationDAOMockRecorder struct {
+	mock *MockNotificationDAO
+}
+
+// NewMockNotificationDAO creates a new mock instance.
+func NewMockNotificationDAO(ctrl *gomock.Controller) *MockNotificationDAO {
+	mock := &MockNotificationDAO{ctrl: ctrl}
+	mock.recorder = &MockNotificationDAOMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockNotificationDAO) EXPECT() *MockNotificationDAOMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockNotificationDAO) Add(ctx context.Context, n dao.Notification, actor, utime int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", ctx, n, actor, utime)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockNotificationDAOMockRecorder) Add(ctx, n, actor, utime any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockNotificationDAO)(nil).Add), ctx, n, actor, utime)
+}
+
+// List mocks base method.
+func (m *MockNotificationDAO) List(ctx context.Context, uid int64, cursor dao.TimeCursor, limit int) ([]dao.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, uid, cursor, limit)
+	ret0, _ := ret[0].([]dao.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockNotificationDAOMockRecorder) List(ctx, uid, cursor, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockNotificationDAO)(nil).List), ctx, uid, cursor, limit)
+}
+
+// MarkRead mocks base method.
+func (m *MockNotificationDAO) MarkRead(ctx context.Context, uid int64, ids []int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", ctx, uid, ids)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRead indicates an expected call of MarkRead.
+func (mr *MockNotificationDAOMockRecorder) MarkRead(ctx, uid, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockNotificationDAO)(nil).MarkRead), ctx, uid, ids)
+}
+
+// UnreadCnt mocks base method.
+func (m *MockNotificationDAO) UnreadCnt(ctx context.Context, uid int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnreadCnt", ctx, uid)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnreadCnt indicates an expected call of UnreadCnt.
+func (mr *MockNotificationDAOMockRecorder) UnreadCnt(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnreadCnt", reflect.TypeOf((*MockNotificationDAO)(nil).UnreadCnt), ctx, uid)
+}
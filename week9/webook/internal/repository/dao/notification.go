@@ -0,0 +1,210 @@
+package dao
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+// 一条通知里面最多记住最近的几个人，前端展示 "X、Y 等 13 人"
+const maxNotificationActors = 3
+
+// Notification 的 Status
+const (
+	NotificationUnread uint8 = iota
+	NotificationRead
+)
+
+//go:generate mockgen -source=./notification.go -package=daomocks -destination=mocks/notification.mock.go NotificationDAO
+type NotificationDAO interface {
+	// Add 有同类的未读通知就合并进去，没有就新建一条，新建了的时候返回 true。
+	// n 里面只需要 Uid、Type、Biz、BizId，utime 是这一次互动的时间
+	Add(ctx context.Context, n Notification, actor int64, utime int64) (bool, error)
+	// List uid 收到的通知，按照最后一次互动的时间倒序
+	List(ctx context.Context, uid int64, cursor TimeCursor, limit int) ([]Notification, error)
+	// MarkRead ids 为空的时候把 uid 的通知全部标记成已读
+	MarkRead(ctx context.Context, uid int64, ids []int64) error
+	UnreadCnt(ctx context.Context, uid int64) (int64, error)
+}
+
+type GORMNotificationDAO struct {
+	db *gorm.DB
+}
+
+func NewGORMNotificationDAO(db *gorm.DB) NotificationDAO {
+	return &GORMNotificationDAO{db: db}
+}
+
+func (dao *GORMNotificationDAO) Add(ctx context.Context,
+	n Notification, actor int64, utime int64) (bool, error) {
+	created, err := dao.add(ctx, n, actor, utime)
+	if isUniqueConflict(err) {
+		// 并发的消费者同时新建了同一条未读通知，另外一条已经提交了，再来一次就会合并进去
+		created, err = dao.add(ctx, n, actor, utime)
+	}
+	return created, err
+}
+
+func (dao *GORMNotificationDAO) add(ctx context.Context,
+	n Notification, actor int64, utime int64) (bool, error) {
+	created := false
+	err := dao.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var old Notification
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("uid = ? AND status = ? AND type = ? AND biz = ? AND biz_id = ?",
+				n.Uid, NotificationUnread, n.Type, n.Biz, n.BizId).
+			First(&old).Error
+		switch err {
+		case nil:
+			return dao.merge(tx, old, actor, utime)
+		case gorm.ErrRecordNotFound:
+			actors, err := json.Marshal([]int64{actor})
+			if err != nil {
+				return err
+			}
+			unread := true
+			n.Id = 0
+			n.Status = NotificationUnread
+			n.Unread = &unread
+			n.Actors = string(actors)
+			n.ActorCnt = 1
+			n.Ctime = time.Now().UnixMilli()
+			n.Utime = utime
+			// 同一条未读通知只能有一条，并发新建的时候这里会冲突
+			err = tx.Create(&n).Error
+			if err != nil {
+				return err
+			}
+			created = true
+			return tx.Create(&NotificationActor{
+				NotificationId: n.Id,
+				Actor:          actor,
+				Ctime:          n.Ctime,
+			}).Error
+		default:
+			return err
+		}
+	})
+	return created, err
+}
+
+// merge 把 actor 放到最前面。互动过的人记在 NotificationActor 里面，
+// 已经互动过的不重复计数，比如说取消点赞之后又点了一次，或者同一个事件重复投递
+func (dao *GORMNotificationDAO) merge(tx *gorm.DB,
+	old Notification, actor int64, utime int64) error {
+	var actors []int64
+	err := json.Unmarshal([]byte(old.Actors), &actors)
+	if err != nil {
+		return err
+	}
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&NotificationActor{
+			NotificationId: old.Id,
+			Actor:          actor,
+			Ctime:          time.Now().UnixMilli(),
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	cnt := old.ActorCnt
+	if res.RowsAffected > 0 {
+		cnt++
+	}
+	recent := make([]int64, 0, maxNotificationActors)
+	recent = append(recent, actor)
+	for _, a := range actors {
+		if a != actor && len(recent) < maxNotificationActors {
+			recent = append(recent, a)
+		}
+	}
+	data, err := json.Marshal(recent)
+	if err != nil {
+		return err
+	}
+	return tx.Model(&Notification{}).Where("id = ?", old.Id).
+		Updates(map[string]any{
+			"actors":    string(data),
+			"actor_cnt": cnt,
+			"utime":     utime,
+		}).Error
+}
+
+// isUniqueConflict 违反了唯一索引
+func isUniqueConflict(err error) bool {
+	const uniqueConflictsErrNo uint16 = 1062
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == uniqueConflictsErrNo
+}
+
+func (dao *GORMNotificationDAO) List(ctx context.Context,
+	uid int64, cursor TimeCursor, limit int) ([]Notification, error) {
+	var res []Notification
+	// 命中 uid_utime 索引
+	db := dao.db.WithContext(ctx).Where("uid = ?", uid)
+	err := afterTimeCursor(db, "utime", cursor).
+		Order("utime DESC, id DESC").
+		Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (dao *GORMNotificationDAO) MarkRead(ctx context.Context, uid int64, ids []int64) error {
+	db := dao.db.WithContext(ctx).Model(&Notification{}).
+		Where("uid = ? AND status = ?", uid, NotificationUnread)
+	if len(ids) > 0 {
+		db = db.Where("id IN ?", ids)
+	}
+	// 已读不改 utime，不然列表的顺序就乱了
+	return db.Updates(map[string]any{
+		"status": NotificationRead,
+		"unread": nil,
+	}).Error
+}
+
+func (dao *GORMNotificationDAO) UnreadCnt(ctx context.Context, uid int64) (int64, error) {
+	var res int64
+	err := dao.db.WithContext(ctx).Model(&Notification{}).
+		Where("uid = ? AND status = ?", uid, NotificationUnread).
+		Count(&res).Error
+	return res, err
+}
+
+// Notification 收到的通知。没读之前，同一个资源上同一种互动都合并到一条里面，
+// 读了之后再有新的互动就新建一条
+type Notification struct {
+	Id int64 `gorm:"primaryKey,autoIncrement"`
+	// 找未读的同类通知和未读数用 uid_status_type_biz，列表用 uid_utime
+	Uid    int64  `gorm:"index:uid_status_type_biz,priority:1;index:uid_utime,priority:1;uniqueIndex:uid_type_biz_unread,priority:1"`
+	Status uint8  `gorm:"index:uid_status_type_biz,priority:2"`
+	Type   string `gorm:"type:varchar(32);index:uid_status_type_biz,priority:3;uniqueIndex:uid_type_biz_unread,priority:2"`
+	Biz    string `gorm:"type:varchar(128);index:uid_status_type_biz,priority:4;uniqueIndex:uid_type_biz_unread,priority:3"`
+	BizId  int64  `gorm:"index:uid_status_type_biz,priority:5;uniqueIndex:uid_type_biz_unread,priority:4"`
+	// Unread 未读的时候是 true，已读了之后是 NULL。
+	// 唯一索引里面 NULL 不算重复，所以同一个资源上同一种互动只会有一条未读的
+	Unread *bool `gorm:"uniqueIndex:uid_type_biz_unread,priority:5"`
+	// Actors 最近的几个人，JSON 数组，最新的在前面
+	Actors   string `gorm:"type:varchar(256)"`
+	ActorCnt int64
+	Ctime    int64
+	// 最后一次互动的时间
+	Utime int64 `gorm:"index:uid_utime,priority:2"`
+}
+
+// NotificationActor 一条通知上面互动过的人，用来准确地去重计数
+type NotificationActor struct {
+	Id             int64 `gorm:"primaryKey,autoIncrement"`
+	NotificationId int64 `gorm:"uniqueIndex:notification_actor"`
+	Actor          int64 `gorm:"uniqueIndex:notification_actor"`
+	Ctime          int64
+}
+
+// ActorIds 解析不了的时候当作没有
+func (n Notification) ActorIds() []int64 {
+	var res []int64
+	_ = json.Unmarshal([]byte(n.Actors), &res)
+	return res
+}
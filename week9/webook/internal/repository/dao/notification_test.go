@@ -0,0 +1,126 @@
+package dao
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gormMysql "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestGORMNotificationDAO_Add(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(t *testing.T) *sql.DB
+
+		actor int64
+
+		wantCreated bool
+		wantErr     error
+	}{
+		{
+			name: "新建",
+			mock: func(t *testing.T) *sql.DB {
+				mockDB, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM `notifications` .* FOR UPDATE").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec("INSERT INTO `notifications` .*").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO `notification_actors` .*").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
+				return mockDB
+			},
+			actor:       2,
+			wantCreated: true,
+		},
+		{
+			name: "合并一个新的人",
+			mock: func(t *testing.T) *sql.DB {
+				mockDB, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM `notifications` .* FOR UPDATE").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "actors", "actor_cnt"}).
+						AddRow(1, "[3,4,5]", 10))
+				mock.ExpectExec("INSERT INTO `notification_actors` .*").
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectExec("UPDATE `notifications` SET").
+					WithArgs(int64(11), "[2,3,4]", int64(100), int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				return mockDB
+			},
+			actor: 2,
+		},
+		{
+			name: "早就互动过了，不在最近几个人里面也不重复计数",
+			mock: func(t *testing.T) *sql.DB {
+				mockDB, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM `notifications` .* FOR UPDATE").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "actors", "actor_cnt"}).
+						AddRow(1, "[3,4,5]", 10))
+				// 唯一索引冲突，什么都没插入
+				mock.ExpectExec("INSERT INTO `notification_actors` .*").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectExec("UPDATE `notifications` SET").
+					WithArgs(int64(10), "[2,3,4]", int64(100), int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				return mockDB
+			},
+			actor: 2,
+		},
+		{
+			name: "并发新建冲突，重来一次合并进去",
+			mock: func(t *testing.T) *sql.DB {
+				mockDB, mock, err := sqlmock.New()
+				require.NoError(t, err)
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM `notifications` .* FOR UPDATE").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectExec("INSERT INTO `notifications` .*").
+					WillReturnError(&mysql.MySQLError{Number: 1062})
+				mock.ExpectRollback()
+				mock.ExpectBegin()
+				mock.ExpectQuery("SELECT \\* FROM `notifications` .* FOR UPDATE").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "actors", "actor_cnt"}).
+						AddRow(1, "[3]", 1))
+				mock.ExpectExec("INSERT INTO `notification_actors` .*").
+					WillReturnResult(sqlmock.NewResult(2, 1))
+				mock.ExpectExec("UPDATE `notifications` SET").
+					WithArgs(int64(2), "[2,3]", int64(100), int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				return mockDB
+			},
+			actor: 2,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, err := gorm.Open(gormMysql.New(gormMysql.Config{
+				Conn:                      tc.mock(t),
+				SkipInitializeWithVersion: true,
+			}), &gorm.Config{
+				DisableAutomaticPing:   true,
+				SkipDefaultTransaction: true,
+			})
+			require.NoError(t, err)
+			d := NewGORMNotificationDAO(db)
+			created, err := d.Add(context.Background(), Notification{
+				Uid: 1, Type: "like", Biz: "article", BizId: 1,
+			}, tc.actor, 100)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantCreated, created)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
+)
+
+//go:generate mockgen -source=./feed.go -package=repomocks -destination=mocks/feed.mock.go FeedRepository
+type FeedRepository interface {
+	// Push 推到 uids 每个人的收件箱
+	Push(ctx context.Context, uids []int64, item domain.FeedItem) error
+	// Remove 从 uids 每个人的收件箱里面删掉
+	Remove(ctx context.Context, uids []int64, aid int64) error
+	ListInbox(ctx context.Context, uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FeedItem, error)
+	AddToOutbox(ctx context.Context, uid int64, item domain.FeedItem) error
+	RemoveFromOutbox(ctx context.Context, uid int64, aid int64) error
+	ListOutbox(ctx context.Context, uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FeedItem, error)
+	// MarkBigAuthor 粉丝太多的作者不推，读的时候去拉
+	MarkBigAuthor(ctx context.Context, uid int64) error
+	BigAuthors(ctx context.Context) ([]int64, error)
+}
+
+// CachedFeedRepository feed 流只放在 Redis 里面，
+// 丢了也没关系，帖子本身还在，只是关注的人的新帖子要等下次发表才会出现
+type CachedFeedRepository struct {
+	cache cache.FeedCache
+}
+
+func NewCachedFeedRepository(cache cache.FeedCache) FeedRepository {
+	return &CachedFeedRepository{cache: cache}
+}
+
+func (c *CachedFeedRepository) Push(ctx context.Context, uids []int64, item domain.FeedItem) error {
+	return c.cache.PushToInboxes(ctx, uids, item)
+}
+
+func (c *CachedFeedRepository) Remove(ctx context.Context, uids []int64, aid int64) error {
+	return c.cache.RemoveFromInboxes(ctx, uids, aid)
+}
+
+func (c *CachedFeedRepository) ListInbox(ctx context.Context,
+	uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FeedItem, error) {
+	return c.cache.ListInbox(ctx, uid, cursor, limit)
+}
+
+func (c *CachedFeedRepository) AddToOutbox(ctx context.Context, uid int64, item domain.FeedItem) error {
+	return c.cache.AddToOutbox(ctx, uid, item)
+}
+
+func (c *CachedFeedRepository) RemoveFromOutbox(ctx context.Context, uid int64, aid int64) error {
+	return c.cache.RemoveFromOutbox(ctx, uid, aid)
+}
+
+func (c *CachedFeedRepository) ListOutbox(ctx context.Context,
+	uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FeedItem, error) {
+	return c.cache.ListOutbox(ctx, uid, cursor, limit)
+}
+
+func (c *CachedFeedRepository) MarkBigAuthor(ctx context.Context, uid int64) error {
+	return c.cache.AddBigAuthor(ctx, uid)
+}
+
+func (c *CachedFeedRepository) BigAuthors(ctx context.Context) ([]int64, error) {
+	return c.cache.BigAuthors(ctx)
+}
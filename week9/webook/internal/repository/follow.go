@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/dao"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"time"
+)
+
+//go:generate mockgen -source=./follow.go -package=repomocks -destination=mocks/follow.mock.go FollowRepository
+type FollowRepository interface {
+	// Follow 关注，已经关注了的时候返回 false
+	Follow(ctx context.Context, follower, followee int64) (bool, error)
+	// Unfollow 取消关注，本来就没有关注的时候返回 false
+	Unfollow(ctx context.Context, follower, followee int64) (bool, error)
+	// ListFollowers 粉丝列表，最近关注的在前面
+	ListFollowers(ctx context.Context, uid int64,
+		cursor domain.UserBizCursor, limit int) ([]domain.FollowRelation, error)
+	// ListFollowees 关注列表，最近关注的在前面
+	ListFollowees(ctx context.Context, uid int64,
+		cursor domain.UserBizCursor, limit int) ([]domain.FollowRelation, error)
+	// FindFollowees uids 里面 follower 关注了的那些
+	FindFollowees(ctx context.Context, follower int64, uids []int64) ([]int64, error)
+	// FindFollowers uids 里面关注了 followee 的那些
+	FindFollowers(ctx context.Context, followee int64, uids []int64) ([]int64, error)
+	// GetStatics 没有关注数据的用户返回零值
+	GetStatics(ctx context.Context, uid int64) (domain.FollowStatics, error)
+}
+
+// CachedFollowRepository 关注关系直接查数据库，
+// 关注数和粉丝数在个人主页、帖子详情里面都要展示，所以缓存起来
+type CachedFollowRepository struct {
+	dao   dao.FollowDAO
+	cache cache.FollowCache
+	l     logger.LoggerV1
+}
+
+func NewCachedFollowRepository(dao dao.FollowDAO,
+	cache cache.FollowCache, l logger.LoggerV1) FollowRepository {
+	return &CachedFollowRepository{
+		dao:   dao,
+		cache: cache,
+		l:     l,
+	}
+}
+
+func (c *CachedFollowRepository) Follow(ctx context.Context, follower, followee int64) (bool, error) {
+	changed, err := c.dao.Follow(ctx, follower, followee)
+	if err != nil || !changed {
+		return changed, err
+	}
+	// 关注已经成功了，缓存更新不了也不能算失败，缓存过期之后就对了
+	err = c.cache.Follow(ctx, follower, followee)
+	if err != nil {
+		c.l.Error("更新关注数缓存失败",
+			logger.Int64("follower", follower),
+			logger.Int64("followee", followee),
+			logger.Error(err))
+	}
+	return true, nil
+}
+
+func (c *CachedFollowRepository) Unfollow(ctx context.Context, follower, followee int64) (bool, error) {
+	changed, err := c.dao.Unfollow(ctx, follower, followee)
+	if err != nil || !changed {
+		return changed, err
+	}
+	err = c.cache.Unfollow(ctx, follower, followee)
+	if err != nil {
+		c.l.Error("更新关注数缓存失败",
+			logger.Int64("follower", follower),
+			logger.Int64("followee", followee),
+			logger.Error(err))
+	}
+	return true, nil
+}
+
+func (c *CachedFollowRepository) ListFollowers(ctx context.Context,
+	uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FollowRelation, error) {
+	res, err := c.dao.ListFollowers(ctx, uid, toTimeCursor(cursor), limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.FollowRelation) domain.FollowRelation {
+		return c.toDomain(src)
+	}), nil
+}
+
+func (c *CachedFollowRepository) ListFollowees(ctx context.Context,
+	uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FollowRelation, error) {
+	res, err := c.dao.ListFollowees(ctx, uid, toTimeCursor(cursor), limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.FollowRelation) domain.FollowRelation {
+		return c.toDomain(src)
+	}), nil
+}
+
+func (c *CachedFollowRepository) FindFollowees(ctx context.Context,
+	follower int64, uids []int64) ([]int64, error) {
+	return c.dao.FindFollowees(ctx, follower, uids)
+}
+
+func (c *CachedFollowRepository) FindFollowers(ctx context.Context,
+	followee int64, uids []int64) ([]int64, error) {
+	return c.dao.FindFollowers(ctx, followee, uids)
+}
+
+func (c *CachedFollowRepository) GetStatics(ctx context.Context, uid int64) (domain.FollowStatics, error) {
+	res, err := c.cache.GetStatics(ctx, uid)
+	if err == nil {
+		return res, nil
+	}
+	statics, err := c.dao.GetStatics(ctx, uid)
+	switch err {
+	case nil:
+		res = domain.FollowStatics{
+			Followers: statics.Followers,
+			Followees: statics.Followees,
+		}
+	case dao.ErrRecordNotFound:
+		// 没有关注过别人也没有粉丝，零值也要缓存起来，不然每次都打到数据库
+		res = domain.FollowStatics{}
+	default:
+		return domain.FollowStatics{}, err
+	}
+	err = c.cache.SetStatics(ctx, uid, res)
+	if err != nil {
+		c.l.Error("回写关注数缓存失败",
+			logger.Int64("uid", uid),
+			logger.Error(err))
+	}
+	return res, nil
+}
+
+func (c *CachedFollowRepository) toDomain(fr dao.FollowRelation) domain.FollowRelation {
+	return domain.FollowRelation{
+		Id:       fr.Id,
+		Follower: fr.Follower,
+		Followee: fr.Followee,
+		// 重新关注会更新 utime，所以 utime 才是关注的时间
+		Time: time.UnixMilli(fr.Utime),
+	}
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
+	cachemocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/cache/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/dao"
+	daomocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/dao/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func TestCachedFollowRepository_GetStatics(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (dao.FollowDAO, cache.FollowCache)
+
+		wantRes domain.FollowStatics
+		wantErr error
+	}{
+		{
+			name: "命中缓存",
+			mock: func(ctrl *gomock.Controller) (dao.FollowDAO, cache.FollowCache) {
+				c := cachemocks.NewMockFollowCache(ctrl)
+				c.EXPECT().GetStatics(gomock.Any(), int64(1)).
+					Return(domain.FollowStatics{Followers: 2, Followees: 3}, nil)
+				return daomocks.NewMockFollowDAO(ctrl), c
+			},
+			wantRes: domain.FollowStatics{Followers: 2, Followees: 3},
+		},
+		{
+			name: "没有命中，查询数据库并回写",
+			mock: func(ctrl *gomock.Controller) (dao.FollowDAO, cache.FollowCache) {
+				c := cachemocks.NewMockFollowCache(ctrl)
+				c.EXPECT().GetStatics(gomock.Any(), int64(1)).
+					Return(domain.FollowStatics{}, cache.ErrKeyNotExist)
+				d := daomocks.NewMockFollowDAO(ctrl)
+				d.EXPECT().GetStatics(gomock.Any(), int64(1)).
+					Return(dao.FollowStatics{Uid: 1, Followers: 2, Followees: 3}, nil)
+				c.EXPECT().SetStatics(gomock.Any(), int64(1),
+					domain.FollowStatics{Followers: 2, Followees: 3}).Return(nil)
+				return d, c
+			},
+			wantRes: domain.FollowStatics{Followers: 2, Followees: 3},
+		},
+		{
+			name: "数据库也没有，零值也要缓存",
+			mock: func(ctrl *gomock.Controller) (dao.FollowDAO, cache.FollowCache) {
+				c := cachemocks.NewMockFollowCache(ctrl)
+				c.EXPECT().GetStatics(gomock.Any(), int64(1)).
+					Return(domain.FollowStatics{}, cache.ErrKeyNotExist)
+				d := daomocks.NewMockFollowDAO(ctrl)
+				d.EXPECT().GetStatics(gomock.Any(), int64(1)).
+					Return(dao.FollowStatics{}, dao.ErrRecordNotFound)
+				c.EXPECT().SetStatics(gomock.Any(), int64(1), domain.FollowStatics{}).
+					Return(errors.New("mock redis error"))
+				return d, c
+			},
+		},
+		{
+			name: "数据库出错",
+			mock: func(ctrl *gomock.Controller) (dao.FollowDAO, cache.FollowCache) {
+				c := cachemocks.NewMockFollowCache(ctrl)
+				c.EXPECT().GetStatics(gomock.Any(), int64(1)).
+					Return(domain.FollowStatics{}, cache.ErrKeyNotExist)
+				d := daomocks.NewMockFollowDAO(ctrl)
+				d.EXPECT().GetStatics(gomock.Any(), int64(1)).
+					Return(dao.FollowStatics{}, errors.New("mock db error"))
+				return d, c
+			},
+			wantErr: errors.New("mock db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			d, c := tc.mock(ctrl)
+			repo := NewCachedFollowRepository(d, c, &logger.NopLogger{})
+			res, err := repo.GetStatics(context.Background(), 1)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantRes, res)
+		})
+	}
+}
+
+func TestCachedFollowRepository_Follow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	d := daomocks.NewMockFollowDAO(ctrl)
+	c := cachemocks.NewMockFollowCache(ctrl)
+	d.EXPECT().Follow(gomock.Any(), int64(1), int64(2)).Return(true, nil)
+	c.EXPECT().Follow(gomock.Any(), int64(1), int64(2)).Return(errors.New("mock redis error"))
+	repo := NewCachedFollowRepository(d, c, &logger.NopLogger{})
+	// 数据库已经改好了，缓存出错也算关注成功
+	changed, err := repo.Follow(context.Background(), 1, 2)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/dao"
+	"time"
+)
+
+//go:generate mockgen -source=./history.go -package=repomocks -destination=mocks/history.mock.go HistoryRepository
+type HistoryRepository interface {
+	AddRecord(ctx context.Context, h domain.ReadHistory) error
+	List(ctx context.Context, uid int64, offset, limit int) ([]domain.ReadHistory, error)
+	Delete(ctx context.Context, uid int64, biz string, bizId int64) error
+	Clear(ctx context.Context, uid int64) error
+}
+
+type HistoryDBRepository struct {
+	dao dao.HistoryDAO
+}
+
+func NewHistoryDBRepository(dao dao.HistoryDAO) HistoryRepository {
+	return &HistoryDBRepository{dao: dao}
+}
+
+func (h *HistoryDBRepository) AddRecord(ctx context.Context, his domain.ReadHistory) error {
+	return h.dao.Upsert(ctx, dao.ReadHistory{
+		Uid:      his.Uid,
+		Biz:      his.Biz,
+		BizId:    his.BizId,
+		ReadTime: his.ReadTime.UnixMilli(),
+	})
+}
+
+func (h *HistoryDBRepository) List(ctx context.Context,
+	uid int64, offset, limit int) ([]domain.ReadHistory, error) {
+	res, err := h.dao.List(ctx, uid, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.ReadHistory) domain.ReadHistory {
+		return domain.ReadHistory{
+			Uid:      src.Uid,
+			Biz:      src.Biz,
+			BizId:    src.BizId,
+			ReadTime: time.UnixMilli(src.ReadTime),
+		}
+	}), nil
+}
+
+func (h *HistoryDBRepository) Delete(ctx context.Context,
+	uid int64, biz string, bizId int64) error {
+	return h.dao.Delete(ctx, uid, biz, bizId)
+}
+
+func (h *HistoryDBRepository) Clear(ctx context.Context, uid int64) error {
+	return h.dao.DeleteAll(ctx, uid)
+}
@@ -2,63 +2,312 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"github.com/ecodeclub/ekit/slice"
 	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository/dao"
 	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"sort"
+	"strconv"
+	"time"
 )
 
 //go:generate mockgen -source=./interactive.go -package=repomocks -destination=mocks/interactive.mock.go InteractiveRepository
 type InteractiveRepository interface {
+	// IncrReadCnt 阅读数先记在 Redis 里面，FlushReadCnt 的时候才写数据库
 	IncrReadCnt(ctx context.Context,
 		biz string, bizId int64) error
 	BatchIncrReadCnt(ctx context.Context,
 		biz []string, bizId []int64) error
-	IncrLike(ctx context.Context, biz string, bizId, uid int64) error
-	DecrLike(ctx context.Context, biz string, bizId, uid int64) error
+	// FlushReadCnt 把 Redis 里面累加的阅读数合并到数据库，返回合并了多少个资源。
+	// 中途失败了下一次会重试同一批，不会多算也不会少算
+	FlushReadCnt(ctx context.Context) (int, error)
+	// AddUV 记录读者，按照阅读的时间算到那一天的 UV 里面。
+	// bizs、bizIds、uids 和 times 一一对应，重复记录同一个读者没有影响
+	AddUV(ctx context.Context, bizs []string, bizIds []int64, uids []int64, times []time.Time) error
+	// RollupUV 把某一天的 UV 和总的 UV 汇总到数据库，返回汇总了多少个资源
+	RollupUV(ctx context.Context, day time.Time) (int, error)
+	// React 表态，点赞也是一种表态。换一种表态的时候会同时调整两个计数。
+	// 原来没有表态，这一次新建了的时候返回 true，重复表态和换一种表态都是 false
+	React(ctx context.Context, biz string, bizId, uid int64, reaction domain.ReactionType) (bool, error)
+	// CancelReaction 取消表态，不管是哪一种
+	CancelReaction(ctx context.Context, biz string, bizId, uid int64) error
 	AddCollectionItem(ctx context.Context, biz string, bizId, cid int64, uid int64) error
+	// DeleteCollectionItem 取消收藏，重复取消不会报错
+	DeleteCollectionItem(ctx context.Context, biz string, bizId, uid int64) error
 	Get(ctx context.Context, biz string, bizId int64) (domain.Interactive, error)
-	Liked(ctx context.Context, biz string, id int64, uid int64) (bool, error)
+	// GetByIds 每一个 bizId 都会有结果，没有互动数据的就是零值
+	GetByIds(ctx context.Context, biz string, bizIds []int64) (map[int64]domain.Interactive, error)
+	// Reaction 用户的表态，没有表态返回空字符串
+	Reaction(ctx context.Context, biz string, id int64, uid int64) (domain.ReactionType, error)
+	// ListLikes 用户点赞过的所有资源，最近点赞的在前面
+	ListLikes(ctx context.Context, uid int64,
+		cursor domain.UserBizCursor, limit int) ([]domain.UserBizRecord, error)
 	Collected(ctx context.Context, biz string, id int64, uid int64) (bool, error)
-	AddRecord(ctx context.Context, aid int64, uid int64) error
+	// ReconcileCnt 按照点赞和收藏的记录校对 id 之后的一批计数，改掉的计数会清掉缓存
+	ReconcileCnt(ctx context.Context, id int64, limit int) (domain.CntReconcileBatch, error)
+	// Delete 删除资源的所有互动数据
+	Delete(ctx context.Context, biz string, bizId int64) error
 }
 
 type CachedReadCntRepository struct {
 	cache cache.InteractiveCache
 	dao   dao.InteractiveDAO
 	l     logger.LoggerV1
+	// 合并阅读数的时候，一个事务里面更新多少个资源
+	flushBatchSize int
 }
 
-func (c *CachedReadCntRepository) AddRecord(ctx context.Context, aid int64, uid int64) error {
-	//TODO implement me
-	panic("implement me")
+func (c *CachedReadCntRepository) Delete(ctx context.Context, biz string, bizId int64) error {
+	err := c.dao.DeleteByBiz(ctx, biz, bizId)
+	if err != nil {
+		return err
+	}
+	// 缓存删不掉也没关系，15 分钟之后就过期了
+	return c.cache.Del(ctx, biz, bizId)
+}
+
+func (c *CachedReadCntRepository) ReconcileCnt(ctx context.Context,
+	id int64, limit int) (domain.CntReconcileBatch, error) {
+	res, err := c.dao.ReconcileCnt(ctx, id, limit)
+	if err != nil {
+		return domain.CntReconcileBatch{}, err
+	}
+	for _, d := range res.Drifts {
+		// 删不掉的话，缓存里面错误的计数最多再撑 15 分钟
+		err = c.cache.Del(ctx, d.Biz, d.BizId)
+		if err != nil {
+			c.l.Error("校对计数之后删除缓存失败",
+				logger.String("biz", d.Biz),
+				logger.Int64("bizId", d.BizId),
+				logger.Error(err))
+		}
+	}
+	return res, nil
+}
+
+func (c *CachedReadCntRepository) AddUV(ctx context.Context,
+	bizs []string, bizIds []int64, uids []int64, times []time.Time) error {
+	// 一批里面基本都是同一天的，跨天的时候才会拆成两次
+	type uvBatch struct {
+		bizs   []string
+		bizIds []int64
+		uids   []int64
+	}
+	days := make([]string, 0, 1)
+	batches := make(map[string]*uvBatch, 1)
+	for i := range bizIds {
+		d := times[i].Format(uvDayLayout)
+		b, ok := batches[d]
+		if !ok {
+			b = &uvBatch{}
+			batches[d] = b
+			days = append(days, d)
+		}
+		b.bizs = append(b.bizs, bizs[i])
+		b.bizIds = append(b.bizIds, bizIds[i])
+		b.uids = append(b.uids, uids[i])
+	}
+	c.restoreUV(ctx, bizs, bizIds)
+	for _, d := range days {
+		b := batches[d]
+		err := c.cache.AddUV(ctx, b.bizs, b.bizIds, b.uids, d)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreUV 总的 UV 的 HyperLogLog 很久没人看会过期，过期之后 PFADD 会从头算。
+// 所以记录之前，先把汇总的时候存到数据库里面的合并回去，不然总的 UV 就只是旧的和新的里面大的那个。
+// 恢复失败不影响记录，汇总的时候总的 UV 也不会变小
+func (c *CachedReadCntRepository) restoreUV(ctx context.Context, bizs []string, bizIds []int64) {
+	type uvKey struct {
+		biz   string
+		bizId int64
+	}
+	seen := make(map[uvKey]struct{}, len(bizIds))
+	uniqBizs := make([]string, 0, len(bizIds))
+	uniqIds := make([]int64, 0, len(bizIds))
+	for i, id := range bizIds {
+		key := uvKey{biz: bizs[i], bizId: id}
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		uniqBizs = append(uniqBizs, bizs[i])
+		uniqIds = append(uniqIds, id)
+	}
+	missing, err := c.cache.MissingUV(ctx, uniqBizs, uniqIds)
+	if err != nil {
+		c.l.Error("检查总的 UV 是否过期失败", logger.Error(err))
+		return
+	}
+	var missBizs []string
+	var missIds []int64
+	for i, miss := range missing {
+		if miss {
+			missBizs = append(missBizs, uniqBizs[i])
+			missIds = append(missIds, uniqIds[i])
+		}
+	}
+	if len(missIds) == 0 {
+		return
+	}
+	sketches, err := c.dao.GetUVSketches(ctx, missBizs, missIds)
+	if err != nil {
+		c.l.Error("查询保存的 UV 失败", logger.Error(err))
+		return
+	}
+	for _, s := range sketches {
+		err = c.cache.RestoreUV(ctx, s.Biz, s.BizId, s.Sketch)
+		if err != nil {
+			c.l.Error("恢复总的 UV 失败",
+				logger.String("biz", s.Biz),
+				logger.Int64("bizId", s.BizId),
+				logger.Error(err))
+		}
+	}
+}
+
+// uvDayLayout 按天汇总 UV 的日期格式
+const uvDayLayout = "20060102"
+
+// 一次 SSCAN 大概拿多少个资源
+const uvRollupBatchSize = 500
+
+func (c *CachedReadCntRepository) RollupUV(ctx context.Context, day time.Time) (int, error) {
+	d := day.Format(uvDayLayout)
+	var (
+		cursor uint64
+		cnt    int
+	)
+	for {
+		next, uvs, err := c.cache.ScanDailyUV(ctx, d, cursor, uvRollupBatchSize)
+		if err != nil {
+			return cnt, err
+		}
+		// SSCAN 中间可能返回空的一批，也可能返回重复的元素，
+		// 重复保存没有影响，只是计数会多一点
+		if len(uvs) > 0 {
+			err = c.dao.SaveUV(ctx, d, slice.Map(uvs, func(idx int, src cache.UVCnt) dao.UVCnt {
+				return dao.UVCnt{
+					Biz:         src.Biz,
+					BizId:       src.BizId,
+					DayUV:       src.DayUV,
+					TotalUV:     src.TotalUV,
+					TotalSketch: src.TotalSketch,
+				}
+			}))
+			if err != nil {
+				return cnt, err
+			}
+			cnt += len(uvs)
+		}
+		if next == 0 {
+			return cnt, nil
+		}
+		cursor = next
+	}
 }
 
 // BatchIncrReadCnt bizs 和 ids 的长度必须相等
 func (c *CachedReadCntRepository) BatchIncrReadCnt(ctx context.Context,
 	bizs []string, bizId []int64) error {
-	// 我在这里要不要检测 bizs 和 ids 的长度是否相等？
-	err := c.dao.BatchIncrReadCnt(ctx, bizs, bizId)
+	// 一个 lua 脚本搞定一整批，数据库由 FlushReadCnt 定时更新
+	err := c.cache.IncrReadCntPending(ctx, bizs, bizId)
 	if err != nil {
 		return err
 	}
-	// 你也要批量的去修改 redis，所以就要去改 lua 脚本
-	// c.cache.IncrReadCntIfPresent()
-	// TODO, 等我写新的 lua 脚本/或者用 pipeline
+	// 按照 biz 分组通知
+	ids := make(map[string][]int64, 1)
+	for i, biz := range bizs {
+		ids[biz] = append(ids[biz], bizId[i])
+	}
+	for biz, bizIds := range ids {
+		c.publishChange(ctx, biz, bizIds...)
+	}
 	return nil
 }
 
-func (c *CachedReadCntRepository) Liked(ctx context.Context, biz string, id int64, uid int64) (bool, error) {
-	_, err := c.dao.GetLikeInfo(ctx, biz, id, uid)
+func (c *CachedReadCntRepository) FlushReadCnt(ctx context.Context) (int, error) {
+	// 批次号只在第一次冻结的时候用得上，重试的时候用的是上一次的
+	batch, deltas, err := c.cache.SnapshotPendingReadCnt(ctx,
+		strconv.FormatInt(time.Now().UnixNano(), 10))
+	if err != nil || len(deltas) == 0 {
+		return 0, err
+	}
+	// 重试的时候要切出一样的小批次，所以先排个序
+	sort.Slice(deltas, func(i, j int) bool {
+		if deltas[i].Biz != deltas[j].Biz {
+			return deltas[i].Biz < deltas[j].Biz
+		}
+		return deltas[i].BizId < deltas[j].BizId
+	})
+	for start := 0; start < len(deltas); start += c.flushBatchSize {
+		end := start + c.flushBatchSize
+		if end > len(deltas) {
+			end = len(deltas)
+		}
+		chunk := slice.Map(deltas[start:end], func(idx int, src cache.ReadCntDelta) dao.ReadCntDelta {
+			return dao.ReadCntDelta{Biz: src.Biz, BizId: src.BizId, Delta: src.Delta}
+		})
+		err = c.dao.MergeReadCnt(ctx,
+			fmt.Sprintf("%s:%d", batch, start/c.flushBatchSize), chunk)
+		if err != nil {
+			// 已经合并的小批次有记录，下一次不会重复合并
+			return 0, err
+		}
+	}
+	err = c.cache.AckPendingReadCnt(ctx, batch)
+	if err != nil {
+		return 0, err
+	}
+	err = c.dao.DeleteFlushLogsBefore(ctx, time.Now().Add(-time.Hour*24).UnixMilli())
+	if err != nil {
+		c.l.Error("清理阅读数合并记录失败", logger.Error(err))
+	}
+	return len(deltas), nil
+}
+
+func (c *CachedReadCntRepository) Reaction(ctx context.Context,
+	biz string, id int64, uid int64) (domain.ReactionType, error) {
+	res, err := c.dao.GetLikeInfo(ctx, biz, id, uid)
 	switch err {
 	case nil:
-		return true, nil
+		return domain.ReactionType(res.Reaction), nil
 	case dao.ErrRecordNotFound:
 		// 你要吞掉
-		return false, nil
+		return "", nil
 	default:
-		return false, err
+		return "", err
+	}
+}
+
+func (c *CachedReadCntRepository) ListLikes(ctx context.Context,
+	uid int64, cursor domain.UserBizCursor, limit int) ([]domain.UserBizRecord, error) {
+	res, err := c.dao.ListLikesByUid(ctx, uid, toTimeCursor(cursor), limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.UserLikeBiz) domain.UserBizRecord {
+		return domain.UserBizRecord{
+			Id:    src.Id,
+			Biz:   src.Biz,
+			BizId: src.BizId,
+			// 重新点赞会更新 utime，所以 utime 才是点赞的时间
+			Time: time.UnixMilli(src.Utime),
+		}
+	}), nil
+}
+
+func toTimeCursor(cursor domain.UserBizCursor) dao.TimeCursor {
+	if cursor.IsZero() {
+		return dao.TimeCursor{}
 	}
+	return dao.TimeCursor{Time: cursor.Time.UnixMilli(), Id: cursor.Id}
 }
 
 func (c *CachedReadCntRepository) Collected(ctx context.Context, biz string, id int64, uid int64) (bool, error) {
@@ -74,41 +323,52 @@ func (c *CachedReadCntRepository) Collected(ctx context.Context, biz string, id
 	}
 }
 
-func (c *CachedReadCntRepository) IncrLike(ctx context.Context,
-	biz string, bizId int64, uid int64) error {
-	// 先插入点赞，然后更新点赞计数，更新缓存
-	err := c.dao.InsertLikeInfo(ctx, biz, bizId, uid)
+func (c *CachedReadCntRepository) React(ctx context.Context,
+	biz string, bizId int64, uid int64, reaction domain.ReactionType) (bool, error) {
+	// 先记录表态，然后更新计数，更新缓存
+	old, err := c.dao.SetReaction(ctx, biz, bizId, uid, string(reaction))
 	if err != nil {
-		return err
+		return false, err
+	}
+	// 重复表态，计数没有变
+	if domain.ReactionType(old) == reaction {
+		return false, nil
 	}
+	created := old == ""
 	// 这种做法，你需要在 repository 层面上维持住事务
 	//c.dao.IncrLikeCnt()
-	return c.cache.IncrLikeCntIfPresent(ctx, biz, bizId)
+	err = c.cache.SwitchReactionIfPresent(ctx, biz, bizId, domain.ReactionType(old), reaction)
+	if err != nil {
+		return created, err
+	}
+	c.publishChange(ctx, biz, bizId)
+	return created, nil
 }
 
-func (c *CachedReadCntRepository) DecrLike(ctx context.Context,
+func (c *CachedReadCntRepository) CancelReaction(ctx context.Context,
 	biz string, bizId int64, uid int64) error {
-	err := c.dao.DeleteLikeInfo(ctx, biz, bizId, uid)
+	old, err := c.dao.DeleteLikeInfo(ctx, biz, bizId, uid)
+	if err != nil || old == "" {
+		return err
+	}
+	err = c.cache.SwitchReactionIfPresent(ctx, biz, bizId, domain.ReactionType(old), "")
 	if err != nil {
 		return err
 	}
-	return c.cache.DecrLikeCntIfPresent(ctx, biz, bizId)
+	c.publishChange(ctx, biz, bizId)
+	return nil
 }
 
 func (c *CachedReadCntRepository) IncrReadCnt(ctx context.Context,
 	biz string, bizId int64) error {
-	// 要考虑缓存方案了
-	// 这两个操作能不能换顺序？ —— 不能
-	err := c.dao.IncrReadCnt(ctx, biz, bizId)
+	// 阅读数不需要那么准，也不需要马上落库，
+	// 先在 Redis 里面攒着，定时合并到数据库，数据库的压力就小了很多
+	err := c.cache.IncrReadCntPending(ctx, []string{biz}, []int64{bizId})
 	if err != nil {
 		return err
 	}
-	//go func() {
-	//	c.cache.IncrReadCntIfPresent(ctx, biz, bizId)
-	//}()
-	//return err
-
-	return c.cache.IncrReadCntIfPresent(ctx, biz, bizId)
+	c.publishChange(ctx, biz, bizId)
+	return nil
 }
 
 func (c *CachedReadCntRepository) AddCollectionItem(ctx context.Context,
@@ -127,7 +387,36 @@ func (c *CachedReadCntRepository) AddCollectionItem(ctx context.Context,
 		return err
 	}
 	// 收藏个数（有多少个人收藏了这个 biz + bizId)
-	return c.cache.IncrCollectCntIfPresent(ctx, biz, bizId)
+	err = c.cache.IncrCollectCntIfPresent(ctx, biz, bizId)
+	if err != nil {
+		return err
+	}
+	c.publishChange(ctx, biz, bizId)
+	return nil
+}
+
+func (c *CachedReadCntRepository) DeleteCollectionItem(ctx context.Context,
+	biz string, bizId, uid int64) error {
+	deleted, err := c.dao.DeleteCollectionBiz(ctx, biz, bizId, uid)
+	if err != nil || !deleted {
+		return err
+	}
+	err = c.cache.DecrCollectCntIfPresent(ctx, biz, bizId)
+	if err != nil {
+		return err
+	}
+	c.publishChange(ctx, biz, bizId)
+	return nil
+}
+
+// publishChange 计数已经改好了，通知不出去只是页面上的实时计数慢一点，记个日志
+func (c *CachedReadCntRepository) publishChange(ctx context.Context, biz string, bizIds ...int64) {
+	err := c.cache.PublishChange(ctx, biz, bizIds...)
+	if err != nil {
+		c.l.Error("通知计数变化失败",
+			logger.String("biz", biz),
+			logger.Error(err))
+	}
 }
 
 func (c *CachedReadCntRepository) Get(ctx context.Context,
@@ -142,25 +431,94 @@ func (c *CachedReadCntRepository) Get(ctx context.Context,
 	//if intr == (domain.Interactive{}) {
 	//
 	//}
-	// 在这里查询数据库
+	// 在这里查询数据库。代数要在查询之前拿到，回写缓存的时候用来判断有没有和合并阅读数撞上
+	gen, genErr := c.cache.ReadCntGen(ctx)
 	daoIntr, err := c.dao.Get(ctx, biz, bizId)
 	if err != nil {
 		return domain.Interactive{}, err
 	}
 	intr = c.toDomain(daoIntr)
-	go func() {
-		er := c.cache.Set(ctx, biz, bizId, intr)
-		// 记录日志
-		if er != nil {
-			c.l.Error("回写缓存失败",
-				logger.String("biz", biz),
-				logger.Int64("bizId", bizId),
-			)
-		}
-	}()
+	// 缓存里面存的是数据库的值，所以要在算上待合并的阅读数之前回写
+	c.setCache(ctx, biz, map[int64]domain.Interactive{bizId: intr}, gen, genErr)
+	intr.ReadCnt += c.pendingReadCnt(ctx, biz, []int64{bizId})[bizId]
+	intr.Uv = c.liveUV(ctx, biz, []int64{bizId}, map[int64]int64{bizId: intr.Uv})[bizId]
 	return intr, nil
 }
 
+// setCache 回写缓存失败只是下一次还要查数据库。拿不到代数的时候没法判断会不会写进去旧的阅读数，就不写了
+func (c *CachedReadCntRepository) setCache(ctx context.Context,
+	biz string, intrs map[int64]domain.Interactive, gen int64, genErr error) {
+	err := genErr
+	if err == nil {
+		err = c.cache.SetByIds(ctx, biz, intrs, gen)
+	}
+	if err != nil {
+		c.l.Error("回写缓存失败",
+			logger.String("biz", biz),
+			logger.Error(err))
+	}
+}
+
+func (c *CachedReadCntRepository) GetByIds(ctx context.Context,
+	biz string, bizIds []int64) (map[int64]domain.Interactive, error) {
+	res, err := c.cache.GetByIds(ctx, biz, bizIds)
+	if err != nil {
+		// 缓存出问题了，全部查数据库
+		c.l.Error("批量查询缓存失败",
+			logger.String("biz", biz),
+			logger.Error(err))
+		res = make(map[int64]domain.Interactive, len(bizIds))
+	}
+	missed := make([]int64, 0, len(bizIds)-len(res))
+	for _, id := range bizIds {
+		if _, ok := res[id]; !ok {
+			missed = append(missed, id)
+		}
+	}
+	if len(missed) == 0 {
+		return res, nil
+	}
+	gen, genErr := c.cache.ReadCntGen(ctx)
+	daoIntrs, err := c.dao.GetByIds(ctx, biz, missed)
+	if err != nil {
+		return nil, err
+	}
+	fromDB := make(map[int64]domain.Interactive, len(missed))
+	// 数据库里面没有的也要给零值，顺便缓存起来，免得下次再查数据库
+	for _, id := range missed {
+		fromDB[id] = domain.Interactive{}
+	}
+	for _, intr := range daoIntrs {
+		fromDB[intr.BizId] = c.toDomain(intr)
+	}
+	c.setCache(ctx, biz, fromDB, gen, genErr)
+	pending := c.pendingReadCnt(ctx, biz, missed)
+	dbUV := make(map[int64]int64, len(fromDB))
+	for id, intr := range fromDB {
+		dbUV[id] = intr.Uv
+	}
+	uv := c.liveUV(ctx, biz, missed, dbUV)
+	for id, intr := range fromDB {
+		intr.ReadCnt += pending[id]
+		intr.Uv = uv[id]
+		res[id] = intr
+	}
+	return res, nil
+}
+
+// pendingReadCnt 查不到待合并的阅读数只是少算一点，不影响返回结果
+func (c *CachedReadCntRepository) pendingReadCnt(ctx context.Context,
+	biz string, bizIds []int64) map[int64]int64 {
+	res, err := c.cache.GetPendingReadCnt(ctx, biz, bizIds)
+	if err != nil {
+		c.l.Error("查询待合并的阅读数失败",
+			logger.String("biz", biz),
+			logger.Error(err))
+		return map[int64]int64{}
+	}
+	return res
+}
+
 // UpdateCnt 这不是好的实践
 func (c *CachedReadCntRepository) UpdateCnt(intr *dao.Interactive) {
 	intr.LikeCnt = 30
@@ -180,30 +538,44 @@ func (c *CachedReadCntRepository) UpdateCntV1(intr dao.Interactive) dao.Interact
 // 最简原则：
 // 1. 接收器永远用指针
 // 2. 输入输出都用结构体
-func (c *CachedReadCntRepository) toDomain(intr dao.Interactive) domain.Interactive {
-	return domain.Interactive{
-		LikeCnt:    intr.LikeCnt,
-		CollectCnt: intr.CollectCnt,
-		ReadCnt:    intr.ReadCnt,
+// liveUV Redis 里面的 UV 是实时的，数据库里面的是汇总过的，取大的那个。
+// 查不到 Redis 就用数据库的
+func (c *CachedReadCntRepository) liveUV(ctx context.Context,
+	biz string, bizIds []int64, dbUV map[int64]int64) map[int64]int64 {
+	res, err := c.cache.GetUV(ctx, biz, bizIds)
+	if err != nil {
+		c.l.Error("查询 UV 失败",
+			logger.String("biz", biz),
+			logger.Error(err))
+		return dbUV
+	}
+	for id, uv := range dbUV {
+		if uv > res[id] {
+			res[id] = uv
+		}
 	}
+	return res
 }
 
-func (c *CachedReadCntRepository) GetCollection() (domain.Collection, error) {
-	items, err := c.dao.(*dao.GORMInteractiveDAO).GetItems()
-	if err != nil {
-		return domain.Collection{}, err
+func (c *CachedReadCntRepository) toDomain(intr dao.Interactive) domain.Interactive {
+	return domain.Interactive{
+		LikeCnt:       intr.LikeCnt,
+		CollectCnt:    intr.CollectCnt,
+		ReadCnt:       intr.ReadCnt,
+		CommentCnt:    intr.CommentCnt,
+		LoveCnt:       intr.LoveCnt,
+		LaughCnt:      intr.LaughCnt,
+		InsightfulCnt: intr.InsightfulCnt,
+		Uv:            intr.Uv,
 	}
-	// 用 items 来构造一个 Collection
-	return domain.Collection{
-		Name: items[0].Cname,
-	}, nil
 }
 
 func NewCachedInteractiveRepository(dao dao.InteractiveDAO,
 	cache cache.InteractiveCache, l logger.LoggerV1) InteractiveRepository {
 	return &CachedReadCntRepository{
-		dao:   dao,
-		cache: cache,
-		l:     l,
+		dao:            dao,
+		cache:          cache,
+		l:              l,
+		flushBatchSize: 500,
 	}
 }
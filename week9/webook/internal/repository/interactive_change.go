@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
+)
+
+// InteractiveSubscription 监听一批资源的计数变化，用 Watch 和 Unwatch 增减
+type InteractiveSubscription = cache.InteractiveSubscription
+
+//go:generate mockgen -source=./interactive_change.go -package=repomocks -destination=mocks/interactive_change.mock.go InteractiveChangeRepository
+type InteractiveChangeRepository interface {
+	// Subscribe 计数变了的通知由 InteractiveRepository 在计数改好之后发出来，
+	// 只通知哪个资源变了，最新的计数要自己去查
+	Subscribe(ctx context.Context) InteractiveSubscription
+}
+
+type CachedInteractiveChangeRepository struct {
+	sub cache.InteractiveChangeSubscriber
+}
+
+func NewCachedInteractiveChangeRepository(sub cache.InteractiveChangeSubscriber) InteractiveChangeRepository {
+	return &CachedInteractiveChangeRepository{sub: sub}
+}
+
+func (c *CachedInteractiveChangeRepository) Subscribe(ctx context.Context) InteractiveSubscription {
+	return c.sub.Subscribe(ctx)
+}
@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
+	cachemocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/cache/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/dao"
+	daomocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/dao/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func TestCachedReadCntRepository_GetByIds(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache)
+
+		ids []int64
+
+		wantRes map[int64]domain.Interactive
+		wantErr error
+	}{
+		{
+			name: "全部命中缓存",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache) {
+				c := cachemocks.NewMockInteractiveCache(ctrl)
+				c.EXPECT().GetByIds(gomock.Any(), "article", []int64{1, 2}).
+					Return(map[int64]domain.Interactive{
+						1: {LikeCnt: 1},
+						2: {LikeCnt: 2},
+					}, nil)
+				return daomocks.NewMockInteractiveDAO(ctrl), c
+			},
+			ids: []int64{1, 2},
+			wantRes: map[int64]domain.Interactive{
+				1: {LikeCnt: 1},
+				2: {LikeCnt: 2},
+			},
+		},
+		{
+			name: "部分命中，没有数据的给零值",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache) {
+				c := cachemocks.NewMockInteractiveCache(ctrl)
+				c.EXPECT().GetByIds(gomock.Any(), "article", []int64{1, 2, 3}).
+					Return(map[int64]domain.Interactive{
+						1: {LikeCnt: 1},
+					}, nil)
+				c.EXPECT().ReadCntGen(gomock.Any()).Return(int64(7), nil)
+				d := daomocks.NewMockInteractiveDAO(ctrl)
+				d.EXPECT().GetByIds(gomock.Any(), "article", []int64{2, 3}).
+					Return([]dao.Interactive{
+						{Biz: "article", BizId: 2, LikeCnt: 2, ReadCnt: 3, Uv: 4},
+					}, nil)
+				// 缓存里面是数据库的值
+				c.EXPECT().SetByIds(gomock.Any(), "article", map[int64]domain.Interactive{
+					2: {LikeCnt: 2, ReadCnt: 3, Uv: 4},
+					3: {},
+				}, int64(7)).Return(nil)
+				c.EXPECT().GetPendingReadCnt(gomock.Any(), "article", []int64{2, 3}).
+					Return(map[int64]int64{3: 5}, nil)
+				c.EXPECT().GetUV(gomock.Any(), "article", []int64{2, 3}).
+					Return(map[int64]int64{2: 1, 3: 2}, nil)
+				return d, c
+			},
+			ids: []int64{1, 2, 3},
+			wantRes: map[int64]domain.Interactive{
+				1: {LikeCnt: 1},
+				// HyperLogLog 丢过，用数据库里面汇总过的
+				2: {LikeCnt: 2, ReadCnt: 3, Uv: 4},
+				// 还没有合并到数据库的阅读数，以及还没有汇总的 UV
+				3: {ReadCnt: 5, Uv: 2},
+			},
+		},
+		{
+			name: "缓存出错，回写失败也不影响结果",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache) {
+				c := cachemocks.NewMockInteractiveCache(ctrl)
+				c.EXPECT().GetByIds(gomock.Any(), "article", []int64{1}).
+					Return(nil, errors.New("mock redis error"))
+				d := daomocks.NewMockInteractiveDAO(ctrl)
+				d.EXPECT().GetByIds(gomock.Any(), "article", []int64{1}).
+					Return([]dao.Interactive{
+						{Biz: "article", BizId: 1, CollectCnt: 1, Uv: 3},
+					}, nil)
+				// 拿不到代数就不回写了
+				c.EXPECT().ReadCntGen(gomock.Any()).Return(int64(0), errors.New("mock redis error"))
+				c.EXPECT().GetPendingReadCnt(gomock.Any(), "article", []int64{1}).
+					Return(nil, errors.New("mock redis error"))
+				c.EXPECT().GetUV(gomock.Any(), "article", []int64{1}).
+					Return(nil, errors.New("mock redis error"))
+				return d, c
+			},
+			ids: []int64{1},
+			wantRes: map[int64]domain.Interactive{
+				1: {CollectCnt: 1, Uv: 3},
+			},
+		},
+		{
+			name: "数据库出错",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache) {
+				c := cachemocks.NewMockInteractiveCache(ctrl)
+				c.EXPECT().GetByIds(gomock.Any(), "article", []int64{1}).
+					Return(map[int64]domain.Interactive{}, nil)
+				c.EXPECT().ReadCntGen(gomock.Any()).Return(int64(0), nil)
+				d := daomocks.NewMockInteractiveDAO(ctrl)
+				d.EXPECT().GetByIds(gomock.Any(), "article", []int64{1}).
+					Return(nil, errors.New("mock db error"))
+				return d, c
+			},
+			ids:     []int64{1},
+			wantErr: errors.New("mock db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			d, c := tc.mock(ctrl)
+			repo := NewCachedInteractiveRepository(d, c, &logger.NopLogger{})
+			res, err := repo.GetByIds(context.Background(), "article", tc.ids)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantRes, res)
+		})
+	}
+}
+
+func TestCachedReadCntRepository_FlushReadCnt(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache)
+
+		wantCnt int
+		wantErr error
+	}{
+		{
+			name: "没有需要合并的",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache) {
+				c := cachemocks.NewMockInteractiveCache(ctrl)
+				c.EXPECT().SnapshotPendingReadCnt(gomock.Any(), gomock.Any()).
+					Return("", nil, nil)
+				return daomocks.NewMockInteractiveDAO(ctrl), c
+			},
+		},
+		{
+			name: "分批合并，然后确认",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache) {
+				c := cachemocks.NewMockInteractiveCache(ctrl)
+				c.EXPECT().SnapshotPendingReadCnt(gomock.Any(), gomock.Any()).
+					Return("100", []cache.ReadCntDelta{
+						{Biz: "article", BizId: 3, Delta: 3},
+						{Biz: "article", BizId: 1, Delta: 1},
+						{Biz: "article", BizId: 2, Delta: 2},
+					}, nil)
+				d := daomocks.NewMockInteractiveDAO(ctrl)
+				// 排序之后两个一批
+				d.EXPECT().MergeReadCnt(gomock.Any(), "100:0", []dao.ReadCntDelta{
+					{Biz: "article", BizId: 1, Delta: 1},
+					{Biz: "article", BizId: 2, Delta: 2},
+				}).Return(nil)
+				d.EXPECT().MergeReadCnt(gomock.Any(), "100:1", []dao.ReadCntDelta{
+					{Biz: "article", BizId: 3, Delta: 3},
+				}).Return(nil)
+				c.EXPECT().AckPendingReadCnt(gomock.Any(), "100").Return(nil)
+				d.EXPECT().DeleteFlushLogsBefore(gomock.Any(), gomock.Any()).Return(nil)
+				return d, c
+			},
+			wantCnt: 3,
+		},
+		{
+			name: "合并失败，不确认",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache) {
+				c := cachemocks.NewMockInteractiveCache(ctrl)
+				c.EXPECT().SnapshotPendingReadCnt(gomock.Any(), gomock.Any()).
+					Return("100", []cache.ReadCntDelta{
+						{Biz: "article", BizId: 1, Delta: 1},
+					}, nil)
+				d := daomocks.NewMockInteractiveDAO(ctrl)
+				d.EXPECT().MergeReadCnt(gomock.Any(), "100:0", gomock.Any()).
+					Return(errors.New("mock db error"))
+				return d, c
+			},
+			wantErr: errors.New("mock db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			d, c := tc.mock(ctrl)
+			repo := NewCachedInteractiveRepository(d, c, &logger.NopLogger{}).(*CachedReadCntRepository)
+			repo.flushBatchSize = 2
+			cnt, err := repo.FlushReadCnt(context.Background())
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantCnt, cnt)
+		})
+	}
+}
+
+func TestCachedReadCntRepository_RollupUV(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	day := time.Date(2023, 11, 11, 10, 0, 0, 0, time.Local)
+	c := cachemocks.NewMockInteractiveCache(ctrl)
+	d := daomocks.NewMockInteractiveDAO(ctrl)
+	gomock.InOrder(
+		c.EXPECT().ScanDailyUV(gomock.Any(), "20231111", uint64(0), int64(uvRollupBatchSize)).
+			Return(uint64(7), []cache.UVCnt{
+				{Biz: "article", BizId: 1, DayUV: 2, TotalUV: 10},
+			}, nil),
+		d.EXPECT().SaveUV(gomock.Any(), "20231111", []dao.UVCnt{
+			{Biz: "article", BizId: 1, DayUV: 2, TotalUV: 10},
+		}).Return(nil),
+		// SSCAN 中间可能会返回空的一批
+		c.EXPECT().ScanDailyUV(gomock.Any(), "20231111", uint64(7), int64(uvRollupBatchSize)).
+			Return(uint64(9), nil, nil),
+		c.EXPECT().ScanDailyUV(gomock.Any(), "20231111", uint64(9), int64(uvRollupBatchSize)).
+			Return(uint64(0), []cache.UVCnt{
+				{Biz: "article", BizId: 2, DayUV: 1, TotalUV: 1},
+			}, nil),
+		d.EXPECT().SaveUV(gomock.Any(), "20231111", []dao.UVCnt{
+			{Biz: "article", BizId: 2, DayUV: 1, TotalUV: 1},
+		}).Return(nil),
+	)
+	repo := NewCachedInteractiveRepository(d, c, &logger.NopLogger{})
+	cnt, err := repo.RollupUV(context.Background(), day)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cnt)
+}
+
+func TestCachedReadCntRepository_AddUV(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	day := time.Date(2023, 11, 11, 23, 59, 0, 0, time.Local)
+	next := day.Add(time.Minute * 2)
+	c := cachemocks.NewMockInteractiveCache(ctrl)
+	d := daomocks.NewMockInteractiveDAO(ctrl)
+	gomock.InOrder(
+		// 总的 UV 过期了的，先把数据库里面存的 HyperLogLog 合并回去，同一个资源只查一次
+		c.EXPECT().MissingUV(gomock.Any(), []string{"article", "article"},
+			[]int64{1, 2}).Return([]bool{false, true}, nil),
+		d.EXPECT().GetUVSketches(gomock.Any(), []string{"article"}, []int64{2}).
+			Return([]dao.InteractiveUVSketch{{Biz: "article", BizId: 2, Sketch: []byte("HYLL")}}, nil),
+		c.EXPECT().RestoreUV(gomock.Any(), "article", int64(2), []byte("HYLL")).Return(nil),
+		// 按照阅读的时间拆到两天里面
+		c.EXPECT().AddUV(gomock.Any(), []string{"article", "article"},
+			[]int64{1, 1}, []int64{11, 13}, "20231111").Return(nil),
+		c.EXPECT().AddUV(gomock.Any(), []string{"article"},
+			[]int64{2}, []int64{12}, "20231112").Return(nil),
+	)
+	repo := NewCachedInteractiveRepository(d, c, &logger.NopLogger{})
+	err := repo.AddUV(context.Background(), []string{"article", "article", "article"},
+		[]int64{1, 2, 1}, []int64{11, 12, 13}, []time.Time{day, next, day})
+	require.NoError(t, err)
+}
+
+func TestCachedReadCntRepository_React(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache)
+
+		reaction    domain.ReactionType
+		wantCreated bool
+		wantErr     error
+	}{
+		{
+			name: "第一次表态，只加计数",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache) {
+				d := daomocks.NewMockInteractiveDAO(ctrl)
+				d.EXPECT().SetReaction(gomock.Any(), "article", int64(1), int64(123), "love").
+					Return("", nil)
+				c := cachemocks.NewMockInteractiveCache(ctrl)
+				c.EXPECT().SwitchReactionIfPresent(gomock.Any(), "article", int64(1),
+					domain.ReactionType(""), domain.ReactionLove).Return(nil)
+				c.EXPECT().PublishChange(gomock.Any(), "article", int64(1)).Return(nil)
+				return d, c
+			},
+			reaction:    domain.ReactionLove,
+			wantCreated: true,
+		},
+		{
+			name: "换一种表态",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache) {
+				d := daomocks.NewMockInteractiveDAO(ctrl)
+				d.EXPECT().SetReaction(gomock.Any(), "article", int64(1), int64(123), "love").
+					Return("like", nil)
+				c := cachemocks.NewMockInteractiveCache(ctrl)
+				c.EXPECT().SwitchReactionIfPresent(gomock.Any(), "article", int64(1),
+					domain.ReactionLike, domain.ReactionLove).Return(nil)
+				// 通知不出去也不影响表态
+				c.EXPECT().PublishChange(gomock.Any(), "article", int64(1)).
+					Return(errors.New("mock redis error"))
+				return d, c
+			},
+			reaction: domain.ReactionLove,
+		},
+		{
+			name: "重复表态，不动缓存",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache) {
+				d := daomocks.NewMockInteractiveDAO(ctrl)
+				d.EXPECT().SetReaction(gomock.Any(), "article", int64(1), int64(123), "love").
+					Return("love", nil)
+				return d, cachemocks.NewMockInteractiveCache(ctrl)
+			},
+			reaction: domain.ReactionLove,
+		},
+		{
+			name: "数据库出错",
+			mock: func(ctrl *gomock.Controller) (dao.InteractiveDAO, cache.InteractiveCache) {
+				d := daomocks.NewMockInteractiveDAO(ctrl)
+				d.EXPECT().SetReaction(gomock.Any(), "article", int64(1), int64(123), "love").
+					Return("", errors.New("mock db error"))
+				return d, cachemocks.NewMockInteractiveCache(ctrl)
+			},
+			reaction: domain.ReactionLove,
+			wantErr:  errors.New("mock db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			d, c := tc.mock(ctrl)
+			repo := NewCachedInteractiveRepository(d, c, &logger.NopLogger{})
+			created, err := repo.React(context.Background(), "article", 1, 123, tc.reaction)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantCreated, created)
+		})
+	}
+}
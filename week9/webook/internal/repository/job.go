@@ -7,18 +7,52 @@ import (
 	"time"
 )
 
+//go:generate mockgen -source=./job.go -package=repomocks -destination=mocks/job.mock.go JobRepository
 type JobRepository interface {
 	Preempt(ctx context.Context, refreshInterval time.Duration) (domain.Job, error)
-	Release(ctx context.Context, id int64) error
+	Release(ctx context.Context, id int64, version int) error
 	UpdateUtime(ctx context.Context, id int64) error
 	UpdateNextTime(ctx context.Context, id int64, next time.Time) error
 	Stop(ctx context.Context, id int64) error
+	// Upsert 保存任务，并且在 next 时刻调度
+	Upsert(ctx context.Context, j domain.Job, next time.Time) error
+	// CreateIfAbsent 同名任务不存在的时候才保存，并且在 next 时刻调度
+	CreateIfAbsent(ctx context.Context, j domain.Job, next time.Time) error
+	StopByName(ctx context.Context, name string) error
 }
 
 type PreemptCronJobRepository struct {
 	dao dao.JobDAO
 }
 
+func NewPreemptCronJobRepository(dao dao.JobDAO) JobRepository {
+	return &PreemptCronJobRepository{dao: dao}
+}
+
+func (p *PreemptCronJobRepository) Upsert(ctx context.Context, j domain.Job, next time.Time) error {
+	return p.dao.Upsert(ctx, dao.Job{
+		Name:     j.Name,
+		Executor: j.Executor,
+		Cfg:      j.Cfg,
+		Cron:     j.Cron,
+		NextTime: next.UnixMilli(),
+	})
+}
+
+func (p *PreemptCronJobRepository) CreateIfAbsent(ctx context.Context, j domain.Job, next time.Time) error {
+	return p.dao.InsertIfAbsent(ctx, dao.Job{
+		Name:     j.Name,
+		Executor: j.Executor,
+		Cfg:      j.Cfg,
+		Cron:     j.Cron,
+		NextTime: next.UnixMilli(),
+	})
+}
+
+func (p *PreemptCronJobRepository) StopByName(ctx context.Context, name string) error {
+	return p.dao.StopByName(ctx, name)
+}
+
 func (p *PreemptCronJobRepository) UpdateUtime(ctx context.Context, id int64) error {
 	return p.dao.UpdateUtime(ctx, id)
 }
@@ -31,8 +65,8 @@ func (p *PreemptCronJobRepository) Stop(ctx context.Context, id int64) error {
 	return p.dao.Stop(ctx, id)
 }
 
-func (p *PreemptCronJobRepository) Release(ctx context.Context, id int64) error {
-	return p.dao.Release(ctx, id)
+func (p *PreemptCronJobRepository) Release(ctx context.Context, id int64, version int) error {
+	return p.dao.Release(ctx, id, version)
 }
 
 func (p *PreemptCronJobRepository) Preempt(ctx context.Context, refreshInterval time.Duration) (domain.Job, error) {
@@ -45,5 +79,7 @@ func (p *PreemptCronJobRepository) Preempt(ctx context.Context, refreshInterval
 		Id:       j.Id,
 		Name:     j.Name,
 		Executor: j.Executor,
+		Cron:     j.Cron,
+		Version:  j.Version,
 	}, nil
 }
@@ -0,0 +1,155 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: feed.go
+//
+// Generated by this command:
+//
+//	mockgen -source=feed.go -package=repomocks -destination=mocks/feed.mock.go
+//
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockFeedRepository is a mock of FeedRepository interface.
+type MockFeedRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockFeedRepositoryMockRecorder
+}
+
+// MockFeedRepositoryMockRecorder is the mock recorder for MockFeedRepository.
+type MockFeedRepositoryMockRecorder struct {
+	mock *MockFeedRepository
+}
+
+// NewMockFeedRepository creates a new mock instance.
+func NewMockFeedRepository(ctrl *gomock.Controller) *MockFeedRepository {
+	mock := &MockFeedRepository{ctrl: ctrl}
+	mock.recorder = &MockFeedRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFeedRepository) EXPECT() *MockFeedRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddToOutbox mocks base method.
+func (m *MockFeedRepository) AddToOutbox(ctx context.Context, uid int64, item domain.FeedItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddToOutbox", ctx, uid, item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddToOutbox indicates an expected call of AddToOutbox.
+func (mr *MockFeedRepositoryMockRecorder) AddToOutbox(ctx, uid, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddToOutbox", reflect.TypeOf((*MockFeedRepository)(nil).AddToOutbox), ctx, uid, item)
+}
+
+// BigAuthors mocks base method.
+func (m *MockFeedRepository) BigAuthors(ctx context.Context) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BigAuthors", ctx)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BigAuthors indicates an expected call of BigAuthors.
+func (mr *MockFeedRepositoryMockRecorder) BigAuthors(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BigAuthors", reflect.TypeOf((*MockFeedRepository)(nil).BigAuthors), ctx)
+}
+
+// ListInbox mocks base method.
+func (m *MockFeedRepository) ListInbox(ctx context.Context, uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FeedItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInbox", ctx, uid, cursor, limit)
+	ret0, _ := ret[0].([]domain.FeedItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInbox indicates an expected call of ListInbox.
+func (mr *MockFeedRepositoryMockRecorder) ListInbox(ctx, uid, cursor, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInbox", reflect.TypeOf((*MockFeedRepository)(nil).ListInbox), ctx, uid, cursor, limit)
+}
+
+// ListOutbox mocks base method.
+func (m *MockFeedRepository) ListOutbox(ctx context.Context, uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FeedItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOutbox", ctx, uid, cursor, limit)
+	ret0, _ := ret[0].([]domain.FeedItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOutbox indicates an expected call of ListOutbox.
+func (mr *MockFeedRepositoryMockRecorder) ListOutbox(ctx, uid, cursor, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOutbox", reflect.TypeOf((*MockFeedRepository)(nil).ListOutbox), ctx, uid, cursor, limit)
+}
+
+// MarkBigAuthor mocks base method.
+func (m *MockFeedRepository) MarkBigAuthor(ctx context.Context, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkBigAuthor", ctx, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkBigAuthor indicates an expected call of MarkBigAuthor.
+func (mr *MockFeedRepositoryMockRecorder) MarkBigAuthor(ctx, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkBigAuthor", reflect.TypeOf((*MockFeedRepository)(nil).MarkBigAuthor), ctx, uid)
+}
+
+// Push mocks base method.
+func (m *MockFeedRepository) Push(ctx context.Context, uids []int64, item domain.FeedItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Push", ctx, uids, item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Push indicates an expected call of Push.
+func (mr *MockFeedRepositoryMockRecorder) Push(ctx, uids, item any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Push", reflect.TypeOf((*MockFeedRepository)(nil).Push), ctx, uids, item)
+}
+
+// Remove mocks base method.
+func (m *MockFeedRepository) Remove(ctx context.Context, uids []int64, aid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", ctx, uids, aid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Remove indicates an expected call of Remove.
+func (mr *MockFeedRepositoryMockRecorder) Remove(ctx, uids, aid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockFeedRepository)(nil).Remove), ctx, uids, aid)
+}
+
+// RemoveFromOutbox mocks base method.
+func (m *MockFeedRepository) RemoveFromOutbox(ctx context.Context, uid, aid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveFromOutbox", ctx, uid, aid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveFromOutbox indicates an expected call of RemoveFromOutbox.
+func (mr *MockFeedRepositoryMockRecorder) RemoveFromOutbox(ctx, uid, aid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFromOutbox", reflect.TypeOf((*MockFeedRepository)(nil).RemoveFromOutbox), ctx, uid, aid)
+}
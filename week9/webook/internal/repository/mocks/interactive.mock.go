@@ -0,0 +1,274 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interactive.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interactive.go -package=repomocks -destination=mocks/interactive.mock.go
+//
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	domain "github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockInteractiveRepository is a mock of InteractiveRepository interface.
+type MockInteractiveRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockInteractiveRepositoryMockRecorder
+}
+
+// MockInteractiveRepositoryMockRecorder is the mock recorder for MockInteractiveRepository.
+type MockInteractiveRepositoryMockRecorder struct {
+	mock *MockInteractiveRepository
+}
+
+// NewMockInteractiveRepository creates a new mock instance.
+func NewMockInteractiveRepository(ctrl *gomock.Controller) *MockInteractiveRepository {
+	mock := &MockInteractiveRepository{ctrl: ctrl}
+	mock.recorder = &MockInteractiveRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInteractiveRepository) EXPECT() *MockInteractiveRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AddCollectionItem mocks base method.
+func (m *MockInteractiveRepository) AddCollectionItem(ctx context.Context, biz string, bizId, cid, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddCollectionItem", ctx, biz, bizId, cid, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddCollectionItem indicates an expected call of AddCollectionItem.
+func (mr *MockInteractiveRepositoryMockRecorder) AddCollectionItem(ctx, biz, bizId, cid, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddCollectionItem", reflect.TypeOf((*MockInteractiveRepository)(nil).AddCollectionItem), ctx, biz, bizId, cid, uid)
+}
+
+// AddUV mocks base method.
+func (m *MockInteractiveRepository) AddUV(ctx context.Context, bizs []string, bizIds, uids []int64, times []time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUV", ctx, bizs, bizIds, uids, times)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddUV indicates an expected call of AddUV.
+func (mr *MockInteractiveRepositoryMockRecorder) AddUV(ctx, bizs, bizIds, uids, times any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUV", reflect.TypeOf((*MockInteractiveRepository)(nil).AddUV), ctx, bizs, bizIds, uids, times)
+}
+
+// BatchIncrReadCnt mocks base method.
+func (m *MockInteractiveRepository) BatchIncrReadCnt(ctx context.Context, biz []string, bizId []int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BatchIncrReadCnt", ctx, biz, bizId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BatchIncrReadCnt indicates an expected call of BatchIncrReadCnt.
+func (mr *MockInteractiveRepositoryMockRecorder) BatchIncrReadCnt(ctx, biz, bizId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BatchIncrReadCnt", reflect.TypeOf((*MockInteractiveRepository)(nil).BatchIncrReadCnt), ctx, biz, bizId)
+}
+
+// CancelReaction mocks base method.
+func (m *MockInteractiveRepository) CancelReaction(ctx context.Context, biz string, bizId, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelReaction", ctx, biz, bizId, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelReaction indicates an expected call of CancelReaction.
+func (mr *MockInteractiveRepositoryMockRecorder) CancelReaction(ctx, biz, bizId, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelReaction", reflect.TypeOf((*MockInteractiveRepository)(nil).CancelReaction), ctx, biz, bizId, uid)
+}
+
+// Collected mocks base method.
+func (m *MockInteractiveRepository) Collected(ctx context.Context, biz string, id, uid int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Collected", ctx, biz, id, uid)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Collected indicates an expected call of Collected.
+func (mr *MockInteractiveRepositoryMockRecorder) Collected(ctx, biz, id, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Collected", reflect.TypeOf((*MockInteractiveRepository)(nil).Collected), ctx, biz, id, uid)
+}
+
+// Delete mocks base method.
+func (m *MockInteractiveRepository) Delete(ctx context.Context, biz string, bizId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, biz, bizId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockInteractiveRepositoryMockRecorder) Delete(ctx, biz, bizId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockInteractiveRepository)(nil).Delete), ctx, biz, bizId)
+}
+
+// DeleteCollectionItem mocks base method.
+func (m *MockInteractiveRepository) DeleteCollectionItem(ctx context.Context, biz string, bizId, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCollectionItem", ctx, biz, bizId, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCollectionItem indicates an expected call of DeleteCollectionItem.
+func (mr *MockInteractiveRepositoryMockRecorder) DeleteCollectionItem(ctx, biz, bizId, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCollectionItem", reflect.TypeOf((*MockInteractiveRepository)(nil).DeleteCollectionItem), ctx, biz, bizId, uid)
+}
+
+// FlushReadCnt mocks base method.
+func (m *MockInteractiveRepository) FlushReadCnt(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushReadCnt", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FlushReadCnt indicates an expected call of FlushReadCnt.
+func (mr *MockInteractiveRepositoryMockRecorder) FlushReadCnt(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushReadCnt", reflect.TypeOf((*MockInteractiveRepository)(nil).FlushReadCnt), ctx)
+}
+
+// Get mocks base method.
+func (m *MockInteractiveRepository) Get(ctx context.Context, biz string, bizId int64) (domain.Interactive, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, biz, bizId)
+	ret0, _ := ret[0].(domain.Interactive)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockInteractiveRepositoryMockRecorder) Get(ctx, biz, bizId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockInteractiveRepository)(nil).Get), ctx, biz, bizId)
+}
+
+// GetByIds mocks base method.
+func (m *MockInteractiveRepository) GetByIds(ctx context.Context, biz string, bizIds []int64) (map[int64]domain.Interactive, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIds", ctx, biz, bizIds)
+	ret0, _ := ret[0].(map[int64]domain.Interactive)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIds indicates an expected call of GetByIds.
+func (mr *MockInteractiveRepositoryMockRecorder) GetByIds(ctx, biz, bizIds any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIds", reflect.TypeOf((*MockInteractiveRepository)(nil).GetByIds), ctx, biz, bizIds)
+}
+
+// IncrReadCnt mocks base method.
+func (m *MockInteractiveRepository) IncrReadCnt(ctx context.Context, biz string, bizId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrReadCnt", ctx, biz, bizId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrReadCnt indicates an expected call of IncrReadCnt.
+func (mr *MockInteractiveRepositoryMockRecorder) IncrReadCnt(ctx, biz, bizId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrReadCnt", reflect.TypeOf((*MockInteractiveRepository)(nil).IncrReadCnt), ctx, biz, bizId)
+}
+
+// ListLikes mocks base method.
+func (m *MockInteractiveRepository) ListLikes(ctx context.Context, uid int64, cursor domain.UserBizCursor, limit int) ([]domain.UserBizRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLikes", ctx, uid, cursor, limit)
+	ret0, _ := ret[0].([]domain.UserBizRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLikes indicates an expected call of ListLikes.
+func (mr *MockInteractiveRepositoryMockRecorder) ListLikes(ctx, uid, cursor, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLikes", reflect.TypeOf((*MockInteractiveRepository)(nil).ListLikes), ctx, uid, cursor, limit)
+}
+
+// React mocks base method.
+func (m *MockInteractiveRepository) React(ctx context.Context, biz string, bizId, uid int64, reaction domain.ReactionType) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "React", ctx, biz, bizId, uid, reaction)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// React indicates an expected call of React.
+func (mr *MockInteractiveRepositoryMockRecorder) React(ctx, biz, bizId, uid, reaction any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "React", reflect.TypeOf((*MockInteractiveRepository)(nil).React), ctx, biz, bizId, uid, reaction)
+}
+
+// Reaction mocks base method.
+func (m *MockInteractiveRepository) Reaction(ctx context.Context, biz string, id, uid int64) (domain.ReactionType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reaction", ctx, biz, id, uid)
+	ret0, _ := ret[0].(domain.ReactionType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reaction indicates an expected call of Reaction.
+func (mr *MockInteractiveRepositoryMockRecorder) Reaction(ctx, biz, id, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reaction", reflect.TypeOf((*MockInteractiveRepository)(nil).Reaction), ctx, biz, id, uid)
+}
+
+// ReconcileCnt mocks base method.
+func (m *MockInteractiveRepository) ReconcileCnt(ctx context.Context, id int64, limit int) (domain.CntReconcileBatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileCnt", ctx, id, limit)
+	ret0, _ := ret[0].(domain.CntReconcileBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReconcileCnt indicates an expected call of ReconcileCnt.
+func (mr *MockInteractiveRepositoryMockRecorder) ReconcileCnt(ctx, id, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileCnt", reflect.TypeOf((*MockInteractiveRepository)(nil).ReconcileCnt), ctx, id, limit)
+}
+
+// RollupUV mocks base method.
+func (m *MockInteractiveRepository) RollupUV(ctx context.Context, day time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollupUV", ctx, day)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RollupUV indicates an expected call of RollupUV.
+func (mr *MockInteractiveRepositoryMockRecorder) RollupUV(ctx, day any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollupUV", reflect.TypeOf((*MockInteractiveRepository)(nil).RollupUV), ctx, day)
+}
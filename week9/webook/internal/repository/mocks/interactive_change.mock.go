@@ -0,0 +1,54 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interactive_change.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interactive_change.go -package=repomocks -destination=mocks/interactive_change.mock.go
+//
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	repository "github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockInteractiveChangeRepository is a mock of InteractiveChangeRepository interface.
+type MockInteractiveChangeRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockInteractiveChangeRepositoryMockRecorder
+}
+
+// MockInteractiveChangeRepositoryMockRecorder is the mock recorder for MockInteractiveChangeRepository.
+type MockInteractiveChangeRepositoryMockRecorder struct {
+	mock *MockInteractiveChangeRepository
+}
+
+// NewMockInteractiveChangeRepository creates a new mock instance.
+func NewMockInteractiveChangeRepository(ctrl *gomock.Controller) *MockInteractiveChangeRepository {
+	mock := &MockInteractiveChangeRepository{ctrl: ctrl}
+	mock.recorder = &MockInteractiveChangeRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInteractiveChangeRepository) EXPECT() *MockInteractiveChangeRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Subscribe mocks base method.
+func (m *MockInteractiveChangeRepository) Subscribe(ctx context.Context) repository.InteractiveSubscription {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx)
+	ret0, _ := ret[0].(repository.InteractiveSubscription)
+	return ret0
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockInteractiveChangeRepositoryMockRecorder) Subscribe(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockInteractiveChangeRepository)(nil).Subscribe), ctx)
+}
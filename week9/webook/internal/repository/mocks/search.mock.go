@@ -0,0 +1,97 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./search.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./search.go -package=repomocks -destination=mocks/search.mock.go SearchRepository
+//
+// Package repomocks is a generated GoMock package.
+package repomocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSearchRepository is a mock of SearchRepository interface.
+type MockSearchRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSearchRepositoryMockRecorder
+}
+
+// MockSearchRepositoryMockRecorder is the mock recorder for MockSearchRepository.
+type MockSearchRepositoryMockRecorder struct {
+	mock *MockSearchRepository
+}
+
+// NewMockSearchRepository creates a new mock instance.
+func NewMockSearchRepository(ctrl *gomock.Controller) *MockSearchRepository {
+	mock := &MockSearchRepository{ctrl: ctrl}
+	mock.recorder = &MockSearchRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSearchRepository) EXPECT() *MockSearchRepositoryMockRecorder {
+	return m.recorder
+}
+
+// DeleteArticle mocks base method.
+func (m *MockSearchRepository) DeleteArticle(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteArticle", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteArticle indicates an expected call of DeleteArticle.
+func (mr *MockSearchRepositoryMockRecorder) DeleteArticle(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteArticle", reflect.TypeOf((*MockSearchRepository)(nil).DeleteArticle), ctx, id)
+}
+
+// InputArticle mocks base method.
+func (m *MockSearchRepository) InputArticle(ctx context.Context, art domain.Article) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InputArticle", ctx, art)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InputArticle indicates an expected call of InputArticle.
+func (mr *MockSearchRepositoryMockRecorder) InputArticle(ctx, art any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InputArticle", reflect.TypeOf((*MockSearchRepository)(nil).InputArticle), ctx, art)
+}
+
+// ReplaceArticles mocks base method.
+func (m *MockSearchRepository) ReplaceArticles(ctx context.Context, arts []domain.Article) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceArticles", ctx, arts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceArticles indicates an expected call of ReplaceArticles.
+func (mr *MockSearchRepositoryMockRecorder) ReplaceArticles(ctx, arts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceArticles", reflect.TypeOf((*MockSearchRepository)(nil).ReplaceArticles), ctx, arts)
+}
+
+// SearchArticle mocks base method.
+func (m *MockSearchRepository) SearchArticle(ctx context.Context, q string, offset, limit int) (domain.ArticleSearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchArticle", ctx, q, offset, limit)
+	ret0, _ := ret[0].(domain.ArticleSearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchArticle indicates an expected call of SearchArticle.
+func (mr *MockSearchRepositoryMockRecorder) SearchArticle(ctx, q, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchArticle", reflect.TypeOf((*MockSearchRepository)(nil).SearchArticle), ctx, q, offset, limit)
+}
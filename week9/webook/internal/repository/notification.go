@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/dao"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"time"
+)
+
+//go:generate mockgen -source=./notification.go -package=repomocks -destination=mocks/notification.mock.go NotificationRepository
+type NotificationRepository interface {
+	// Add 给 uid 发一条通知，有同类的未读通知就合并进去
+	Add(ctx context.Context, uid int64, evt domain.NotificationEvent) error
+	// List 最近有互动的在前面
+	List(ctx context.Context, uid int64,
+		cursor domain.UserBizCursor, limit int) ([]domain.Notification, error)
+	// MarkRead ids 为空的时候全部标记成已读
+	MarkRead(ctx context.Context, uid int64, ids []int64) error
+	UnreadCnt(ctx context.Context, uid int64) (int64, error)
+}
+
+type CachedNotificationRepository struct {
+	dao   dao.NotificationDAO
+	cache cache.NotificationCache
+	l     logger.LoggerV1
+}
+
+func NewCachedNotificationRepository(dao dao.NotificationDAO,
+	cache cache.NotificationCache, l logger.LoggerV1) NotificationRepository {
+	return &CachedNotificationRepository{
+		dao:   dao,
+		cache: cache,
+		l:     l,
+	}
+}
+
+func (c *CachedNotificationRepository) Add(ctx context.Context,
+	uid int64, evt domain.NotificationEvent) error {
+	created, err := c.dao.Add(ctx, dao.Notification{
+		Uid:   uid,
+		Type:  string(evt.Type),
+		Biz:   evt.Biz,
+		BizId: evt.BizId,
+	}, evt.Actor, evt.Time.UnixMilli())
+	if err != nil || !created {
+		// 合并进已有的未读通知，未读数不变
+		return err
+	}
+	return c.cache.DelUnreadCnt(ctx, uid)
+}
+
+func (c *CachedNotificationRepository) List(ctx context.Context,
+	uid int64, cursor domain.UserBizCursor, limit int) ([]domain.Notification, error) {
+	res, err := c.dao.List(ctx, uid, toTimeCursor(cursor), limit)
+	if err != nil {
+		return nil, err
+	}
+	return slice.Map(res, func(idx int, src dao.Notification) domain.Notification {
+		return c.toDomain(src)
+	}), nil
+}
+
+func (c *CachedNotificationRepository) MarkRead(ctx context.Context, uid int64, ids []int64) error {
+	err := c.dao.MarkRead(ctx, uid, ids)
+	if err != nil {
+		return err
+	}
+	return c.cache.DelUnreadCnt(ctx, uid)
+}
+
+func (c *CachedNotificationRepository) UnreadCnt(ctx context.Context, uid int64) (int64, error) {
+	res, err := c.cache.GetUnreadCnt(ctx, uid)
+	if err == nil {
+		return res, nil
+	}
+	res, err = c.dao.UnreadCnt(ctx, uid)
+	if err != nil {
+		return 0, err
+	}
+	err = c.cache.SetUnreadCnt(ctx, uid, res)
+	if err != nil {
+		c.l.Error("回写未读数缓存失败",
+			logger.Int64("uid", uid),
+			logger.Error(err))
+	}
+	return res, nil
+}
+
+func (c *CachedNotificationRepository) toDomain(n dao.Notification) domain.Notification {
+	return domain.Notification{
+		Id:       n.Id,
+		Uid:      n.Uid,
+		Type:     domain.NotificationType(n.Type),
+		Biz:      n.Biz,
+		BizId:    n.BizId,
+		Actors:   n.ActorIds(),
+		ActorCnt: n.ActorCnt,
+		Read:     n.Status == dao.NotificationRead,
+		Time:     time.UnixMilli(n.Utime),
+	}
+}
@@ -4,29 +4,37 @@ import (
 	"context"
 	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
+	"time"
 )
 
+//go:generate mockgen -source=./ranking.go -package=repomocks -destination=mocks/ranking.mock.go RankingRepository
 type RankingRepository interface {
-	ReplaceTopN(ctx context.Context, arts []domain.Article) error
-	GetTopN(ctx context.Context) ([]domain.Article, error)
+	// ReplaceTopN board 是榜单的名字，每个榜单单独存。
+	// expiration 要比这个榜单计算的间隔长
+	ReplaceTopN(ctx context.Context, board string, arts []domain.Article, expiration time.Duration) error
+	GetTopN(ctx context.Context, board string) ([]domain.Article, error)
 }
 
+// localRankingExpiration 从 redis 回填到本地缓存的榜单只留一小会，
+// 过期了再去 redis 拿，redis 里面的才是按照榜单设置了过期时间的
+const localRankingExpiration = time.Minute * 10
+
 type CachedRankingRepository struct {
 	// 使用具体实现，可读性更好，对测试不友好，因为咩有面向接口编程
 	redis *cache.RankingRedisCache
 	local *cache.RankingLocalCache
 }
 
-func (c *CachedRankingRepository) GetTopN(ctx context.Context) ([]domain.Article, error) {
-	data, err := c.local.Get(ctx)
+func (c *CachedRankingRepository) GetTopN(ctx context.Context, board string) ([]domain.Article, error) {
+	data, err := c.local.Get(ctx, board)
 	if err == nil {
 		return data, nil
 	}
-	data, err = c.redis.Get(ctx)
+	data, err = c.redis.Get(ctx, board)
 	if err == nil {
-		c.local.Set(ctx, data)
+		c.local.Set(ctx, board, data, localRankingExpiration)
 	} else {
-		return c.local.ForceGet(ctx)
+		return c.local.ForceGet(ctx, board)
 	}
 	return data, err
 }
@@ -38,7 +46,8 @@ func NewCachedRankingRepository(
 	return &CachedRankingRepository{local: local, redis: redis}
 }
 
-func (c *CachedRankingRepository) ReplaceTopN(ctx context.Context, arts []domain.Article) error {
-	_ = c.local.Set(ctx, arts)
-	return c.redis.Set(ctx, arts)
+func (c *CachedRankingRepository) ReplaceTopN(ctx context.Context, board string,
+	arts []domain.Article, expiration time.Duration) error {
+	_ = c.local.Set(ctx, board, arts, expiration)
+	return c.redis.Set(ctx, board, arts, expiration)
 }
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/searchx"
+)
+
+const (
+	articleFieldTitle   = "title"
+	articleFieldContent = "content"
+	articleFieldAuthor  = "author"
+
+	// 内容片段的长度
+	snippetWidth = 120
+)
+
+//go:generate mockgen -source=./search.go -package=repomocks -destination=mocks/search.mock.go SearchRepository
+type SearchRepository interface {
+	// InputArticle 插入或者更新一篇已发表的帖子
+	InputArticle(ctx context.Context, art domain.Article) error
+	DeleteArticle(ctx context.Context, id int64) error
+	// ReplaceArticles 用 arts 替换掉全部帖子，重建索引的时候使用
+	ReplaceArticles(ctx context.Context, arts []domain.Article) error
+	SearchArticle(ctx context.Context, q string, offset, limit int) (domain.ArticleSearchResult, error)
+}
+
+// InMemorySearchRepository 基于进程内倒排索引的实现，不依赖 Elasticsearch。
+// 代价就是每个实例都有一份完整的索引，重启之后要重建。
+// 写的时候只会更新处理了这个请求的实例，其他实例的索引是旧的，
+// 所以只能单实例部署，多实例部署要换成 Elasticsearch 之类的共享索引
+type InMemorySearchRepository struct {
+	idx *searchx.Index
+}
+
+func NewInMemorySearchRepository() SearchRepository {
+	return &InMemorySearchRepository{
+		// 标题命中比作者命中重要，作者命中比内容命中重要
+		idx: searchx.NewIndex(map[string]float64{
+			articleFieldTitle:   3,
+			articleFieldAuthor:  2,
+			articleFieldContent: 1,
+		}),
+	}
+}
+
+func (r *InMemorySearchRepository) InputArticle(ctx context.Context, art domain.Article) error {
+	r.idx.Put(r.toDoc(art))
+	return nil
+}
+
+func (r *InMemorySearchRepository) DeleteArticle(ctx context.Context, id int64) error {
+	r.idx.Delete(id)
+	return nil
+}
+
+func (r *InMemorySearchRepository) ReplaceArticles(ctx context.Context, arts []domain.Article) error {
+	r.idx.Replace(slice.Map(arts, func(idx int, src domain.Article) searchx.Doc {
+		return r.toDoc(src)
+	}))
+	return nil
+}
+
+func (r *InMemorySearchRepository) SearchArticle(ctx context.Context,
+	q string, offset, limit int) (domain.ArticleSearchResult, error) {
+	hits, total := r.idx.Search(q, offset, limit)
+	return domain.ArticleSearchResult{
+		Total: int64(total),
+		Hits: slice.Map(hits, func(idx int, src searchx.Hit) domain.ArticleSearchHit {
+			art := src.Doc.Data.(domain.Article)
+			return domain.ArticleSearchHit{
+				Article: art,
+				Score:   src.Score,
+				// 标题不截断
+				TitleHighlight: searchx.Highlight(art.Title, q, "<em>", "</em>",
+					len([]rune(art.Title))),
+				ContentHighlight: searchx.Highlight(art.Content, q, "<em>", "</em>",
+					snippetWidth),
+			}
+		}),
+	}, nil
+}
+
+func (r *InMemorySearchRepository) toDoc(art domain.Article) searchx.Doc {
+	return searchx.Doc{
+		Id: art.Id,
+		Fields: map[string]string{
+			articleFieldTitle:   art.Title,
+			articleFieldContent: art.Content,
+			articleFieldAuthor:  art.Author.Name,
+		},
+		Data: art,
+	}
+}
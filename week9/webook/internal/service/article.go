@@ -2,13 +2,58 @@ package service
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ecodeclub/ekit/slice"
 	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
 	events "github.com/gevinzone/basic-go/week9/webook/internal/events/article"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository/article"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/diffx"
 	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/markdownx"
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
+var (
+	ErrRevisionNotMatch = errors.New("历史版本不属于该帖子")
+	// ErrRevisionNotFound 历史版本不存在，或者不是这个作者的
+	ErrRevisionNotFound = article.ErrRevisionNotFound
+	ErrInvalidTags      = errors.New("标签不合法")
+	// ErrArticleVersionConflict 帖子在编辑期间被别的地方修改过了
+	ErrArticleVersionConflict = article.ErrVersionConflict
+	// ErrArticleNotFound 帖子不存在，或者不是这个作者的，或者已经被删除了
+	ErrArticleNotFound   = errors.New("帖子不存在")
+	ErrArticleNotInTrash = errors.New("帖子不在回收站里面")
+	// ErrArticleInTrash 回收站里面的帖子不能修改、发表或者撤回，要先恢复
+	ErrArticleInTrash = article.ErrArticleDeleted
+	// ErrTrashExpired 超过了保留期限，不能再恢复了
+	ErrTrashExpired = errors.New("帖子已经超过回收站的保留期限")
+)
+
+// TrashRetention 帖子在回收站里面保留的时间，超过之后会被彻底删除
+const TrashRetention = time.Hour * 24 * 30
+
+const (
+	// 一篇帖子最多打这么多个标签
+	maxTagCnt = 5
+	// 单个标签最长的字符数
+	maxTagLen = 20
+)
+
+// ScheduledPublishExecutor 定时发表任务的执行器的名字
+const ScheduledPublishExecutor = "article_publish"
+
+// ScheduledPublishCfg 定时发表任务的 Cfg，序列化成 JSON 保存在任务里面
+type ScheduledPublishCfg struct {
+	Aid int64 `json:"aid"`
+	Uid int64 `json:"uid"`
+}
+
 //go:generate mockgen -source=article.go -package=svcmocks -destination=mocks/article.mock.go ArticleService
 type ArticleService interface {
 	Save(ctx context.Context, art domain.Article) (int64, error)
@@ -16,10 +61,48 @@ type ArticleService interface {
 	Publish(ctx context.Context, art domain.Article) (int64, error)
 	PublishV1(ctx context.Context, art domain.Article) (int64, error)
 	List(ctx context.Context, uid int64, offset int, limit int) ([]domain.Article, error)
+	// ListByCursor 作者查看自己的帖子，用游标翻页
+	ListByCursor(ctx context.Context, uid int64, cursor domain.ArticleCursor, limit int) ([]domain.Article, error)
 	// ListPub 根据这个 start 时间来查询
 	ListPub(ctx context.Context, start time.Time, offset, limit int) ([]domain.Article, error)
+	// ListPubByCursor 按照 (utime, id) 倒序遍历已经发表的帖子
+	ListPubByCursor(ctx context.Context, cursor domain.ArticleCursor, limit int) ([]domain.Article, error)
 	GetById(ctx context.Context, id int64) (domain.Article, error)
 	GetPublishedById(ctx context.Context, id, uid int64) (domain.Article, error)
+
+	// ListRevisions 作者查看自己帖子的历史版本
+	ListRevisions(ctx context.Context, uid, id int64, offset, limit int) ([]domain.ArticleRevision, error)
+	GetRevision(ctx context.Context, uid, revId int64) (domain.ArticleRevision, error)
+	// DiffRevisions 对比同一篇帖子的两个历史版本
+	DiffRevisions(ctx context.Context, uid, id, from, to int64) (domain.ArticleRevisionDiff, error)
+	// RestoreRevision 把某个历史版本恢复成当前的草稿
+	RestoreRevision(ctx context.Context, uid, id, revId int64) error
+
+	// ListScheduled 作者查看自己等待定时发表的帖子
+	ListScheduled(ctx context.Context, uid int64, offset, limit int) ([]domain.Article, error)
+	// CancelScheduled 取消定时发表，帖子回到未发表状态
+	CancelScheduled(ctx context.Context, uid, id int64) error
+	// PublishScheduled 定时任务到点之后调用，真正发表帖子
+	PublishScheduled(ctx context.Context, uid, id int64) error
+
+	// ListPubByTag 读者按照标签浏览已经发表的帖子
+	ListPubByTag(ctx context.Context, tag string, offset, limit int) ([]domain.Article, error)
+	// TagCloud 已发表帖子最多的 limit 个标签
+	TagCloud(ctx context.Context, limit int) ([]domain.Tag, error)
+	// ListPubByIds 批量查询已经发表的帖子，结果按照 ids 的顺序排列，
+	// 已经删除或者不可见的帖子会被跳过
+	ListPubByIds(ctx context.Context, ids []int64) ([]domain.Article, error)
+
+	// Delete 把帖子挪到回收站，读者就看不到了
+	Delete(ctx context.Context, uid, id int64) error
+	// ListTrash 作者查看自己回收站里面的帖子，最近删除的在前面
+	ListTrash(ctx context.Context, uid int64, offset, limit int) ([]domain.Article, error)
+	// RestoreFromTrash 在保留期限内恢复帖子，恢复之后是未发表的状态
+	RestoreFromTrash(ctx context.Context, uid, id int64) error
+	// ListExpiredTrash 超过保留期限的帖子，清理回收站的任务用
+	ListExpiredTrash(ctx context.Context, limit int) ([]domain.Article, error)
+	// Purge 彻底删除帖子
+	Purge(ctx context.Context, id int64) error
 }
 
 type articleService struct {
@@ -30,6 +113,10 @@ type articleService struct {
 	reader   article.ArticleReaderRepository
 	l        logger.LoggerV1
 	producer events.Producer
+	// 定时发表依赖于任务调度
+	jobSvc JobService
+	// 发表的时候把 Markdown 渲染成 HTML
+	renderer *markdownx.Renderer
 
 	ch chan readInfo
 }
@@ -47,33 +134,120 @@ type readInfo struct {
 func (svc *articleService) GetPublishedById(ctx context.Context, id, uid int64) (domain.Article, error) {
 	// 另一个选项，在这里组装 Author，调用 UserService
 	art, err := svc.repo.GetPublishedById(ctx, id)
+	if err == nil && art.Status == domain.ArticleStatusDeleted {
+		return domain.Article{}, ErrArticleNotFound
+	}
 	if err == nil {
+		now := time.Now().UnixMilli()
 		go func() {
+			// 请求返回之后 ctx 就取消了，不能用它来发消息
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
 			// 生产者也可以通过改批量来提高性能
 			er := svc.producer.ProduceReadEvent(
 				ctx,
 				events.ReadEvent{
 					// 即便你的消费者要用 art 的里面的数据，
 					// 让它去查询，你不要在 event 里面带
-					Uid: uid,
-					Aid: id,
+					Uid:   uid,
+					Aid:   id,
+					Ctime: now,
 				})
-			if er == nil {
-				svc.l.Error("发送读者阅读事件失败")
+			if er != nil {
+				svc.l.Error("发送读者阅读事件失败",
+					logger.Int64("aid", id),
+					logger.Error(er))
 			}
 		}()
 
-		go func() {
-			// 改批量的做法
-			svc.ch <- readInfo{
-				aid: id,
-				uid: uid,
-			}
-		}()
+		// 只有 NewArticleServiceV2 才有 ch，往 nil channel 里面发会永远阻塞
+		if svc.ch != nil {
+			go func() {
+				// 改批量的做法
+				svc.ch <- readInfo{
+					aid: id,
+					uid: uid,
+				}
+			}()
+		}
 	}
 	return art, err
 }
 
+func (a *articleService) ListRevisions(ctx context.Context,
+	uid, id int64, offset, limit int) ([]domain.ArticleRevision, error) {
+	return a.repo.ListRevisions(ctx, uid, id, offset, limit)
+}
+
+func (a *articleService) GetRevision(ctx context.Context,
+	uid, revId int64) (domain.ArticleRevision, error) {
+	return a.repo.GetRevision(ctx, uid, revId)
+}
+
+func (a *articleService) DiffRevisions(ctx context.Context,
+	uid, id, from, to int64) (domain.ArticleRevisionDiff, error) {
+	var (
+		eg             errgroup.Group
+		fromRev, toRev domain.ArticleRevision
+	)
+	eg.Go(func() error {
+		var err error
+		fromRev, err = a.revisionOf(ctx, uid, id, from)
+		return err
+	})
+	eg.Go(func() error {
+		var err error
+		toRev, err = a.revisionOf(ctx, uid, id, to)
+		return err
+	})
+	if err := eg.Wait(); err != nil {
+		return domain.ArticleRevisionDiff{}, err
+	}
+	return domain.ArticleRevisionDiff{
+		From:    fromRev,
+		To:      toRev,
+		Title:   diffx.Lines(fromRev.Title, toRev.Title),
+		Content: diffx.Lines(fromRev.Content, toRev.Content),
+	}, nil
+}
+
+func (a *articleService) RestoreRevision(ctx context.Context, uid, id, revId int64) error {
+	rev, err := a.revisionOf(ctx, uid, id, revId)
+	if err != nil {
+		return err
+	}
+	// 历史版本里面没有标签，沿用当前的标签
+	cur, err := a.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	// 恢复之后就是一份新的草稿，走的是和 Save 一样的路径
+	// 所以 UpdateById 里面的 author_id 校验依旧生效，并且会生成一个新的版本
+	return a.repo.Update(ctx, domain.Article{
+		Id:      id,
+		Title:   rev.Title,
+		Content: rev.Content,
+		Author: domain.Author{
+			Id: uid,
+		},
+		Tags:   cur.Tags,
+		Status: domain.ArticleStatusUnpublished,
+	})
+}
+
+// revisionOf 查找版本，并且确认这个版本确实属于这篇帖子
+func (a *articleService) revisionOf(ctx context.Context,
+	uid, id, revId int64) (domain.ArticleRevision, error) {
+	rev, err := a.repo.GetRevision(ctx, uid, revId)
+	if err != nil {
+		return domain.ArticleRevision{}, err
+	}
+	if rev.ArticleId != id {
+		return domain.ArticleRevision{}, ErrRevisionNotMatch
+	}
+	return rev, nil
+}
+
 func (a *articleService) GetById(ctx context.Context, id int64) (domain.Article, error) {
 	return a.repo.GetByID(ctx, id)
 }
@@ -82,18 +256,308 @@ func (a *articleService) List(ctx context.Context, uid int64, offset int, limit
 	return a.repo.List(ctx, uid, offset, limit)
 }
 
+func (a *articleService) ListByCursor(ctx context.Context,
+	uid int64, cursor domain.ArticleCursor, limit int) ([]domain.Article, error) {
+	return a.repo.ListByCursor(ctx, uid, cursor, limit)
+}
+
+func (a *articleService) ListPubByCursor(ctx context.Context,
+	cursor domain.ArticleCursor, limit int) ([]domain.Article, error) {
+	return a.repo.ListPubByCursor(ctx, cursor, limit)
+}
+
 func (a *articleService) Withdraw(ctx context.Context, art domain.Article) error {
 	// art.Status = domain.ArticleStatusPrivate 然后直接把整个 art 往下传
-	return a.repo.SyncStatus(ctx, art.Id, art.Author.Id, domain.ArticleStatusPrivate)
+	err := a.repo.SyncStatus(ctx, art.Id, art.Author.Id, domain.ArticleStatusPrivate)
+	if err != nil {
+		return err
+	}
+	a.producePublishEvent(ctx, art.Id, art.Author.Id, false)
+	return nil
+}
+
+// producePublishEvent 帖子的状态已经改好了，事件发不出去也不影响结果，
+// 只是关注者的 feed 流里面会少一条，或者多一条看不了的
+func (a *articleService) producePublishEvent(ctx context.Context, aid, uid int64, publish bool) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	ctime := time.Now()
+	if publish {
+		ctime = a.firstPublishTime(ctx, aid, ctime)
+	}
+	err := a.producer.ProducePublishEvent(ctx, events.PublishEvent{
+		Aid:     aid,
+		Uid:     uid,
+		Publish: publish,
+		Ctime:   ctime.UnixMilli(),
+	})
+	if err != nil {
+		a.l.Error("发送帖子发表事件失败",
+			logger.Int64("aid", aid),
+			logger.Error(err))
+	}
+}
+
+// firstPublishTime 修改之后重新发表，或者撤回之后重新发表，
+// feed 流里面都还是按照第一次发表的时间排，不然老帖子改一下就跑到最前面去了。
+// 线上库的 Ctime 就是第一次发表的时间，查不到就用 def
+func (a *articleService) firstPublishTime(ctx context.Context, aid int64, def time.Time) time.Time {
+	art, err := a.repo.GetPublishedById(ctx, aid)
+	if err != nil {
+		a.l.Error("查询帖子第一次发表的时间失败",
+			logger.Int64("aid", aid),
+			logger.Error(err))
+		return def
+	}
+	if art.Ctime.UnixMilli() <= 0 {
+		return def
+	}
+	return art.Ctime
 }
 
 func (a *articleService) Publish(ctx context.Context, art domain.Article) (int64, error) {
+	tags, err := normalizeTags(art.Tags)
+	if err != nil {
+		return 0, err
+	}
+	art.Tags = tags
+	if art.PublishAt.After(time.Now()) {
+		return a.schedule(ctx, art)
+	}
+	art.PublishAt = time.Time{}
 	art.Status = domain.ArticleStatusPublished
+	art, err = a.render(art)
+	if err != nil {
+		return 0, err
+	}
 	// 制作库
 	//id, err := a.repo.Create(ctx, art)
 	//// 线上库呢？
 	//a.repo.SyncToLiveDB(ctx, art)
-	return a.repo.Sync(ctx, art)
+	id, err := a.repo.Sync(ctx, art)
+	if err != nil {
+		return 0, err
+	}
+	a.producePublishEvent(ctx, id, art.Author.Id, true)
+	return id, nil
+}
+
+// schedule 先把帖子保存为定时发表状态，再注册一个到点执行的任务
+func (a *articleService) schedule(ctx context.Context, art domain.Article) (int64, error) {
+	art.Status = domain.ArticleStatusScheduled
+	var (
+		id  = art.Id
+		err error
+	)
+	if id > 0 {
+		err = a.repo.Update(ctx, art)
+	} else {
+		id, err = a.repo.Create(ctx, art)
+	}
+	if err != nil {
+		return 0, err
+	}
+	cfg, err := json.Marshal(ScheduledPublishCfg{Aid: id, Uid: art.Author.Id})
+	if err != nil {
+		return 0, err
+	}
+	err = a.jobSvc.ScheduleOnce(ctx, domain.Job{
+		Name:     scheduledPublishJobName(id),
+		Executor: ScheduledPublishExecutor,
+		Cfg:      string(cfg),
+	}, art.PublishAt)
+	if err != nil {
+		// 任务没注册上，帖子不能一直停留在定时发表状态
+		// 尽力改回未发表，失败了也只能靠作者自己取消
+		if er := a.repo.CancelSchedule(ctx, art.Author.Id, id); er != nil {
+			a.l.Error("注册定时发表任务失败，并且回滚帖子状态失败",
+				logger.Int64("aid", id),
+				logger.Error(er))
+		}
+		return 0, err
+	}
+	return id, nil
+}
+
+func (a *articleService) ListScheduled(ctx context.Context,
+	uid int64, offset, limit int) ([]domain.Article, error) {
+	return a.repo.ListScheduled(ctx, uid, offset, limit)
+}
+
+func (a *articleService) CancelScheduled(ctx context.Context, uid, id int64) error {
+	// 先改状态，这一步会校验作者。
+	// 即便任务没有停掉，PublishScheduled 也会因为状态不对而跳过
+	err := a.repo.CancelSchedule(ctx, uid, id)
+	if err != nil {
+		return err
+	}
+	return a.jobSvc.Cancel(ctx, scheduledPublishJobName(id))
+}
+
+func (a *articleService) PublishScheduled(ctx context.Context, uid, id int64) error {
+	art, err := a.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	// 作者可能已经取消了，或者已经手动发表了，这时候什么都不用做
+	if art.Status != domain.ArticleStatusScheduled || art.Author.Id != uid {
+		a.l.Info("帖子不再需要定时发表",
+			logger.Int64("aid", id),
+			logger.Int64("uid", uid))
+		return nil
+	}
+	art.Status = domain.ArticleStatusPublished
+	art.PublishAt = time.Time{}
+	art, err = a.render(art)
+	if err != nil {
+		return err
+	}
+	_, err = a.repo.Sync(ctx, art)
+	if err != nil {
+		return err
+	}
+	a.producePublishEvent(ctx, id, uid, true)
+	return nil
+}
+
+// render 把内容渲染成过滤过的 HTML，顺便生成目录、字数和阅读时间
+func (a *articleService) render(art domain.Article) (domain.Article, error) {
+	res, err := a.renderer.Render(art.Content)
+	if err != nil {
+		return domain.Article{}, fmt.Errorf("渲染帖子失败 %w", err)
+	}
+	art.HTML = res.HTML
+	art.TOC = slice.Map(res.TOC, func(idx int, src markdownx.Heading) domain.ArticleHeading {
+		return domain.ArticleHeading{Level: src.Level, Text: src.Text, Id: src.Id}
+	})
+	art.WordCnt = res.WordCnt
+	art.ReadMinutes = res.ReadMinutes
+	return art, nil
+}
+
+func (a *articleService) Delete(ctx context.Context, uid, id int64) error {
+	art, err := a.repo.GetByID(ctx, id)
+	if err == article.ErrArticleNotFound {
+		return ErrArticleNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if art.Author.Id != uid || art.Status == domain.ArticleStatusDeleted {
+		return ErrArticleNotFound
+	}
+	err = a.repo.SoftDelete(ctx, uid, id)
+	if err == article.ErrPossibleIncorrectAuthor {
+		// 并发删除
+		return ErrArticleNotFound
+	}
+	if err != nil || art.Status != domain.ArticleStatusScheduled {
+		return err
+	}
+	// 任务没停掉也没关系，PublishScheduled 会因为状态不对而跳过
+	if er := a.jobSvc.Cancel(ctx, scheduledPublishJobName(id)); er != nil {
+		a.l.Error("删除帖子之后取消定时发表任务失败",
+			logger.Int64("aid", id),
+			logger.Error(er))
+	}
+	return nil
+}
+
+func (a *articleService) ListTrash(ctx context.Context,
+	uid int64, offset, limit int) ([]domain.Article, error) {
+	return a.repo.ListDeleted(ctx, uid, offset, limit)
+}
+
+func (a *articleService) RestoreFromTrash(ctx context.Context, uid, id int64) error {
+	art, err := a.repo.GetByID(ctx, id)
+	if err == article.ErrArticleNotFound {
+		return ErrArticleNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if art.Author.Id != uid {
+		return ErrArticleNotFound
+	}
+	if art.Status != domain.ArticleStatusDeleted {
+		return ErrArticleNotInTrash
+	}
+	// 清理任务不是准点跑的，所以要自己校验时间
+	if time.Since(art.DeletedAt) > TrashRetention {
+		return ErrTrashExpired
+	}
+	err = a.repo.Restore(ctx, uid, id)
+	if err == article.ErrPossibleIncorrectAuthor {
+		// 并发恢复
+		return ErrArticleNotInTrash
+	}
+	return err
+}
+
+func (a *articleService) ListExpiredTrash(ctx context.Context, limit int) ([]domain.Article, error) {
+	return a.repo.ListDeletedBefore(ctx, time.Now().Add(-TrashRetention), limit)
+}
+
+func (a *articleService) Purge(ctx context.Context, id int64) error {
+	return a.repo.Purge(ctx, id)
+}
+
+func scheduledPublishJobName(aid int64) string {
+	return fmt.Sprintf("%s:%d", ScheduledPublishExecutor, aid)
+}
+
+func (a *articleService) ListPubByTag(ctx context.Context,
+	tag string, offset, limit int) ([]domain.Article, error) {
+	return a.repo.ListPubByTag(ctx, strings.ToLower(strings.TrimSpace(tag)), offset, limit)
+}
+
+func (a *articleService) TagCloud(ctx context.Context, limit int) ([]domain.Tag, error) {
+	return a.repo.TagCloud(ctx, limit)
+}
+
+func (a *articleService) ListPubByIds(ctx context.Context, ids []int64) ([]domain.Article, error) {
+	if len(ids) == 0 {
+		return []domain.Article{}, nil
+	}
+	arts, err := a.repo.ListPubByIds(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[int64]domain.Article, len(arts))
+	for _, art := range arts {
+		m[art.Id] = art
+	}
+	res := make([]domain.Article, 0, len(arts))
+	for _, id := range ids {
+		if art, ok := m[id]; ok {
+			res = append(res, art)
+		}
+	}
+	return res, nil
+}
+
+// normalizeTags 去掉空白，统一小写并且去重，保持原本的顺序
+func normalizeTags(tags []string) ([]string, error) {
+	res := make([]string, 0, len(tags))
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		if utf8.RuneCountInString(tag) > maxTagLen {
+			return nil, ErrInvalidTags
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		res = append(res, tag)
+	}
+	if len(res) > maxTagCnt {
+		return nil, ErrInvalidTags
+	}
+	return res, nil
 }
 
 func (a *articleService) PublishV1(ctx context.Context, art domain.Article) (int64, error) {
@@ -134,11 +598,14 @@ func (a *articleService) PublishV1(ctx context.Context, art domain.Article) (int
 
 func NewArticleService(repo article.ArticleRepository,
 	l logger.LoggerV1,
-	producer events.Producer) ArticleService {
+	producer events.Producer,
+	jobSvc JobService) ArticleService {
 	return &articleService{
 		repo:     repo,
 		producer: producer,
 		l:        l,
+		jobSvc:   jobSvc,
+		renderer: markdownx.NewRenderer(),
 		//ch:       make(chan readInfo, 10),
 	}
 }
@@ -168,8 +635,10 @@ func NewArticleServiceV2(repo article.ArticleRepository,
 			cancel()
 			ctx, cancel = context.WithTimeout(context.Background(), time.Second)
 			producer.ProduceReadEventV1(ctx, events.ReadEventV1{
-				Uids: uids,
-				Aids: aids,
+				// 一批一个 EventId
+				EventId: uuid.New().String(),
+				Uids:    uids,
+				Aids:    aids,
 			})
 			cancel()
 		}
@@ -192,6 +661,11 @@ func NewArticleServiceV1(author article.ArticleAuthorRepository,
 }
 
 func (a *articleService) Save(ctx context.Context, art domain.Article) (int64, error) {
+	tags, err := normalizeTags(art.Tags)
+	if err != nil {
+		return 0, err
+	}
+	art.Tags = tags
 	art.Status = domain.ArticleStatusUnpublished
 	if art.Id > 0 {
 		err := a.repo.Update(ctx, art)
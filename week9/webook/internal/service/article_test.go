@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	events "github.com/gevinzone/basic-go/week9/webook/internal/events/article"
+	evtmocks "github.com/gevinzone/basic-go/week9/webook/internal/events/article/mocks"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository/article"
 	artrepomocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/article/mocks"
 	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"testing"
+	"time"
 )
 
 func Test_articleService_Publish(t *testing.T) {
@@ -217,3 +220,384 @@ func Test_articleService_Publish(t *testing.T) {
 		})
 	}
 }
+
+func Test_articleService_RestoreRevision(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) article.ArticleRepository
+
+		uid   int64
+		id    int64
+		revId int64
+
+		wantErr error
+	}{
+		{
+			name: "恢复成功",
+			mock: func(ctrl *gomock.Controller) article.ArticleRepository {
+				repo := artrepomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetRevision(gomock.Any(), int64(123), int64(11)).
+					Return(domain.ArticleRevision{
+						Id:        11,
+						ArticleId: 1,
+						Title:     "旧的标题",
+						Content:   "旧的内容",
+						Author:    domain.Author{Id: 123},
+						Status:    domain.ArticleStatusPublished,
+					}, nil)
+				repo.EXPECT().GetByID(gomock.Any(), int64(1)).
+					Return(domain.Article{
+						Id:   1,
+						Tags: []string{"go"},
+					}, nil)
+				// 恢复成草稿，标签不变
+				repo.EXPECT().Update(gomock.Any(), domain.Article{
+					Id:      1,
+					Title:   "旧的标题",
+					Content: "旧的内容",
+					Author:  domain.Author{Id: 123},
+					Tags:    []string{"go"},
+					Status:  domain.ArticleStatusUnpublished,
+				}).Return(nil)
+				return repo
+			},
+			uid:   123,
+			id:    1,
+			revId: 11,
+		},
+		{
+			name: "版本不属于这篇帖子",
+			mock: func(ctrl *gomock.Controller) article.ArticleRepository {
+				repo := artrepomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetRevision(gomock.Any(), int64(123), int64(11)).
+					Return(domain.ArticleRevision{
+						Id:        11,
+						ArticleId: 2,
+					}, nil)
+				return repo
+			},
+			uid:     123,
+			id:      1,
+			revId:   11,
+			wantErr: ErrRevisionNotMatch,
+		},
+		{
+			name: "查询版本失败",
+			mock: func(ctrl *gomock.Controller) article.ArticleRepository {
+				repo := artrepomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetRevision(gomock.Any(), int64(123), int64(11)).
+					Return(domain.ArticleRevision{}, errors.New("mock db error"))
+				return repo
+			},
+			uid:     123,
+			id:      1,
+			revId:   11,
+			wantErr: errors.New("mock db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewArticleService(tc.mock(ctrl), &logger.NopLogger{}, nil, nil)
+			err := svc.RestoreRevision(context.Background(), tc.uid, tc.id, tc.revId)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func Test_articleService_PublishScheduled(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (article.ArticleRepository, events.Producer)
+
+		uid int64
+		id  int64
+
+		wantErr error
+	}{
+		{
+			name: "到点发表",
+			mock: func(ctrl *gomock.Controller) (article.ArticleRepository, events.Producer) {
+				repo := artrepomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetByID(gomock.Any(), int64(1)).
+					Return(domain.Article{
+						Id:        1,
+						Title:     "我的标题",
+						Content:   "我的内容",
+						Author:    domain.Author{Id: 123},
+						Status:    domain.ArticleStatusScheduled,
+						PublishAt: time.UnixMilli(100),
+					}, nil)
+				repo.EXPECT().Sync(gomock.Any(), domain.Article{
+					Id:      1,
+					Title:   "我的标题",
+					Content: "我的内容",
+					Author:  domain.Author{Id: 123},
+					Status:  domain.ArticleStatusPublished,
+					// 发表之前会渲染
+					HTML:        "<p>我的内容</p>\n",
+					TOC:         []domain.ArticleHeading{},
+					WordCnt:     4,
+					ReadMinutes: 1,
+				}).Return(int64(1), nil)
+				// 以前发表过，用第一次发表的时间
+				repo.EXPECT().GetPublishedById(gomock.Any(), int64(1)).
+					Return(domain.Article{Id: 1, Ctime: time.UnixMilli(50)}, nil)
+				producer := evtmocks.NewMockProducer(ctrl)
+				producer.EXPECT().ProducePublishEvent(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, evt events.PublishEvent) error {
+						assert.Equal(t, int64(1), evt.Aid)
+						assert.Equal(t, int64(123), evt.Uid)
+						assert.True(t, evt.Publish)
+						assert.Equal(t, int64(50), evt.Ctime)
+						return nil
+					})
+				return repo, producer
+			},
+			uid: 123,
+			id:  1,
+		},
+		{
+			name: "已经取消了",
+			mock: func(ctrl *gomock.Controller) (article.ArticleRepository, events.Producer) {
+				repo := artrepomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetByID(gomock.Any(), int64(1)).
+					Return(domain.Article{
+						Id:     1,
+						Author: domain.Author{Id: 123},
+						Status: domain.ArticleStatusUnpublished,
+					}, nil)
+				return repo, nil
+			},
+			uid: 123,
+			id:  1,
+		},
+		{
+			name: "作者不对",
+			mock: func(ctrl *gomock.Controller) (article.ArticleRepository, events.Producer) {
+				repo := artrepomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetByID(gomock.Any(), int64(1)).
+					Return(domain.Article{
+						Id:     1,
+						Author: domain.Author{Id: 234},
+						Status: domain.ArticleStatusScheduled,
+					}, nil)
+				return repo, nil
+			},
+			uid: 123,
+			id:  1,
+		},
+		{
+			name: "查询帖子失败",
+			mock: func(ctrl *gomock.Controller) (article.ArticleRepository, events.Producer) {
+				repo := artrepomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetByID(gomock.Any(), int64(1)).
+					Return(domain.Article{}, errors.New("mock db error"))
+				return repo, nil
+			},
+			uid:     123,
+			id:      1,
+			wantErr: errors.New("mock db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo, producer := tc.mock(ctrl)
+			svc := NewArticleService(repo, &logger.NopLogger{}, producer, nil)
+			err := svc.PublishScheduled(context.Background(), tc.uid, tc.id)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func Test_articleService_RestoreFromTrash(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) article.ArticleRepository
+
+		uid int64
+		id  int64
+
+		wantErr error
+	}{
+		{
+			name: "恢复成功",
+			mock: func(ctrl *gomock.Controller) article.ArticleRepository {
+				repo := artrepomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetByID(gomock.Any(), int64(1)).
+					Return(domain.Article{
+						Id:        1,
+						Author:    domain.Author{Id: 123},
+						Status:    domain.ArticleStatusDeleted,
+						DeletedAt: time.Now().Add(-time.Hour),
+					}, nil)
+				repo.EXPECT().Restore(gomock.Any(), int64(123), int64(1)).Return(nil)
+				return repo
+			},
+			uid: 123,
+			id:  1,
+		},
+		{
+			name: "超过保留期限",
+			mock: func(ctrl *gomock.Controller) article.ArticleRepository {
+				repo := artrepomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetByID(gomock.Any(), int64(1)).
+					Return(domain.Article{
+						Id:        1,
+						Author:    domain.Author{Id: 123},
+						Status:    domain.ArticleStatusDeleted,
+						DeletedAt: time.Now().Add(-TrashRetention - time.Hour),
+					}, nil)
+				return repo
+			},
+			uid:     123,
+			id:      1,
+			wantErr: ErrTrashExpired,
+		},
+		{
+			name: "不在回收站里面",
+			mock: func(ctrl *gomock.Controller) article.ArticleRepository {
+				repo := artrepomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetByID(gomock.Any(), int64(1)).
+					Return(domain.Article{
+						Id:     1,
+						Author: domain.Author{Id: 123},
+						Status: domain.ArticleStatusPublished,
+					}, nil)
+				return repo
+			},
+			uid:     123,
+			id:      1,
+			wantErr: ErrArticleNotInTrash,
+		},
+		{
+			name: "作者不对",
+			mock: func(ctrl *gomock.Controller) article.ArticleRepository {
+				repo := artrepomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetByID(gomock.Any(), int64(1)).
+					Return(domain.Article{
+						Id:        1,
+						Author:    domain.Author{Id: 234},
+						Status:    domain.ArticleStatusDeleted,
+						DeletedAt: time.Now(),
+					}, nil)
+				return repo
+			},
+			uid:     123,
+			id:      1,
+			wantErr: ErrArticleNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewArticleService(tc.mock(ctrl), &logger.NopLogger{}, nil, nil)
+			err := svc.RestoreFromTrash(context.Background(), tc.uid, tc.id)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func Test_articleService_Delete(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) article.ArticleRepository
+
+		wantErr error
+	}{
+		{
+			name: "删除成功",
+			mock: func(ctrl *gomock.Controller) article.ArticleRepository {
+				repo := artrepomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetByID(gomock.Any(), int64(1)).
+					Return(domain.Article{
+						Id:     1,
+						Author: domain.Author{Id: 123},
+						Status: domain.ArticleStatusPublished,
+					}, nil)
+				repo.EXPECT().SoftDelete(gomock.Any(), int64(123), int64(1)).Return(nil)
+				return repo
+			},
+		},
+		{
+			name: "帖子不存在",
+			mock: func(ctrl *gomock.Controller) article.ArticleRepository {
+				repo := artrepomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetByID(gomock.Any(), int64(1)).
+					Return(domain.Article{}, article.ErrArticleNotFound)
+				return repo
+			},
+			wantErr: ErrArticleNotFound,
+		},
+		{
+			name: "作者不对",
+			mock: func(ctrl *gomock.Controller) article.ArticleRepository {
+				repo := artrepomocks.NewMockArticleRepository(ctrl)
+				repo.EXPECT().GetByID(gomock.Any(), int64(1)).
+					Return(domain.Article{
+						Id:     1,
+						Author: domain.Author{Id: 234},
+						Status: domain.ArticleStatusPublished,
+					}, nil)
+				return repo
+			},
+			wantErr: ErrArticleNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewArticleService(tc.mock(ctrl), &logger.NopLogger{}, nil, nil)
+			err := svc.Delete(context.Background(), 123, 1)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func Test_normalizeTags(t *testing.T) {
+	testCases := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr error
+	}{
+		{
+			name: "去掉空白并且去重",
+			tags: []string{" Go ", "go", "", "MySQL"},
+			want: []string{"go", "mysql"},
+		},
+		{
+			name: "没有标签",
+			want: []string{},
+		},
+		{
+			name:    "标签太多",
+			tags:    []string{"a", "b", "c", "d", "e", "f"},
+			wantErr: ErrInvalidTags,
+		},
+		{
+			name:    "标签太长",
+			tags:    []string{"这是一个非常非常非常非常非常非常非常长的标签"},
+			wantErr: ErrInvalidTags,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := normalizeTags(tc.tags)
+			assert.Equal(t, tc.wantErr, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tc.want, res)
+		})
+	}
+}
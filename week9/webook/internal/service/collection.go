@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrCollectionNotFound 收藏夹不存在，或者不是自己的
+	ErrCollectionNotFound    = errors.New("收藏夹不存在")
+	ErrInvalidCollectionName = errors.New("收藏夹名字不合法")
+	ErrNotCollected          = errors.New("没有收藏")
+)
+
+// 收藏夹名字最长的字符数
+const maxCollectionNameLen = 64
+
+//go:generate mockgen -source=collection.go -package=svcmocks -destination=mocks/collection.mock.go CollectionService
+type CollectionService interface {
+	Create(ctx context.Context, uid int64, name string) (int64, error)
+	Rename(ctx context.Context, uid, id int64, name string) error
+	// Delete 删除收藏夹，里面的东西挪到默认收藏夹
+	Delete(ctx context.Context, uid, id int64) error
+	// List 用户自己创建的收藏夹，不包含默认收藏夹
+	List(ctx context.Context, uid int64) ([]domain.Collection, error)
+	// ListItems cid 为 0 的时候是默认收藏夹
+	ListItems(ctx context.Context, uid, cid int64, offset, limit int) ([]domain.CollectionItem, error)
+	// ListAllItems 不分收藏夹和 biz，用户收藏过的所有东西，最近收藏的在前面
+	ListAllItems(ctx context.Context, uid int64,
+		cursor domain.UserBizCursor, limit int) ([]domain.UserBizRecord, error)
+	// Move 把收藏的东西挪到另外一个收藏夹
+	Move(ctx context.Context, uid int64, biz string, bizId, cid int64) error
+}
+
+type collectionService struct {
+	repo repository.CollectionRepository
+}
+
+func NewCollectionService(repo repository.CollectionRepository) CollectionService {
+	return &collectionService{repo: repo}
+}
+
+func (s *collectionService) Create(ctx context.Context, uid int64, name string) (int64, error) {
+	name, err := normalizeCollectionName(name)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.Create(ctx, domain.Collection{
+		Name: name,
+		Uid:  uid,
+	})
+}
+
+func (s *collectionService) Rename(ctx context.Context, uid, id int64, name string) error {
+	name, err := normalizeCollectionName(name)
+	if err != nil {
+		return err
+	}
+	err = s.repo.Rename(ctx, uid, id, name)
+	if err == repository.ErrCollectionNotFound {
+		return ErrCollectionNotFound
+	}
+	return err
+}
+
+func (s *collectionService) Delete(ctx context.Context, uid, id int64) error {
+	err := s.repo.Delete(ctx, uid, id)
+	if err == repository.ErrCollectionNotFound {
+		return ErrCollectionNotFound
+	}
+	return err
+}
+
+func (s *collectionService) List(ctx context.Context, uid int64) ([]domain.Collection, error) {
+	return s.repo.List(ctx, uid)
+}
+
+func (s *collectionService) ListItems(ctx context.Context,
+	uid, cid int64, offset, limit int) ([]domain.CollectionItem, error) {
+	err := checkCollectionOwner(ctx, s.repo, uid, cid)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListItems(ctx, uid, cid, offset, limit)
+}
+
+func (s *collectionService) ListAllItems(ctx context.Context,
+	uid int64, cursor domain.UserBizCursor, limit int) ([]domain.UserBizRecord, error) {
+	return s.repo.ListAllItems(ctx, uid, cursor, limit)
+}
+
+func (s *collectionService) Move(ctx context.Context,
+	uid int64, biz string, bizId, cid int64) error {
+	err := checkCollectionOwner(ctx, s.repo, uid, cid)
+	if err != nil {
+		return err
+	}
+	err = s.repo.MoveItem(ctx, uid, biz, bizId, cid)
+	if err == repository.ErrCollectionNotFound {
+		return ErrNotCollected
+	}
+	return err
+}
+
+// checkCollectionOwner 默认收藏夹谁都可以用，别的收藏夹只能是自己的
+func checkCollectionOwner(ctx context.Context,
+	repo repository.CollectionRepository, uid, cid int64) error {
+	if cid == 0 {
+		return nil
+	}
+	c, err := repo.GetById(ctx, cid)
+	switch {
+	case err == repository.ErrCollectionNotFound:
+		return ErrCollectionNotFound
+	case err != nil:
+		return err
+	case c.Uid != uid:
+		// 不告诉别人这个收藏夹存在
+		return ErrCollectionNotFound
+	default:
+		return nil
+	}
+}
+
+func normalizeCollectionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxCollectionNameLen {
+		return "", ErrInvalidCollectionName
+	}
+	return name, nil
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	events "github.com/gevinzone/basic-go/week9/webook/internal/events/interactive"
+	evtmocks "github.com/gevinzone/basic-go/week9/webook/internal/events/interactive/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	repomocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func Test_interactiveService_Collect(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.InteractiveRepository,
+			repository.CollectionRepository)
+
+		cid int64
+
+		wantErr error
+	}{
+		{
+			name: "收藏到默认收藏夹",
+			mock: func(ctrl *gomock.Controller) (repository.InteractiveRepository,
+				repository.CollectionRepository) {
+				repo := repomocks.NewMockInteractiveRepository(ctrl)
+				repo.EXPECT().AddCollectionItem(gomock.Any(), "article",
+					int64(1), int64(0), int64(123)).Return(nil)
+				return repo, repomocks.NewMockCollectionRepository(ctrl)
+			},
+		},
+		{
+			name: "收藏到自己的收藏夹",
+			mock: func(ctrl *gomock.Controller) (repository.InteractiveRepository,
+				repository.CollectionRepository) {
+				collRepo := repomocks.NewMockCollectionRepository(ctrl)
+				collRepo.EXPECT().GetById(gomock.Any(), int64(10)).
+					Return(domain.Collection{Id: 10, Uid: 123}, nil)
+				repo := repomocks.NewMockInteractiveRepository(ctrl)
+				repo.EXPECT().AddCollectionItem(gomock.Any(), "article",
+					int64(1), int64(10), int64(123)).Return(nil)
+				return repo, collRepo
+			},
+			cid: 10,
+		},
+		{
+			name: "别人的收藏夹",
+			mock: func(ctrl *gomock.Controller) (repository.InteractiveRepository,
+				repository.CollectionRepository) {
+				collRepo := repomocks.NewMockCollectionRepository(ctrl)
+				collRepo.EXPECT().GetById(gomock.Any(), int64(10)).
+					Return(domain.Collection{Id: 10, Uid: 234}, nil)
+				return repomocks.NewMockInteractiveRepository(ctrl), collRepo
+			},
+			cid:     10,
+			wantErr: ErrCollectionNotFound,
+		},
+		{
+			name: "收藏夹不存在",
+			mock: func(ctrl *gomock.Controller) (repository.InteractiveRepository,
+				repository.CollectionRepository) {
+				collRepo := repomocks.NewMockCollectionRepository(ctrl)
+				collRepo.EXPECT().GetById(gomock.Any(), int64(10)).
+					Return(domain.Collection{}, repository.ErrCollectionNotFound)
+				return repomocks.NewMockInteractiveRepository(ctrl), collRepo
+			},
+			cid:     10,
+			wantErr: ErrCollectionNotFound,
+		},
+		{
+			name: "查询收藏夹出错",
+			mock: func(ctrl *gomock.Controller) (repository.InteractiveRepository,
+				repository.CollectionRepository) {
+				collRepo := repomocks.NewMockCollectionRepository(ctrl)
+				collRepo.EXPECT().GetById(gomock.Any(), int64(10)).
+					Return(domain.Collection{}, errors.New("mock db error"))
+				return repomocks.NewMockInteractiveRepository(ctrl), collRepo
+			},
+			cid:     10,
+			wantErr: errors.New("mock db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo, collRepo := tc.mock(ctrl)
+			producer := evtmocks.NewMockProducer(ctrl)
+			if tc.wantErr == nil {
+				// 收藏成功了才通知作者
+				producer.EXPECT().ProduceInteractiveEvent(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, evt events.InteractiveEvent) error {
+						assert.Equal(t, events.TypeCollect, evt.Type)
+						assert.Equal(t, "article", evt.Biz)
+						assert.Equal(t, int64(1), evt.BizId)
+						assert.Equal(t, int64(123), evt.Uid)
+						return nil
+					})
+			}
+			svc := NewInteractiveService(repo, collRepo, producer, &logger.NopLogger{})
+			err := svc.Collect(context.Background(), "article", 1, tc.cid, 123)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
+
+func Test_collectionService_Rename(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.CollectionRepository
+
+		newName string
+
+		wantErr error
+	}{
+		{
+			name: "改名成功",
+			mock: func(ctrl *gomock.Controller) repository.CollectionRepository {
+				repo := repomocks.NewMockCollectionRepository(ctrl)
+				repo.EXPECT().Rename(gomock.Any(), int64(123), int64(10), "技术").
+					Return(nil)
+				return repo
+			},
+			newName: " 技术 ",
+		},
+		{
+			name: "名字是空的",
+			mock: func(ctrl *gomock.Controller) repository.CollectionRepository {
+				return repomocks.NewMockCollectionRepository(ctrl)
+			},
+			newName: "  ",
+			wantErr: ErrInvalidCollectionName,
+		},
+		{
+			name: "不是自己的收藏夹",
+			mock: func(ctrl *gomock.Controller) repository.CollectionRepository {
+				repo := repomocks.NewMockCollectionRepository(ctrl)
+				repo.EXPECT().Rename(gomock.Any(), int64(123), int64(10), "技术").
+					Return(repository.ErrCollectionNotFound)
+				return repo
+			},
+			newName: "技术",
+			wantErr: ErrCollectionNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewCollectionService(tc.mock(ctrl))
+			err := svc.Rename(context.Background(), 123, 10, tc.newName)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	events "github.com/gevinzone/basic-go/week9/webook/internal/events/interactive"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrCommentNotFound = errors.New("评论不存在")
+	// ErrInvalidComment 内容不合法，或者回复的评论不在同一个资源下面
+	ErrInvalidComment = errors.New("评论不合法")
+	// ErrCommentTargetNotFound 被评论的资源不存在，或者不允许评论
+	ErrCommentTargetNotFound = errors.New("评论的资源不存在")
+	// ErrCommentPermissionDenied 只有评论的人和资源的作者可以删除评论
+	ErrCommentPermissionDenied = errors.New("没有权限删除评论")
+)
+
+// 评论最长的字符数
+const maxCommentLen = 1000
+
+// BizOwnerFunc 查找资源的作者，资源的作者可以删除资源下面的任何评论，
+// 资源上面的互动也是通知资源的作者。
+// 资源不存在或者不允许评论的时候返回 ErrCommentTargetNotFound，
+// 用来删除评论的时候，只有资源不存在才返回 ErrCommentTargetNotFound
+type BizOwnerFunc func(ctx context.Context, bizId int64) (int64, error)
+
+//go:generate mockgen -source=comment.go -package=svcmocks -destination=mocks/comment.mock.go CommentService
+type CommentService interface {
+	// Comment 发表评论，ParentId 不为 0 的时候是回复
+	Comment(ctx context.Context, c domain.Comment) (int64, error)
+	// ListRoot 资源下面的一级评论
+	ListRoot(ctx context.Context, biz string, bizId int64,
+		order domain.CommentOrder, offset, limit int) ([]domain.Comment, error)
+	// ListReplies 一级评论下面的回复，前端根据 ParentId 组装成树
+	ListReplies(ctx context.Context, rootId int64, offset, limit int) ([]domain.Comment, error)
+	// Delete 删除评论，连带删除它下面的回复
+	Delete(ctx context.Context, uid, id int64) error
+}
+
+type commentService struct {
+	repo repository.CommentRepository
+	// 每一种 biz 怎么找资源的作者，没有注册的 biz 不能评论
+	owners map[string]BizOwnerFunc
+	// 删除评论的时候怎么找资源的作者。资源不允许评论了，作者也要能删除下面的评论
+	managers map[string]BizOwnerFunc
+	// 评论之后通知资源的作者，回复的时候通知被回复的人
+	producer events.Producer
+	l        logger.LoggerV1
+}
+
+func NewCommentService(repo repository.CommentRepository,
+	owners map[string]BizOwnerFunc,
+	managers map[string]BizOwnerFunc,
+	producer events.Producer,
+	l logger.LoggerV1) CommentService {
+	return &commentService{
+		repo:     repo,
+		owners:   owners,
+		managers: managers,
+		producer: producer,
+		l:        l,
+	}
+}
+
+func (s *commentService) Comment(ctx context.Context, c domain.Comment) (int64, error) {
+	c.Content = strings.TrimSpace(c.Content)
+	if c.Content == "" || utf8.RuneCountInString(c.Content) > maxCommentLen {
+		return 0, ErrInvalidComment
+	}
+	receiver, err := ownerOf(ctx, s.owners, c.Biz, c.BizId)
+	if err != nil {
+		return 0, err
+	}
+	c.RootId = 0
+	if c.ParentId > 0 {
+		parent, err := s.get(ctx, c.ParentId)
+		if err != nil {
+			return 0, err
+		}
+		if parent.Biz != c.Biz || parent.BizId != c.BizId {
+			return 0, ErrInvalidComment
+		}
+		// 回复全部挂在一级评论下面
+		c.RootId = parent.RootId
+		if parent.IsRoot() {
+			c.RootId = parent.Id
+		}
+		receiver = parent.Commentator.Id
+	}
+	id, err := s.repo.Create(ctx, c)
+	if err != nil {
+		return 0, err
+	}
+	s.produce(ctx, c, receiver)
+	return id, nil
+}
+
+// produce 评论已经发出去了，事件发不出去只是对方收不到通知，记个日志
+func (s *commentService) produce(ctx context.Context, c domain.Comment, receiver int64) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	err := s.producer.ProduceInteractiveEvent(ctx, events.InteractiveEvent{
+		Type:     events.TypeComment,
+		Biz:      c.Biz,
+		BizId:    c.BizId,
+		Uid:      c.Commentator.Id,
+		Receiver: receiver,
+		Ctime:    time.Now().UnixMilli(),
+	})
+	if err != nil {
+		s.l.Error("发送评论事件失败",
+			logger.String("biz", c.Biz),
+			logger.Int64("bizId", c.BizId),
+			logger.Int64("uid", c.Commentator.Id),
+			logger.Error(err))
+	}
+}
+
+func (s *commentService) ListRoot(ctx context.Context,
+	biz string, bizId int64, order domain.CommentOrder,
+	offset, limit int) ([]domain.Comment, error) {
+	return s.repo.ListRoot(ctx, biz, bizId, order, offset, limit)
+}
+
+func (s *commentService) ListReplies(ctx context.Context,
+	rootId int64, offset, limit int) ([]domain.Comment, error) {
+	return s.repo.ListReplies(ctx, rootId, offset, limit)
+}
+
+func (s *commentService) Delete(ctx context.Context, uid, id int64) error {
+	c, err := s.get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if c.Commentator.Id != uid {
+		owner, err := ownerOf(ctx, s.managers, c.Biz, c.BizId)
+		if err != nil {
+			return err
+		}
+		if owner != uid {
+			return ErrCommentPermissionDenied
+		}
+	}
+	return s.repo.Delete(ctx, c)
+}
+
+func (s *commentService) get(ctx context.Context, id int64) (domain.Comment, error) {
+	c, err := s.repo.GetById(ctx, id)
+	if err == repository.ErrCommentNotFound {
+		return domain.Comment{}, ErrCommentNotFound
+	}
+	return c, err
+}
+
+func ownerOf(ctx context.Context, owners map[string]BizOwnerFunc,
+	biz string, bizId int64) (int64, error) {
+	fn, ok := owners[biz]
+	if !ok {
+		return 0, ErrCommentTargetNotFound
+	}
+	return fn(ctx, bizId)
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	events "github.com/gevinzone/basic-go/week9/webook/internal/events/interactive"
+	evtmocks "github.com/gevinzone/basic-go/week9/webook/internal/events/interactive/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	repomocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"strings"
+	"testing"
+)
+
+// 帖子 1 的作者是 123
+var testCommentOwners = map[string]BizOwnerFunc{
+	"article": func(ctx context.Context, bizId int64) (int64, error) {
+		if bizId != 1 {
+			return 0, ErrCommentTargetNotFound
+		}
+		return 123, nil
+	},
+}
+
+func Test_commentService_Comment(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.CommentRepository
+
+		cmt domain.Comment
+
+		wantId  int64
+		wantErr error
+		// 通知谁
+		wantReceiver int64
+	}{
+		{
+			name: "一级评论",
+			mock: func(ctrl *gomock.Controller) repository.CommentRepository {
+				repo := repomocks.NewMockCommentRepository(ctrl)
+				repo.EXPECT().Create(gomock.Any(), domain.Comment{
+					Biz:         "article",
+					BizId:       1,
+					Commentator: domain.User{Id: 234},
+					Content:     "写得好",
+				}).Return(int64(10), nil)
+				return repo
+			},
+			cmt: domain.Comment{
+				Biz:         "article",
+				BizId:       1,
+				Commentator: domain.User{Id: 234},
+				Content:     "  写得好 ",
+			},
+			wantId:       10,
+			wantReceiver: 123,
+		},
+		{
+			name: "回复别人的回复，挂在一级评论下面",
+			mock: func(ctrl *gomock.Controller) repository.CommentRepository {
+				repo := repomocks.NewMockCommentRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(11)).
+					Return(domain.Comment{
+						Id:       11,
+						Biz:      "article",
+						BizId:    1,
+						RootId:   10,
+						ParentId: 10,
+						// 回复的是 345 的评论
+						Commentator: domain.User{Id: 345},
+					}, nil)
+				repo.EXPECT().Create(gomock.Any(), domain.Comment{
+					Biz:         "article",
+					BizId:       1,
+					Commentator: domain.User{Id: 234},
+					Content:     "同意",
+					RootId:      10,
+					ParentId:    11,
+				}).Return(int64(12), nil)
+				return repo
+			},
+			cmt: domain.Comment{
+				Biz:         "article",
+				BizId:       1,
+				Commentator: domain.User{Id: 234},
+				Content:     "同意",
+				ParentId:    11,
+			},
+			wantId:       12,
+			wantReceiver: 345,
+		},
+		{
+			name: "回复的评论不在同一篇帖子下面",
+			mock: func(ctrl *gomock.Controller) repository.CommentRepository {
+				repo := repomocks.NewMockCommentRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(11)).
+					Return(domain.Comment{
+						Id:    11,
+						Biz:   "article",
+						BizId: 2,
+					}, nil)
+				return repo
+			},
+			cmt: domain.Comment{
+				Biz:      "article",
+				BizId:    1,
+				Content:  "同意",
+				ParentId: 11,
+			},
+			wantErr: ErrInvalidComment,
+		},
+		{
+			name: "回复的评论不存在",
+			mock: func(ctrl *gomock.Controller) repository.CommentRepository {
+				repo := repomocks.NewMockCommentRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(11)).
+					Return(domain.Comment{}, repository.ErrCommentNotFound)
+				return repo
+			},
+			cmt: domain.Comment{
+				Biz:      "article",
+				BizId:    1,
+				Content:  "同意",
+				ParentId: 11,
+			},
+			wantErr: ErrCommentNotFound,
+		},
+		{
+			name: "帖子不存在",
+			mock: func(ctrl *gomock.Controller) repository.CommentRepository {
+				return repomocks.NewMockCommentRepository(ctrl)
+			},
+			cmt: domain.Comment{
+				Biz:     "article",
+				BizId:   2,
+				Content: "写得好",
+			},
+			wantErr: ErrCommentTargetNotFound,
+		},
+		{
+			name: "不能评论的 biz",
+			mock: func(ctrl *gomock.Controller) repository.CommentRepository {
+				return repomocks.NewMockCommentRepository(ctrl)
+			},
+			cmt: domain.Comment{
+				Biz:     "user",
+				BizId:   1,
+				Content: "写得好",
+			},
+			wantErr: ErrCommentTargetNotFound,
+		},
+		{
+			name: "内容太长",
+			mock: func(ctrl *gomock.Controller) repository.CommentRepository {
+				return repomocks.NewMockCommentRepository(ctrl)
+			},
+			cmt: domain.Comment{
+				Biz:     "article",
+				BizId:   1,
+				Content: strings.Repeat("字", maxCommentLen+1),
+			},
+			wantErr: ErrInvalidComment,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			producer := evtmocks.NewMockProducer(ctrl)
+			if tc.wantErr == nil {
+				producer.EXPECT().ProduceInteractiveEvent(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, evt events.InteractiveEvent) error {
+						assert.Equal(t, events.TypeComment, evt.Type)
+						assert.Equal(t, tc.cmt.Commentator.Id, evt.Uid)
+						assert.Equal(t, tc.wantReceiver, evt.Receiver)
+						return nil
+					})
+			}
+			svc := NewCommentService(tc.mock(ctrl), testCommentOwners, nil, producer, &logger.NopLogger{})
+			id, err := svc.Comment(context.Background(), tc.cmt)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantId, id)
+		})
+	}
+}
+
+func Test_commentService_Delete(t *testing.T) {
+	cmt := domain.Comment{
+		Id:          10,
+		Biz:         "article",
+		BizId:       1,
+		Commentator: domain.User{Id: 234},
+	}
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.CommentRepository
+
+		uid int64
+		id  int64
+
+		wantErr error
+	}{
+		{
+			name: "评论的人删除",
+			mock: func(ctrl *gomock.Controller) repository.CommentRepository {
+				repo := repomocks.NewMockCommentRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(10)).Return(cmt, nil)
+				repo.EXPECT().Delete(gomock.Any(), cmt).Return(nil)
+				return repo
+			},
+			uid: 234,
+			id:  10,
+		},
+		{
+			name: "帖子的作者删除",
+			mock: func(ctrl *gomock.Controller) repository.CommentRepository {
+				repo := repomocks.NewMockCommentRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(10)).Return(cmt, nil)
+				repo.EXPECT().Delete(gomock.Any(), cmt).Return(nil)
+				return repo
+			},
+			uid: 123,
+			id:  10,
+		},
+		{
+			name: "别人不能删除",
+			mock: func(ctrl *gomock.Controller) repository.CommentRepository {
+				repo := repomocks.NewMockCommentRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(10)).Return(cmt, nil)
+				return repo
+			},
+			uid:     345,
+			id:      10,
+			wantErr: ErrCommentPermissionDenied,
+		},
+		{
+			name: "帖子已经不存在了",
+			mock: func(ctrl *gomock.Controller) repository.CommentRepository {
+				repo := repomocks.NewMockCommentRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(11)).Return(domain.Comment{
+					Id:          11,
+					Biz:         "article",
+					BizId:       2,
+					Commentator: domain.User{Id: 234},
+				}, nil)
+				return repo
+			},
+			uid:     123,
+			id:      11,
+			wantErr: ErrCommentTargetNotFound,
+		},
+		{
+			name: "删除失败",
+			mock: func(ctrl *gomock.Controller) repository.CommentRepository {
+				repo := repomocks.NewMockCommentRepository(ctrl)
+				repo.EXPECT().GetById(gomock.Any(), int64(10)).Return(cmt, nil)
+				repo.EXPECT().Delete(gomock.Any(), cmt).Return(errors.New("mock db error"))
+				return repo
+			},
+			uid:     234,
+			id:      10,
+			wantErr: errors.New("mock db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			// 删除评论只看帖子的作者，不管帖子还能不能评论
+			svc := NewCommentService(tc.mock(ctrl), nil, testCommentOwners, nil, &logger.NopLogger{})
+			err := svc.Delete(context.Background(), tc.uid, tc.id)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
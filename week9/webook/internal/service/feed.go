@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"sort"
+	"time"
+)
+
+//go:generate mockgen -source=./feed.go -package=svcmocks -destination=mocks/feed.mock.go FeedService
+type FeedService interface {
+	// Publish 帖子发表之后调用。粉丝少的作者推到每个粉丝的收件箱，
+	// 大 V 只放到自己的发件箱，粉丝读的时候去拉
+	Publish(ctx context.Context, uid, aid int64, t time.Time) error
+	// Withdraw 帖子撤回之后调用
+	Withdraw(ctx context.Context, uid, aid int64) error
+	// List 关注的人发表的帖子，最新的在前面。
+	// 返回的游标是下一页的，零值说明已经到底了
+	List(ctx context.Context, uid int64,
+		cursor domain.UserBizCursor, limit int) ([]domain.FeedItem, domain.UserBizCursor, error)
+}
+
+type feedService struct {
+	repo       repository.FeedRepository
+	followRepo repository.FollowRepository
+	artSvc     ArticleService
+	l          logger.LoggerV1
+	// 粉丝数达到这个值就不推了，改成读的时候拉
+	pushThreshold int64
+	// 推的时候一次处理多少个粉丝
+	fanoutBatchSize int
+}
+
+func NewFeedService(repo repository.FeedRepository,
+	followRepo repository.FollowRepository,
+	artSvc ArticleService,
+	l logger.LoggerV1) FeedService {
+	return &feedService{
+		repo:            repo,
+		followRepo:      followRepo,
+		artSvc:          artSvc,
+		l:               l,
+		pushThreshold:   1000,
+		fanoutBatchSize: 500,
+	}
+}
+
+func (s *feedService) Publish(ctx context.Context, uid, aid int64, t time.Time) error {
+	item := domain.FeedItem{Aid: aid, Time: t}
+	err := s.repo.AddToOutbox(ctx, uid, item)
+	if err != nil {
+		return err
+	}
+	big, err := s.isBigAuthor(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if big {
+		return s.repo.MarkBigAuthor(ctx, uid)
+	}
+	return s.eachFollowers(ctx, uid, func(uids []int64) error {
+		return s.repo.Push(ctx, uids, item)
+	})
+}
+
+func (s *feedService) Withdraw(ctx context.Context, uid, aid int64) error {
+	err := s.repo.RemoveFromOutbox(ctx, uid, aid)
+	if err != nil {
+		return err
+	}
+	big, err := s.isBigAuthor(ctx, uid)
+	if err != nil {
+		return err
+	}
+	if big {
+		// 大 V 以前粉丝少的时候推出去的，读的时候会因为帖子看不了而被过滤掉
+		return nil
+	}
+	return s.eachFollowers(ctx, uid, func(uids []int64) error {
+		return s.repo.Remove(ctx, uids, aid)
+	})
+}
+
+func (s *feedService) isBigAuthor(ctx context.Context, uid int64) (bool, error) {
+	statics, err := s.followRepo.GetStatics(ctx, uid)
+	if err != nil {
+		return false, err
+	}
+	return statics.Followers >= s.pushThreshold, nil
+}
+
+// eachFollowers 分批遍历 uid 的粉丝
+func (s *feedService) eachFollowers(ctx context.Context,
+	uid int64, fn func(uids []int64) error) error {
+	var cursor domain.UserBizCursor
+	for {
+		frs, err := s.followRepo.ListFollowers(ctx, uid, cursor, s.fanoutBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(frs) == 0 {
+			return nil
+		}
+		err = fn(slice.Map(frs, func(idx int, src domain.FollowRelation) int64 {
+			return src.Follower
+		}))
+		if err != nil {
+			return err
+		}
+		if len(frs) < s.fanoutBatchSize {
+			return nil
+		}
+		cursor = frs[len(frs)-1].Cursor()
+	}
+}
+
+func (s *feedService) List(ctx context.Context, uid int64,
+	cursor domain.UserBizCursor, limit int) ([]domain.FeedItem, domain.UserBizCursor, error) {
+	items, err := s.repo.ListInbox(ctx, uid, cursor, limit)
+	if err != nil {
+		return nil, domain.UserBizCursor{}, err
+	}
+	items = append(items, s.pull(ctx, uid, cursor, limit)...)
+	items = mergeFeedItems(items, limit)
+	var next domain.UserBizCursor
+	// 不满一页说明已经到底了
+	if len(items) == limit {
+		next = items[len(items)-1].Cursor()
+	}
+	res, err := s.fillArticles(ctx, items)
+	return res, next, err
+}
+
+// pull 拉关注的大 V 的发件箱，拉不到就少几条，不影响收件箱里面的
+func (s *feedService) pull(ctx context.Context, uid int64,
+	cursor domain.UserBizCursor, limit int) []domain.FeedItem {
+	bigs, err := s.repo.BigAuthors(ctx)
+	if err != nil {
+		s.l.Error("查询大 V 失败", logger.Error(err))
+		return nil
+	}
+	followees, err := s.followRepo.FindFollowees(ctx, uid, bigs)
+	if err != nil {
+		s.l.Error("查询关注的大 V 失败",
+			logger.Int64("uid", uid),
+			logger.Error(err))
+		return nil
+	}
+	var res []domain.FeedItem
+	for _, followee := range followees {
+		items, err := s.repo.ListOutbox(ctx, followee, cursor, limit)
+		if err != nil {
+			s.l.Error("拉取大 V 的发件箱失败",
+				logger.Int64("uid", followee),
+				logger.Error(err))
+			continue
+		}
+		res = append(res, items...)
+	}
+	return res
+}
+
+// fillArticles 撤回、删除了的帖子查不到，直接跳过
+func (s *feedService) fillArticles(ctx context.Context,
+	items []domain.FeedItem) ([]domain.FeedItem, error) {
+	if len(items) == 0 {
+		return items, nil
+	}
+	arts, err := s.artSvc.ListPubByIds(ctx, slice.Map(items,
+		func(idx int, src domain.FeedItem) int64 {
+			return src.Aid
+		}))
+	if err != nil {
+		return nil, err
+	}
+	artMap := make(map[int64]domain.Article, len(arts))
+	for _, art := range arts {
+		artMap[art.Id] = art
+	}
+	res := make([]domain.FeedItem, 0, len(items))
+	for _, item := range items {
+		art, ok := artMap[item.Aid]
+		if !ok {
+			continue
+		}
+		item.Article = art
+		res = append(res, item)
+	}
+	return res, nil
+}
+
+// mergeFeedItems 按照 (time, aid) 倒序排好，去掉重复的，最多保留 limit 条。
+// 作者从普通人变成大 V 之后，同一篇帖子可能既在收件箱里面，又在发件箱里面
+func mergeFeedItems(items []domain.FeedItem, limit int) []domain.FeedItem {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Time.Equal(items[j].Time) {
+			return items[i].Aid > items[j].Aid
+		}
+		return items[i].Time.After(items[j].Time)
+	})
+	res := make([]domain.FeedItem, 0, limit)
+	for i, item := range items {
+		if i > 0 && item.Aid == items[i-1].Aid {
+			continue
+		}
+		res = append(res, item)
+		if len(res) == limit {
+			break
+		}
+	}
+	return res
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	repomocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/mocks"
+	svcmocks "github.com/gevinzone/basic-go/week9/webook/internal/service/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func Test_feedService_Publish(t *testing.T) {
+	now := time.UnixMilli(1000)
+	item := domain.FeedItem{Aid: 10, Time: now}
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.FeedRepository, repository.FollowRepository)
+	}{
+		{
+			name: "粉丝少，分批推到收件箱",
+			mock: func(ctrl *gomock.Controller) (repository.FeedRepository, repository.FollowRepository) {
+				repo := repomocks.NewMockFeedRepository(ctrl)
+				followRepo := repomocks.NewMockFollowRepository(ctrl)
+				repo.EXPECT().AddToOutbox(gomock.Any(), int64(1), item).Return(nil)
+				followRepo.EXPECT().GetStatics(gomock.Any(), int64(1)).
+					Return(domain.FollowStatics{Followers: 3}, nil)
+				t2 := time.UnixMilli(200)
+				followRepo.EXPECT().ListFollowers(gomock.Any(), int64(1), domain.UserBizCursor{}, 2).
+					Return([]domain.FollowRelation{
+						{Id: 3, Follower: 4, Time: time.UnixMilli(300)},
+						{Id: 2, Follower: 3, Time: t2},
+					}, nil)
+				repo.EXPECT().Push(gomock.Any(), []int64{4, 3}, item).Return(nil)
+				followRepo.EXPECT().ListFollowers(gomock.Any(), int64(1),
+					domain.UserBizCursor{Time: t2, Id: 2}, 2).
+					Return([]domain.FollowRelation{
+						{Id: 1, Follower: 2, Time: time.UnixMilli(100)},
+					}, nil)
+				repo.EXPECT().Push(gomock.Any(), []int64{2}, item).Return(nil)
+				return repo, followRepo
+			},
+		},
+		{
+			name: "大 V 不推",
+			mock: func(ctrl *gomock.Controller) (repository.FeedRepository, repository.FollowRepository) {
+				repo := repomocks.NewMockFeedRepository(ctrl)
+				followRepo := repomocks.NewMockFollowRepository(ctrl)
+				repo.EXPECT().AddToOutbox(gomock.Any(), int64(1), item).Return(nil)
+				followRepo.EXPECT().GetStatics(gomock.Any(), int64(1)).
+					Return(domain.FollowStatics{Followers: 5}, nil)
+				repo.EXPECT().MarkBigAuthor(gomock.Any(), int64(1)).Return(nil)
+				return repo, followRepo
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo, followRepo := tc.mock(ctrl)
+			svc := NewFeedService(repo, followRepo, nil, &logger.NopLogger{}).(*feedService)
+			svc.pushThreshold = 5
+			svc.fanoutBatchSize = 2
+			err := svc.Publish(context.Background(), 1, 10, now)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func Test_feedService_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	repo := repomocks.NewMockFeedRepository(ctrl)
+	followRepo := repomocks.NewMockFollowRepository(ctrl)
+	artSvc := svcmocks.NewMockArticleService(ctrl)
+
+	repo.EXPECT().ListInbox(gomock.Any(), int64(1), domain.UserBizCursor{}, 3).
+		Return([]domain.FeedItem{
+			{Aid: 5, Time: time.UnixMilli(500)},
+			{Aid: 3, Time: time.UnixMilli(300)},
+			{Aid: 1, Time: time.UnixMilli(100)},
+		}, nil)
+	repo.EXPECT().BigAuthors(gomock.Any()).Return([]int64{7, 8}, nil)
+	// 只关注了 7
+	followRepo.EXPECT().FindFollowees(gomock.Any(), int64(1), []int64{7, 8}).
+		Return([]int64{7}, nil)
+	repo.EXPECT().ListOutbox(gomock.Any(), int64(7), domain.UserBizCursor{}, 3).
+		Return([]domain.FeedItem{
+			{Aid: 4, Time: time.UnixMilli(400)},
+			// 成为大 V 之前推过的
+			{Aid: 3, Time: time.UnixMilli(300)},
+		}, nil)
+	// 帖子 4 已经撤回了
+	artSvc.EXPECT().ListPubByIds(gomock.Any(), []int64{5, 4, 3}).
+		Return([]domain.Article{{Id: 5}, {Id: 3}}, nil)
+
+	svc := NewFeedService(repo, followRepo, artSvc, &logger.NopLogger{})
+	items, next, err := svc.List(context.Background(), 1, domain.UserBizCursor{}, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.FeedItem{
+		{Aid: 5, Time: time.UnixMilli(500), Article: domain.Article{Id: 5}},
+		{Aid: 3, Time: time.UnixMilli(300), Article: domain.Article{Id: 3}},
+	}, items)
+	// 游标是按照过滤之前的算的
+	assert.Equal(t, domain.UserBizCursor{Time: time.UnixMilli(300), Id: 3}, next)
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	events "github.com/gevinzone/basic-go/week9/webook/internal/events/follow"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"time"
+)
+
+var (
+	// ErrFollowSelf 自己关注自己
+	ErrFollowSelf = errors.New("不能关注自己")
+	// ErrFolloweeNotFound 关注的用户不存在
+	ErrFolloweeNotFound = errors.New("关注的用户不存在")
+)
+
+//go:generate mockgen -source=./follow.go -package=svcmocks -destination=mocks/follow.mock.go FollowService
+type FollowService interface {
+	// Follow 关注，重复关注不算错
+	Follow(ctx context.Context, follower, followee int64) error
+	// Unfollow 取消关注，本来就没有关注也不算错
+	Unfollow(ctx context.Context, follower, followee int64) error
+	// ListFollowers uid 的粉丝，Mutual 代表 uid 也关注了对方
+	ListFollowers(ctx context.Context, uid int64,
+		cursor domain.UserBizCursor, limit int) ([]domain.FollowRelation, error)
+	// ListFollowees uid 关注的人，Mutual 代表对方也关注了 uid
+	ListFollowees(ctx context.Context, uid int64,
+		cursor domain.UserBizCursor, limit int) ([]domain.FollowRelation, error)
+	IsFollowing(ctx context.Context, follower, followee int64) (bool, error)
+	GetStatics(ctx context.Context, uid int64) (domain.FollowStatics, error)
+}
+
+type followService struct {
+	repo     repository.FollowRepository
+	users    repository.UserRepository
+	producer events.Producer
+	l        logger.LoggerV1
+}
+
+func NewFollowService(repo repository.FollowRepository,
+	users repository.UserRepository,
+	producer events.Producer, l logger.LoggerV1) FollowService {
+	return &followService{
+		repo:     repo,
+		users:    users,
+		producer: producer,
+		l:        l,
+	}
+}
+
+func (s *followService) Follow(ctx context.Context, follower, followee int64) error {
+	if follower == followee {
+		return ErrFollowSelf
+	}
+	_, err := s.users.FindById(ctx, followee)
+	if err == repository.ErrUserNotFound {
+		return ErrFolloweeNotFound
+	}
+	if err != nil {
+		return err
+	}
+	changed, err := s.repo.Follow(ctx, follower, followee)
+	if err != nil || !changed {
+		return err
+	}
+	s.produce(ctx, follower, followee, true)
+	return nil
+}
+
+func (s *followService) Unfollow(ctx context.Context, follower, followee int64) error {
+	changed, err := s.repo.Unfollow(ctx, follower, followee)
+	if err != nil || !changed {
+		return err
+	}
+	s.produce(ctx, follower, followee, false)
+	return nil
+}
+
+// produce 关注关系已经改好了，事件发不出去也不影响结果，记个日志
+func (s *followService) produce(ctx context.Context, follower, followee int64, follow bool) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	err := s.producer.ProduceFollowEvent(ctx, events.FollowEvent{
+		Follower: follower,
+		Followee: followee,
+		Follow:   follow,
+		Ctime:    time.Now().UnixMilli(),
+	})
+	if err != nil {
+		s.l.Error("发送关注事件失败",
+			logger.Int64("follower", follower),
+			logger.Int64("followee", followee),
+			logger.Error(err))
+	}
+}
+
+func (s *followService) ListFollowers(ctx context.Context,
+	uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FollowRelation, error) {
+	res, err := s.repo.ListFollowers(ctx, uid, cursor, limit)
+	if err != nil || len(res) == 0 {
+		return res, err
+	}
+	// 粉丝里面 uid 也关注了的，就是互相关注
+	mutual, err := s.repo.FindFollowees(ctx, uid,
+		slice.Map(res, func(idx int, src domain.FollowRelation) int64 {
+			return src.Follower
+		}))
+	if err != nil {
+		return nil, err
+	}
+	marks := toSet(mutual)
+	for i := range res {
+		_, res[i].Mutual = marks[res[i].Follower]
+	}
+	return res, nil
+}
+
+func (s *followService) ListFollowees(ctx context.Context,
+	uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FollowRelation, error) {
+	res, err := s.repo.ListFollowees(ctx, uid, cursor, limit)
+	if err != nil || len(res) == 0 {
+		return res, err
+	}
+	// 关注的人里面也关注了 uid 的，就是互相关注
+	mutual, err := s.repo.FindFollowers(ctx, uid,
+		slice.Map(res, func(idx int, src domain.FollowRelation) int64 {
+			return src.Followee
+		}))
+	if err != nil {
+		return nil, err
+	}
+	marks := toSet(mutual)
+	for i := range res {
+		_, res[i].Mutual = marks[res[i].Followee]
+	}
+	return res, nil
+}
+
+func (s *followService) IsFollowing(ctx context.Context, follower, followee int64) (bool, error) {
+	if follower == followee {
+		return false, nil
+	}
+	res, err := s.repo.FindFollowees(ctx, follower, []int64{followee})
+	return len(res) > 0, err
+}
+
+func (s *followService) GetStatics(ctx context.Context, uid int64) (domain.FollowStatics, error) {
+	return s.repo.GetStatics(ctx, uid)
+}
+
+func toSet(uids []int64) map[int64]struct{} {
+	res := make(map[int64]struct{}, len(uids))
+	for _, uid := range uids {
+		res[uid] = struct{}{}
+	}
+	return res
+}
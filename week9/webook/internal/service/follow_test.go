@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	events "github.com/gevinzone/basic-go/week9/webook/internal/events/follow"
+	evtmocks "github.com/gevinzone/basic-go/week9/webook/internal/events/follow/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	repomocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func Test_followService_ListFollowers(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.FollowRepository
+
+		wantRes []domain.FollowRelation
+		wantErr error
+	}{
+		{
+			name: "标记互相关注",
+			mock: func(ctrl *gomock.Controller) repository.FollowRepository {
+				repo := repomocks.NewMockFollowRepository(ctrl)
+				repo.EXPECT().ListFollowers(gomock.Any(), int64(1), domain.UserBizCursor{}, 10).
+					Return([]domain.FollowRelation{
+						{Id: 3, Follower: 2, Followee: 1},
+						{Id: 2, Follower: 3, Followee: 1},
+					}, nil)
+				repo.EXPECT().FindFollowees(gomock.Any(), int64(1), []int64{2, 3}).
+					Return([]int64{3}, nil)
+				return repo
+			},
+			wantRes: []domain.FollowRelation{
+				{Id: 3, Follower: 2, Followee: 1},
+				{Id: 2, Follower: 3, Followee: 1, Mutual: true},
+			},
+		},
+		{
+			name: "没有粉丝",
+			mock: func(ctrl *gomock.Controller) repository.FollowRepository {
+				repo := repomocks.NewMockFollowRepository(ctrl)
+				repo.EXPECT().ListFollowers(gomock.Any(), int64(1), domain.UserBizCursor{}, 10).
+					Return(nil, nil)
+				return repo
+			},
+		},
+		{
+			name: "查询互相关注出错",
+			mock: func(ctrl *gomock.Controller) repository.FollowRepository {
+				repo := repomocks.NewMockFollowRepository(ctrl)
+				repo.EXPECT().ListFollowers(gomock.Any(), int64(1), domain.UserBizCursor{}, 10).
+					Return([]domain.FollowRelation{
+						{Id: 3, Follower: 2, Followee: 1},
+					}, nil)
+				repo.EXPECT().FindFollowees(gomock.Any(), int64(1), []int64{2}).
+					Return(nil, errors.New("mock db error"))
+				return repo
+			},
+			wantErr: errors.New("mock db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewFollowService(tc.mock(ctrl), nil, nil, &logger.NopLogger{})
+			res, err := svc.ListFollowers(context.Background(), 1, domain.UserBizCursor{}, 10)
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantRes, res)
+		})
+	}
+}
+
+func Test_followService_Follow(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mock     func(ctrl *gomock.Controller) (repository.FollowRepository, repository.UserRepository, events.Producer)
+		follower int64
+		followee int64
+
+		wantErr error
+	}{
+		{
+			name: "关注成功，发送事件",
+			mock: func(ctrl *gomock.Controller) (repository.FollowRepository, repository.UserRepository, events.Producer) {
+				users := repomocks.NewMockUserRepository(ctrl)
+				users.EXPECT().FindById(gomock.Any(), int64(2)).Return(domain.User{Id: 2}, nil)
+				repo := repomocks.NewMockFollowRepository(ctrl)
+				repo.EXPECT().Follow(gomock.Any(), int64(1), int64(2)).Return(true, nil)
+				producer := evtmocks.NewMockProducer(ctrl)
+				producer.EXPECT().ProduceFollowEvent(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, evt events.FollowEvent) error {
+						assert.Equal(t, int64(1), evt.Follower)
+						assert.Equal(t, int64(2), evt.Followee)
+						assert.True(t, evt.Follow)
+						return nil
+					})
+				return repo, users, producer
+			},
+			follower: 1,
+			followee: 2,
+		},
+		{
+			name: "已经关注过了，不发事件",
+			mock: func(ctrl *gomock.Controller) (repository.FollowRepository, repository.UserRepository, events.Producer) {
+				users := repomocks.NewMockUserRepository(ctrl)
+				users.EXPECT().FindById(gomock.Any(), int64(2)).Return(domain.User{Id: 2}, nil)
+				repo := repomocks.NewMockFollowRepository(ctrl)
+				repo.EXPECT().Follow(gomock.Any(), int64(1), int64(2)).Return(false, nil)
+				return repo, users, evtmocks.NewMockProducer(ctrl)
+			},
+			follower: 1,
+			followee: 2,
+		},
+		{
+			name: "关注自己",
+			mock: func(ctrl *gomock.Controller) (repository.FollowRepository, repository.UserRepository, events.Producer) {
+				return repomocks.NewMockFollowRepository(ctrl),
+					repomocks.NewMockUserRepository(ctrl), evtmocks.NewMockProducer(ctrl)
+			},
+			follower: 1,
+			followee: 1,
+			wantErr:  ErrFollowSelf,
+		},
+		{
+			name: "关注的用户不存在",
+			mock: func(ctrl *gomock.Controller) (repository.FollowRepository, repository.UserRepository, events.Producer) {
+				users := repomocks.NewMockUserRepository(ctrl)
+				users.EXPECT().FindById(gomock.Any(), int64(3)).
+					Return(domain.User{}, repository.ErrUserNotFound)
+				return repomocks.NewMockFollowRepository(ctrl), users, evtmocks.NewMockProducer(ctrl)
+			},
+			follower: 1,
+			followee: 3,
+			wantErr:  ErrFolloweeNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo, users, producer := tc.mock(ctrl)
+			svc := NewFollowService(repo, users, producer, &logger.NopLogger{})
+			err := svc.Follow(context.Background(), tc.follower, tc.followee)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
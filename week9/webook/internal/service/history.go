@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+)
+
+//go:generate mockgen -source=history.go -package=svcmocks -destination=mocks/history.mock.go HistoryService
+type HistoryService interface {
+	// List 用户的阅读记录，最近读过的在前面
+	List(ctx context.Context, uid int64, offset, limit int) ([]domain.ReadHistory, error)
+	Delete(ctx context.Context, uid int64, biz string, bizId int64) error
+	// Clear 清空用户的阅读记录
+	Clear(ctx context.Context, uid int64) error
+}
+
+// historyService 阅读记录是消费阅读事件写进去的，这里只负责查询和删除
+type historyService struct {
+	repo repository.HistoryRepository
+}
+
+func NewHistoryService(repo repository.HistoryRepository) HistoryService {
+	return &historyService{repo: repo}
+}
+
+func (h *historyService) List(ctx context.Context,
+	uid int64, offset, limit int) ([]domain.ReadHistory, error) {
+	return h.repo.List(ctx, uid, offset, limit)
+}
+
+func (h *historyService) Delete(ctx context.Context,
+	uid int64, biz string, bizId int64) error {
+	return h.repo.Delete(ctx, uid, biz, bizId)
+}
+
+func (h *historyService) Clear(ctx context.Context, uid int64) error {
+	return h.repo.Clear(ctx, uid)
+}
@@ -2,47 +2,134 @@ package service
 
 import (
 	"context"
+	"errors"
 	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	events "github.com/gevinzone/basic-go/week9/webook/internal/events/interactive"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
 	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
 	"golang.org/x/sync/errgroup"
+	"time"
 )
 
+// ErrInvalidReaction 不支持的表态
+var ErrInvalidReaction = errors.New("不支持的表态")
+
 //go:generate mockgen -source=./interactive.go -package=svcmocks -destination=mocks/interactive.mock.go InteractiveService
 type InteractiveService interface {
 	IncrReadCnt(ctx context.Context, biz string, bizId int64) error
-	// Like 点赞
+	// FlushReadCnt 把累加的阅读数合并到数据库，定时任务调用
+	FlushReadCnt(ctx context.Context) (int, error)
+	// RollupUV 把某一天的 UV 汇总到数据库，定时任务调用
+	RollupUV(ctx context.Context, day time.Time) (int, error)
+	// ReconcileCnt 按照点赞和收藏的记录校对所有的点赞数和收藏数，定时任务调用
+	ReconcileCnt(ctx context.Context) (domain.CntReconcileReport, error)
+	// Like 点赞，也就是 ReactionLike 的表态
 	Like(ctx context.Context, biz string, bizId int64, uid int64) error
-	// CancelLike 取消点赞
+	// CancelLike 取消点赞，和 CancelReaction 一样
 	CancelLike(ctx context.Context, biz string, bizId int64, uid int64) error
+	// React 表态，一个资源只能有一种表态，新的会替换掉旧的。
+	// 不支持的表态返回 ErrInvalidReaction
+	React(ctx context.Context, biz string, bizId, uid int64, reaction domain.ReactionType) error
+	// CancelReaction 取消表态，不管是哪一种
+	CancelReaction(ctx context.Context, biz string, bizId, uid int64) error
 	// Collect 收藏, cid 是收藏夹的 ID
 	// cid 不一定有，或者说 0 对应的是该用户的默认收藏夹
+	// 收藏夹不是自己的会返回 ErrCollectionNotFound
 	Collect(ctx context.Context, biz string, bizId, cid, uid int64) error
+	// Uncollect 取消收藏，不管在哪个收藏夹里面
+	Uncollect(ctx context.Context, biz string, bizId, uid int64) error
 	Get(ctx context.Context, biz string, bizId, uid int64) (domain.Interactive, error)
 	GetByIds(ctx context.Context, biz string, bizIds []int64) (map[int64]domain.Interactive, error)
+	// ListLikes 用户点赞过的所有资源，不区分 biz，最近点赞的在前面
+	ListLikes(ctx context.Context, uid int64,
+		cursor domain.UserBizCursor, limit int) ([]domain.UserBizRecord, error)
+	// Delete 删除资源的计数、点赞、收藏和评论，资源被彻底删除的时候调用
+	Delete(ctx context.Context, biz string, bizId int64) error
 }
 
 type interactiveService struct {
 	repo repository.InteractiveRepository
-	l    logger.LoggerV1
+	// 收藏的时候校验收藏夹
+	collRepo repository.CollectionRepository
+	// 点赞和收藏之后通知作者
+	producer events.Producer
+	l        logger.LoggerV1
 }
 
 func (i *interactiveService) GetByIds(ctx context.Context, biz string, bizIds []int64) (map[int64]domain.Interactive, error) {
-	//TODO implement me
-	panic("implement me")
+	if len(bizIds) == 0 {
+		return map[int64]domain.Interactive{}, nil
+	}
+	return i.repo.GetByIds(ctx, biz, bizIds)
+}
+
+func (i *interactiveService) ListLikes(ctx context.Context,
+	uid int64, cursor domain.UserBizCursor, limit int) ([]domain.UserBizRecord, error) {
+	return i.repo.ListLikes(ctx, uid, cursor, limit)
 }
 
 func (i *interactiveService) IncrReadCnt(ctx context.Context, biz string, bizId int64) error {
 	return i.repo.IncrReadCnt(ctx, biz, bizId)
 }
 
+func (i *interactiveService) FlushReadCnt(ctx context.Context) (int, error) {
+	return i.repo.FlushReadCnt(ctx)
+}
+
+// 一批锁住的计数不能太多，不然会卡住这一批资源的点赞和收藏
+const reconcileBatchSize = 100
+
+func (i *interactiveService) RollupUV(ctx context.Context, day time.Time) (int, error) {
+	return i.repo.RollupUV(ctx, day)
+}
+
+func (i *interactiveService) ReconcileCnt(ctx context.Context) (domain.CntReconcileReport, error) {
+	var (
+		report domain.CntReconcileReport
+		id     int64
+	)
+	for {
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+		batch, err := i.repo.ReconcileCnt(ctx, id, reconcileBatchSize)
+		if err != nil {
+			return report, err
+		}
+		report.Checked += batch.Cnt
+		for _, d := range batch.Drifts {
+			i.l.Warn("点赞数或者收藏数不对",
+				logger.String("biz", d.Biz),
+				logger.Int64("bizId", d.BizId),
+				logger.Int64("likeCnt", d.LikeCnt),
+				logger.Int64("actualLike", d.ActualLike),
+				logger.Int64("collectCnt", d.CollectCnt),
+				logger.Int64("actualCollect", d.ActualCollect))
+			report.Fixed++
+			report.LikeDrift += abs(d.ActualLike - d.LikeCnt)
+			report.CollectDrift += abs(d.ActualCollect - d.CollectCnt)
+		}
+		if batch.Cnt < reconcileBatchSize {
+			return report, nil
+		}
+		id = batch.LastId
+	}
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
 func (i *interactiveService) Get(ctx context.Context,
 	biz string, bizId, uid int64) (domain.Interactive, error) {
 	// 按照 repository 的语义(完成 domain.Interactive 的完整构造)，你这里拿到的就应该是包含全部字段的
 	var (
 		eg        errgroup.Group
 		intr      domain.Interactive
-		liked     bool
+		reaction  domain.ReactionType
 		collected bool
 	)
 	eg.Go(func() error {
@@ -52,44 +139,108 @@ func (i *interactiveService) Get(ctx context.Context,
 	})
 	eg.Go(func() error {
 		var err error
-		liked, err = i.repo.Liked(ctx, biz, bizId, uid)
+		reaction, err = i.repo.Reaction(ctx, biz, bizId, uid)
 		return err
 	})
 	eg.Go(func() error {
 		var err error
-		liked, err = i.repo.Collected(ctx, biz, bizId, uid)
+		collected, err = i.repo.Collected(ctx, biz, bizId, uid)
 		return err
 	})
 	err := eg.Wait()
 	if err != nil {
 		return domain.Interactive{}, err
 	}
-	intr.Liked = liked
+	intr.Reaction = reaction
+	intr.Liked = reaction == domain.ReactionLike
 	intr.Collected = collected
 	return intr, err
 }
 
 func (i *interactiveService) Like(ctx context.Context, biz string, bizId int64, uid int64) error {
 	// 点赞
-	return i.repo.IncrLike(ctx, biz, bizId, uid)
+	return i.React(ctx, biz, bizId, uid, domain.ReactionLike)
 }
 
 func (i *interactiveService) CancelLike(ctx context.Context, biz string, bizId int64, uid int64) error {
-	return i.repo.DecrLike(ctx, biz, bizId, uid)
+	return i.repo.CancelReaction(ctx, biz, bizId, uid)
+}
+
+func (i *interactiveService) React(ctx context.Context,
+	biz string, bizId, uid int64, reaction domain.ReactionType) error {
+	if !reaction.Valid() {
+		return ErrInvalidReaction
+	}
+	created, err := i.repo.React(ctx, biz, bizId, uid, reaction)
+	if err != nil || !created {
+		// 重复表态和换一种表态都不再通知作者
+		return err
+	}
+	// 别的表态也算作点赞通知作者
+	i.produce(ctx, events.TypeLike, biz, bizId, uid)
+	return nil
+}
+
+func (i *interactiveService) CancelReaction(ctx context.Context,
+	biz string, bizId, uid int64) error {
+	return i.repo.CancelReaction(ctx, biz, bizId, uid)
 }
 
 // Collect 收藏
 func (i *interactiveService) Collect(ctx context.Context,
 	biz string, bizId, cid, uid int64) error {
+	err := checkCollectionOwner(ctx, i.collRepo, uid, cid)
+	if err != nil {
+		return err
+	}
 	// service 还叫做收藏
 	// repository
-	return i.repo.AddCollectionItem(ctx, biz, bizId, cid, uid)
+	err = i.repo.AddCollectionItem(ctx, biz, bizId, cid, uid)
+	if err != nil {
+		return err
+	}
+	i.produce(ctx, events.TypeCollect, biz, bizId, uid)
+	return nil
+}
+
+// produce 点赞和收藏已经成功了，事件发不出去只是作者收不到通知，记个日志
+func (i *interactiveService) produce(ctx context.Context,
+	typ string, biz string, bizId, uid int64) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	err := i.producer.ProduceInteractiveEvent(ctx, events.InteractiveEvent{
+		Type:  typ,
+		Biz:   biz,
+		BizId: bizId,
+		Uid:   uid,
+		Ctime: time.Now().UnixMilli(),
+	})
+	if err != nil {
+		i.l.Error("发送互动事件失败",
+			logger.String("type", typ),
+			logger.String("biz", biz),
+			logger.Int64("bizId", bizId),
+			logger.Int64("uid", uid),
+			logger.Error(err))
+	}
+}
+
+func (i *interactiveService) Uncollect(ctx context.Context, biz string, bizId, uid int64) error {
+	return i.repo.DeleteCollectionItem(ctx, biz, bizId, uid)
+}
+
+func (i *interactiveService) Delete(ctx context.Context, biz string, bizId int64) error {
+	return i.repo.Delete(ctx, biz, bizId)
 }
 
 func NewInteractiveService(repo repository.InteractiveRepository,
+	collRepo repository.CollectionRepository,
+	producer events.Producer,
 	l logger.LoggerV1) InteractiveService {
 	return &interactiveService{
-		repo: repo,
-		l:    l,
+		repo:     repo,
+		collRepo: collRepo,
+		producer: producer,
+		l:        l,
 	}
 }
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"sync"
+	"time"
+)
+
+// ErrTooManyWatchers 这个实例或者这个用户的实时计数连接太多了
+var ErrTooManyWatchers = errors.New("实时计数的连接太多了")
+
+//go:generate mockgen -source=./interactive_live.go -package=svcmocks -destination=mocks/interactive_live.mock.go InteractiveLiveService
+type InteractiveLiveService interface {
+	// Watch 订阅资源的实时计数，很快会收到一次当前的计数，之后计数变了再推。
+	// 来不及读的旧计数会被丢掉，只保留最新的。ctx 结束之后 channel 会被关掉
+	Watch(ctx context.Context, uid int64, biz string, bizId int64) (<-chan domain.Interactive, error)
+}
+
+type liveKey struct {
+	biz   string
+	bizId int64
+}
+
+// liveWatcher 一个连接。ch 的容量是 1，满了就换成最新的计数
+type liveWatcher struct {
+	uid int64
+	ch  chan domain.Interactive
+}
+
+func (w *liveWatcher) send(intr domain.Interactive) {
+	select {
+	case w.ch <- intr:
+		return
+	default:
+	}
+	// 上一次的还没有被读走，换成最新的
+	select {
+	case <-w.ch:
+	default:
+	}
+	select {
+	case w.ch <- intr:
+	default:
+	}
+}
+
+// liveInteractiveService 每个实例只向 Redis 订阅一次，本实例上有人看的资源才订阅。
+// 收到变化只是标记一下，每隔 interval 每个资源查一次计数，推给看这个资源的所有连接，
+// 所以热门资源一秒钟变很多次也不会把连接冲垮。
+// 订阅和取消订阅要访问 Redis，不能在锁里面做，由 sync 对比 watchers 和 subscribed 来补齐
+type liveInteractiveService struct {
+	repo       repository.InteractiveRepository
+	changeRepo repository.InteractiveChangeRepository
+	l          logger.LoggerV1
+
+	// 两次推送之间至少隔这么久
+	interval time.Duration
+	// 一个实例最多多少个连接，以及一个用户最多多少个连接
+	maxWatchers        int
+	maxWatchersPerUser int
+
+	once     sync.Once
+	mutex    sync.Mutex
+	sub      repository.InteractiveSubscription
+	watchers map[liveKey]map[*liveWatcher]struct{}
+	// 已经在 sub 上订阅了的资源，只有 sync 会加，重新订阅的时候清空
+	subscribed map[liveKey]struct{}
+	// 有人开始看或者不再看某个资源了，通知 sync
+	syncCh  chan struct{}
+	userCnt map[int64]int
+	total   int
+	// 计数变了，下一次推送的时候要查的资源
+	dirty map[liveKey]struct{}
+}
+
+func NewLiveInteractiveService(repo repository.InteractiveRepository,
+	changeRepo repository.InteractiveChangeRepository,
+	l logger.LoggerV1) InteractiveLiveService {
+	return &liveInteractiveService{
+		repo:               repo,
+		changeRepo:         changeRepo,
+		l:                  l,
+		interval:           time.Second,
+		maxWatchers:        10000,
+		maxWatchersPerUser: 5,
+		watchers:           make(map[liveKey]map[*liveWatcher]struct{}),
+		subscribed:         make(map[liveKey]struct{}),
+		syncCh:             make(chan struct{}, 1),
+		userCnt:            make(map[int64]int),
+		dirty:              make(map[liveKey]struct{}),
+	}
+}
+
+func (s *liveInteractiveService) Watch(ctx context.Context,
+	uid int64, biz string, bizId int64) (<-chan domain.Interactive, error) {
+	// 第一次有人订阅的时候才开始，之后跟着进程一直运行
+	s.once.Do(s.start)
+	key := liveKey{biz: biz, bizId: bizId}
+	w := &liveWatcher{uid: uid, ch: make(chan domain.Interactive, 1)}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.total >= s.maxWatchers || s.userCnt[uid] >= s.maxWatchersPerUser {
+		return nil, ErrTooManyWatchers
+	}
+	ws, ok := s.watchers[key]
+	if !ok {
+		// 本实例上第一个看这个资源的人，交给 sync 去订阅
+		ws = make(map[*liveWatcher]struct{})
+		s.watchers[key] = ws
+		s.notifySync()
+	}
+	ws[w] = struct{}{}
+	s.userCnt[uid]++
+	s.total++
+	// 下一次推送的时候把当前的计数推出去
+	s.dirty[key] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		s.remove(key, w)
+	}()
+	return w.ch, nil
+}
+
+func (s *liveInteractiveService) remove(key liveKey, w *liveWatcher) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	ws := s.watchers[key]
+	delete(ws, w)
+	// 在锁里面关，推送也在锁里面，所以不会往关掉的 channel 里面写
+	close(w.ch)
+	s.total--
+	s.userCnt[w.uid]--
+	if s.userCnt[w.uid] <= 0 {
+		delete(s.userCnt, w.uid)
+	}
+	if len(ws) > 0 {
+		return
+	}
+	// 本实例上已经没有人看了，交给 sync 去取消订阅
+	delete(s.watchers, key)
+	delete(s.dirty, key)
+	s.notifySync()
+}
+
+// notifySync 不阻塞，sync 还没处理的通知合并成一个
+func (s *liveInteractiveService) notifySync() {
+	select {
+	case s.syncCh <- struct{}{}:
+	default:
+	}
+}
+
+func (s *liveInteractiveService) start() {
+	s.sub = s.changeRepo.Subscribe(context.Background())
+	go s.receive()
+	go s.sync()
+	go s.push()
+}
+
+// sync 只有这一个 goroutine 访问 Redis 订阅和取消订阅，所以同一个资源的订阅和取消订阅不会乱序
+func (s *liveInteractiveService) sync() {
+	for range s.syncCh {
+		if !s.syncOnce() {
+			// 有失败的，过一会再试
+			time.AfterFunc(s.interval, s.notifySync)
+		}
+	}
+}
+
+// syncOnce 订阅有人看但是还没订阅的资源，取消订阅没人看了的资源，全部成功的时候返回 true
+func (s *liveInteractiveService) syncOnce() bool {
+	s.mutex.Lock()
+	sub := s.sub
+	var toWatch, toUnwatch []liveKey
+	for key := range s.watchers {
+		if _, ok := s.subscribed[key]; !ok {
+			toWatch = append(toWatch, key)
+		}
+	}
+	for key := range s.subscribed {
+		if _, ok := s.watchers[key]; !ok {
+			toUnwatch = append(toUnwatch, key)
+		}
+	}
+	s.mutex.Unlock()
+
+	ok := true
+	for _, key := range toWatch {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := sub.Watch(ctx, key.biz, key.bizId)
+		cancel()
+		if err != nil {
+			s.l.Error("订阅计数变化失败",
+				logger.String("biz", key.biz),
+				logger.Int64("bizId", key.bizId),
+				logger.Error(err))
+			ok = false
+			continue
+		}
+		s.markSubscribed(sub, key, true)
+	}
+	for _, key := range toUnwatch {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := sub.Unwatch(ctx, key.biz, key.bizId)
+		cancel()
+		if err != nil {
+			s.l.Error("取消订阅计数变化失败",
+				logger.String("biz", key.biz),
+				logger.Int64("bizId", key.bizId),
+				logger.Error(err))
+			ok = false
+			continue
+		}
+		s.markSubscribed(sub, key, false)
+	}
+	return ok
+}
+
+// markSubscribed 期间重新订阅过的话，旧的订阅上的结果就不算了
+func (s *liveInteractiveService) markSubscribed(sub repository.InteractiveSubscription,
+	key liveKey, subscribed bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.sub != sub {
+		return
+	}
+	if subscribed {
+		s.subscribed[key] = struct{}{}
+	} else {
+		delete(s.subscribed, key)
+	}
+}
+
+// receive 收到变化只标记，不马上推。
+// 订阅断了的话 Changes 会被关掉，这时候重新订阅，不然实时计数就悄悄地停了
+func (s *liveInteractiveService) receive() {
+	for {
+		s.mutex.Lock()
+		sub := s.sub
+		s.mutex.Unlock()
+		for change := range sub.Changes() {
+			key := liveKey{biz: change.Biz, bizId: change.BizId}
+			s.mutex.Lock()
+			if _, ok := s.watchers[key]; ok {
+				s.dirty[key] = struct{}{}
+			}
+			s.mutex.Unlock()
+		}
+		s.l.Error("计数变化的订阅断开了，重新订阅")
+		_ = sub.Close()
+		time.Sleep(s.interval)
+		newSub := s.changeRepo.Subscribe(context.Background())
+		s.mutex.Lock()
+		s.sub = newSub
+		s.subscribed = make(map[liveKey]struct{}, len(s.watchers))
+		// 断开的这段时间里面的变化都收不到了，全部重新推一次
+		for key := range s.watchers {
+			s.dirty[key] = struct{}{}
+		}
+		s.mutex.Unlock()
+		s.notifySync()
+	}
+}
+
+func (s *liveInteractiveService) push() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.pushOnce()
+	}
+}
+
+// pushOnce 每个变了的资源只查一次计数，推给所有看这个资源的连接
+func (s *liveInteractiveService) pushOnce() {
+	s.mutex.Lock()
+	dirty := s.dirty
+	s.dirty = make(map[liveKey]struct{}, len(dirty))
+	s.mutex.Unlock()
+	for key := range dirty {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		intr, err := s.repo.Get(ctx, key.biz, key.bizId)
+		cancel()
+		if err != nil {
+			s.l.Error("查询实时计数失败",
+				logger.String("biz", key.biz),
+				logger.Int64("bizId", key.bizId),
+				logger.Error(err))
+			continue
+		}
+		s.mutex.Lock()
+		for w := range s.watchers[key] {
+			w.send(intr)
+		}
+		s.mutex.Unlock()
+	}
+}
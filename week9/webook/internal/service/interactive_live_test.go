@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
+	cachemocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/cache/mocks"
+	repomocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_liveInteractiveService_Watch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	changes := make(chan cache.InteractiveChange, 10)
+	sub := cachemocks.NewMockInteractiveSubscription(ctrl)
+	sub.EXPECT().Changes().Return((<-chan cache.InteractiveChange)(changes))
+	// 两个人看同一篇帖子，只订阅一次，最后一个人走了才取消
+	sub.EXPECT().Watch(gomock.Any(), "article", int64(1)).Return(nil)
+	changeRepo := repomocks.NewMockInteractiveChangeRepository(ctrl)
+	changeRepo.EXPECT().Subscribe(gomock.Any()).Return(sub)
+
+	var readCnt atomic.Int64
+	repo := repomocks.NewMockInteractiveRepository(ctrl)
+	repo.EXPECT().Get(gomock.Any(), "article", int64(1)).
+		DoAndReturn(func(ctx context.Context, biz string, bizId int64) (domain.Interactive, error) {
+			return domain.Interactive{BizId: bizId, ReadCnt: readCnt.Load()}, nil
+		}).AnyTimes()
+
+	svc := NewLiveInteractiveService(repo, changeRepo, &logger.NopLogger{}).(*liveInteractiveService)
+	svc.interval = time.Millisecond * 10
+	svc.maxWatchersPerUser = 1
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ch1, err := svc.Watch(ctx1, 123, "article", 1)
+	require.NoError(t, err)
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	ch2, err := svc.Watch(ctx2, 234, "article", 1)
+	require.NoError(t, err)
+	// 一个用户最多一个连接
+	_, err = svc.Watch(context.Background(), 123, "article", 1)
+	assert.Equal(t, ErrTooManyWatchers, err)
+
+	// 一连上就推当前的计数
+	waitLive(t, ch1, 0)
+	waitLive(t, ch2, 0)
+
+	readCnt.Store(3)
+	changes <- cache.InteractiveChange{Biz: "article", BizId: 1}
+	waitLive(t, ch1, 3)
+	waitLive(t, ch2, 3)
+
+	cancel1()
+	_, ok := <-ch1
+	assert.False(t, ok)
+
+	done := make(chan struct{})
+	sub.EXPECT().Unwatch(gomock.Any(), "article", int64(1)).
+		DoAndReturn(func(ctx context.Context, biz string, bizId int64) error {
+			close(done)
+			return nil
+		})
+	cancel2()
+	_, ok = <-ch2
+	assert.False(t, ok)
+	<-done
+}
+
+func Test_liveInteractiveService_Resubscribe(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	oldChanges := make(chan cache.InteractiveChange)
+	oldSub := cachemocks.NewMockInteractiveSubscription(ctrl)
+	oldSub.EXPECT().Changes().Return((<-chan cache.InteractiveChange)(oldChanges))
+	oldSub.EXPECT().Watch(gomock.Any(), "article", int64(1)).Return(nil)
+	oldSub.EXPECT().Close().Return(nil)
+
+	// 断开以后重新订阅，之前在看的资源要重新订阅一遍
+	watched := make(chan struct{})
+	newChanges := make(chan cache.InteractiveChange, 10)
+	newSub := cachemocks.NewMockInteractiveSubscription(ctrl)
+	newSub.EXPECT().Changes().Return((<-chan cache.InteractiveChange)(newChanges))
+	newSub.EXPECT().Watch(gomock.Any(), "article", int64(1)).
+		DoAndReturn(func(ctx context.Context, biz string, bizId int64) error {
+			close(watched)
+			return nil
+		})
+	changeRepo := repomocks.NewMockInteractiveChangeRepository(ctrl)
+	gomock.InOrder(
+		changeRepo.EXPECT().Subscribe(gomock.Any()).Return(oldSub),
+		changeRepo.EXPECT().Subscribe(gomock.Any()).Return(newSub),
+	)
+
+	var readCnt atomic.Int64
+	repo := repomocks.NewMockInteractiveRepository(ctrl)
+	repo.EXPECT().Get(gomock.Any(), "article", int64(1)).
+		DoAndReturn(func(ctx context.Context, biz string, bizId int64) (domain.Interactive, error) {
+			return domain.Interactive{BizId: bizId, ReadCnt: readCnt.Load()}, nil
+		}).AnyTimes()
+
+	svc := NewLiveInteractiveService(repo, changeRepo, &logger.NopLogger{}).(*liveInteractiveService)
+	svc.interval = time.Millisecond * 10
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := svc.Watch(ctx, 123, "article", 1)
+	require.NoError(t, err)
+	waitLive(t, ch, 0)
+
+	// 订阅断了，断开期间的变化收不到，重新订阅以后会再推一次
+	readCnt.Store(2)
+	close(oldChanges)
+	waitLive(t, ch, 2)
+	<-watched
+
+	readCnt.Store(5)
+	newChanges <- cache.InteractiveChange{Biz: "article", BizId: 1}
+	waitLive(t, ch, 5)
+
+	done := make(chan struct{})
+	newSub.EXPECT().Unwatch(gomock.Any(), "article", int64(1)).
+		DoAndReturn(func(ctx context.Context, biz string, bizId int64) error {
+			close(done)
+			return nil
+		})
+	cancel()
+	<-done
+}
+
+func TestLiveWatcher_Send(t *testing.T) {
+	w := &liveWatcher{ch: make(chan domain.Interactive, 1)}
+	// 没有读走的旧计数被换成最新的
+	w.send(domain.Interactive{ReadCnt: 1})
+	w.send(domain.Interactive{ReadCnt: 2})
+	w.send(domain.Interactive{ReadCnt: 3})
+	assert.Equal(t, int64(3), (<-w.ch).ReadCnt)
+	assert.Len(t, w.ch, 0)
+}
+
+// waitLive 两个人连上的时间不一样，前面可能会多推一次旧的计数
+func waitLive(t *testing.T, ch <-chan domain.Interactive, readCnt int64) {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case intr := <-ch:
+			if intr.ReadCnt == readCnt {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("没有收到阅读数 %d", readCnt)
+		}
+	}
+}
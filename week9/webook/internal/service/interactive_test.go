@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	events "github.com/gevinzone/basic-go/week9/webook/internal/events/interactive"
+	evtmocks "github.com/gevinzone/basic-go/week9/webook/internal/events/interactive/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	repomocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func Test_interactiveService_ReconcileCnt(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.InteractiveRepository
+
+		wantReport domain.CntReconcileReport
+		wantErr    error
+	}{
+		{
+			name: "分两批校对完",
+			mock: func(ctrl *gomock.Controller) repository.InteractiveRepository {
+				repo := repomocks.NewMockInteractiveRepository(ctrl)
+				repo.EXPECT().ReconcileCnt(gomock.Any(), int64(0), reconcileBatchSize).
+					Return(domain.CntReconcileBatch{
+						LastId: 150,
+						Cnt:    reconcileBatchSize,
+						Drifts: []domain.CntDrift{
+							{Biz: "article", BizId: 1, LikeCnt: 3, ActualLike: 2, CollectCnt: 1, ActualCollect: 1},
+							{Biz: "article", BizId: 2, LikeCnt: 0, ActualLike: 2, CollectCnt: 4, ActualCollect: 1},
+						},
+					}, nil)
+				repo.EXPECT().ReconcileCnt(gomock.Any(), int64(150), reconcileBatchSize).
+					Return(domain.CntReconcileBatch{
+						LastId: 180,
+						Cnt:    20,
+					}, nil)
+				return repo
+			},
+			wantReport: domain.CntReconcileReport{
+				Checked:      reconcileBatchSize + 20,
+				Fixed:        2,
+				LikeDrift:    3,
+				CollectDrift: 3,
+			},
+		},
+		{
+			name: "中途失败，返回已经校对的部分",
+			mock: func(ctrl *gomock.Controller) repository.InteractiveRepository {
+				repo := repomocks.NewMockInteractiveRepository(ctrl)
+				repo.EXPECT().ReconcileCnt(gomock.Any(), int64(0), reconcileBatchSize).
+					Return(domain.CntReconcileBatch{
+						LastId: 100,
+						Cnt:    reconcileBatchSize,
+						Drifts: []domain.CntDrift{
+							{Biz: "article", BizId: 1, LikeCnt: 3, ActualLike: 2},
+						},
+					}, nil)
+				repo.EXPECT().ReconcileCnt(gomock.Any(), int64(100), reconcileBatchSize).
+					Return(domain.CntReconcileBatch{}, errors.New("mock db error"))
+				return repo
+			},
+			wantReport: domain.CntReconcileReport{
+				Checked:   reconcileBatchSize,
+				Fixed:     1,
+				LikeDrift: 1,
+			},
+			wantErr: errors.New("mock db error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewInteractiveService(tc.mock(ctrl), nil, nil, &logger.NopLogger{})
+			report, err := svc.ReconcileCnt(context.Background())
+			assert.Equal(t, tc.wantErr, err)
+			assert.Equal(t, tc.wantReport, report)
+		})
+	}
+}
+
+func Test_interactiveService_React(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (repository.InteractiveRepository, events.Producer)
+
+		reaction domain.ReactionType
+		wantErr  error
+	}{
+		{
+			name: "第一次表态，通知作者",
+			mock: func(ctrl *gomock.Controller) (repository.InteractiveRepository, events.Producer) {
+				repo := repomocks.NewMockInteractiveRepository(ctrl)
+				repo.EXPECT().React(gomock.Any(), "article", int64(1), int64(123), domain.ReactionLove).
+					Return(true, nil)
+				producer := evtmocks.NewMockProducer(ctrl)
+				producer.EXPECT().ProduceInteractiveEvent(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, evt events.InteractiveEvent) error {
+						assert.Equal(t, events.TypeLike, evt.Type)
+						assert.Equal(t, int64(123), evt.Uid)
+						return nil
+					})
+				return repo, producer
+			},
+			reaction: domain.ReactionLove,
+		},
+		{
+			name: "重复表态或者换一种表态，不再通知",
+			mock: func(ctrl *gomock.Controller) (repository.InteractiveRepository, events.Producer) {
+				repo := repomocks.NewMockInteractiveRepository(ctrl)
+				repo.EXPECT().React(gomock.Any(), "article", int64(1), int64(123), domain.ReactionLove).
+					Return(false, nil)
+				return repo, evtmocks.NewMockProducer(ctrl)
+			},
+			reaction: domain.ReactionLove,
+		},
+		{
+			name: "不支持的表态",
+			mock: func(ctrl *gomock.Controller) (repository.InteractiveRepository, events.Producer) {
+				return repomocks.NewMockInteractiveRepository(ctrl), evtmocks.NewMockProducer(ctrl)
+			},
+			reaction: "angry",
+			wantErr:  ErrInvalidReaction,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			repo, producer := tc.mock(ctrl)
+			svc := NewInteractiveService(repo, nil, producer, &logger.NopLogger{})
+			err := svc.React(context.Background(), "article", 1, 123, tc.reaction)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
@@ -2,16 +2,26 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
 	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
 	"time"
 )
 
+//go:generate mockgen -source=job.go -package=svcmocks -destination=mocks/job.mock.go JobService
 type JobService interface {
 	// Preempt 抢占
 	Preempt(ctx context.Context) (domain.Job, error)
 	ResetNextTime(ctx context.Context, j domain.Job) error
+	// ScheduleOnce 注册一个只在 at 时刻执行一次的任务
+	// 同名的任务会被覆盖，所以重复调用只会调整执行时间
+	ScheduleOnce(ctx context.Context, j domain.Job, at time.Time) error
+	// Cancel 取消还没有执行的任务
+	Cancel(ctx context.Context, name string) error
+	// Register 注册按照 cron 表达式周期执行的任务。
+	// 已经注册过的任务保持原样，所以每个实例启动的时候都可以调用
+	Register(ctx context.Context, j domain.Job) error
 	// 我返回一个释放的方法，然后调用者取调
 	// PreemptV1(ctx context.Context) (domain.Job, func() error,  error)
 	// Release
@@ -24,8 +34,38 @@ type cronJobService struct {
 	l               logger.LoggerV1
 }
 
+func NewCronJobService(repo repository.JobRepository, l logger.LoggerV1) JobService {
+	return &cronJobService{
+		repo:            repo,
+		l:               l,
+		refreshInterval: time.Minute,
+	}
+}
+
+func (p *cronJobService) ScheduleOnce(ctx context.Context, j domain.Job, at time.Time) error {
+	// 只执行一次的任务不需要 cron 表达式
+	j.Cron = ""
+	return p.repo.Upsert(ctx, j, at)
+}
+
+func (p *cronJobService) Register(ctx context.Context, j domain.Job) error {
+	next := j.NextTime()
+	if next.IsZero() {
+		return fmt.Errorf("任务 %s 的 cron 表达式不合法 %q", j.Name, j.Cron)
+	}
+	return p.repo.CreateIfAbsent(ctx, j, next)
+}
+
+func (p *cronJobService) Cancel(ctx context.Context, name string) error {
+	return p.repo.StopByName(ctx, name)
+}
+
 func (p *cronJobService) Preempt(ctx context.Context) (domain.Job, error) {
 	j, err := p.repo.Preempt(ctx, p.refreshInterval)
+	if err != nil {
+		// 没抢到，自然也就不需要续约
+		return domain.Job{}, err
+	}
 
 	// 你的续约呢？
 	//ch := make(chan struct{})
@@ -43,24 +83,31 @@ func (p *cronJobService) Preempt(ctx context.Context) (domain.Job, error) {
 	//	}
 	//}()
 
+	// ticker.Stop 不会关闭 ticker.C，所以要用 ch 来通知续约的 goroutine 退出
+	ch := make(chan struct{})
 	ticker := time.NewTicker(p.refreshInterval)
 	go func() {
-		for range ticker.C {
-			p.refresh(j.Id)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.refresh(j.Id)
+			case <-ch:
+				return
+			}
 		}
 	}()
 
 	// 你抢占之后，你一直抢占着吗？
 	// 你要考虑一个释放的问题
 	j.CancelFunc = func() error {
-		//close(ch)
+		close(ch)
 		// 自己在这里释放掉
-		ticker.Stop()
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 		defer cancel()
-		return p.repo.Release(ctx, j.Id)
+		return p.repo.Release(ctx, j.Id, j.Version)
 	}
-	return j, err
+	return j, nil
 }
 
 func (p *cronJobService) ResetNextTime(ctx context.Context, j domain.Job) error {
@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: ./interactive.go
+// Source: interactive.go
 //
 // Generated by this command:
 //
-//	mockgen -source=./interactive.go -package=svcmocks -destination=mocks/interactive.mock.go InteractiveService
+//	mockgen -source=interactive.go -package=svcmocks -destination=mocks/interactive.mock.go
 //
 // Package svcmocks is a generated GoMock package.
 package svcmocks
@@ -11,6 +11,7 @@ package svcmocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	domain "github.com/gevinzone/basic-go/week9/webook/internal/domain"
 	gomock "go.uber.org/mock/gomock"
@@ -53,6 +54,20 @@ func (mr *MockInteractiveServiceMockRecorder) CancelLike(ctx, biz, bizId, uid an
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelLike", reflect.TypeOf((*MockInteractiveService)(nil).CancelLike), ctx, biz, bizId, uid)
 }
 
+// CancelReaction mocks base method.
+func (m *MockInteractiveService) CancelReaction(ctx context.Context, biz string, bizId, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelReaction", ctx, biz, bizId, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelReaction indicates an expected call of CancelReaction.
+func (mr *MockInteractiveServiceMockRecorder) CancelReaction(ctx, biz, bizId, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelReaction", reflect.TypeOf((*MockInteractiveService)(nil).CancelReaction), ctx, biz, bizId, uid)
+}
+
 // Collect mocks base method.
 func (m *MockInteractiveService) Collect(ctx context.Context, biz string, bizId, cid, uid int64) error {
 	m.ctrl.T.Helper()
@@ -67,6 +82,35 @@ func (mr *MockInteractiveServiceMockRecorder) Collect(ctx, biz, bizId, cid, uid
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Collect", reflect.TypeOf((*MockInteractiveService)(nil).Collect), ctx, biz, bizId, cid, uid)
 }
 
+// Delete mocks base method.
+func (m *MockInteractiveService) Delete(ctx context.Context, biz string, bizId int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, biz, bizId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockInteractiveServiceMockRecorder) Delete(ctx, biz, bizId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockInteractiveService)(nil).Delete), ctx, biz, bizId)
+}
+
+// FlushReadCnt mocks base method.
+func (m *MockInteractiveService) FlushReadCnt(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FlushReadCnt", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FlushReadCnt indicates an expected call of FlushReadCnt.
+func (mr *MockInteractiveServiceMockRecorder) FlushReadCnt(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushReadCnt", reflect.TypeOf((*MockInteractiveService)(nil).FlushReadCnt), ctx)
+}
+
 // Get mocks base method.
 func (m *MockInteractiveService) Get(ctx context.Context, biz string, bizId, uid int64) (domain.Interactive, error) {
 	m.ctrl.T.Helper()
@@ -124,3 +168,76 @@ func (mr *MockInteractiveServiceMockRecorder) Like(ctx, biz, bizId, uid any) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Like", reflect.TypeOf((*MockInteractiveService)(nil).Like), ctx, biz, bizId, uid)
 }
+
+// ListLikes mocks base method.
+func (m *MockInteractiveService) ListLikes(ctx context.Context, uid int64, cursor domain.UserBizCursor, limit int) ([]domain.UserBizRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLikes", ctx, uid, cursor, limit)
+	ret0, _ := ret[0].([]domain.UserBizRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLikes indicates an expected call of ListLikes.
+func (mr *MockInteractiveServiceMockRecorder) ListLikes(ctx, uid, cursor, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLikes", reflect.TypeOf((*MockInteractiveService)(nil).ListLikes), ctx, uid, cursor, limit)
+}
+
+// React mocks base method.
+func (m *MockInteractiveService) React(ctx context.Context, biz string, bizId, uid int64, reaction domain.ReactionType) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "React", ctx, biz, bizId, uid, reaction)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// React indicates an expected call of React.
+func (mr *MockInteractiveServiceMockRecorder) React(ctx, biz, bizId, uid, reaction any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "React", reflect.TypeOf((*MockInteractiveService)(nil).React), ctx, biz, bizId, uid, reaction)
+}
+
+// ReconcileCnt mocks base method.
+func (m *MockInteractiveService) ReconcileCnt(ctx context.Context) (domain.CntReconcileReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileCnt", ctx)
+	ret0, _ := ret[0].(domain.CntReconcileReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReconcileCnt indicates an expected call of ReconcileCnt.
+func (mr *MockInteractiveServiceMockRecorder) ReconcileCnt(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileCnt", reflect.TypeOf((*MockInteractiveService)(nil).ReconcileCnt), ctx)
+}
+
+// RollupUV mocks base method.
+func (m *MockInteractiveService) RollupUV(ctx context.Context, day time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollupUV", ctx, day)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RollupUV indicates an expected call of RollupUV.
+func (mr *MockInteractiveServiceMockRecorder) RollupUV(ctx, day any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollupUV", reflect.TypeOf((*MockInteractiveService)(nil).RollupUV), ctx, day)
+}
+
+// Uncollect mocks base method.
+func (m *MockInteractiveService) Uncollect(ctx context.Context, biz string, bizId, uid int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Uncollect", ctx, biz, bizId, uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Uncollect indicates an expected call of Uncollect.
+func (mr *MockInteractiveServiceMockRecorder) Uncollect(ctx, biz, bizId, uid any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Uncollect", reflect.TypeOf((*MockInteractiveService)(nil).Uncollect), ctx, biz, bizId, uid)
+}
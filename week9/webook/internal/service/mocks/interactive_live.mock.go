@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interactive_live.go
+//
+// Generated by this command:
+//
+//	mockgen -source=interactive_live.go -package=svcmocks -destination=mocks/interactive_live.mock.go
+//
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockInteractiveLiveService is a mock of InteractiveLiveService interface.
+type MockInteractiveLiveService struct {
+	ctrl     *gomock.Controller
+	recorder *MockInteractiveLiveServiceMockRecorder
+}
+
+// MockInteractiveLiveServiceMockRecorder is the mock recorder for MockInteractiveLiveService.
+type MockInteractiveLiveServiceMockRecorder struct {
+	mock *MockInteractiveLiveService
+}
+
+// NewMockInteractiveLiveService creates a new mock instance.
+func NewMockInteractiveLiveService(ctrl *gomock.Controller) *MockInteractiveLiveService {
+	mock := &MockInteractiveLiveService{ctrl: ctrl}
+	mock.recorder = &MockInteractiveLiveServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInteractiveLiveService) EXPECT() *MockInteractiveLiveServiceMockRecorder {
+	return m.recorder
+}
+
+// Watch mocks base method.
+func (m *MockInteractiveLiveService) Watch(ctx context.Context, uid int64, biz string, bizId int64) (<-chan domain.Interactive, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Watch", ctx, uid, biz, bizId)
+	ret0, _ := ret[0].(<-chan domain.Interactive)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Watch indicates an expected call of Watch.
+func (mr *MockInteractiveLiveServiceMockRecorder) Watch(ctx, uid, biz, bizId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockInteractiveLiveService)(nil).Watch), ctx, uid, biz, bizId)
+}
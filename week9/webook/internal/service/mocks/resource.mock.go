@@ -0,0 +1,54 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: resource.go
+//
+// Generated by this command:
+//
+//	mockgen -source=resource.go -package=svcmocks -destination=mocks/resource.mock.go
+//
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockResourceService is a mock of ResourceService interface.
+type MockResourceService struct {
+	ctrl     *gomock.Controller
+	recorder *MockResourceServiceMockRecorder
+}
+
+// MockResourceServiceMockRecorder is the mock recorder for MockResourceService.
+type MockResourceServiceMockRecorder struct {
+	mock *MockResourceService
+}
+
+// NewMockResourceService creates a new mock instance.
+func NewMockResourceService(ctrl *gomock.Controller) *MockResourceService {
+	mock := &MockResourceService{ctrl: ctrl}
+	mock.recorder = &MockResourceServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockResourceService) EXPECT() *MockResourceServiceMockRecorder {
+	return m.recorder
+}
+
+// Resolve mocks base method.
+func (m *MockResourceService) Resolve(ctx context.Context, res []domain.Resource) map[domain.Resource]domain.ResourceSummary {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resolve", ctx, res)
+	ret0, _ := ret[0].(map[domain.Resource]domain.ResourceSummary)
+	return ret0
+}
+
+// Resolve indicates an expected call of Resolve.
+func (mr *MockResourceServiceMockRecorder) Resolve(ctx, res any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resolve", reflect.TypeOf((*MockResourceService)(nil).Resolve), ctx, res)
+}
@@ -0,0 +1,69 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: search.go
+//
+// Generated by this command:
+//
+//	mockgen -source=search.go -package=svcmocks -destination=mocks/search.mock.go SearchService
+//
+// Package svcmocks is a generated GoMock package.
+package svcmocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSearchService is a mock of SearchService interface.
+type MockSearchService struct {
+	ctrl     *gomock.Controller
+	recorder *MockSearchServiceMockRecorder
+}
+
+// MockSearchServiceMockRecorder is the mock recorder for MockSearchService.
+type MockSearchServiceMockRecorder struct {
+	mock *MockSearchService
+}
+
+// NewMockSearchService creates a new mock instance.
+func NewMockSearchService(ctrl *gomock.Controller) *MockSearchService {
+	mock := &MockSearchService{ctrl: ctrl}
+	mock.recorder = &MockSearchServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSearchService) EXPECT() *MockSearchServiceMockRecorder {
+	return m.recorder
+}
+
+// RebuildArticleIndex mocks base method.
+func (m *MockSearchService) RebuildArticleIndex(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RebuildArticleIndex", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RebuildArticleIndex indicates an expected call of RebuildArticleIndex.
+func (mr *MockSearchServiceMockRecorder) RebuildArticleIndex(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RebuildArticleIndex", reflect.TypeOf((*MockSearchService)(nil).RebuildArticleIndex), ctx)
+}
+
+// SearchArticle mocks base method.
+func (m *MockSearchService) SearchArticle(ctx context.Context, q string, offset, limit int) (domain.ArticleSearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchArticle", ctx, q, offset, limit)
+	ret0, _ := ret[0].(domain.ArticleSearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchArticle indicates an expected call of SearchArticle.
+func (mr *MockSearchServiceMockRecorder) SearchArticle(ctx, q, offset, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchArticle", reflect.TypeOf((*MockSearchService)(nil).SearchArticle), ctx, q, offset, limit)
+}
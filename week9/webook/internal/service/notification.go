@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+)
+
+//go:generate mockgen -source=./notification.go -package=svcmocks -destination=mocks/notification.mock.go NotificationService
+type NotificationService interface {
+	// Notify 收到一次互动之后通知对方，自己和自己的互动不通知
+	Notify(ctx context.Context, evt domain.NotificationEvent) error
+	// List 收到的通知，最近有互动的在前面
+	List(ctx context.Context, uid int64,
+		cursor domain.UserBizCursor, limit int) ([]domain.Notification, error)
+	// MarkRead ids 为空的时候全部标记成已读
+	MarkRead(ctx context.Context, uid int64, ids []int64) error
+	UnreadCnt(ctx context.Context, uid int64) (int64, error)
+}
+
+type notificationService struct {
+	repo repository.NotificationRepository
+	// 点赞和收藏的时候没有带上作者，要按照 biz 去找
+	owners map[string]BizOwnerFunc
+	l      logger.LoggerV1
+}
+
+func NewNotificationService(repo repository.NotificationRepository,
+	owners map[string]BizOwnerFunc,
+	l logger.LoggerV1) NotificationService {
+	return &notificationService{
+		repo:   repo,
+		owners: owners,
+		l:      l,
+	}
+}
+
+func (s *notificationService) Notify(ctx context.Context, evt domain.NotificationEvent) error {
+	receiver := evt.Receiver
+	if receiver <= 0 {
+		fn, ok := s.owners[evt.Biz]
+		if !ok {
+			s.l.Warn("不知道怎么找资源的作者",
+				logger.String("biz", evt.Biz),
+				logger.Int64("bizId", evt.BizId))
+			return nil
+		}
+		var err error
+		receiver, err = fn(ctx, evt.BizId)
+		if err == ErrCommentTargetNotFound {
+			// 资源已经没有了，也就不用通知了
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if receiver == evt.Actor {
+		return nil
+	}
+	return s.repo.Add(ctx, receiver, evt)
+}
+
+func (s *notificationService) List(ctx context.Context, uid int64,
+	cursor domain.UserBizCursor, limit int) ([]domain.Notification, error) {
+	return s.repo.List(ctx, uid, cursor, limit)
+}
+
+func (s *notificationService) MarkRead(ctx context.Context, uid int64, ids []int64) error {
+	return s.repo.MarkRead(ctx, uid, ids)
+}
+
+func (s *notificationService) UnreadCnt(ctx context.Context, uid int64) (int64, error) {
+	return s.repo.UnreadCnt(ctx, uid)
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	repomocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+	"time"
+)
+
+func Test_notificationService_Notify(t *testing.T) {
+	now := time.UnixMilli(1000)
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) repository.NotificationRepository
+
+		evt domain.NotificationEvent
+
+		wantErr error
+	}{
+		{
+			name: "点赞通知帖子的作者",
+			mock: func(ctrl *gomock.Controller) repository.NotificationRepository {
+				repo := repomocks.NewMockNotificationRepository(ctrl)
+				repo.EXPECT().Add(gomock.Any(), int64(123), domain.NotificationEvent{
+					Type:  domain.NotificationLike,
+					Biz:   "article",
+					BizId: 1,
+					Actor: 234,
+					Time:  now,
+				}).Return(nil)
+				return repo
+			},
+			evt: domain.NotificationEvent{
+				Type:  domain.NotificationLike,
+				Biz:   "article",
+				BizId: 1,
+				Actor: 234,
+				Time:  now,
+			},
+		},
+		{
+			name: "回复通知被回复的人",
+			mock: func(ctrl *gomock.Controller) repository.NotificationRepository {
+				repo := repomocks.NewMockNotificationRepository(ctrl)
+				repo.EXPECT().Add(gomock.Any(), int64(345), gomock.Any()).Return(nil)
+				return repo
+			},
+			evt: domain.NotificationEvent{
+				Type:     domain.NotificationComment,
+				Biz:      "article",
+				BizId:    1,
+				Actor:    234,
+				Receiver: 345,
+				Time:     now,
+			},
+		},
+		{
+			name: "给自己点赞不通知",
+			mock: func(ctrl *gomock.Controller) repository.NotificationRepository {
+				return repomocks.NewMockNotificationRepository(ctrl)
+			},
+			evt: domain.NotificationEvent{
+				Type:  domain.NotificationLike,
+				Biz:   "article",
+				BizId: 1,
+				Actor: 123,
+			},
+		},
+		{
+			name: "帖子已经没有了",
+			mock: func(ctrl *gomock.Controller) repository.NotificationRepository {
+				return repomocks.NewMockNotificationRepository(ctrl)
+			},
+			evt: domain.NotificationEvent{
+				Type:  domain.NotificationCollect,
+				Biz:   "article",
+				BizId: 2,
+				Actor: 234,
+			},
+		},
+		{
+			name: "不认识的 biz",
+			mock: func(ctrl *gomock.Controller) repository.NotificationRepository {
+				return repomocks.NewMockNotificationRepository(ctrl)
+			},
+			evt: domain.NotificationEvent{
+				Type:  domain.NotificationLike,
+				Biz:   "unknown",
+				BizId: 1,
+				Actor: 234,
+			},
+		},
+		{
+			name: "找作者出错",
+			mock: func(ctrl *gomock.Controller) repository.NotificationRepository {
+				return repomocks.NewMockNotificationRepository(ctrl)
+			},
+			evt: domain.NotificationEvent{
+				Type:  domain.NotificationLike,
+				Biz:   "broken",
+				BizId: 1,
+				Actor: 234,
+			},
+			wantErr: errors.New("mock db error"),
+		},
+	}
+
+	owners := map[string]BizOwnerFunc{
+		"article": testCommentOwners["article"],
+		"broken": func(ctx context.Context, bizId int64) (int64, error) {
+			return 0, errors.New("mock db error")
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			svc := NewNotificationService(tc.mock(ctrl), owners, &logger.NopLogger{})
+			err := svc.Notify(context.Background(), tc.evt)
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
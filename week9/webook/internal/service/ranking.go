@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/ecodeclub/ekit/queue"
 	"github.com/ecodeclub/ekit/slice"
+	"github.com/ecodeclub/ekit/syncx/atomicx"
 	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
 	"math"
@@ -11,132 +14,219 @@ import (
 )
 
 type RankingService interface {
+	// TopN 算有窗口的榜单，比如 24 小时热榜、周榜，只扫到最大的窗口，每个榜单单独存起来
 	TopN(ctx context.Context) error
+	// TopNAllTime 算不限窗口的榜单，要扫全部帖子，所以单独调度，超时也要给长一点
+	TopNAllTime(ctx context.Context) error
 	//TopN(ctx context.Context, n int64) error
 	//TopN(ctx context.Context, n int64) ([]domain.Article, error)
 }
 
+// RankingStrategy 计算一篇帖子的热度，不能返回负数
+type RankingStrategy interface {
+	Score(art domain.Article, intr domain.Interactive, now time.Time) float64
+}
+
+// GravityStrategy 阅读数、点赞数、收藏数加权求和，再按照帖子的年龄衰减：
+// (阅读 * ReadWeight + 点赞 * LikeWeight + 收藏 * CollectWeight) / (小时数 + 2) ^ Gravity。
+// 权重和 Gravity 都不能是负数，Gravity 越大，老帖子掉得越快，0 就是不衰减
+type GravityStrategy struct {
+	ReadWeight    float64
+	LikeWeight    float64
+	CollectWeight float64
+	Gravity       float64
+}
+
+func (s GravityStrategy) Score(art domain.Article, intr domain.Interactive, now time.Time) float64 {
+	weighted := float64(intr.ReadCnt)*s.ReadWeight +
+		float64(intr.LikeCnt)*s.LikeWeight +
+		float64(intr.CollectCnt)*s.CollectWeight
+	// 机器之间的时钟有偏差，刚更新的帖子可能算出负数
+	hours := math.Max(now.Sub(art.Utime).Hours(), 0)
+	return weighted / math.Pow(hours+2, s.Gravity)
+}
+
+// RankingBoard 一个榜单，比如 24 小时热榜、周榜、总榜
+type RankingBoard struct {
+	// Name 也用来区分每个榜单存在哪里
+	Name string
+	// Window 只有这段时间内更新过的帖子才能上榜，0 代表不限制
+	Window time.Duration
+	// N 取前多少名
+	N        int
+	Strategy RankingStrategy
+	// Expiration 榜单缓存多久，要比这个榜单计算的间隔长，
+	// 不然两次计算之间榜单就过期了
+	Expiration time.Duration
+}
+
 type BatchRankingService struct {
 	artSvc    ArticleService
 	intrSvc   InteractiveService
 	repo      repository.RankingRepository
 	batchSize int
-	n         int
-	// scoreFunc 不能返回负数
-	scoreFunc func(t time.Time, likeCnt int64) float64
+	// 配置变更的时候整体替换，正在算的那一轮还是用旧的
+	boards *atomicx.Value[[]RankingBoard]
 
 	// 负载
 	load int64
 }
 
-func NewBatchRankingService(artSvc ArticleService, intrSvc InteractiveService) RankingService {
+func NewBatchRankingService(artSvc ArticleService,
+	intrSvc InteractiveService,
+	repo repository.RankingRepository,
+	boards []RankingBoard) *BatchRankingService {
 	return &BatchRankingService{
 		artSvc:    artSvc,
 		intrSvc:   intrSvc,
+		repo:      repo,
 		batchSize: 100,
-		n:         100,
-		scoreFunc: func(t time.Time, likeCnt int64) float64 {
-			sec := time.Since(t).Seconds()
-			return float64(likeCnt-1) / math.Pow(float64(sec+2), 1.5)
-		},
+		boards:    atomicx.NewValueOf(boards),
 	}
 }
 
-// 准备分批
+// UpdateBoards 替换榜单的配置，下一次计算生效
+func (svc *BatchRankingService) UpdateBoards(boards []RankingBoard) {
+	svc.boards.Store(boards)
+}
+
 func (svc *BatchRankingService) TopN(ctx context.Context) error {
-	arts, err := svc.topN(ctx)
+	boards := slice.FilterMap(svc.boards.Load(), func(idx int, src RankingBoard) (RankingBoard, bool) {
+		return src, src.Window > 0
+	})
+	return svc.replaceTopN(ctx, boards)
+}
+
+func (svc *BatchRankingService) TopNAllTime(ctx context.Context) error {
+	boards := slice.FilterMap(svc.boards.Load(), func(idx int, src RankingBoard) (RankingBoard, bool) {
+		return src, src.Window == 0
+	})
+	return svc.replaceTopN(ctx, boards)
+}
+
+// replaceTopN 算完之后每个榜单单独存，一个榜单存失败了不影响别的榜单
+func (svc *BatchRankingService) replaceTopN(ctx context.Context, boards []RankingBoard) error {
+	res, err := svc.topN(ctx, boards)
 	if err != nil {
 		return err
 	}
-	// 在这里，存起来
-	return svc.repo.ReplaceTopN(ctx, arts)
+	var errs []error
+	for i, board := range boards {
+		err = svc.repo.ReplaceTopN(ctx, board.Name, res[i], board.Expiration)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("存储榜单 %s 失败 %w", board.Name, err))
+		}
+	}
+	return errors.Join(errs...)
 }
 
-// topN 已经搞完了
-func (svc *BatchRankingService) topN(ctx context.Context) ([]domain.Article, error) {
-	// 我只取七天内的数据
-	now := time.Now()
-	// 先拿一批数据
-	offset := 0
-	type Score struct {
-		art   domain.Article
-		score float64
+type rankingScore struct {
+	art   domain.Article
+	score float64
+}
+
+// topN 所有榜单共用一次扫描，返回的结果和 boards 一一对应
+func (svc *BatchRankingService) topN(ctx context.Context,
+	boards []RankingBoard) ([][]domain.Article, error) {
+	if len(boards) == 0 {
+		return nil, nil
 	}
+	now := time.Now()
+	// 只扫到最大的那个窗口，有总榜的话就要扫全部
+	window := scanWindow(boards)
 	// 这里可以用非并发安全
-	topN := queue.NewConcurrentPriorityQueue[Score](svc.n,
-		func(src Score, dst Score) int {
-			if src.score > dst.score {
-				return 1
-			} else if src.score == dst.score {
-				return 0
-			} else {
-				return -1
-			}
-		})
-
+	topNs := slice.Map(boards, func(idx int, src RankingBoard) *queue.ConcurrentPriorityQueue[rankingScore] {
+		return queue.NewConcurrentPriorityQueue[rankingScore](src.N,
+			func(src rankingScore, dst rankingScore) int {
+				if src.score > dst.score {
+					return 1
+				} else if src.score == dst.score {
+					return 0
+				} else {
+					return -1
+				}
+			})
+	})
+	// 先拿一批数据。用游标翻页，不然越往后 offset 越大，扫描的数据越多
+	var cursor domain.ArticleCursor
 	for {
 		// 这里拿了一批
-		arts, err := svc.artSvc.ListPub(ctx, now, offset, svc.batchSize)
+		arts, err := svc.artSvc.ListPubByCursor(ctx, cursor, svc.batchSize)
 		if err != nil {
 			return nil, err
 		}
+		if len(arts) == 0 {
+			break
+		}
 		ids := slice.Map[domain.Article, int64](arts,
 			func(idx int, src domain.Article) int64 {
 				return src.Id
 			})
-		// 要去找到对应的点赞数据
+		// 要去找到对应的阅读、点赞、收藏数据
 		intrs, err := svc.intrSvc.GetByIds(ctx, "article", ids)
 		if err != nil {
 			return nil, err
 		}
-		// 合并计算 score
-		// 排序
+		// 合并计算 score，一篇帖子可能同时上好几个榜
 		for _, art := range arts {
 			intr := intrs[art.Id]
-			//if !ok {
-			//	// 你都没有，肯定不可能是热榜
-			//	continue
-			//}
-			score := svc.scoreFunc(art.Utime, intr.LikeCnt)
-			// 我要考虑，我这个 score 在不在前一百名
-			// 拿到热度最低的
-			err = topN.Enqueue(Score{
-				art:   art,
-				score: score,
-			})
-			// 这种写法，要求 topN 已经满了
-			if err == queue.ErrOutOfCapacity {
-				val, _ := topN.Dequeue()
-				if val.score < score {
-					_ = topN.Enqueue(Score{
-						art:   art,
-						score: score,
-					})
-				} else {
-					_ = topN.Enqueue(val)
+			for i, board := range boards {
+				if board.Window > 0 && now.Sub(art.Utime) > board.Window {
+					continue
 				}
+				enqueueTopN(topNs[i], rankingScore{
+					art:   art,
+					score: board.Strategy.Score(art, intr, now),
+				})
 			}
 		}
 
 		// 一批已经处理完了，问题来了，我要不要进入下一批？我怎么知道还有没有？
 		if len(arts) < svc.batchSize ||
-			now.Sub(arts[len(arts)-1].Utime).Hours() > 7*24 {
+			(window > 0 && now.Sub(arts[len(arts)-1].Utime) > window) {
 			// 我这一批都没取够，我当然可以肯定没有下一批了
-			// 又或者已经取到了七天之前的数据了，说明可以中断了
+			// 又或者已经取到了所有榜单的窗口之外，说明可以中断了
 			break
 		}
-		// 这边要更新 offset
-		offset = offset + len(arts)
+		// 下一批从这一批的最后一条开始
+		cursor = arts[len(arts)-1].Cursor()
 	}
 	// 最后得出结果
-	res := make([]domain.Article, svc.n)
-	for i := svc.n - 1; i >= 0; i-- {
-		val, err := topN.Dequeue()
-		if err != nil {
-			// 说明取完了，不够 n
-			break
+	return slice.Map(topNs, func(idx int, src *queue.ConcurrentPriorityQueue[rankingScore]) []domain.Article {
+		// 不够 n 的时候有多少返回多少，不能拿零值凑数
+		res := make([]domain.Article, src.Len())
+		for i := len(res) - 1; i >= 0; i-- {
+			val, _ := src.Dequeue()
+			res[i] = val.art
+		}
+		return res
+	}), nil
+}
+
+// enqueueTopN topN 是小顶堆，满了之后只有比堆顶热度高的才能挤进去
+func enqueueTopN(topN *queue.ConcurrentPriorityQueue[rankingScore], s rankingScore) {
+	err := topN.Enqueue(s)
+	// 这种写法，要求 topN 已经满了
+	if err == queue.ErrOutOfCapacity {
+		val, _ := topN.Dequeue()
+		if val.score < s.score {
+			_ = topN.Enqueue(s)
+		} else {
+			_ = topN.Enqueue(val)
+		}
+	}
+}
+
+// scanWindow 所有榜单里面最大的窗口，0 代表要扫全部
+func scanWindow(boards []RankingBoard) time.Duration {
+	var res time.Duration
+	for _, board := range boards {
+		if board.Window == 0 {
+			return 0
+		}
+		if board.Window > res {
+			res = board.Window
 		}
-		res[i] = val.art
 	}
-	return res, nil
+	return res
 }
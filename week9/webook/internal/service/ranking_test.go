@@ -2,55 +2,180 @@ package service
 
 import (
 	"context"
+	"errors"
 	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	repomocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/mocks"
 	svcmocks "github.com/gevinzone/basic-go/week9/webook/internal/service/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	"testing"
 	"time"
 )
 
+// likeCntStrategy 为了测试，热度就是点赞数
+type likeCntStrategy struct{}
+
+func (likeCntStrategy) Score(art domain.Article, intr domain.Interactive, now time.Time) float64 {
+	return float64(intr.LikeCnt)
+}
+
 func TestRankingTopN(t *testing.T) {
 	now := time.Now()
+	hourAgo := now.Add(-time.Hour)
+	weekAgo := now.Add(-time.Hour * 24 * 8)
 	testCases := []struct {
-		name string
-		mock func(ctrl *gomock.Controller) (ArticleService,
+		name   string
+		boards []RankingBoard
+		mock   func(ctrl *gomock.Controller) (ArticleService,
 			InteractiveService)
 
 		wantErr  error
-		wantArts []domain.Article
+		wantArts [][]domain.Article
 	}{
 		{
 			name: "计算成功",
+			boards: []RankingBoard{
+				{Name: "all-time", N: 3, Strategy: likeCntStrategy{}},
+			},
 			// 怎么模拟我的数据？
 			mock: func(ctrl *gomock.Controller) (ArticleService, InteractiveService) {
 				artSvc := svcmocks.NewMockArticleService(ctrl)
 				// 最简单，一批就搞完
-				artSvc.EXPECT().ListPub(gomock.Any(), gomock.Any(), 0, 3).
+				artSvc.EXPECT().ListPubByCursor(gomock.Any(), domain.ArticleCursor{}, 3).
 					Return([]domain.Article{
-						{Id: 1, Utime: now, Ctime: now},
-						{Id: 2, Utime: now, Ctime: now},
 						{Id: 3, Utime: now, Ctime: now},
+						{Id: 2, Utime: now, Ctime: now},
+						{Id: 1, Utime: now, Ctime: now},
 					}, nil)
-				artSvc.EXPECT().ListPub(gomock.Any(), gomock.Any(), 3, 3).
+				// 下一批从上一批的最后一条开始
+				artSvc.EXPECT().ListPubByCursor(gomock.Any(),
+					domain.ArticleCursor{Utime: now, Id: 1}, 3).
 					Return([]domain.Article{}, nil)
 				intrSvc := svcmocks.NewMockInteractiveService(ctrl)
 				intrSvc.EXPECT().GetByIds(gomock.Any(),
-					"article", []int64{1, 2, 3}).
+					"article", []int64{3, 2, 1}).
 					Return(map[int64]domain.Interactive{
 						1: {BizId: 1, LikeCnt: 1},
 						2: {BizId: 2, LikeCnt: 2},
 						3: {BizId: 3, LikeCnt: 3},
 					}, nil)
+				return artSvc, intrSvc
+			},
+			wantArts: [][]domain.Article{
+				{
+					{Id: 3, Utime: now, Ctime: now},
+					{Id: 2, Utime: now, Ctime: now},
+					{Id: 1, Utime: now, Ctime: now},
+				},
+			},
+		},
+		{
+			name: "不够 n 篇",
+			boards: []RankingBoard{
+				{Name: "all-time", N: 5, Strategy: likeCntStrategy{}},
+			},
+			mock: func(ctrl *gomock.Controller) (ArticleService, InteractiveService) {
+				artSvc := svcmocks.NewMockArticleService(ctrl)
+				artSvc.EXPECT().ListPubByCursor(gomock.Any(), domain.ArticleCursor{}, 3).
+					Return([]domain.Article{
+						{Id: 2, Utime: now},
+						{Id: 1, Utime: now},
+					}, nil)
+				intrSvc := svcmocks.NewMockInteractiveService(ctrl)
+				intrSvc.EXPECT().GetByIds(gomock.Any(),
+					"article", []int64{2, 1}).
+					Return(map[int64]domain.Interactive{
+						1: {BizId: 1, LikeCnt: 2},
+					}, nil)
+				return artSvc, intrSvc
+			},
+			// 不能拿零值凑够 5 篇
+			wantArts: [][]domain.Article{
+				{
+					{Id: 1, Utime: now},
+					{Id: 2, Utime: now},
+				},
+			},
+		},
+		{
+			name: "多个榜单",
+			boards: []RankingBoard{
+				{Name: "hot", Window: time.Hour * 24, N: 2, Strategy: likeCntStrategy{}},
+				{Name: "all-time", N: 2, Strategy: likeCntStrategy{}},
+			},
+			mock: func(ctrl *gomock.Controller) (ArticleService, InteractiveService) {
+				artSvc := svcmocks.NewMockArticleService(ctrl)
+				artSvc.EXPECT().ListPubByCursor(gomock.Any(), domain.ArticleCursor{}, 3).
+					Return([]domain.Article{
+						{Id: 4, Utime: now},
+						{Id: 3, Utime: hourAgo},
+						{Id: 2, Utime: weekAgo},
+					}, nil)
+				// 有总榜，超过窗口了也要继续扫
+				artSvc.EXPECT().ListPubByCursor(gomock.Any(),
+					domain.ArticleCursor{Utime: weekAgo, Id: 2}, 3).
+					Return([]domain.Article{
+						{Id: 1, Utime: weekAgo},
+					}, nil)
+				intrSvc := svcmocks.NewMockInteractiveService(ctrl)
+				intrSvc.EXPECT().GetByIds(gomock.Any(),
+					"article", []int64{4, 3, 2}).
+					Return(map[int64]domain.Interactive{
+						4: {BizId: 4, LikeCnt: 1},
+						3: {BizId: 3, LikeCnt: 2},
+						2: {BizId: 2, LikeCnt: 10},
+					}, nil)
 				intrSvc.EXPECT().GetByIds(gomock.Any(),
-					"article", []int64{}).
-					Return(map[int64]domain.Interactive{}, nil)
+					"article", []int64{1}).
+					Return(map[int64]domain.Interactive{
+						1: {BizId: 1, LikeCnt: 5},
+					}, nil)
 				return artSvc, intrSvc
 			},
-			wantArts: []domain.Article{
-				{Id: 3, Utime: now, Ctime: now},
-				{Id: 2, Utime: now, Ctime: now},
-				{Id: 1, Utime: now, Ctime: now},
+			wantArts: [][]domain.Article{
+				{
+					{Id: 3, Utime: hourAgo},
+					{Id: 4, Utime: now},
+				},
+				{
+					{Id: 2, Utime: weekAgo},
+					{Id: 1, Utime: weekAgo},
+				},
+			},
+		},
+		{
+			name: "超出所有榜单的窗口就不再扫了",
+			boards: []RankingBoard{
+				{Name: "hot", Window: time.Hour * 24, N: 2, Strategy: likeCntStrategy{}},
+				{Name: "weekly", Window: time.Hour * 24 * 7, N: 2, Strategy: likeCntStrategy{}},
+			},
+			mock: func(ctrl *gomock.Controller) (ArticleService, InteractiveService) {
+				artSvc := svcmocks.NewMockArticleService(ctrl)
+				artSvc.EXPECT().ListPubByCursor(gomock.Any(), domain.ArticleCursor{}, 3).
+					Return([]domain.Article{
+						{Id: 3, Utime: now},
+						{Id: 2, Utime: hourAgo.Add(-time.Hour * 24)},
+						{Id: 1, Utime: weekAgo},
+					}, nil)
+				intrSvc := svcmocks.NewMockInteractiveService(ctrl)
+				intrSvc.EXPECT().GetByIds(gomock.Any(),
+					"article", []int64{3, 2, 1}).
+					Return(map[int64]domain.Interactive{
+						3: {BizId: 3, LikeCnt: 1},
+						2: {BizId: 2, LikeCnt: 2},
+						1: {BizId: 1, LikeCnt: 3},
+					}, nil)
+				return artSvc, intrSvc
+			},
+			wantArts: [][]domain.Article{
+				{
+					{Id: 3, Utime: now},
+				},
+				{
+					{Id: 2, Utime: hourAgo.Add(-time.Hour * 24)},
+					{Id: 3, Utime: now},
+				},
 			},
 		},
 	}
@@ -59,16 +184,72 @@ func TestRankingTopN(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 			artSvc, intrSvc := tc.mock(ctrl)
-			svc := NewBatchRankingService(artSvc, intrSvc)
+			svc := NewBatchRankingService(artSvc, intrSvc, nil, tc.boards)
 			// 为了测试
 			svc.batchSize = 3
-			svc.n = 3
-			svc.scoreFunc = func(t time.Time, likeCnt int64) float64 {
-				return float64(likeCnt)
-			}
-			arts, err := svc.topN(context.Background())
+			arts, err := svc.topN(context.Background(), tc.boards)
 			assert.Equal(t, tc.wantErr, err)
 			assert.Equal(t, tc.wantArts, arts)
 		})
 	}
 }
+
+func TestBatchRankingService_TopN(t *testing.T) {
+	now := time.Now()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	artSvc := svcmocks.NewMockArticleService(ctrl)
+	artSvc.EXPECT().ListPubByCursor(gomock.Any(), domain.ArticleCursor{}, 100).
+		Return([]domain.Article{{Id: 1, Utime: now}}, nil).Times(2)
+	intrSvc := svcmocks.NewMockInteractiveService(ctrl)
+	intrSvc.EXPECT().GetByIds(gomock.Any(), "article", []int64{1}).
+		Return(map[int64]domain.Interactive{}, nil).Times(2)
+	repo := repomocks.NewMockRankingRepository(ctrl)
+	svc := NewBatchRankingService(artSvc, intrSvc, repo, []RankingBoard{
+		{Name: "hot-24h", Window: time.Hour * 24, N: 10, Strategy: likeCntStrategy{}},
+	})
+	// 配置变了，用新的榜单
+	svc.UpdateBoards([]RankingBoard{
+		{Name: "hot-24h", Window: time.Hour * 24, N: 10, Strategy: likeCntStrategy{},
+			Expiration: time.Minute * 10},
+		{Name: "weekly", Window: time.Hour * 24 * 7, N: 10, Strategy: likeCntStrategy{},
+			Expiration: time.Minute * 10},
+		{Name: "all-time", N: 10, Strategy: likeCntStrategy{},
+			Expiration: time.Hour * 2},
+	})
+	// 每个榜单单独存，一个存失败了，别的榜单照样存
+	repo.EXPECT().ReplaceTopN(gomock.Any(), "hot-24h",
+		[]domain.Article{{Id: 1, Utime: now}}, time.Minute*10).Return(errors.New("redis 错误"))
+	repo.EXPECT().ReplaceTopN(gomock.Any(), "weekly",
+		[]domain.Article{{Id: 1, Utime: now}}, time.Minute*10).Return(nil)
+	err := svc.TopN(context.Background())
+	assert.Error(t, err)
+
+	// 总榜单独算，一个小时才算一次，缓存的时间也要更长
+	repo.EXPECT().ReplaceTopN(gomock.Any(), "all-time",
+		[]domain.Article{{Id: 1, Utime: now}}, time.Hour*2).Return(nil)
+	err = svc.TopNAllTime(context.Background())
+	require.NoError(t, err)
+}
+
+func TestGravityStrategy_Score(t *testing.T) {
+	now := time.Now()
+	s := GravityStrategy{ReadWeight: 0.1, LikeWeight: 1, CollectWeight: 2, Gravity: 1.8}
+	// 没有人点赞的帖子不能是负数
+	assert.Equal(t, float64(0), s.Score(domain.Article{Utime: now}, domain.Interactive{}, now))
+	intr := domain.Interactive{ReadCnt: 100, LikeCnt: 10, CollectCnt: 5}
+	// (100 * 0.1 + 10 * 1 + 5 * 2) / 2 ^ 1.8
+	assert.InDelta(t, 30/3.4822022531844965, s.Score(domain.Article{Utime: now}, intr, now), 1e-9)
+	// 一样的数据，越老越低
+	assert.Greater(t,
+		s.Score(domain.Article{Utime: now.Add(-time.Hour)}, intr, now),
+		s.Score(domain.Article{Utime: now.Add(-time.Hour * 24)}, intr, now))
+	// 收藏比点赞值钱
+	assert.Greater(t,
+		s.Score(domain.Article{Utime: now}, domain.Interactive{CollectCnt: 1}, now),
+		s.Score(domain.Article{Utime: now}, domain.Interactive{LikeCnt: 1}, now))
+	// 不衰减
+	s.Gravity = 0
+	assert.Equal(t, float64(30),
+		s.Score(domain.Article{Utime: now.Add(-time.Hour * 24 * 365)}, intr, now))
+}
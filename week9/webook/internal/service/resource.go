@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+)
+
+// ResourceResolver 批量查询某一种资源的摘要。
+// 不存在或者不可见的资源不用返回，调用方会当成已经失效
+type ResourceResolver func(ctx context.Context, bizIds []int64) ([]domain.ResourceSummary, error)
+
+//go:generate mockgen -source=resource.go -package=svcmocks -destination=mocks/resource.mock.go ResourceService
+type ResourceService interface {
+	// Resolve 按照 biz 分组批量查询摘要。
+	// 没有注册的 biz 和查询失败的 biz 都不会出现在结果里面
+	Resolve(ctx context.Context, res []domain.Resource) map[domain.Resource]domain.ResourceSummary
+}
+
+type resourceService struct {
+	// 每一种 biz 怎么查摘要，新的 biz 在 ioc 里面注册
+	resolvers map[string]ResourceResolver
+	l         logger.LoggerV1
+}
+
+func NewResourceService(resolvers map[string]ResourceResolver,
+	l logger.LoggerV1) ResourceService {
+	return &resourceService{
+		resolvers: resolvers,
+		l:         l,
+	}
+}
+
+func (s *resourceService) Resolve(ctx context.Context,
+	res []domain.Resource) map[domain.Resource]domain.ResourceSummary {
+	// 保持 biz 第一次出现的顺序，方便排查问题
+	var bizs []string
+	ids := make(map[string][]int64)
+	for _, r := range res {
+		if _, ok := ids[r.Biz]; !ok {
+			bizs = append(bizs, r.Biz)
+		}
+		ids[r.Biz] = append(ids[r.Biz], r.BizId)
+	}
+	summaries := make(map[domain.Resource]domain.ResourceSummary, len(res))
+	for _, biz := range bizs {
+		fn, ok := s.resolvers[biz]
+		if !ok {
+			continue
+		}
+		// 某一种资源查不到，列表还是要能展示，只是缺了摘要
+		vals, err := fn(ctx, ids[biz])
+		if err != nil {
+			s.l.Error("查询资源摘要失败",
+				logger.String("biz", biz),
+				logger.Error(err))
+			continue
+		}
+		for _, val := range vals {
+			val.Biz = biz
+			summaries[val.Resource] = val
+		}
+	}
+	return summaries
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_resourceService_Resolve(t *testing.T) {
+	var articleCalls [][]int64
+	svc := NewResourceService(map[string]ResourceResolver{
+		// 帖子 2 已经看不到了
+		"article": func(ctx context.Context, bizIds []int64) ([]domain.ResourceSummary, error) {
+			articleCalls = append(articleCalls, bizIds)
+			var res []domain.ResourceSummary
+			for _, id := range bizIds {
+				if id == 2 {
+					continue
+				}
+				res = append(res, domain.ResourceSummary{
+					Resource: domain.Resource{BizId: id},
+					Title:    "帖子",
+				})
+			}
+			return res, nil
+		},
+		"video": func(ctx context.Context, bizIds []int64) ([]domain.ResourceSummary, error) {
+			return nil, errors.New("mock error")
+		},
+	}, &logger.NopLogger{})
+
+	res := svc.Resolve(context.Background(), []domain.Resource{
+		{Biz: "article", BizId: 1},
+		{Biz: "video", BizId: 1},
+		{Biz: "article", BizId: 2},
+		{Biz: "course", BizId: 1},
+		{Biz: "article", BizId: 3},
+	})
+	// 同一种 biz 只查一次
+	assert.Equal(t, [][]int64{{1, 2, 3}}, articleCalls)
+	assert.Equal(t, map[domain.Resource]domain.ResourceSummary{
+		{Biz: "article", BizId: 1}: {
+			Resource: domain.Resource{Biz: "article", BizId: 1},
+			Title:    "帖子",
+		},
+		{Biz: "article", BizId: 3}: {
+			Resource: domain.Resource{Biz: "article", BizId: 3},
+			Title:    "帖子",
+		},
+	}, res)
+}
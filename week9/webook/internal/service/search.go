@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/article"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"strings"
+)
+
+//go:generate mockgen -source=search.go -package=svcmocks -destination=mocks/search.mock.go SearchService
+type SearchService interface {
+	// SearchArticle 按照相关度搜索已发表的帖子
+	SearchArticle(ctx context.Context, q string, offset, limit int) (domain.ArticleSearchResult, error)
+	// RebuildArticleIndex 从线上库全量重建帖子的索引
+	RebuildArticleIndex(ctx context.Context) error
+}
+
+type searchService struct {
+	artRepo    article.ArticleRepository
+	searchRepo repository.SearchRepository
+	l          logger.LoggerV1
+	// 重建索引的时候每一批查询多少篇
+	batchSize int
+}
+
+func NewSearchService(artRepo article.ArticleRepository,
+	searchRepo repository.SearchRepository,
+	l logger.LoggerV1) SearchService {
+	return &searchService{
+		artRepo:    artRepo,
+		searchRepo: searchRepo,
+		l:          l,
+		batchSize:  100,
+	}
+}
+
+func (s *searchService) SearchArticle(ctx context.Context,
+	q string, offset, limit int) (domain.ArticleSearchResult, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return domain.ArticleSearchResult{}, nil
+	}
+	return s.searchRepo.SearchArticle(ctx, q, offset, limit)
+}
+
+func (s *searchService) RebuildArticleIndex(ctx context.Context) error {
+	var (
+		arts []domain.Article
+		id   int64
+	)
+	for {
+		batch, err := s.artRepo.ListPubAfterId(ctx, id, s.batchSize)
+		if err != nil {
+			return err
+		}
+		for _, art := range batch {
+			// 仅自己可见的帖子也在线上库里面
+			if art.Status == domain.ArticleStatusPublished {
+				arts = append(arts, art)
+			}
+		}
+		if len(batch) < s.batchSize {
+			break
+		}
+		id = batch[len(batch)-1].Id
+	}
+	s.l.Info("重建帖子索引", logger.Int64("cnt", int64(len(arts))))
+	return s.searchRepo.ReplaceArticles(ctx, arts)
+}
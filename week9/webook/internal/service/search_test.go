@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/article"
+	artrepomocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/article/mocks"
+	repomocks "github.com/gevinzone/basic-go/week9/webook/internal/repository/mocks"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+func Test_searchService_RebuildArticleIndex(t *testing.T) {
+	testCases := []struct {
+		name string
+		mock func(ctrl *gomock.Controller) (article.ArticleRepository,
+			repository.SearchRepository)
+		wantErr error
+	}{
+		{
+			name: "分批重建，跳过仅自己可见的",
+			mock: func(ctrl *gomock.Controller) (article.ArticleRepository,
+				repository.SearchRepository) {
+				artRepo := artrepomocks.NewMockArticleRepository(ctrl)
+				searchRepo := repomocks.NewMockSearchRepository(ctrl)
+				artRepo.EXPECT().ListPubAfterId(gomock.Any(), int64(0), 2).
+					Return([]domain.Article{
+						{Id: 1, Status: domain.ArticleStatusPublished},
+						{Id: 2, Status: domain.ArticleStatusPrivate},
+					}, nil)
+				artRepo.EXPECT().ListPubAfterId(gomock.Any(), int64(2), 2).
+					Return([]domain.Article{
+						{Id: 3, Status: domain.ArticleStatusPublished},
+					}, nil)
+				searchRepo.EXPECT().ReplaceArticles(gomock.Any(), []domain.Article{
+					{Id: 1, Status: domain.ArticleStatusPublished},
+					{Id: 3, Status: domain.ArticleStatusPublished},
+				}).Return(nil)
+				return artRepo, searchRepo
+			},
+		},
+		{
+			name: "查询失败，不替换索引",
+			mock: func(ctrl *gomock.Controller) (article.ArticleRepository,
+				repository.SearchRepository) {
+				artRepo := artrepomocks.NewMockArticleRepository(ctrl)
+				searchRepo := repomocks.NewMockSearchRepository(ctrl)
+				artRepo.EXPECT().ListPubAfterId(gomock.Any(), int64(0), 2).
+					Return(nil, errors.New("mock db error"))
+				return artRepo, searchRepo
+			},
+			wantErr: errors.New("mock db error"),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			artRepo, searchRepo := tc.mock(ctrl)
+			svc := &searchService{
+				artRepo:    artRepo,
+				searchRepo: searchRepo,
+				l:          &logger.NopLogger{},
+				batchSize:  2,
+			}
+			err := svc.RebuildArticleIndex(context.Background())
+			assert.Equal(t, tc.wantErr, err)
+		})
+	}
+}
@@ -1,15 +1,18 @@
 package web
 
 import (
+	"errors"
 	"fmt"
 	"github.com/ecodeclub/ekit/slice"
 	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/errs"
 	"github.com/gevinzone/basic-go/week9/webook/internal/service"
 	ijwt "github.com/gevinzone/basic-go/week9/webook/internal/web/jwt"
 	"github.com/gevinzone/basic-go/week9/webook/pkg/ginx"
 	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/sync/errgroup"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -18,18 +21,26 @@ import (
 var _ handler = (*ArticleHandler)(nil)
 
 type ArticleHandler struct {
-	svc     service.ArticleService
-	l       logger.LoggerV1
-	intrSvc service.InteractiveService
-	biz     string
+	svc       service.ArticleService
+	l         logger.LoggerV1
+	intrSvc   service.InteractiveService
+	followSvc service.FollowService
+	liveSvc   service.InteractiveLiveService
+	biz       string
 }
 
 func NewArticleHandler(svc service.ArticleService,
+	intrSvc service.InteractiveService,
+	followSvc service.FollowService,
+	liveSvc service.InteractiveLiveService,
 	l logger.LoggerV1) *ArticleHandler {
 	return &ArticleHandler{
-		svc: svc,
-		l:   l,
-		biz: "article",
+		svc:       svc,
+		intrSvc:   intrSvc,
+		followSvc: followSvc,
+		liveSvc:   liveSvc,
+		l:         l,
+		biz:       "article",
 	}
 }
 
@@ -44,6 +55,9 @@ func (h *ArticleHandler) RegisterRoutes(server *gin.Engine) {
 	g.POST("/edit", h.Edit)
 	g.POST("/withdraw", h.Withdraw)
 	g.POST("/publish", h.Publish)
+	// 删除只是挪到回收站
+	g.POST("/delete",
+		ginx.WrapBodyAndToken[ArticleDeleteReq, ijwt.UserClaims](h.Delete))
 	// 创作者的查询接口
 	// 这个是获取数据的接口，理论上来说（遵循 RESTful 规范），应该是用 GET 方法
 	// GET localhost/articles => List 接口
@@ -51,6 +65,30 @@ func (h *ArticleHandler) RegisterRoutes(server *gin.Engine) {
 		ginx.WrapBodyAndToken[ListReq, ijwt.UserClaims](h.List))
 	g.GET("/detail/:id", ginx.WrapToken[ijwt.UserClaims](h.Detail))
 
+	// 历史版本
+	rev := g.Group("/revisions")
+	rev.POST("/list",
+		ginx.WrapBodyAndToken[RevisionListReq, ijwt.UserClaims](h.ListRevisions))
+	rev.GET("/detail/:id", ginx.WrapToken[ijwt.UserClaims](h.RevisionDetail))
+	rev.POST("/diff",
+		ginx.WrapBodyAndToken[RevisionDiffReq, ijwt.UserClaims](h.DiffRevisions))
+	rev.POST("/restore",
+		ginx.WrapBodyAndToken[RevisionRestoreReq, ijwt.UserClaims](h.RestoreRevision))
+
+	// 定时发表
+	sch := g.Group("/scheduled")
+	sch.POST("/list",
+		ginx.WrapBodyAndToken[ListReq, ijwt.UserClaims](h.ListScheduled))
+	sch.POST("/cancel",
+		ginx.WrapBodyAndToken[ScheduledCancelReq, ijwt.UserClaims](h.CancelScheduled))
+
+	// 回收站
+	trash := g.Group("/trash")
+	trash.POST("/list",
+		ginx.WrapBodyAndToken[ListReq, ijwt.UserClaims](h.ListTrash))
+	trash.POST("/restore",
+		ginx.WrapBodyAndToken[TrashRestoreReq, ijwt.UserClaims](h.RestoreFromTrash))
+
 	pub := g.Group("/pub")
 	pub.GET("/:id", h.PubDetail, func(ctx *gin.Context) {
 		// 增加阅读计数。
@@ -64,12 +102,20 @@ func (h *ArticleHandler) RegisterRoutes(server *gin.Engine) {
 		//	}
 		//}()
 	})
+	// 实时的阅读数、点赞数和收藏数，Server-Sent Events
+	pub.GET("/:id/stream", h.Stream)
 	// 点赞是这个接口，取消点赞也是这个接口
 	// RESTful 风格
 	//pub.POST("/like/:id", ginx.WrapBodyAndToken[LikeReq,
 	//	ijwt.UserClaims](h.Like))
 	pub.POST("/like", ginx.WrapBodyAndToken[LikeReq,
 		ijwt.UserClaims](h.Like))
+	// 表态，reaction 为空就是取消
+	pub.POST("/react", ginx.WrapBodyAndToken[ReactReq,
+		ijwt.UserClaims](h.React))
+	// 按照标签浏览
+	pub.GET("/tag/:tag", ginx.WrapBodyV1[TagListReq](h.ListPubByTag))
+	pub.GET("/tags", ginx.WrapBodyV1[TagCloudReq](h.TagCloud))
 	//pub.POST("/cancel_like", ginx.WrapBodyAndToken[LikeReq,
 	//	ijwt.UserClaims](h.Like))
 }
@@ -91,6 +137,28 @@ func (a *ArticleHandler) Like(ctx *gin.Context, req LikeReq, uc ijwt.UserClaims)
 	return ginx.Result{Msg: "OK"}, nil
 }
 
+func (a *ArticleHandler) React(ctx *gin.Context, req ReactReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	var err error
+	if req.Reaction == "" {
+		err = a.intrSvc.CancelReaction(ctx, a.biz, req.Id, uc.Id)
+	} else {
+		err = a.intrSvc.React(ctx, a.biz, req.Id, uc.Id, domain.ReactionType(req.Reaction))
+	}
+	if errors.Is(err, service.ErrInvalidReaction) {
+		return ginx.Result{
+			Code: 4,
+			Msg:  "不支持的表态",
+		}, nil
+	}
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
+
 func (a *ArticleHandler) PubDetail(ctx *gin.Context) {
 	idstr := ctx.Param("id")
 	id, err := strconv.ParseInt(idstr, 10, 64)
@@ -126,6 +194,13 @@ func (a *ArticleHandler) PubDetail(ctx *gin.Context) {
 
 	// 在这儿等，要保证前面两个
 	err = eg.Wait()
+	if errors.Is(err, service.ErrArticleNotFound) {
+		ctx.JSON(http.StatusOK, Result{
+			Code: 4,
+			Msg:  "帖子不存在",
+		})
+		return
+	}
 	if err != nil {
 		// 代表查询出错了
 		ctx.JSON(http.StatusOK, Result{
@@ -147,6 +222,9 @@ func (a *ArticleHandler) PubDetail(ctx *gin.Context) {
 		}
 	}()
 
+	// 作者的粉丝数和我有没有关注作者，查不到也不影响看帖子
+	author := a.authorFollow(ctx, art.Author.Id, uc.Id)
+
 	// ctx.Set("art", art)
 
 	// 这个功能是不是可以让前端，主动发一个 HTTP 请求，来增加一个计数？
@@ -157,18 +235,118 @@ func (a *ArticleHandler) PubDetail(ctx *gin.Context) {
 			Status:  art.Status.ToUint8(),
 			Content: art.Content,
 			// 要把作者信息带出去
-			Author:     art.Author.Name,
-			Ctime:      art.Ctime.Format(time.DateTime),
-			Utime:      art.Utime.Format(time.DateTime),
-			Liked:      intr.Liked,
-			Collected:  intr.Collected,
-			LikeCnt:    intr.LikeCnt,
-			ReadCnt:    intr.ReadCnt,
-			CollectCnt: intr.CollectCnt,
+			Author:          art.Author.Name,
+			AuthorId:        art.Author.Id,
+			AuthorFollowers: author.Followers,
+			Following:       author.Following,
+			Tags:            art.Tags,
+			Html:            art.HTML,
+			Toc: slice.Map(art.TOC, func(idx int, src domain.ArticleHeading) HeadingVO {
+				return HeadingVO{Level: src.Level, Text: src.Text, Id: src.Id}
+			}),
+			WordCnt:     art.WordCnt,
+			ReadMinutes: art.ReadMinutes,
+			Ctime:       art.Ctime.Format(time.DateTime),
+			Utime:       art.Utime.Format(time.DateTime),
+			Liked:       intr.Liked,
+			Collected:   intr.Collected,
+			Reaction:    string(intr.Reaction),
+			Reactions:   toReactionsVO(intr),
+			LikeCnt:     intr.LikeCnt,
+			ReadCnt:     intr.ReadCnt,
+			Uv:          intr.Uv,
+			CollectCnt:  intr.CollectCnt,
+			CommentCnt:  intr.CommentCnt,
 		},
 	})
 }
 
+// Stream 用 SSE 推送帖子的实时计数，连接断开的时候 Watch 的 ctx 被取消，订阅也就清理掉了
+func (a *ArticleHandler) Stream(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusOK, Result{
+			Code: 4,
+			Msg:  "参数错误",
+		})
+		return
+	}
+	uc := ctx.MustGet("users").(ijwt.UserClaims)
+	// 只有看得到的帖子才能订阅。GetPublishedById 会算一次阅读，所以不用它
+	arts, err := a.svc.ListPubByIds(ctx, []int64{id})
+	if err != nil {
+		ctx.JSON(http.StatusOK, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		a.l.Error("查询帖子失败", logger.Int64("aid", id), logger.Error(err))
+		return
+	}
+	if len(arts) == 0 {
+		ctx.JSON(http.StatusOK, Result{
+			Code: 4,
+			Msg:  "帖子不存在",
+		})
+		return
+	}
+	ch, err := a.liveSvc.Watch(ctx.Request.Context(), uc.Id, a.biz, id)
+	if errors.Is(err, service.ErrTooManyWatchers) {
+		ctx.JSON(http.StatusOK, Result{
+			Code: 4,
+			Msg:  "连接太多了",
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusOK, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		a.l.Error("订阅实时计数失败", logger.Int64("aid", id), logger.Error(err))
+		return
+	}
+	// 有些代理长时间没有数据会断开连接
+	heartbeat := time.NewTicker(time.Second * 15)
+	defer heartbeat.Stop()
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case intr, ok := <-ch:
+			if !ok {
+				return false
+			}
+			ctx.SSEvent("cnt", LiveCntVO{
+				ReadCnt:    intr.ReadCnt,
+				LikeCnt:    intr.LikeCnt,
+				CollectCnt: intr.CollectCnt,
+				Reactions:  toReactionsVO(intr),
+			})
+		case <-heartbeat.C:
+			ctx.SSEvent("ping", "")
+		}
+		return true
+	})
+}
+
+// authorFollow 出错了就记个日志，返回零值
+func (a *ArticleHandler) authorFollow(ctx *gin.Context, authorId, uid int64) FollowStaticsVO {
+	var res FollowStaticsVO
+	statics, err := a.followSvc.GetStatics(ctx, authorId)
+	if err != nil {
+		a.l.Error("查询作者粉丝数失败",
+			logger.Int64("authorId", authorId),
+			logger.Error(err))
+	}
+	res.Followers = statics.Followers
+	res.Following, err = a.followSvc.IsFollowing(ctx, uid, authorId)
+	if err != nil {
+		a.l.Error("查询是否关注了作者失败",
+			logger.Int64("authorId", authorId),
+			logger.Int64("uid", uid),
+			logger.Error(err))
+	}
+	return res
+}
+
 func (a *ArticleHandler) Detail(ctx *gin.Context, usr ijwt.UserClaims) (ginx.Result, error) {
 	idstr := ctx.Param("id")
 	id, err := strconv.ParseInt(idstr, 10, 64)
@@ -211,8 +389,10 @@ func (a *ArticleHandler) Detail(ctx *gin.Context, usr ijwt.UserClaims) (ginx.Res
 			Content: art.Content,
 			// 这个是创作者看自己的文章列表，也不需要这个字段
 			//Author: art.Author
-			Ctime: art.Ctime.Format(time.DateTime),
-			Utime: art.Utime.Format(time.DateTime),
+			Tags:    art.Tags,
+			Version: art.Version,
+			Ctime:   art.Ctime.Format(time.DateTime),
+			Utime:   art.Utime.Format(time.DateTime),
 		},
 	}, nil
 }
@@ -236,6 +416,27 @@ func (h *ArticleHandler) Publish(ctx *gin.Context) {
 	}
 
 	id, err := h.svc.Publish(ctx, req.toDomain(claims.Id))
+	if errors.Is(err, service.ErrArticleVersionConflict) {
+		ctx.JSON(http.StatusOK, Result{
+			Code: errs.ArticleVersionConflict,
+			Msg:  "帖子已经被修改过，请刷新之后再编辑",
+		})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidTags) {
+		ctx.JSON(http.StatusOK, Result{
+			Code: 4,
+			Msg:  "标签不合法",
+		})
+		return
+	}
+	if errors.Is(err, service.ErrArticleInTrash) {
+		ctx.JSON(http.StatusOK, Result{
+			Code: 4,
+			Msg:  "帖子在回收站里面，请先恢复",
+		})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusOK, Result{
 			Code: 5,
@@ -246,7 +447,11 @@ func (h *ArticleHandler) Publish(ctx *gin.Context) {
 		return
 	}
 	ctx.JSON(http.StatusOK, Result{
-		Data: id,
+		Msg: "OK",
+		Data: ArticleSaveVO{
+			Id:      id,
+			Version: req.savedVersion(),
+		},
 	})
 }
 
@@ -279,6 +484,13 @@ func (h *ArticleHandler) Withdraw(ctx *gin.Context) {
 			Id: claims.Id,
 		},
 	})
+	if errors.Is(err, service.ErrArticleInTrash) {
+		ctx.JSON(http.StatusOK, Result{
+			Code: 4,
+			Msg:  "帖子在回收站里面，请先恢复",
+		})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusOK, Result{
 			Code: 5,
@@ -313,6 +525,27 @@ func (h *ArticleHandler) Edit(ctx *gin.Context) {
 	// 检测输入，跳过这一步
 	// 调用 svc 的代码
 	id, err := h.svc.Save(ctx, req.toDomain(claims.Id))
+	if errors.Is(err, service.ErrArticleVersionConflict) {
+		ctx.JSON(http.StatusOK, Result{
+			Code: errs.ArticleVersionConflict,
+			Msg:  "帖子已经被修改过，请刷新之后再编辑",
+		})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidTags) {
+		ctx.JSON(http.StatusOK, Result{
+			Code: 4,
+			Msg:  "标签不合法",
+		})
+		return
+	}
+	if errors.Is(err, service.ErrArticleInTrash) {
+		ctx.JSON(http.StatusOK, Result{
+			Code: 4,
+			Msg:  "帖子在回收站里面，请先恢复",
+		})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusOK, Result{
 			Code: 5,
@@ -323,11 +556,19 @@ func (h *ArticleHandler) Edit(ctx *gin.Context) {
 		return
 	}
 	ctx.JSON(http.StatusOK, Result{
-		Data: id,
+		Data: ArticleSaveVO{
+			Id:      id,
+			Version: req.savedVersion(),
+		},
 	})
 }
 
+// List 老的前端什么都不传，按照 offset 翻页，返回的还是原本的数组。
+// 传了 cursor_mode 或者 cursor 就是游标翻页，返回 ArticleListVO，带上 next_cursor
 func (h *ArticleHandler) List(ctx *gin.Context, req ListReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	if req.CursorMode || req.Cursor != "" {
+		return h.ListByCursor(ctx, req, uc)
+	}
 	res, err := h.svc.List(ctx, uc.Id, req.Offset, req.Limit)
 	if err != nil {
 		return ginx.Result{
@@ -338,6 +579,263 @@ func (h *ArticleHandler) List(ctx *gin.Context, req ListReq, uc ijwt.UserClaims)
 	// 在列表页，不显示全文，只显示一个"摘要"
 	// 比如说，简单的摘要就是前几句话
 	// 强大的摘要是 AI 帮你生成的
+	return ginx.Result{
+		Data: slice.Map[domain.Article, ArticleVO](res, toListArticleVO),
+	}, nil
+}
+
+// ListByCursor 游标翻页，第一页不传 cursor
+func (h *ArticleHandler) ListByCursor(ctx *gin.Context, req ListReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	cursor, err := decodeArticleCursor(req.Cursor)
+	if err != nil {
+		return ginx.Result{
+			Code: 4,
+			Msg:  "参数错误",
+		}, err
+	}
+	limit := req.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	res, err := h.svc.ListByCursor(ctx, uc.Id, cursor, limit)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, nil
+	}
+	vo := ArticleListVO{
+		Articles: slice.Map[domain.Article, ArticleVO](res, toListArticleVO),
+	}
+	// 不满一页说明已经到底了
+	if len(res) == limit {
+		vo.NextCursor = encodeArticleCursor(res[len(res)-1].Cursor())
+	}
+	return ginx.Result{
+		Data: vo,
+	}, nil
+}
+
+func toListArticleVO(idx int, src domain.Article) ArticleVO {
+	return ArticleVO{
+		Id:       src.Id,
+		Title:    src.Title,
+		Abstract: src.Abstract(),
+		Status:   src.Status.ToUint8(),
+		// 这个列表请求，不需要返回内容
+		//Content: src.Content,
+		// 这个是创作者看自己的文章列表，也不需要这个字段
+		//Author: src.Author
+		Ctime: src.Ctime.Format(time.DateTime),
+		Utime: src.Utime.Format(time.DateTime),
+	}
+}
+
+func (h *ArticleHandler) ListRevisions(ctx *gin.Context,
+	req RevisionListReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	revs, err := h.svc.ListRevisions(ctx, uc.Id, req.Id, req.Offset, req.Limit)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	return ginx.Result{
+		Data: slice.Map[domain.ArticleRevision, ArticleRevisionVO](revs,
+			func(idx int, src domain.ArticleRevision) ArticleRevisionVO {
+				vo := newArticleRevisionVO(src)
+				vo.Abstract = domain.Article{Content: src.Content}.Abstract()
+				vo.Content = ""
+				return vo
+			}),
+	}, nil
+}
+
+func (h *ArticleHandler) RevisionDetail(ctx *gin.Context, uc ijwt.UserClaims) (ginx.Result, error) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		return ginx.Result{
+			Code: 4,
+			Msg:  "参数错误",
+		}, err
+	}
+	// GetRevision 里面已经校验了作者
+	rev, err := h.svc.GetRevision(ctx, uc.Id, id)
+	switch err {
+	case nil:
+		return ginx.Result{
+			Data: newArticleRevisionVO(rev),
+		}, nil
+	case service.ErrRevisionNotFound:
+		return ginx.Result{
+			Code: 4,
+			Msg:  "历史版本不存在",
+		}, err
+	default:
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+}
+
+func (h *ArticleHandler) DiffRevisions(ctx *gin.Context,
+	req RevisionDiffReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	diff, err := h.svc.DiffRevisions(ctx, uc.Id, req.Id, req.From, req.To)
+	switch err {
+	case nil:
+		return ginx.Result{
+			Data: newArticleRevisionDiffVO(diff),
+		}, nil
+	case service.ErrRevisionNotMatch, service.ErrRevisionNotFound:
+		return ginx.Result{
+			Code: 4,
+			Msg:  "参数错误",
+		}, err
+	default:
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+}
+
+func (h *ArticleHandler) RestoreRevision(ctx *gin.Context,
+	req RevisionRestoreReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.svc.RestoreRevision(ctx, uc.Id, req.Id, req.RevisionId)
+	switch err {
+	case nil:
+		return ginx.Result{Msg: "OK"}, nil
+	case service.ErrRevisionNotMatch, service.ErrRevisionNotFound:
+		return ginx.Result{
+			Code: 4,
+			Msg:  "参数错误",
+		}, err
+	default:
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+}
+
+func (h *ArticleHandler) ListScheduled(ctx *gin.Context,
+	req ListReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	res, err := h.svc.ListScheduled(ctx, uc.Id, req.Offset, req.Limit)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	return ginx.Result{
+		Data: slice.Map[domain.Article, ArticleVO](res,
+			func(idx int, src domain.Article) ArticleVO {
+				return ArticleVO{
+					Id:        src.Id,
+					Title:     src.Title,
+					Abstract:  src.Abstract(),
+					Status:    src.Status.ToUint8(),
+					PublishAt: src.PublishAt.Format(time.DateTime),
+					Ctime:     src.Ctime.Format(time.DateTime),
+					Utime:     src.Utime.Format(time.DateTime),
+				}
+			}),
+	}, nil
+}
+
+func (h *ArticleHandler) CancelScheduled(ctx *gin.Context,
+	req ScheduledCancelReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.svc.CancelScheduled(ctx, uc.Id, req.Id)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
+
+func (h *ArticleHandler) Delete(ctx *gin.Context,
+	req ArticleDeleteReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.svc.Delete(ctx, uc.Id, req.Id)
+	switch err {
+	case nil:
+		return ginx.Result{Msg: "OK"}, nil
+	case service.ErrArticleNotFound:
+		return ginx.Result{
+			Code: 4,
+			Msg:  "帖子不存在",
+		}, err
+	default:
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+}
+
+func (h *ArticleHandler) ListTrash(ctx *gin.Context,
+	req ListReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	res, err := h.svc.ListTrash(ctx, uc.Id, req.Offset, req.Limit)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	return ginx.Result{
+		Data: slice.Map[domain.Article, ArticleVO](res,
+			func(idx int, src domain.Article) ArticleVO {
+				return ArticleVO{
+					Id:        src.Id,
+					Title:     src.Title,
+					Abstract:  src.Abstract(),
+					Status:    src.Status.ToUint8(),
+					DeletedAt: src.DeletedAt.Format(time.DateTime),
+					Ctime:     src.Ctime.Format(time.DateTime),
+					Utime:     src.Utime.Format(time.DateTime),
+				}
+			}),
+	}, nil
+}
+
+func (h *ArticleHandler) RestoreFromTrash(ctx *gin.Context,
+	req TrashRestoreReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.svc.RestoreFromTrash(ctx, uc.Id, req.Id)
+	switch err {
+	case nil:
+		return ginx.Result{Msg: "OK"}, nil
+	case service.ErrArticleNotFound, service.ErrArticleNotInTrash:
+		return ginx.Result{
+			Code: 4,
+			Msg:  "帖子不在回收站里面",
+		}, err
+	case service.ErrTrashExpired:
+		return ginx.Result{
+			Code: 4,
+			Msg:  "帖子已经超过保留期限，不能恢复了",
+		}, err
+	default:
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+}
+
+func (h *ArticleHandler) ListPubByTag(ctx *gin.Context, req TagListReq) (ginx.Result, error) {
+	limit := req.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	res, err := h.svc.ListPubByTag(ctx, ctx.Param("tag"), req.Offset, limit)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
 	return ginx.Result{
 		Data: slice.Map[domain.Article, ArticleVO](res,
 			func(idx int, src domain.Article) ArticleVO {
@@ -346,13 +844,28 @@ func (h *ArticleHandler) List(ctx *gin.Context, req ListReq, uc ijwt.UserClaims)
 					Title:    src.Title,
 					Abstract: src.Abstract(),
 					Status:   src.Status.ToUint8(),
-					// 这个列表请求，不需要返回内容
-					//Content: src.Content,
-					// 这个是创作者看自己的文章列表，也不需要这个字段
-					//Author: src.Author
-					Ctime: src.Ctime.Format(time.DateTime),
-					Utime: src.Utime.Format(time.DateTime),
+					Ctime:    src.Ctime.Format(time.DateTime),
+					Utime:    src.Utime.Format(time.DateTime),
 				}
 			}),
 	}, nil
 }
+
+func (h *ArticleHandler) TagCloud(ctx *gin.Context, req TagCloudReq) (ginx.Result, error) {
+	limit := req.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	res, err := h.svc.TagCloud(ctx, limit)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	return ginx.Result{
+		Data: slice.Map[domain.Tag, TagVO](res, func(idx int, src domain.Tag) TagVO {
+			return TagVO{Name: src.Name, ArticleCnt: src.ArticleCnt}
+		}),
+	}, nil
+}
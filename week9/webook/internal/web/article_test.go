@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/errs"
 	"github.com/gevinzone/basic-go/week9/webook/internal/service"
 	svcmocks "github.com/gevinzone/basic-go/week9/webook/internal/service/mocks"
 	ijwt "github.com/gevinzone/basic-go/week9/webook/internal/web/jwt"
@@ -16,6 +17,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestArticleHandler_Publish(t *testing.T) {
@@ -50,7 +52,38 @@ func TestArticleHandler_Publish(t *testing.T) {
 `,
 			wantCode: 200,
 			wantRes: Result{
-				Data: float64(1),
+				// 新建的帖子版本号从 1 开始
+				Data: map[string]any{"id": float64(1), "version": float64(1)},
+				Msg:  "OK",
+			},
+		},
+		{
+			name: "修改并发表",
+			mock: func(ctrl *gomock.Controller) service.ArticleService {
+				svc := svcmocks.NewMockArticleService(ctrl)
+				svc.EXPECT().Publish(gomock.Any(), domain.Article{
+					Id:      1,
+					Title:   "我的标题",
+					Content: "我的内容",
+					Author: domain.Author{
+						Id: 123,
+					},
+					Version: 3,
+				}).Return(int64(1), nil)
+				return svc
+			},
+			reqBody: `
+{
+	"id": 1,
+	"title":"我的标题",
+	"content": "我的内容",
+	"version": 3
+}
+`,
+			wantCode: 200,
+			wantRes: Result{
+				// 下一次保存要带上新的版本号
+				Data: map[string]any{"id": float64(1), "version": float64(4)},
 				Msg:  "OK",
 			},
 		},
@@ -79,6 +112,35 @@ func TestArticleHandler_Publish(t *testing.T) {
 				Msg:  "系统错误",
 			},
 		},
+		{
+			name: "版本冲突",
+			mock: func(ctrl *gomock.Controller) service.ArticleService {
+				svc := svcmocks.NewMockArticleService(ctrl)
+				svc.EXPECT().Publish(gomock.Any(), domain.Article{
+					Id:      1,
+					Title:   "我的标题",
+					Content: "我的内容",
+					Author: domain.Author{
+						Id: 123,
+					},
+					Version: 3,
+				}).Return(int64(0), service.ErrArticleVersionConflict)
+				return svc
+			},
+			reqBody: `
+{
+	"id": 1,
+	"title":"我的标题",
+	"content": "我的内容",
+	"version": 3
+}
+`,
+			wantCode: 200,
+			wantRes: Result{
+				Code: errs.ArticleVersionConflict,
+				Msg:  "帖子已经被修改过，请刷新之后再编辑",
+			},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -91,7 +153,7 @@ func TestArticleHandler_Publish(t *testing.T) {
 				})
 			})
 			// 用不上 codeSvc
-			h := NewArticleHandler(tc.mock(ctrl), &logger.NopLogger{})
+			h := NewArticleHandler(tc.mock(ctrl), nil, nil, nil, &logger.NopLogger{})
 			h.RegisterRoutes(server)
 
 			req, err := http.NewRequest(http.MethodPost,
@@ -112,3 +174,102 @@ func TestArticleHandler_Publish(t *testing.T) {
 		})
 	}
 }
+
+func TestArticleHandler_List(t *testing.T) {
+	now := time.UnixMilli(1700000000123)
+	arts := []domain.Article{{Id: 2, Utime: now}, {Id: 1, Utime: now}}
+	testCases := []struct {
+		name    string
+		mock    func(ctrl *gomock.Controller) service.ArticleService
+		reqBody string
+
+		wantArray      bool
+		wantNextCursor string
+	}{
+		{
+			name: "老的前端，还是返回数组",
+			mock: func(ctrl *gomock.Controller) service.ArticleService {
+				svc := svcmocks.NewMockArticleService(ctrl)
+				svc.EXPECT().List(gomock.Any(), int64(123), 0, 2).Return(arts, nil)
+				return svc
+			},
+			reqBody:   `{"offset":0,"limit":2}`,
+			wantArray: true,
+		},
+		{
+			name: "游标翻页的第一页，返回 next_cursor",
+			mock: func(ctrl *gomock.Controller) service.ArticleService {
+				svc := svcmocks.NewMockArticleService(ctrl)
+				svc.EXPECT().ListByCursor(gomock.Any(), int64(123),
+					domain.ArticleCursor{}, 2).Return(arts, nil)
+				return svc
+			},
+			reqBody:        `{"cursor_mode":true,"limit":2}`,
+			wantNextCursor: encodeArticleCursor(arts[1].Cursor()),
+		},
+		{
+			name: "不满一页，没有下一页",
+			mock: func(ctrl *gomock.Controller) service.ArticleService {
+				svc := svcmocks.NewMockArticleService(ctrl)
+				svc.EXPECT().ListByCursor(gomock.Any(), int64(123),
+					gomock.Any(), 3).Return(arts, nil)
+				return svc
+			},
+			reqBody: `{"cursor":"` + encodeArticleCursor(arts[0].Cursor()) + `","limit":3}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			server := gin.Default()
+			server.Use(func(ctx *gin.Context) {
+				ctx.Set("users", ijwt.UserClaims{
+					Id: 123,
+				})
+			})
+			h := NewArticleHandler(tc.mock(ctrl), nil, nil, nil, &logger.NopLogger{})
+			h.RegisterRoutes(server)
+
+			req, err := http.NewRequest(http.MethodPost,
+				"/articles/list", bytes.NewBuffer([]byte(tc.reqBody)))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			server.ServeHTTP(resp, req)
+			require.Equal(t, http.StatusOK, resp.Code)
+
+			var webRes struct {
+				Data json.RawMessage `json:"data"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&webRes)
+			require.NoError(t, err)
+			if tc.wantArray {
+				var vos []ArticleVO
+				require.NoError(t, json.Unmarshal(webRes.Data, &vos))
+				assert.Len(t, vos, len(arts))
+				return
+			}
+			var vo ArticleListVO
+			require.NoError(t, json.Unmarshal(webRes.Data, &vo))
+			assert.Len(t, vo.Articles, len(arts))
+			assert.Equal(t, tc.wantNextCursor, vo.NextCursor)
+		})
+	}
+}
+
+func TestArticleCursor(t *testing.T) {
+	c := domain.ArticleCursor{Utime: time.UnixMilli(1700000000123), Id: 42}
+	res, err := decodeArticleCursor(encodeArticleCursor(c))
+	require.NoError(t, err)
+	assert.True(t, c.Utime.Equal(res.Utime))
+	assert.Equal(t, c.Id, res.Id)
+
+	// 第一页
+	res, err = decodeArticleCursor("")
+	require.NoError(t, err)
+	assert.True(t, res.IsZero())
+
+	_, err = decodeArticleCursor("not-a-cursor")
+	assert.Error(t, err)
+}
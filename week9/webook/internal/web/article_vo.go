@@ -1,6 +1,15 @@
 package web
 
-import "github.com/gevinzone/basic-go/week9/webook/internal/domain"
+import (
+	"encoding/base64"
+	"errors"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/diffx"
+	"strconv"
+	"strings"
+	"time"
+)
 
 // VO view object，就是对标前端的
 
@@ -10,6 +19,12 @@ type LikeReq struct {
 	Like bool `json:"like"`
 }
 
+type ReactReq struct {
+	Id int64 `json:"id"`
+	// like, love, laugh, insightful，空的就是取消表态
+	Reaction string `json:"reaction"`
+}
+
 type ArticleVO struct {
 	Id    int64  `json:"id"`
 	Title string `json:"title"`
@@ -23,37 +38,264 @@ type ArticleVO struct {
 	// 涉及到国际化，也是后端来处理
 	Status uint8  `json:"status"`
 	Author string `json:"author"`
-	// 计数
+	// 作者的 ID 和粉丝数，以及我有没有关注作者，只有读者看详情的时候才有
+	AuthorId        int64 `json:"author_id,omitempty"`
+	AuthorFollowers int64 `json:"author_followers,omitempty"`
+	Following       bool  `json:"following,omitempty"`
+	// 计数，ReadCnt 是每次打开都算，Uv 是去重之后的读者数
 	ReadCnt    int64 `json:"read_cnt"`
+	Uv         int64 `json:"uv"`
 	LikeCnt    int64 `json:"like_cnt"`
 	CollectCnt int64 `json:"collect_cnt"`
+	CommentCnt int64 `json:"comment_cnt"`
 
 	// 我个人有没有收藏，有没有点赞
 	Liked     bool `json:"liked"`
 	Collected bool `json:"collected"`
+	// 每一种表态的计数，以及我自己的表态，没有表态就是空的
+	Reactions map[string]int64 `json:"reactions,omitempty"`
+	Reaction  string           `json:"reaction,omitempty"`
+
+	// 定时发表的时间，只有定时发表的帖子才有
+	PublishAt string   `json:"publish_at,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	// 编辑的时候要带回来
+	Version int64 `json:"version,omitempty"`
+	// 删除的时间，只有回收站里面的帖子才有
+	DeletedAt string `json:"deleted_at,omitempty"`
+
+	// 渲染之后的内容，只有读者看详情的时候才有
+	Html        string      `json:"html,omitempty"`
+	Toc         []HeadingVO `json:"toc,omitempty"`
+	WordCnt     int         `json:"word_cnt,omitempty"`
+	ReadMinutes int         `json:"read_minutes,omitempty"`
 
 	Ctime string `json:"ctime"`
 	Utime string `json:"utime"`
 }
 
+// LiveCntVO 实时计数，SSE 里面 cnt 事件的内容
+type LiveCntVO struct {
+	ReadCnt    int64            `json:"read_cnt"`
+	LikeCnt    int64            `json:"like_cnt"`
+	CollectCnt int64            `json:"collect_cnt"`
+	Reactions  map[string]int64 `json:"reactions,omitempty"`
+}
+
+// HeadingVO 目录里面的一项，Id 是标题的锚点
+type HeadingVO struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+	Id    string `json:"id"`
+}
+
 type ListReq struct {
+	// Offset 已经废弃了，深翻页的时候很慢，用 Cursor
 	Offset int `json:"offset"`
 	Limit  int `json:"limit"`
+	// CursorMode 用游标翻页，第一页要传，后面传了 Cursor 就可以不传
+	CursorMode bool `json:"cursor_mode"`
+	// Cursor 上一页返回的 next_cursor，第一页不传
+	Cursor string `json:"cursor"`
+}
+
+type ArticleListVO struct {
+	Articles []ArticleVO `json:"articles"`
+	// 下一页的游标，为空说明已经到底了
+	NextCursor string `json:"next_cursor"`
+}
+
+// encodeArticleCursor 游标对前端来说是不透明的，前端只需要原样传回来
+func encodeArticleCursor(c domain.ArticleCursor) string {
+	return encodeTimeCursor(c.Utime, c.Id)
+}
+
+func decodeArticleCursor(s string) (domain.ArticleCursor, error) {
+	t, id, err := decodeTimeCursor(s)
+	if err != nil {
+		return domain.ArticleCursor{}, err
+	}
+	return domain.ArticleCursor{Utime: t, Id: id}, nil
+}
+
+// encodeTimeCursor 按照 (时间, id) 翻页的游标都用这个格式
+func encodeTimeCursor(t time.Time, id int64) string {
+	raw := strconv.FormatInt(t.UnixMilli(), 10) + "_" + strconv.FormatInt(id, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeTimeCursor 空字符串是第一页，返回零值
+func decodeTimeCursor(s string) (time.Time, int64, error) {
+	if s == "" {
+		return time.Time{}, 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	ts, idStr, ok := strings.Cut(string(raw), "_")
+	if !ok {
+		return time.Time{}, 0, errors.New("游标格式不对")
+	}
+	ms, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	return time.UnixMilli(ms), id, nil
 }
 
 type ArticleReq struct {
 	Id      int64  `json:"id"`
 	Title   string `json:"title"`
 	Content string `json:"content"`
+	// 定时发表的时间，毫秒数。不传就是立刻发表
+	PublishAt int64    `json:"publish_at"`
+	Tags      []string `json:"tags"`
+	// Version 加载帖子的时候拿到的版本号，保存成功之后版本号加一。
+	// 不传就不校验，兼容老的前端
+	Version int64 `json:"version"`
+}
+
+// savedVersion 保存成功之后的版本号。新建的帖子从 1 开始，
+// 之后每保存一次 DAO 都会加一。没有传版本号的老前端不校验，返回 0
+func (req ArticleReq) savedVersion() int64 {
+	if req.Id == 0 {
+		return 1
+	}
+	if req.Version > 0 {
+		return req.Version + 1
+	}
+	return 0
+}
+
+// ArticleSaveVO 保存和发表的结果。
+// 前端下一次保存的时候要带上这里的 Version，不然会被当成版本冲突
+type ArticleSaveVO struct {
+	Id      int64 `json:"id"`
+	Version int64 `json:"version"`
 }
 
 func (req ArticleReq) toDomain(uid int64) domain.Article {
-	return domain.Article{
+	art := domain.Article{
 		Id:      req.Id,
 		Title:   req.Title,
 		Content: req.Content,
 		Author: domain.Author{
 			Id: uid,
 		},
+		Tags:    req.Tags,
+		Version: req.Version,
+	}
+	if req.PublishAt > 0 {
+		art.PublishAt = time.UnixMilli(req.PublishAt)
+	}
+	return art
+}
+
+// TagListReq 标签下的帖子，标签本身在路径里面
+type TagListReq struct {
+	Offset int `form:"offset"`
+	Limit  int `form:"limit"`
+}
+
+type TagCloudReq struct {
+	Limit int `form:"limit"`
+}
+
+type TagVO struct {
+	Name       string `json:"name"`
+	ArticleCnt int64  `json:"article_cnt"`
+}
+
+type ScheduledCancelReq struct {
+	Id int64 `json:"id"`
+}
+
+type ArticleDeleteReq struct {
+	Id int64 `json:"id"`
+}
+
+type TrashRestoreReq struct {
+	Id int64 `json:"id"`
+}
+
+type RevisionListReq struct {
+	// 帖子 ID
+	Id     int64 `json:"id"`
+	Offset int   `json:"offset"`
+	Limit  int   `json:"limit"`
+}
+
+type RevisionDiffReq struct {
+	Id   int64 `json:"id"`
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+type RevisionRestoreReq struct {
+	Id         int64 `json:"id"`
+	RevisionId int64 `json:"revision_id"`
+}
+
+type ArticleRevisionVO struct {
+	Id        int64  `json:"id"`
+	ArticleId int64  `json:"article_id"`
+	Title     string `json:"title"`
+	// 列表页只给摘要，详情才给内容
+	Abstract string `json:"abstract,omitempty"`
+	Content  string `json:"content,omitempty"`
+	Status   uint8  `json:"status"`
+	Ctime    string `json:"ctime"`
+}
+
+func newArticleRevisionVO(rev domain.ArticleRevision) ArticleRevisionVO {
+	return ArticleRevisionVO{
+		Id:        rev.Id,
+		ArticleId: rev.ArticleId,
+		Title:     rev.Title,
+		Content:   rev.Content,
+		Status:    rev.Status.ToUint8(),
+		Ctime:     rev.Ctime.Format(time.DateTime),
+	}
+}
+
+type DiffLineVO struct {
+	// equal, insert, delete
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+type ArticleRevisionDiffVO struct {
+	From    ArticleRevisionVO `json:"from"`
+	To      ArticleRevisionVO `json:"to"`
+	Title   []DiffLineVO      `json:"title"`
+	Content []DiffLineVO      `json:"content"`
+}
+
+func newArticleRevisionDiffVO(diff domain.ArticleRevisionDiff) ArticleRevisionDiffVO {
+	toVO := func(idx int, src diffx.Line) DiffLineVO {
+		return DiffLineVO{Op: src.Op.String(), Text: src.Text}
+	}
+	from := newArticleRevisionVO(diff.From)
+	to := newArticleRevisionVO(diff.To)
+	// 差异里面已经有全文了
+	from.Content, to.Content = "", ""
+	return ArticleRevisionDiffVO{
+		From:    from,
+		To:      to,
+		Title:   slice.Map(diff.Title, toVO),
+		Content: slice.Map(diff.Content, toVO),
+	}
+}
+
+func toReactionsVO(intr domain.Interactive) map[string]int64 {
+	res := make(map[string]int64, 4)
+	for typ, cnt := range intr.Reactions() {
+		res[string(typ)] = cnt
 	}
+	return res
 }
@@ -0,0 +1,211 @@
+package web
+
+import (
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	ijwt "github.com/gevinzone/basic-go/week9/webook/internal/web/jwt"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/ginx"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+var _ handler = (*CollectionHandler)(nil)
+
+// CollectionHandler 收藏夹管理，以及收藏和取消收藏帖子
+type CollectionHandler struct {
+	svc     service.CollectionService
+	intrSvc service.InteractiveService
+	artSvc  service.ArticleService
+	biz     string
+}
+
+func NewCollectionHandler(svc service.CollectionService,
+	intrSvc service.InteractiveService,
+	artSvc service.ArticleService) *CollectionHandler {
+	return &CollectionHandler{
+		svc:     svc,
+		intrSvc: intrSvc,
+		artSvc:  artSvc,
+		biz:     "article",
+	}
+}
+
+func (h *CollectionHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/collections")
+	g.POST("/create",
+		ginx.WrapBodyAndToken[CollectionReq, ijwt.UserClaims](h.Create))
+	g.POST("/rename",
+		ginx.WrapBodyAndToken[CollectionReq, ijwt.UserClaims](h.Rename))
+	g.POST("/delete",
+		ginx.WrapBodyAndToken[CollectionReq, ijwt.UserClaims](h.Delete))
+	g.POST("/list", ginx.WrapToken[ijwt.UserClaims](h.List))
+	g.POST("/items",
+		ginx.WrapBodyAndToken[CollectionItemListReq, ijwt.UserClaims](h.ListItems))
+	g.POST("/move",
+		ginx.WrapBodyAndToken[CollectReq, ijwt.UserClaims](h.Move))
+	g.POST("/collect",
+		ginx.WrapBodyAndToken[CollectReq, ijwt.UserClaims](h.Collect))
+	g.POST("/uncollect",
+		ginx.WrapBodyAndToken[CollectReq, ijwt.UserClaims](h.Uncollect))
+}
+
+func (h *CollectionHandler) Create(ctx *gin.Context,
+	req CollectionReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	id, err := h.svc.Create(ctx, uc.Id, req.Name)
+	if err != nil {
+		return h.errResult(err), err
+	}
+	return ginx.Result{Data: id}, nil
+}
+
+func (h *CollectionHandler) Rename(ctx *gin.Context,
+	req CollectionReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.svc.Rename(ctx, uc.Id, req.Id, req.Name)
+	if err != nil {
+		return h.errResult(err), err
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
+
+func (h *CollectionHandler) Delete(ctx *gin.Context,
+	req CollectionReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.svc.Delete(ctx, uc.Id, req.Id)
+	if err != nil {
+		return h.errResult(err), err
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
+
+func (h *CollectionHandler) List(ctx *gin.Context, uc ijwt.UserClaims) (ginx.Result, error) {
+	res, err := h.svc.List(ctx, uc.Id)
+	if err != nil {
+		return h.errResult(err), err
+	}
+	return ginx.Result{
+		Data: slice.Map(res, func(idx int, src domain.Collection) CollectionVO {
+			return CollectionVO{
+				Id:    src.Id,
+				Name:  src.Name,
+				Ctime: src.Ctime.Format(time.DateTime),
+				Utime: src.Utime.Format(time.DateTime),
+			}
+		}),
+	}, nil
+}
+
+func (h *CollectionHandler) ListItems(ctx *gin.Context,
+	req CollectionItemListReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	limit := req.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	items, err := h.svc.ListItems(ctx, uc.Id, req.Cid, req.Offset, limit)
+	if err != nil {
+		return h.errResult(err), err
+	}
+	ids := slice.Map(items, func(idx int, src domain.CollectionItem) int64 {
+		return src.BizId
+	})
+	arts, err := h.artSvc.ListPubByIds(ctx, ids)
+	if err != nil {
+		return h.errResult(err), err
+	}
+	artMap := make(map[int64]domain.Article, len(arts))
+	for _, art := range arts {
+		artMap[art.Id] = art
+	}
+	return ginx.Result{
+		Data: slice.Map(items, func(idx int, src domain.CollectionItem) CollectionItemVO {
+			vo := CollectionItemVO{
+				Cid:   src.Cid,
+				BizId: src.BizId,
+				Ctime: src.Ctime.Format(time.DateTime),
+			}
+			// 帖子被删了或者设置成仅自己可见，收藏还在，只是看不到摘要
+			if art, ok := artMap[src.BizId]; ok {
+				vo.Article = &ArticleVO{
+					Id:       art.Id,
+					Title:    art.Title,
+					Abstract: art.Abstract(),
+					Ctime:    art.Ctime.Format(time.DateTime),
+					Utime:    art.Utime.Format(time.DateTime),
+				}
+			}
+			return vo
+		}),
+	}, nil
+}
+
+func (h *CollectionHandler) Move(ctx *gin.Context,
+	req CollectReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.svc.Move(ctx, uc.Id, h.biz, req.BizId, req.Cid)
+	if err != nil {
+		return h.errResult(err), err
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
+
+func (h *CollectionHandler) Collect(ctx *gin.Context,
+	req CollectReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.intrSvc.Collect(ctx, h.biz, req.BizId, req.Cid, uc.Id)
+	if err != nil {
+		return h.errResult(err), err
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
+
+func (h *CollectionHandler) Uncollect(ctx *gin.Context,
+	req CollectReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.intrSvc.Uncollect(ctx, h.biz, req.BizId, uc.Id)
+	if err != nil {
+		return h.errResult(err), err
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
+
+func (h *CollectionHandler) errResult(err error) ginx.Result {
+	switch err {
+	case service.ErrCollectionNotFound:
+		return ginx.Result{Code: 4, Msg: "收藏夹不存在"}
+	case service.ErrInvalidCollectionName:
+		return ginx.Result{Code: 4, Msg: "收藏夹名字不合法"}
+	case service.ErrNotCollected:
+		return ginx.Result{Code: 4, Msg: "没有收藏"}
+	default:
+		return ginx.Result{Code: 5, Msg: "系统错误"}
+	}
+}
+
+type CollectionReq struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type CollectionItemListReq struct {
+	// 0 是默认收藏夹
+	Cid    int64 `json:"cid"`
+	Offset int   `json:"offset"`
+	Limit  int   `json:"limit"`
+}
+
+// CollectReq 收藏、取消收藏和挪动收藏夹共用，取消收藏的时候不需要 cid
+type CollectReq struct {
+	BizId int64 `json:"biz_id"`
+	Cid   int64 `json:"cid"`
+}
+
+type CollectionVO struct {
+	Id    int64  `json:"id"`
+	Name  string `json:"name"`
+	Ctime string `json:"ctime"`
+	Utime string `json:"utime"`
+}
+
+type CollectionItemVO struct {
+	Cid   int64  `json:"cid"`
+	BizId int64  `json:"biz_id"`
+	Ctime string `json:"ctime"`
+	// 帖子看不到的时候是 null
+	Article *ArticleVO `json:"article"`
+}
@@ -0,0 +1,164 @@
+package web
+
+import (
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	ijwt "github.com/gevinzone/basic-go/week9/webook/internal/web/jwt"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/ginx"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+var _ handler = (*CommentHandler)(nil)
+
+// CommentHandler 帖子的评论，CommentService 本身不关心 biz，
+// 以后别的资源要评论，换一个 biz 再注册一组路由就可以
+type CommentHandler struct {
+	svc service.CommentService
+	biz string
+}
+
+func NewCommentHandler(svc service.CommentService) *CommentHandler {
+	return &CommentHandler{svc: svc, biz: "article"}
+}
+
+func (h *CommentHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/articles/pub/comments")
+	g.POST("/create",
+		ginx.WrapBodyAndToken[CommentReq, ijwt.UserClaims](h.Comment))
+	g.POST("/delete",
+		ginx.WrapBodyAndToken[CommentDeleteReq, ijwt.UserClaims](h.Delete))
+	g.GET("/list", ginx.WrapBodyV1[CommentListReq](h.List))
+	g.GET("/replies", ginx.WrapBodyV1[CommentReplyListReq](h.ListReplies))
+}
+
+func (h *CommentHandler) Comment(ctx *gin.Context,
+	req CommentReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	id, err := h.svc.Comment(ctx, domain.Comment{
+		Biz:         h.biz,
+		BizId:       req.BizId,
+		Commentator: domain.User{Id: uc.Id},
+		Content:     req.Content,
+		ParentId:    req.ParentId,
+	})
+	switch err {
+	case nil:
+		return ginx.Result{Data: id}, nil
+	case service.ErrInvalidComment, service.ErrCommentNotFound,
+		service.ErrCommentTargetNotFound:
+		return ginx.Result{
+			Code: 4,
+			Msg:  "参数错误",
+		}, err
+	default:
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+}
+
+func (h *CommentHandler) Delete(ctx *gin.Context,
+	req CommentDeleteReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.svc.Delete(ctx, uc.Id, req.Id)
+	switch err {
+	case nil:
+		return ginx.Result{Msg: "OK"}, nil
+	case service.ErrCommentNotFound, service.ErrCommentPermissionDenied,
+		service.ErrCommentTargetNotFound:
+		return ginx.Result{
+			Code: 4,
+			// 不需要告诉前端究竟是哪一种
+			Msg: "评论不存在",
+		}, err
+	default:
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+}
+
+func (h *CommentHandler) List(ctx *gin.Context, req CommentListReq) (ginx.Result, error) {
+	order := domain.CommentOrderTime
+	if req.Order == "hot" {
+		order = domain.CommentOrderHot
+	}
+	res, err := h.svc.ListRoot(ctx, h.biz, req.BizId, order,
+		req.Offset, commentPageSize(req.Limit))
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	return ginx.Result{Data: slice.Map(res, toCommentVO)}, nil
+}
+
+func (h *CommentHandler) ListReplies(ctx *gin.Context, req CommentReplyListReq) (ginx.Result, error) {
+	res, err := h.svc.ListReplies(ctx, req.RootId, req.Offset, commentPageSize(req.Limit))
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	return ginx.Result{Data: slice.Map(res, toCommentVO)}, nil
+}
+
+func commentPageSize(limit int) int {
+	if limit <= 0 || limit > 100 {
+		return 20
+	}
+	return limit
+}
+
+func toCommentVO(idx int, src domain.Comment) CommentVO {
+	return CommentVO{
+		Id:       src.Id,
+		Uid:      src.Commentator.Id,
+		Content:  src.Content,
+		RootId:   src.RootId,
+		ParentId: src.ParentId,
+		ReplyCnt: src.ReplyCnt,
+		Ctime:    src.Ctime.Format(time.DateTime),
+	}
+}
+
+type CommentReq struct {
+	// 帖子的 ID
+	BizId int64 `json:"biz_id"`
+	// 回复的评论，一级评论不传
+	ParentId int64  `json:"parent_id"`
+	Content  string `json:"content"`
+}
+
+type CommentDeleteReq struct {
+	Id int64 `json:"id"`
+}
+
+type CommentListReq struct {
+	BizId int64 `form:"biz_id"`
+	// time 或者 hot，默认是 time
+	Order  string `form:"order"`
+	Offset int    `form:"offset"`
+	Limit  int    `form:"limit"`
+}
+
+type CommentReplyListReq struct {
+	RootId int64 `form:"root_id"`
+	Offset int   `form:"offset"`
+	Limit  int   `form:"limit"`
+}
+
+type CommentVO struct {
+	Id      int64  `json:"id"`
+	Uid     int64  `json:"uid"`
+	Content string `json:"content"`
+	// 一级评论的 root_id 和 parent_id 都是 0
+	RootId   int64  `json:"root_id"`
+	ParentId int64  `json:"parent_id"`
+	ReplyCnt int64  `json:"reply_cnt"`
+	Ctime    string `json:"ctime"`
+}
@@ -0,0 +1,76 @@
+package web
+
+import (
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	ijwt "github.com/gevinzone/basic-go/week9/webook/internal/web/jwt"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/ginx"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+var _ handler = (*FeedHandler)(nil)
+
+// FeedHandler 首页的 feed 流，关注的人发表的帖子
+type FeedHandler struct {
+	svc service.FeedService
+}
+
+func NewFeedHandler(svc service.FeedService) *FeedHandler {
+	return &FeedHandler{svc: svc}
+}
+
+func (h *FeedHandler) RegisterRoutes(server *gin.Engine) {
+	server.GET("/feed",
+		ginx.WrapBodyAndToken[FeedReq, ijwt.UserClaims](h.List))
+}
+
+func (h *FeedHandler) List(ctx *gin.Context,
+	req FeedReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	t, id, err := decodeTimeCursor(req.Cursor)
+	if err != nil {
+		return ginx.Result{
+			Code: 4,
+			Msg:  "参数错误",
+		}, err
+	}
+	limit := req.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	items, next, err := h.svc.List(ctx, uc.Id, domain.UserBizCursor{Time: t, Id: id}, limit)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	vo := ArticleListVO{
+		Articles: slice.Map(items, func(idx int, src domain.FeedItem) ArticleVO {
+			art := src.Article
+			return ArticleVO{
+				Id:       art.Id,
+				Title:    art.Title,
+				Abstract: art.Abstract(),
+				Status:   art.Status.ToUint8(),
+				Author:   art.Author.Name,
+				AuthorId: art.Author.Id,
+				Tags:     art.Tags,
+				Ctime:    art.Ctime.Format(time.DateTime),
+				Utime:    art.Utime.Format(time.DateTime),
+			}
+		}),
+	}
+	// 撤回的帖子被过滤掉之后，这一页可能不满，但是后面还有
+	if !next.IsZero() {
+		vo.NextCursor = encodeTimeCursor(next.Time, next.Id)
+	}
+	return ginx.Result{Data: vo}, nil
+}
+
+type FeedReq struct {
+	// Cursor 上一页返回的 next_cursor，第一页不传
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+}
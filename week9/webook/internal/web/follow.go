@@ -0,0 +1,202 @@
+package web
+
+import (
+	"errors"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	ijwt "github.com/gevinzone/basic-go/week9/webook/internal/web/jwt"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/ginx"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+var _ handler = (*FollowHandler)(nil)
+
+// FollowHandler 关注、取消关注，以及粉丝和关注列表
+type FollowHandler struct {
+	svc service.FollowService
+}
+
+func NewFollowHandler(svc service.FollowService) *FollowHandler {
+	return &FollowHandler{svc: svc}
+}
+
+func (h *FollowHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/users")
+	g.POST("/follow",
+		ginx.WrapBodyAndToken[FollowReq, ijwt.UserClaims](h.Follow))
+	g.POST("/unfollow",
+		ginx.WrapBodyAndToken[FollowReq, ijwt.UserClaims](h.Unfollow))
+	// 不传 uid 就是看自己的
+	g.GET("/followers",
+		ginx.WrapBodyAndToken[FollowListReq, ijwt.UserClaims](h.Followers))
+	g.GET("/followees",
+		ginx.WrapBodyAndToken[FollowListReq, ijwt.UserClaims](h.Followees))
+	g.GET("/follow_statics",
+		ginx.WrapBodyAndToken[FollowStaticsReq, ijwt.UserClaims](h.Statics))
+}
+
+func (h *FollowHandler) Follow(ctx *gin.Context,
+	req FollowReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.svc.Follow(ctx, uc.Id, req.Followee)
+	if errors.Is(err, service.ErrFollowSelf) {
+		return ginx.Result{
+			Code: 4,
+			Msg:  "不能关注自己",
+		}, nil
+	}
+	if errors.Is(err, service.ErrFolloweeNotFound) {
+		return ginx.Result{
+			Code: 4,
+			Msg:  "用户不存在",
+		}, nil
+	}
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
+
+func (h *FollowHandler) Unfollow(ctx *gin.Context,
+	req FollowReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.svc.Unfollow(ctx, uc.Id, req.Followee)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
+
+func (h *FollowHandler) Followers(ctx *gin.Context,
+	req FollowListReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	return h.list(req, uc, func(uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FollowRelation, error) {
+		return h.svc.ListFollowers(ctx, uid, cursor, limit)
+	}, func(fr domain.FollowRelation) int64 {
+		return fr.Follower
+	})
+}
+
+func (h *FollowHandler) Followees(ctx *gin.Context,
+	req FollowListReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	return h.list(req, uc, func(uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FollowRelation, error) {
+		return h.svc.ListFollowees(ctx, uid, cursor, limit)
+	}, func(fr domain.FollowRelation) int64 {
+		return fr.Followee
+	})
+}
+
+// list other 从关注关系里面取出列表里面要展示的那个人
+func (h *FollowHandler) list(req FollowListReq, uc ijwt.UserClaims,
+	fn func(uid int64, cursor domain.UserBizCursor, limit int) ([]domain.FollowRelation, error),
+	other func(fr domain.FollowRelation) int64) (ginx.Result, error) {
+	t, id, err := decodeTimeCursor(req.Cursor)
+	if err != nil {
+		return ginx.Result{
+			Code: 4,
+			Msg:  "参数错误",
+		}, err
+	}
+	uid := req.Uid
+	if uid <= 0 {
+		uid = uc.Id
+	}
+	limit := req.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	res, err := fn(uid, domain.UserBizCursor{Time: t, Id: id}, limit)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	vo := FollowListVO{
+		Items: slice.Map(res, func(idx int, src domain.FollowRelation) FollowVO {
+			return FollowVO{
+				Uid:    other(src),
+				Mutual: src.Mutual,
+				Time:   src.Time.Format(time.DateTime),
+			}
+		}),
+	}
+	// 不满一页说明已经到底了
+	if len(res) == limit {
+		c := res[len(res)-1].Cursor()
+		vo.NextCursor = encodeTimeCursor(c.Time, c.Id)
+	}
+	return ginx.Result{Data: vo}, nil
+}
+
+func (h *FollowHandler) Statics(ctx *gin.Context,
+	req FollowStaticsReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	uid := req.Uid
+	if uid <= 0 {
+		uid = uc.Id
+	}
+	statics, err := h.svc.GetStatics(ctx, uid)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	vo := FollowStaticsVO{
+		Followers: statics.Followers,
+		Followees: statics.Followees,
+	}
+	if uid != uc.Id {
+		vo.Following, err = h.svc.IsFollowing(ctx, uc.Id, uid)
+		if err != nil {
+			return ginx.Result{
+				Code: 5,
+				Msg:  "系统错误",
+			}, err
+		}
+	}
+	return ginx.Result{Data: vo}, nil
+}
+
+type FollowReq struct {
+	Followee int64 `json:"followee"`
+}
+
+type FollowListReq struct {
+	// 看谁的列表，不传就是自己的
+	Uid int64 `form:"uid"`
+	// Cursor 上一页返回的 next_cursor，第一页不传
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+}
+
+type FollowStaticsReq struct {
+	Uid int64 `form:"uid"`
+}
+
+type FollowListVO struct {
+	Items []FollowVO `json:"items"`
+	// 下一页的游标，为空说明已经到底了
+	NextCursor string `json:"next_cursor"`
+}
+
+type FollowVO struct {
+	// 粉丝列表里面是粉丝，关注列表里面是关注的人
+	Uid int64 `json:"uid"`
+	// 是不是互相关注
+	Mutual bool `json:"mutual"`
+	// 关注的时间
+	Time string `json:"time"`
+}
+
+type FollowStaticsVO struct {
+	Followers int64 `json:"followers"`
+	Followees int64 `json:"followees"`
+	// 我有没有关注对方，看自己的时候永远是 false
+	Following bool `json:"following"`
+}
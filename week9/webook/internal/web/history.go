@@ -0,0 +1,99 @@
+package web
+
+import (
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	ijwt "github.com/gevinzone/basic-go/week9/webook/internal/web/jwt"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/ginx"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+var _ handler = (*HistoryHandler)(nil)
+
+// HistoryHandler 用户的阅读记录
+type HistoryHandler struct {
+	svc service.HistoryService
+}
+
+func NewHistoryHandler(svc service.HistoryService) *HistoryHandler {
+	return &HistoryHandler{svc: svc}
+}
+
+func (h *HistoryHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/users/history")
+	g.POST("/list",
+		ginx.WrapBodyAndToken[HistoryListReq, ijwt.UserClaims](h.List))
+	g.POST("/delete",
+		ginx.WrapBodyAndToken[HistoryDeleteReq, ijwt.UserClaims](h.Delete))
+	g.POST("/clear", ginx.WrapToken[ijwt.UserClaims](h.Clear))
+}
+
+func (h *HistoryHandler) List(ctx *gin.Context,
+	req HistoryListReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	limit := req.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	res, err := h.svc.List(ctx, uc.Id, req.Offset, limit)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	return ginx.Result{
+		Data: slice.Map(res, func(idx int, src domain.ReadHistory) HistoryVO {
+			return HistoryVO{
+				Biz:      src.Biz,
+				BizId:    src.BizId,
+				ReadTime: src.ReadTime.Format(time.DateTime),
+			}
+		}),
+	}, nil
+}
+
+func (h *HistoryHandler) Delete(ctx *gin.Context,
+	req HistoryDeleteReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	biz := req.Biz
+	if biz == "" {
+		biz = "article"
+	}
+	err := h.svc.Delete(ctx, uc.Id, biz, req.BizId)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
+
+func (h *HistoryHandler) Clear(ctx *gin.Context, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.svc.Clear(ctx, uc.Id)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
+
+type HistoryListReq struct {
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+type HistoryDeleteReq struct {
+	// 不传就是 article
+	Biz   string `json:"biz"`
+	BizId int64  `json:"biz_id"`
+}
+
+type HistoryVO struct {
+	Biz      string `json:"biz"`
+	BizId    int64  `json:"biz_id"`
+	ReadTime string `json:"read_time"`
+}
@@ -0,0 +1,129 @@
+package web
+
+import (
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	ijwt "github.com/gevinzone/basic-go/week9/webook/internal/web/jwt"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/ginx"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+var _ handler = (*NotificationHandler)(nil)
+
+// NotificationHandler 收到的点赞、收藏、评论和关注的通知
+type NotificationHandler struct {
+	svc service.NotificationService
+}
+
+func NewNotificationHandler(svc service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{svc: svc}
+}
+
+func (h *NotificationHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/notifications")
+	g.GET("",
+		ginx.WrapBodyAndToken[NotificationListReq, ijwt.UserClaims](h.List))
+	// 不传 ids 就是全部已读
+	g.POST("/read",
+		ginx.WrapBodyAndToken[MarkReadReq, ijwt.UserClaims](h.MarkRead))
+	g.GET("/unread_cnt", ginx.WrapToken[ijwt.UserClaims](h.UnreadCnt))
+}
+
+func (h *NotificationHandler) List(ctx *gin.Context,
+	req NotificationListReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	t, id, err := decodeTimeCursor(req.Cursor)
+	if err != nil {
+		return ginx.Result{
+			Code: 4,
+			Msg:  "参数错误",
+		}, err
+	}
+	limit := req.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	res, err := h.svc.List(ctx, uc.Id, domain.UserBizCursor{Time: t, Id: id}, limit)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	vo := NotificationListVO{
+		Items: slice.Map(res, func(idx int, src domain.Notification) NotificationVO {
+			return NotificationVO{
+				Id:       src.Id,
+				Type:     string(src.Type),
+				Biz:      src.Biz,
+				BizId:    src.BizId,
+				Actors:   src.Actors,
+				ActorCnt: src.ActorCnt,
+				Read:     src.Read,
+				Time:     src.Time.Format(time.DateTime),
+			}
+		}),
+	}
+	// 不满一页说明已经到底了
+	if len(res) == limit {
+		c := res[len(res)-1].Cursor()
+		vo.NextCursor = encodeTimeCursor(c.Time, c.Id)
+	}
+	return ginx.Result{Data: vo}, nil
+}
+
+func (h *NotificationHandler) MarkRead(ctx *gin.Context,
+	req MarkReadReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	err := h.svc.MarkRead(ctx, uc.Id, req.Ids)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	return ginx.Result{Msg: "OK"}, nil
+}
+
+func (h *NotificationHandler) UnreadCnt(ctx *gin.Context,
+	uc ijwt.UserClaims) (ginx.Result, error) {
+	cnt, err := h.svc.UnreadCnt(ctx, uc.Id)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	return ginx.Result{Data: cnt}, nil
+}
+
+type NotificationListReq struct {
+	// Cursor 上一页返回的 next_cursor，第一页不传
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+}
+
+type MarkReadReq struct {
+	Ids []int64 `json:"ids"`
+}
+
+type NotificationListVO struct {
+	Items []NotificationVO `json:"items"`
+	// 下一页的游标，为空说明已经到底了
+	NextCursor string `json:"next_cursor"`
+}
+
+type NotificationVO struct {
+	Id int64 `json:"id"`
+	// like、collect、comment 或者 follow
+	Type string `json:"type"`
+	// 关注的时候是 user，BizId 就是自己
+	Biz   string `json:"biz"`
+	BizId int64  `json:"biz_id"`
+	// 最近的几个人，最新的在前面
+	Actors []int64 `json:"actors"`
+	// 一共多少人，"X 等 13 人赞了你的文章"
+	ActorCnt int64  `json:"actor_cnt"`
+	Read     bool   `json:"read"`
+	Time     string `json:"time"`
+}
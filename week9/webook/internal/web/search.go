@@ -0,0 +1,81 @@
+package web
+
+import (
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/ginx"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+var _ handler = (*SearchHandler)(nil)
+
+type SearchHandler struct {
+	svc service.SearchService
+}
+
+func NewSearchHandler(svc service.SearchService) *SearchHandler {
+	return &SearchHandler{svc: svc}
+}
+
+func (h *SearchHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/search")
+	g.GET("/articles", ginx.WrapBodyV1[SearchReq](h.SearchArticle))
+}
+
+func (h *SearchHandler) SearchArticle(ctx *gin.Context, req SearchReq) (ginx.Result, error) {
+	limit := req.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	offset := req.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	res, err := h.svc.SearchArticle(ctx, req.Q, offset, limit)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	return ginx.Result{
+		Data: ArticleSearchVO{
+			Total: res.Total,
+			Hits: slice.Map(res.Hits, func(idx int, src domain.ArticleSearchHit) ArticleSearchHitVO {
+				return ArticleSearchHitVO{
+					Id:               src.Article.Id,
+					Title:            src.Article.Title,
+					Author:           src.Article.Author.Name,
+					Score:            src.Score,
+					TitleHighlight:   src.TitleHighlight,
+					ContentHighlight: src.ContentHighlight,
+					Utime:            src.Article.Utime.Format(time.DateTime),
+				}
+			}),
+		},
+	}, nil
+}
+
+type SearchReq struct {
+	Q      string `form:"q"`
+	Offset int    `form:"offset"`
+	Limit  int    `form:"limit"`
+}
+
+type ArticleSearchVO struct {
+	Total int64                `json:"total"`
+	Hits  []ArticleSearchHitVO `json:"hits"`
+}
+
+type ArticleSearchHitVO struct {
+	Id     int64   `json:"id"`
+	Title  string  `json:"title"`
+	Author string  `json:"author"`
+	Score  float64 `json:"score"`
+	// 命中的部分用 <em> 包起来，其余部分已经转义过了
+	TitleHighlight   string `json:"title_highlight"`
+	ContentHighlight string `json:"content_highlight"`
+	Utime            string `json:"utime"`
+}
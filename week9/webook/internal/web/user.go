@@ -27,6 +27,7 @@ var _ handler = (*UserHandler)(nil)
 type UserHandler struct {
 	svc         service.UserService
 	codeSvc     service.CodeService
+	followSvc   service.FollowService
 	emailExp    *regexp.Regexp
 	passwordExp *regexp.Regexp
 	ijwt.Handler
@@ -34,7 +35,8 @@ type UserHandler struct {
 }
 
 func NewUserHandler(svc service.UserService,
-	codeSvc service.CodeService, jwtHdl ijwt.Handler) *UserHandler {
+	codeSvc service.CodeService,
+	followSvc service.FollowService, jwtHdl ijwt.Handler) *UserHandler {
 	const (
 		emailRegexPattern    = "^\\w+([-+.]\\w+)*@\\w+([-.]\\w+)*\\.\\w+([-.]\\w+)*$"
 		passwordRegexPattern = `^(?=.*[A-Za-z])(?=.*\d)(?=.*[$@$!%*#?&])[A-Za-z\d$@$!%*#?&]{8,}$`
@@ -46,6 +48,7 @@ func NewUserHandler(svc service.UserService,
 		emailExp:    emailExp,
 		passwordExp: passwordExp,
 		codeSvc:     codeSvc,
+		followSvc:   followSvc,
 		Handler:     jwtHdl,
 	}
 }
@@ -392,9 +395,41 @@ func (u *UserHandler) ProfileJWT(ctx *gin.Context) {
 		ctx.String(http.StatusOK, "系统错误")
 		return
 	}
-	println(claims.Id)
-	ctx.String(http.StatusOK, "你的 profile")
-	// 这边就是你补充 profile 的其它代码
+	u2, err := u.svc.Profile(ctx, claims.Id)
+	if err != nil {
+		ctx.JSON(http.StatusOK, Result{
+			Code: 5,
+			Msg:  "系统错误",
+		})
+		return
+	}
+	// 关注数查不到也不影响看自己的资料
+	statics, err := u.followSvc.GetStatics(ctx, claims.Id)
+	if err != nil {
+		zap.L().Error("查询关注数失败",
+			zap.Int64("uid", claims.Id),
+			zap.Error(err))
+	}
+	ctx.JSON(http.StatusOK, Result{
+		Data: ProfileVO{
+			Id:        u2.Id,
+			Email:     u2.Email,
+			Phone:     u2.Phone,
+			Nickname:  u2.Nickname,
+			Followers: statics.Followers,
+			Followees: statics.Followees,
+		},
+	})
+}
+
+type ProfileVO struct {
+	Id       int64  `json:"id"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Nickname string `json:"nickname"`
+	// 粉丝数和关注数
+	Followers int64 `json:"followers"`
+	Followees int64 `json:"followees"`
 }
 
 func (u *UserHandler) Profile(ctx *gin.Context) {
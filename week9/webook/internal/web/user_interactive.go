@@ -0,0 +1,136 @@
+package web
+
+import (
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	ijwt "github.com/gevinzone/basic-go/week9/webook/internal/web/jwt"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/ginx"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+var _ handler = (*UserInteractiveHandler)(nil)
+
+// UserInteractiveHandler 用户看自己点赞过、收藏过的东西，不区分 biz
+type UserInteractiveHandler struct {
+	intrSvc service.InteractiveService
+	collSvc service.CollectionService
+	resSvc  service.ResourceService
+}
+
+func NewUserInteractiveHandler(intrSvc service.InteractiveService,
+	collSvc service.CollectionService,
+	resSvc service.ResourceService) *UserInteractiveHandler {
+	return &UserInteractiveHandler{
+		intrSvc: intrSvc,
+		collSvc: collSvc,
+		resSvc:  resSvc,
+	}
+}
+
+func (h *UserInteractiveHandler) RegisterRoutes(server *gin.Engine) {
+	g := server.Group("/users")
+	g.GET("/likes",
+		ginx.WrapBodyAndToken[UserBizListReq, ijwt.UserClaims](h.Likes))
+	g.GET("/collections/items",
+		ginx.WrapBodyAndToken[UserBizListReq, ijwt.UserClaims](h.Collections))
+}
+
+func (h *UserInteractiveHandler) Likes(ctx *gin.Context,
+	req UserBizListReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	return h.list(ctx, req, func(cursor domain.UserBizCursor, limit int) ([]domain.UserBizRecord, error) {
+		return h.intrSvc.ListLikes(ctx, uc.Id, cursor, limit)
+	})
+}
+
+func (h *UserInteractiveHandler) Collections(ctx *gin.Context,
+	req UserBizListReq, uc ijwt.UserClaims) (ginx.Result, error) {
+	return h.list(ctx, req, func(cursor domain.UserBizCursor, limit int) ([]domain.UserBizRecord, error) {
+		return h.collSvc.ListAllItems(ctx, uc.Id, cursor, limit)
+	})
+}
+
+func (h *UserInteractiveHandler) list(ctx *gin.Context, req UserBizListReq,
+	fn func(cursor domain.UserBizCursor, limit int) ([]domain.UserBizRecord, error)) (ginx.Result, error) {
+	t, id, err := decodeTimeCursor(req.Cursor)
+	if err != nil {
+		return ginx.Result{
+			Code: 4,
+			Msg:  "参数错误",
+		}, err
+	}
+	limit := req.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	records, err := fn(domain.UserBizCursor{Time: t, Id: id}, limit)
+	if err != nil {
+		return ginx.Result{
+			Code: 5,
+			Msg:  "系统错误",
+		}, err
+	}
+	summaries := h.resSvc.Resolve(ctx, slice.Map(records,
+		func(idx int, src domain.UserBizRecord) domain.Resource {
+			return src.Resource()
+		}))
+	vo := UserBizListVO{
+		Items: slice.Map(records, func(idx int, src domain.UserBizRecord) UserBizItemVO {
+			item := UserBizItemVO{
+				Biz:   src.Biz,
+				BizId: src.BizId,
+				Cid:   src.Cid,
+				Time:  src.Time.Format(time.DateTime),
+			}
+			// 资源被删了或者看不到了，记录还在，只是没有摘要
+			if sum, ok := summaries[src.Resource()]; ok {
+				item.Summary = &ResourceSummaryVO{
+					Title:      sum.Title,
+					Abstract:   sum.Abstract,
+					AuthorId:   sum.Author.Id,
+					AuthorName: sum.Author.Name,
+					Utime:      sum.Utime.Format(time.DateTime),
+				}
+			}
+			return item
+		}),
+	}
+	// 不满一页说明已经到底了，省掉前端多翻一次
+	if len(records) == limit {
+		c := records[len(records)-1].Cursor()
+		vo.NextCursor = encodeTimeCursor(c.Time, c.Id)
+	}
+	return ginx.Result{Data: vo}, nil
+}
+
+type UserBizListReq struct {
+	// Cursor 上一页返回的 next_cursor，第一页不传
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit"`
+}
+
+type UserBizListVO struct {
+	Items []UserBizItemVO `json:"items"`
+	// 下一页的游标，为空说明已经到底了
+	NextCursor string `json:"next_cursor"`
+}
+
+type UserBizItemVO struct {
+	Biz   string `json:"biz"`
+	BizId int64  `json:"biz_id"`
+	// 收藏夹 ID，0 是默认收藏夹。点赞列表里面没有意义
+	Cid int64 `json:"cid"`
+	// 点赞或者收藏的时间
+	Time string `json:"time"`
+	// 资源看不到的时候是 null
+	Summary *ResourceSummaryVO `json:"summary"`
+}
+
+type ResourceSummaryVO struct {
+	Title      string `json:"title"`
+	Abstract   string `json:"abstract"`
+	AuthorId   int64  `json:"author_id"`
+	AuthorName string `json:"author_name"`
+	Utime      string `json:"utime"`
+}
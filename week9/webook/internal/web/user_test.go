@@ -19,7 +19,7 @@ import (
 )
 
 func TestEncrypt(t *testing.T) {
-	_ = NewUserHandler(nil, nil, nil)
+	_ = NewUserHandler(nil, nil, nil, nil)
 	password := "hello#world123"
 	encrypted, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
@@ -188,7 +188,7 @@ func TestUserHandler_SignUp(t *testing.T) {
 			defer ctrl.Finish()
 			server := gin.Default()
 			// 用不上 codeSvc
-			h := NewUserHandler(tc.mock(ctrl), nil, nil)
+			h := NewUserHandler(tc.mock(ctrl), nil, nil, nil)
 			h.RegisterRoutes(server)
 
 			req, err := http.NewRequest(http.MethodPost,
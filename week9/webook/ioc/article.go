@@ -0,0 +1,67 @@
+package ioc
+
+import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	dao "github.com/gevinzone/basic-go/week9/webook/internal/repository/dao/article"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/objstore"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// ObjectStoreConfig objstore 下面的配置，决定线上库的内容存在哪里
+type ObjectStoreConfig struct {
+	// Type 为空代表内容直接存在数据库里面，local 是本地文件系统，s3 是 S3 以及兼容 S3 的 COS、OSS
+	Type string `yaml:"type"`
+
+	// local 用的
+	Dir     string `yaml:"dir"`
+	BaseURL string `yaml:"baseURL"`
+	Secret  string `yaml:"secret"`
+
+	// s3 用的，密钥从环境变量里面读，不要写在配置文件里面
+	Region   string `yaml:"region"`
+	Endpoint string `yaml:"endpoint"`
+	Bucket   string `yaml:"bucket"`
+}
+
+// InitArticleDAO 配置了对象存储的话，线上库的内容就放到对象存储里面
+func InitArticleDAO(db *gorm.DB) dao.ArticleDAO {
+	var cfg ObjectStoreConfig
+	err := viper.UnmarshalKey("objstore", &cfg)
+	if err != nil {
+		panic(err)
+	}
+	if cfg.Type == "" {
+		return dao.NewGORMArticleDAO(db)
+	}
+	store, err := newObjectStore(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return dao.NewOssDAO(store, db)
+}
+
+func newObjectStore(cfg ObjectStoreConfig) (objstore.ObjectStore, error) {
+	switch cfg.Type {
+	case "local":
+		return objstore.NewLocalStore(cfg.Dir, cfg.BaseURL, []byte(cfg.Secret))
+	case "s3":
+		sess, err := session.NewSession(&aws.Config{
+			Credentials: credentials.NewEnvCredentials(),
+			Region:      aws.String(cfg.Region),
+			Endpoint:    aws.String(cfg.Endpoint),
+			// 强制使用 /bucket/key 的形态
+			S3ForcePathStyle: aws.Bool(true),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return objstore.NewS3Store(s3.New(sess), cfg.Bucket), nil
+	default:
+		return nil, fmt.Errorf("未知的对象存储类型 %s", cfg.Type)
+	}
+}
@@ -0,0 +1,57 @@
+package ioc
+
+import (
+	"context"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/interactive"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// InitCommentService 注册可以评论的资源，现在只有帖子
+func InitCommentService(repo repository.CommentRepository,
+	artSvc service.ArticleService,
+	producer interactive.Producer,
+	l logger.LoggerV1) service.CommentService {
+	return service.NewCommentService(repo, map[string]service.BizOwnerFunc{
+		"article": articleOwner(artSvc),
+	}, map[string]service.BizOwnerFunc{
+		"article": articleAuthor(artSvc),
+	}, producer, l)
+}
+
+// articleOwner 只有已经发表的帖子才能评论，也才会有点赞和收藏的通知
+func articleOwner(artSvc service.ArticleService) service.BizOwnerFunc {
+	return func(ctx context.Context, bizId int64) (int64, error) {
+		art, err := getArticle(ctx, artSvc, bizId)
+		if err != nil {
+			return 0, err
+		}
+		if art.Status != domain.ArticleStatusPublished {
+			return 0, service.ErrCommentTargetNotFound
+		}
+		return art.Author.Id, nil
+	}
+}
+
+// articleAuthor 删除评论的时候用，帖子撤回了之类的，作者也要能管理下面的评论
+func articleAuthor(artSvc service.ArticleService) service.BizOwnerFunc {
+	return func(ctx context.Context, bizId int64) (int64, error) {
+		art, err := getArticle(ctx, artSvc, bizId)
+		if err != nil {
+			return 0, err
+		}
+		return art.Author.Id, nil
+	}
+}
+
+func getArticle(ctx context.Context, artSvc service.ArticleService, id int64) (domain.Article, error) {
+	// 制作库的状态和线上库是同步的，查制作库不用带上作者的信息
+	art, err := artSvc.GetById(ctx, id)
+	if err == gorm.ErrRecordNotFound {
+		return domain.Article{}, service.ErrCommentTargetNotFound
+	}
+	return art, err
+}
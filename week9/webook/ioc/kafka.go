@@ -4,7 +4,12 @@ import (
 	"github.com/IBM/sarama"
 	"github.com/gevinzone/basic-go/week9/webook/internal/events"
 	"github.com/gevinzone/basic-go/week9/webook/internal/events/article"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/feed"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/notification"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/saramax"
+	"github.com/redis/go-redis/v9"
 	"github.com/spf13/viper"
+	"time"
 )
 
 func InitKafka() sarama.Client {
@@ -33,7 +38,18 @@ func NewSyncProducer(client sarama.Client) sarama.SyncProducer {
 	return res
 }
 
+// InitIdempotencyStore 消费者去重用的，重复投递一般发生在 rebalance 之后不久，
+// 处理过的记一天足够了。处理中的一分钟就过期，消费者崩溃了也不会丢消息
+func InitIdempotencyStore(client redis.Cmdable) saramax.IdempotencyStore {
+	return saramax.NewRedisIdempotencyStore(client, "kafka:consumed:", time.Minute, time.Hour*24)
+}
+
 // NewConsumers 面临的问题依旧是所有的 Consumer 在这里注册一下
-func NewConsumers(c1 *article.InteractiveReadEventBatchConsumer) []events.Consumer {
-	return []events.Consumer{c1}
+func NewConsumers(c1 *article.InteractiveReadEventBatchConsumer,
+	c2 *article.HistoryReadEventConsumer,
+	c3 *article.InteractiveUVEventBatchConsumer,
+	c4 *feed.PublishEventConsumer,
+	c5 *notification.InteractiveEventConsumer,
+	c6 *notification.FollowEventConsumer) []events.Consumer {
+	return []events.Consumer{c1, c2, c3, c4, c5, c6}
 }
@@ -11,13 +11,60 @@ import (
 
 func InitScheduler(l logger.LoggerV1,
 	local *job.LocalFuncExecutor,
+	publish *job.ArticlePublishExecutor,
+	purge *job.ArticlePurgeExecutor,
+	flush *job.ReadCntFlushExecutor,
+	reconcile *job.CntReconcileExecutor,
+	uvRollup *job.UVRollupExecutor,
 	svc service.JobService) *job.Scheduler {
 	res := job.NewScheduler(svc, l)
 	res.RegisterExecutor(local)
+	// 定时发表
+	res.RegisterExecutor(publish)
+	// 清理回收站，和 ranking 不同，这个任务的记录在启动的时候自动注册
+	res.RegisterExecutor(purge)
+	// 合并阅读数
+	res.RegisterExecutor(flush)
+	// 校对点赞数和收藏数
+	res.RegisterExecutor(reconcile)
+	// 汇总 UV
+	res.RegisterExecutor(uvRollup)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := svc.Register(ctx, purge.Job())
+	if err != nil {
+		l.Error("注册清理回收站的任务失败", logger.Error(err))
+	}
+	err = svc.Register(ctx, flush.Job())
+	if err != nil {
+		l.Error("注册合并阅读数的任务失败", logger.Error(err))
+	}
+	err = svc.Register(ctx, reconcile.Job())
+	if err != nil {
+		l.Error("注册校对计数的任务失败", logger.Error(err))
+	}
+	err = svc.Register(ctx, uvRollup.Job())
+	if err != nil {
+		l.Error("注册汇总 UV 的任务失败", logger.Error(err))
+	}
+	err = svc.Register(ctx, domain.Job{
+		Name:     searchRebuildJob,
+		Executor: local.Name(),
+		// 每天凌晨四点重建一次
+		Cron: "0 4 * * *",
+	})
+	if err != nil {
+		l.Error("注册重建搜索索引的任务失败", logger.Error(err))
+	}
 	return res
 }
 
-func InitLocalFuncExecutor(svc service.RankingService) *job.LocalFuncExecutor {
+// searchRebuildJob 搜索索引在进程内，这个任务只会在抢到它的那个实例上跑，
+// 所以和 InMemorySearchRepository 一样，只适用于单实例部署
+const searchRebuildJob = "search_rebuild"
+
+func InitLocalFuncExecutor(svc service.RankingService,
+	searchSvc service.SearchService) *job.LocalFuncExecutor {
 	res := job.NewLocalFuncExecutor()
 	// 要在数据库里面插入一条记录。
 	// ranking job 的记录，通过管理任务接口来插入
@@ -26,5 +73,16 @@ func InitLocalFuncExecutor(svc service.RankingService) *job.LocalFuncExecutor {
 		defer cancel()
 		return svc.TopN(ctx)
 	})
+	res.RegisterFunc("ranking_all_time", func(ctx context.Context, j domain.Job) error {
+		ctx, cancel := context.WithTimeout(ctx, time.Minute*10)
+		defer cancel()
+		return svc.TopNAllTime(ctx)
+	})
+	// 增量更新搜索索引失败的时候，靠定期全量重建兜底
+	res.RegisterFunc(searchRebuildJob, func(ctx context.Context, j domain.Job) error {
+		ctx, cancel := context.WithTimeout(ctx, time.Minute)
+		defer cancel()
+		return searchSvc.RebuildArticleIndex(ctx)
+	})
 	return res
 }
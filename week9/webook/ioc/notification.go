@@ -0,0 +1,16 @@
+package ioc
+
+import (
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+)
+
+// InitNotificationService 点赞和收藏的通知发给资源的作者，现在只有帖子
+func InitNotificationService(repo repository.NotificationRepository,
+	artSvc service.ArticleService,
+	l logger.LoggerV1) service.NotificationService {
+	return service.NewNotificationService(repo, map[string]service.BizOwnerFunc{
+		"article": articleOwner(artSvc),
+	}, l)
+}
@@ -1,21 +1,159 @@
 package ioc
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gevinzone/basic-go/week9/webook/internal/job"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
 	"github.com/gevinzone/basic-go/week9/webook/internal/service"
 	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
 	rlock "github.com/gotomicro/redis-lock"
 	"github.com/robfig/cron/v3"
+	"github.com/spf13/viper"
 	"time"
 )
 
+// RankingBoardConfig ranking.boards 下面的一个榜单
+type RankingBoardConfig struct {
+	Name string `yaml:"name"`
+	// Window 比如 24h，不配就是不限制
+	Window time.Duration `yaml:"window"`
+	N      int           `yaml:"n"`
+	// Strategy 目前只有 gravity，不配就是 gravity
+	Strategy      string  `yaml:"strategy"`
+	ReadWeight    float64 `yaml:"readWeight"`
+	LikeWeight    float64 `yaml:"likeWeight"`
+	CollectWeight float64 `yaml:"collectWeight"`
+	Gravity       float64 `yaml:"gravity"`
+	// Expiration 榜单缓存多久，要比这个榜单计算的间隔长。
+	// 不配的话，配了 window 的榜单三分钟算一次，缓存十分钟；
+	// 总榜一个小时算一次，缓存两个小时
+	Expiration time.Duration `yaml:"expiration"`
+}
+
+const (
+	defaultWindowRankingExpiration  = time.Minute * 10
+	defaultAllTimeRankingExpiration = time.Hour * 2
+)
+
+// defaultRankingBoards 没有配置 ranking 的时候用这个
+var defaultRankingBoards = []RankingBoardConfig{
+	{Name: "hot-24h", Window: time.Hour * 24, N: 100,
+		ReadWeight: 0.1, LikeWeight: 1, CollectWeight: 2, Gravity: 1.8},
+	{Name: "weekly", Window: time.Hour * 24 * 7, N: 100,
+		ReadWeight: 0.1, LikeWeight: 1, CollectWeight: 2, Gravity: 1.2},
+	{Name: "all-time", N: 100,
+		ReadWeight: 0.1, LikeWeight: 1, CollectWeight: 2},
+}
+
+func InitRankingService(artSvc service.ArticleService,
+	intrSvc service.InteractiveService,
+	repo repository.RankingRepository) *service.BatchRankingService {
+	boards, err := loadRankingBoards()
+	if err != nil {
+		panic(err)
+	}
+	return service.NewBatchRankingService(artSvc, intrSvc, repo, boards)
+}
+
+// RankingConfigReloader 榜单配置变了之后重新加载。
+// viper 只保留最后注册的那个 OnConfigChange 回调，所以这里不自己注册，
+// 而是由 main 里面唯一的那个回调来调用 Reload
+type RankingConfigReloader struct {
+	svc *service.BatchRankingService
+	l   logger.LoggerV1
+}
+
+func NewRankingConfigReloader(svc *service.BatchRankingService,
+	l logger.LoggerV1) *RankingConfigReloader {
+	return &RankingConfigReloader{svc: svc, l: l}
+}
+
+func (r *RankingConfigReloader) Reload() {
+	boards, err := loadRankingBoards()
+	if err != nil {
+		// 配错了就继续用旧的，不能把榜单搞没了
+		r.l.Error("榜单配置有误，继续使用旧的配置", logger.Error(err))
+		return
+	}
+	r.svc.UpdateBoards(boards)
+}
+
+func loadRankingBoards() ([]service.RankingBoard, error) {
+	type Config struct {
+		Boards []RankingBoardConfig `yaml:"boards"`
+	}
+	var cfg Config
+	err := viper.UnmarshalKey("ranking", &cfg)
+	if err != nil {
+		return nil, err
+	}
+	// 不能直接拿默认值去 Unmarshal，切片会被改掉，而且配置里面没写的字段会沿用默认值
+	if len(cfg.Boards) == 0 {
+		cfg.Boards = defaultRankingBoards
+	}
+	res := make([]service.RankingBoard, 0, len(cfg.Boards))
+	names := make(map[string]struct{}, len(cfg.Boards))
+	for _, bc := range cfg.Boards {
+		board, err := bc.toBoard()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := names[board.Name]; ok {
+			return nil, fmt.Errorf("榜单 %s 重复了", board.Name)
+		}
+		names[board.Name] = struct{}{}
+		res = append(res, board)
+	}
+	return res, nil
+}
+
+func (c RankingBoardConfig) toBoard() (service.RankingBoard, error) {
+	if c.Name == "" {
+		return service.RankingBoard{}, errors.New("榜单没有名字")
+	}
+	if c.N <= 0 || c.Window < 0 || c.Expiration < 0 {
+		return service.RankingBoard{}, fmt.Errorf("榜单 %s 的 n、window 或者 expiration 不对", c.Name)
+	}
+	expiration := c.Expiration
+	if expiration == 0 {
+		expiration = defaultWindowRankingExpiration
+		if c.Window == 0 {
+			expiration = defaultAllTimeRankingExpiration
+		}
+	}
+	switch c.Strategy {
+	case "", "gravity":
+		// 分数不能是负数，所以权重也不能是负数
+		if c.ReadWeight < 0 || c.LikeWeight < 0 ||
+			c.CollectWeight < 0 || c.Gravity < 0 {
+			return service.RankingBoard{}, fmt.Errorf("榜单 %s 的权重不能是负数", c.Name)
+		}
+		return service.RankingBoard{
+			Name:   c.Name,
+			Window: c.Window,
+			N:      c.N,
+			Strategy: service.GravityStrategy{
+				ReadWeight:    c.ReadWeight,
+				LikeWeight:    c.LikeWeight,
+				CollectWeight: c.CollectWeight,
+				Gravity:       c.Gravity,
+			},
+			Expiration: expiration,
+		}, nil
+	default:
+		return service.RankingBoard{}, fmt.Errorf("榜单 %s 的算法 %s 不支持", c.Name, c.Strategy)
+	}
+}
+
 func InitRankingJob(svc service.RankingService,
 	rlockClient *rlock.Client,
 	l logger.LoggerV1) *job.RankingJob {
 	return job.NewRankingJob(svc, rlockClient, l, time.Second*30)
 }
 
-func InitJobs(l logger.LoggerV1, rankingJob *job.RankingJob) *cron.Cron {
+func InitJobs(l logger.LoggerV1, rankingJob *job.RankingJob,
+	svc service.RankingService, rlockClient *rlock.Client) *cron.Cron {
 	res := cron.New(cron.WithSeconds())
 	cbd := job.NewCronJobBuilder(l)
 	// 这里每三分钟一次
@@ -23,5 +161,11 @@ func InitJobs(l logger.LoggerV1, rankingJob *job.RankingJob) *cron.Cron {
 	if err != nil {
 		panic(err)
 	}
+	// 总榜要扫全部帖子，一个小时算一次，超时也给长一点
+	allTimeJob := job.NewAllTimeRankingJob(svc, rlockClient, l, time.Minute*10)
+	_, err = res.AddJob("0 0 * * * ?", cbd.Build(allTimeJob))
+	if err != nil {
+		panic(err)
+	}
 	return res
 }
@@ -14,6 +14,12 @@ func InitRedis() redis.Cmdable {
 	return redisClient
 }
 
+// InitRedisPubSubClient 发布订阅要用 Subscribe，Cmdable 里面没有，
+// InitRedis 返回的本来就是 *redis.Client
+func InitRedisPubSubClient(cmd redis.Cmdable) redis.UniversalClient {
+	return cmd.(redis.UniversalClient)
+}
+
 func InitRLockClient(cmd redis.Cmdable) *rlock.Client {
 	return rlock.NewClient(cmd)
 }
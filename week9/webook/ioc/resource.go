@@ -0,0 +1,31 @@
+package ioc
+
+import (
+	"context"
+	"github.com/ecodeclub/ekit/slice"
+	"github.com/gevinzone/basic-go/week9/webook/internal/domain"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+)
+
+// InitResourceService 注册点赞、收藏列表里面怎么展示每一种资源，现在只有帖子
+func InitResourceService(artSvc service.ArticleService,
+	l logger.LoggerV1) service.ResourceService {
+	return service.NewResourceService(map[string]service.ResourceResolver{
+		domain.BizArticle: func(ctx context.Context, bizIds []int64) ([]domain.ResourceSummary, error) {
+			arts, err := artSvc.ListPubByIds(ctx, bizIds)
+			if err != nil {
+				return nil, err
+			}
+			return slice.Map(arts, func(idx int, src domain.Article) domain.ResourceSummary {
+				return domain.ResourceSummary{
+					Resource: domain.Resource{Biz: domain.BizArticle, BizId: src.Id},
+					Title:    src.Title,
+					Abstract: src.Abstract(),
+					Author:   src.Author,
+					Utime:    src.Utime,
+				}
+			}), nil
+		},
+	}, l)
+}
@@ -0,0 +1,38 @@
+package ioc
+
+import (
+	"context"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/article"
+	"github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
+	dao "github.com/gevinzone/basic-go/week9/webook/internal/repository/dao/article"
+	"github.com/gevinzone/basic-go/week9/webook/internal/service"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"time"
+)
+
+// InitArticleRepository 线上库有变化的时候，要顺便更新搜索索引
+func InitArticleRepository(d dao.ArticleDAO,
+	userRepo repository.UserRepository,
+	c cache.ArticleCache,
+	search repository.SearchRepository,
+	l logger.LoggerV1) article.ArticleRepository {
+	repo := article.NewArticleRepository(d, userRepo, c, l)
+	return article.NewSearchableArticleRepository(repo, search, l)
+}
+
+func InitSearchService(artRepo article.ArticleRepository,
+	searchRepo repository.SearchRepository,
+	l logger.LoggerV1) service.SearchService {
+	svc := service.NewSearchService(artRepo, searchRepo, l)
+	// 索引在内存里面，启动的时候要从线上库重建一次
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		err := svc.RebuildArticleIndex(ctx)
+		if err != nil {
+			l.Error("启动时重建搜索索引失败", logger.Error(err))
+		}
+	}()
+	return svc
+}
@@ -17,11 +17,27 @@ import (
 )
 
 func InitWebServer(mdls []gin.HandlerFunc, userHdl *web.UserHandler,
-	oauth2WechatHdl *web.OAuth2WechatHandler, articleHdl *web.ArticleHandler) *gin.Engine {
+	oauth2WechatHdl *web.OAuth2WechatHandler, articleHdl *web.ArticleHandler,
+	searchHdl *web.SearchHandler,
+	commentHdl *web.CommentHandler,
+	historyHdl *web.HistoryHandler,
+	collectionHdl *web.CollectionHandler,
+	userIntrHdl *web.UserInteractiveHandler,
+	followHdl *web.FollowHandler,
+	feedHdl *web.FeedHandler,
+	notificationHdl *web.NotificationHandler) *gin.Engine {
 	server := gin.Default()
 	server.Use(mdls...)
 	userHdl.RegisterRoutes(server)
 	articleHdl.RegisterRoutes(server)
+	searchHdl.RegisterRoutes(server)
+	commentHdl.RegisterRoutes(server)
+	historyHdl.RegisterRoutes(server)
+	collectionHdl.RegisterRoutes(server)
+	userIntrHdl.RegisterRoutes(server)
+	followHdl.RegisterRoutes(server)
+	feedHdl.RegisterRoutes(server)
+	notificationHdl.RegisterRoutes(server)
 	oauth2WechatHdl.RegisterRoutes(server)
 	(&web.ObservabilityHandler{}).RegisterRoutes(server)
 	return server
@@ -37,6 +53,8 @@ func InitMiddlewares(redisClient redis.Cmdable,
 	//	ok := viper.GetBool("web.logreq")
 	//	bd.AllowReqBody(ok)
 	//})
+	// ginx 的 Wrap 系列方法出错的时候要用这个来打日志
+	ginx.L = l
 	ginx.InitCounter(prometheus.CounterOpts{
 		Namespace: "geekbang_daming",
 		Subsystem: "webook",
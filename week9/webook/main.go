@@ -37,6 +37,7 @@ func main() {
 	//setting := viper.AllSettings()
 	//fmt.Println(setting)
 	app := InitWebServer()
+	watchConfig(app)
 	// Consumer 在我设计下，类似于 Web，或者 GRPC 之类的，是一个顶级入口
 	for _, c := range app.consumers {
 		err := c.Start()
@@ -46,6 +47,10 @@ func main() {
 	}
 
 	app.cron.Start()
+	schCtx, schCancel := context.WithCancel(context.Background())
+	go func() {
+		_ = app.scheduler.Schedule(schCtx)
+	}()
 
 	server := app.web
 	server.GET("/hello", func(ctx *gin.Context) {
@@ -57,6 +62,7 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	defer cancel()
 	closeFunc(ctx)
+	schCancel()
 
 	ctx = app.cron.Stop()
 	// 想办法 close ？？
@@ -70,6 +76,19 @@ func main() {
 	//server.Run(":8081")
 }
 
+// watchConfig viper 只保留最后注册的那个回调，
+// 所以所有关心配置变更的地方都从这一个回调里面调用
+func watchConfig(app *App) {
+	// 只能告诉你文件变了，不能告诉你，文件的哪些内容变了
+	viper.OnConfigChange(func(in fsnotify.Event) {
+		// 比较好的设计，它会在 in 里面告诉你变更前的数据，和变更后的数据
+		// 更好的设计是，它会直接告诉你差异。
+		fmt.Println(in.Name, in.Op)
+		fmt.Println(viper.GetString("db.dsn"))
+		app.rankingReloader.Reload()
+	})
+}
+
 func initPrometheus() {
 	go func() {
 		http.Handle("/metrics", promhttp.Handler())
@@ -137,15 +156,8 @@ func initViperV1() {
 		"config/config.yaml", "指定配置文件路径")
 	pflag.Parse()
 	viper.SetConfigFile(*cfile)
-	// 实时监听配置变更
+	// 实时监听配置变更，回调在 InitWebServer 之后注册，见 watchConfig
 	viper.WatchConfig()
-	// 只能告诉你文件变了，不能告诉你，文件的哪些内容变了
-	viper.OnConfigChange(func(in fsnotify.Event) {
-		// 比较好的设计，它会在 in 里面告诉你变更前的数据，和变更后的数据
-		// 更好的设计是，它会直接告诉你差异。
-		fmt.Println(in.Name, in.Op)
-		fmt.Println(viper.GetString("db.dsn"))
-	})
 	//viper.SetDefault("db.mysql.dsn",
 	//	"root:root@tcp(localhost:3306)/mysql")
 	//viper.SetConfigFile("config/dev.yaml")
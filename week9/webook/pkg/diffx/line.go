@@ -0,0 +1,84 @@
+package diffx
+
+import "strings"
+
+type Op uint8
+
+const (
+	OpEqual Op = iota
+	OpInsert
+	OpDelete
+)
+
+func (o Op) String() string {
+	switch o {
+	case OpInsert:
+		return "insert"
+	case OpDelete:
+		return "delete"
+	default:
+		return "equal"
+	}
+}
+
+// Line 一行的变更
+type Line struct {
+	Op   Op
+	Text string
+}
+
+// Lines 按行对比 a 和 b，返回把 a 变成 b 的编辑序列
+// 用的是最朴素的最长公共子序列（LCS）算法，O(m*n) 的时间和空间，
+// 对于帖子这种规模的文本足够用了。真的要处理超长文本，换 Myers 算法
+func Lines(a, b string) []Line {
+	as := splitLines(a)
+	bs := splitLines(b)
+	m, n := len(as), len(bs)
+	// lcs[i][j] 表示 as[i:] 和 bs[j:] 的最长公共子序列长度
+	lcs := make([][]int, m+1)
+	for i := range lcs {
+		lcs[i] = make([]int, n+1)
+	}
+	for i := m - 1; i >= 0; i-- {
+		for j := n - 1; j >= 0; j-- {
+			if as[i] == bs[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	res := make([]Line, 0, m+n)
+	i, j := 0, 0
+	for i < m && j < n {
+		switch {
+		case as[i] == bs[j]:
+			res = append(res, Line{Op: OpEqual, Text: as[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			res = append(res, Line{Op: OpDelete, Text: as[i]})
+			i++
+		default:
+			res = append(res, Line{Op: OpInsert, Text: bs[j]})
+			j++
+		}
+	}
+	for ; i < m; i++ {
+		res = append(res, Line{Op: OpDelete, Text: as[i]})
+	}
+	for ; j < n; j++ {
+		res = append(res, Line{Op: OpInsert, Text: bs[j]})
+	}
+	return res
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
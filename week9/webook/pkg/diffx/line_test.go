@@ -0,0 +1,69 @@
+package diffx
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	testCases := []struct {
+		name string
+		a    string
+		b    string
+		want []Line
+	}{
+		{
+			name: "完全相同",
+			a:    "a\nb",
+			b:    "a\nb",
+			want: []Line{
+				{Op: OpEqual, Text: "a"},
+				{Op: OpEqual, Text: "b"},
+			},
+		},
+		{
+			name: "从空到有",
+			a:    "",
+			b:    "a\nb",
+			want: []Line{
+				{Op: OpInsert, Text: "a"},
+				{Op: OpInsert, Text: "b"},
+			},
+		},
+		{
+			name: "全部删除",
+			a:    "a\nb",
+			b:    "",
+			want: []Line{
+				{Op: OpDelete, Text: "a"},
+				{Op: OpDelete, Text: "b"},
+			},
+		},
+		{
+			name: "中间修改一行",
+			a:    "a\nb\nc",
+			b:    "a\nx\nc",
+			want: []Line{
+				{Op: OpEqual, Text: "a"},
+				{Op: OpDelete, Text: "b"},
+				{Op: OpInsert, Text: "x"},
+				{Op: OpEqual, Text: "c"},
+			},
+		},
+		{
+			name: "兼容 windows 换行",
+			a:    "a\r\nb",
+			b:    "a\nb\nc",
+			want: []Line{
+				{Op: OpEqual, Text: "a"},
+				{Op: OpEqual, Text: "b"},
+				{Op: OpInsert, Text: "c"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Lines(tc.a, tc.b))
+		})
+	}
+}
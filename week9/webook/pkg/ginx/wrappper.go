@@ -12,11 +12,18 @@ import (
 // 这个东西，放到你们的 ginx 插件库里面去
 // 技术含量不是很高，但是绝对有技巧
 
-// L 使用包变量
-var L logger.LoggerV1
+// L 使用包变量，默认什么都不记，避免忘了设置的时候 panic
+var L logger.LoggerV1 = logger.NewNoOpLogger()
 
 var vector *prometheus.CounterVec
 
+// incrCounter 没有调用过 InitCounter 的时候，就不统计
+func incrCounter(code int) {
+	if vector != nil {
+		vector.WithLabelValues(strconv.Itoa(code)).Inc()
+	}
+}
+
 func InitCounter(opt prometheus.CounterOpts) {
 	vector = prometheus.NewCounterVec(opt,
 		[]string{"code"})
@@ -51,7 +58,7 @@ func WrapToken[C jwt.Claims](fn func(ctx *gin.Context, uc C) (Result, error)) gi
 				logger.String("route", ctx.FullPath()),
 				logger.Error(err))
 		}
-		vector.WithLabelValues(strconv.Itoa(res.Code)).Inc()
+		incrCounter(res.Code)
 		ctx.JSON(http.StatusOK, res)
 		// 再执行一些东西
 	}
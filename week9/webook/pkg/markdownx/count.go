@@ -0,0 +1,57 @@
+package markdownx
+
+import (
+	"math"
+	"unicode"
+)
+
+// 阅读速度，中文按照字算，英文按照单词算
+const (
+	cjkPerMinute  = 300
+	wordPerMinute = 200
+)
+
+// CountWords 统计字数。中日韩文字一个字算一个，
+// 其余的按照连续的字母和数字算一个单词
+func CountWords(text string) int {
+	cjk, words := count(text)
+	return cjk + words
+}
+
+// ReadMinutes 估算阅读时间，向上取整，有内容的话至少一分钟
+func ReadMinutes(text string) int {
+	cjk, words := count(text)
+	if cjk+words == 0 {
+		return 0
+	}
+	// 两部分加起来再取整，避免各自取整多算
+	return int(math.Ceil(float64(cjk)/cjkPerMinute + float64(words)/wordPerMinute))
+}
+
+func count(text string) (cjk int, words int) {
+	inWord := false
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			cjk++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+				inWord = true
+			}
+		case r == '\'' || r == '-':
+			// don't 和 well-known 都算一个单词
+		default:
+			inWord = false
+		}
+	}
+	return
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
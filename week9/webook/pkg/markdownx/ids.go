@@ -0,0 +1,54 @@
+package markdownx
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// headingIDs 生成标题的锚点。
+// goldmark 默认只保留 ASCII 字符，中文标题会变成空的，所以自己实现一个
+type headingIDs struct {
+	used map[string]struct{}
+}
+
+func newHeadingIDs() *headingIDs {
+	return &headingIDs{used: make(map[string]struct{})}
+}
+
+func (s *headingIDs) Generate(value []byte, kind ast.NodeKind) []byte {
+	var sb strings.Builder
+	dash := false
+	for _, r := range strings.TrimSpace(string(value)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			sb.WriteRune(unicode.ToLower(r))
+			dash = false
+		case unicode.IsSpace(r) || r == '-':
+			if !dash && sb.Len() > 0 {
+				sb.WriteByte('-')
+				dash = true
+			}
+		}
+	}
+	id := strings.TrimSuffix(sb.String(), "-")
+	if id == "" {
+		id = "heading"
+	}
+	// 重复的标题依次加上 -1、-2
+	res := id
+	for i := 1; ; i++ {
+		if _, ok := s.used[res]; !ok {
+			break
+		}
+		res = id + "-" + strconv.Itoa(i)
+	}
+	s.used[res] = struct{}{}
+	return []byte(res)
+}
+
+func (s *headingIDs) Put(value []byte) {
+	s.used[string(value)] = struct{}{}
+}
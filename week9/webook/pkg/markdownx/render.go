@@ -0,0 +1,155 @@
+package markdownx
+
+import (
+	"bytes"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+)
+
+// Heading 目录里面的一项
+type Heading struct {
+	Level int
+	Text  string
+	// Id 渲染之后标题上的锚点
+	Id string
+}
+
+// Result 渲染的结果
+type Result struct {
+	// HTML 已经过滤过，可以直接输出给前端
+	HTML string
+	TOC  []Heading
+	// Text 去掉了 Markdown 标记的纯文本
+	Text        string
+	WordCnt     int
+	ReadMinutes int
+}
+
+// Renderer 把 Markdown 渲染成安全的 HTML。
+// Markdown 本身允许内嵌 HTML，所以渲染的时候不做限制，
+// 最后统一交给白名单过滤，只有白名单一个地方决定什么是安全的
+type Renderer struct {
+	md     goldmark.Markdown
+	policy *bluemonday.Policy
+}
+
+// plainMD 只用来解析，不需要生成标题的 id
+var plainMD = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+func NewRenderer() *Renderer {
+	return &Renderer{
+		md: goldmark.New(
+			goldmark.WithExtensions(extension.GFM),
+			goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+			goldmark.WithRendererOptions(html.WithUnsafe()),
+		),
+		policy: newPolicy(),
+	}
+}
+
+func newPolicy() *bluemonday.Policy {
+	// UGCPolicy 已经去掉了 script、style、事件属性和 javascript: 之类的链接
+	p := bluemonday.UGCPolicy()
+	// 目录要靠标题上的 id 跳转
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)).
+		OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	// 代码高亮要用到语言
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#-]+$`)).
+		OnElements("code")
+	// GFM 的任务列表
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+func (r *Renderer) Render(src string) (Result, error) {
+	source := []byte(src)
+	// 每次渲染的锚点要各自去重，所以每次都用新的 Context
+	ctx := parser.NewContext(parser.WithIDs(newHeadingIDs()))
+	doc := r.md.Parser().Parse(text.NewReader(source), parser.WithContext(ctx))
+	var buf bytes.Buffer
+	if err := r.md.Renderer().Render(&buf, source, doc); err != nil {
+		return Result{}, err
+	}
+	plain := plainText(doc, source)
+	return Result{
+		HTML:        r.policy.Sanitize(buf.String()),
+		TOC:         toc(doc, source),
+		Text:        plain,
+		WordCnt:     CountWords(plain),
+		ReadMinutes: ReadMinutes(plain),
+	}, nil
+}
+
+// PlainText 去掉 Markdown 标记，只保留文字
+func PlainText(src string) string {
+	source := []byte(src)
+	doc := plainMD.Parser().Parse(text.NewReader(source))
+	return plainText(doc, source)
+}
+
+func toc(doc ast.Node, source []byte) []Heading {
+	var res []Heading
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		var id string
+		if v, ok := h.AttributeString("id"); ok {
+			if bs, ok := v.([]byte); ok {
+				id = string(bs)
+			}
+		}
+		res = append(res, Heading{
+			Level: h.Level,
+			Text:  plainText(h, source),
+			Id:    id,
+		})
+		return ast.WalkSkipChildren, nil
+	})
+	return res
+}
+
+func plainText(doc ast.Node, source []byte) string {
+	var buf bytes.Buffer
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			// 块之间换行，避免上一段的结尾和下一段的开头粘在一起
+			if n.Type() == ast.TypeBlock && n != doc && buf.Len() > 0 &&
+				buf.Bytes()[buf.Len()-1] != '\n' {
+				buf.WriteByte('\n')
+			}
+			return ast.WalkContinue, nil
+		}
+		switch node := n.(type) {
+		case *ast.Text:
+			buf.Write(node.Segment.Value(source))
+			if node.SoftLineBreak() || node.HardLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *ast.String:
+			buf.Write(node.Value)
+		case *ast.AutoLink:
+			buf.Write(node.Label(source))
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			lines := n.Lines()
+			for i := 0; i < lines.Len(); i++ {
+				seg := lines.At(i)
+				buf.Write(seg.Value(source))
+			}
+		case *ast.RawHTML, *ast.HTMLBlock, *ast.Image:
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return string(bytes.TrimSpace(buf.Bytes()))
+}
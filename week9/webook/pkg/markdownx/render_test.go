@@ -0,0 +1,124 @@
+package markdownx
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestRenderer_Render(t *testing.T) {
+	testCases := []struct {
+		name string
+		src  string
+		// 渲染结果里面必须有的片段
+		contains []string
+		// 渲染结果里面不能有的片段
+		excludes []string
+		wantTOC  []Heading
+	}{
+		{
+			name: "基本语法",
+			src:  "# 标题\n\n一段 **粗体** 和 `code`\n\n```go\nfmt.Println()\n```",
+			contains: []string{
+				`<h1 id="标题">标题</h1>`,
+				"<strong>粗体</strong>",
+				"<code>code</code>",
+				`<code class="language-go">`,
+			},
+			wantTOC: []Heading{{Level: 1, Text: "标题", Id: "标题"}},
+		},
+		{
+			name: "过滤脚本",
+			src: "<script>alert(1)</script>\n\n" +
+				`<img src="a.png" onerror="alert(1)">` + "\n\n" +
+				"[点我](javascript:alert(1))\n\n" +
+				`<a href="http://a.com" onclick="alert(1)">外链</a>`,
+			contains: []string{`<img src="a.png">`, "点我", `rel="nofollow noopener"`},
+			excludes: []string{"<script", "onerror", "javascript:", "onclick"},
+		},
+		{
+			name: "重复的标题",
+			src:  "## Hello World\n\n## Hello World\n\n### 小结！",
+			contains: []string{
+				`<h2 id="hello-world">`,
+				`<h2 id="hello-world-1">`,
+				`<h3 id="小结">`,
+			},
+			wantTOC: []Heading{
+				{Level: 2, Text: "Hello World", Id: "hello-world"},
+				{Level: 2, Text: "Hello World", Id: "hello-world-1"},
+				{Level: 3, Text: "小结！", Id: "小结"},
+			},
+		},
+	}
+	r := NewRenderer()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := r.Render(tc.src)
+			require.NoError(t, err)
+			for _, s := range tc.contains {
+				assert.Contains(t, res.HTML, s)
+			}
+			for _, s := range tc.excludes {
+				assert.NotContains(t, res.HTML, s)
+			}
+			assert.Equal(t, tc.wantTOC, res.TOC)
+		})
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	testCases := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "去掉标记",
+			src:  "# 标题\n\n一段 **粗体** 和 [链接](http://a.com)\n换行",
+			want: "标题\n一段 粗体 和 链接 换行",
+		},
+		{
+			name: "去掉 HTML 和图片",
+			src:  "<div>原始 HTML</div>\n\n正文![图片](a.png)",
+			want: "正文",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, PlainText(tc.src))
+		})
+	}
+}
+
+func TestReadMinutes(t *testing.T) {
+	testCases := []struct {
+		name        string
+		text        string
+		wantWordCnt int
+		wantMinutes int
+	}{
+		{
+			name: "空",
+		},
+		{
+			name:        "中英文混合",
+			text:        "Go 语言 don't panic",
+			wantWordCnt: 5,
+			wantMinutes: 1,
+		},
+		{
+			name:        "长中文",
+			text:        strings.Repeat("字", 601),
+			wantWordCnt: 601,
+			wantMinutes: 3,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.wantWordCnt, CountWords(tc.text))
+			assert.Equal(t, tc.wantMinutes, ReadMinutes(tc.text))
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package objstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidKey = errors.New("非法的对象 key")
+
+// LocalStore 本地文件系统的实现，开发、测试或者单机部署的时候用。
+// 预签名的链接由 Handler 负责校验和下载
+type LocalStore struct {
+	dir string
+	// baseURL Handler 挂载的地址，比如说 http://localhost:8080/objects
+	baseURL string
+	secret  []byte
+	now     func() time.Time
+}
+
+func NewLocalStore(dir, baseURL string, secret []byte) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		secret:  secret,
+		now:     time.Now,
+	}, nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// Content-Type 放在旁边的一个文件里面，下载的时候原样返回。
+	// 先写它再写内容，读者能读到内容的时候，Content-Type 一定已经在了
+	if err = writeFile(typePath(path), []byte(contentType)); err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
+
+// writeFile 先写临时文件再重命名，读者不会读到写了一半的内容
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *LocalStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrObjectNotFound
+	}
+	return data, err
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	err = os.Remove(typePath(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// contentType 没有记录的话，就根据内容猜一个
+func (s *LocalStore) contentType(key string, data []byte) string {
+	path, err := s.path(key)
+	if err == nil {
+		val, err := os.ReadFile(typePath(path))
+		if err == nil && len(val) > 0 {
+			return string(val)
+		}
+	}
+	return http.DetectContentType(data)
+}
+
+func (s *LocalStore) PresignedURL(ctx context.Context, key string, expire time.Duration) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	expires := s.now().Add(expire).Unix()
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expires, 10))
+	q.Set("sign", s.sign(key, expires))
+	return fmt.Sprintf("%s/%s?%s", s.baseURL, escapeKey(key), q.Encode()), nil
+}
+
+// Handler 校验预签名链接并且返回对象的内容，要挂载在 baseURL 上，比如说
+// server.GET("/objects/*key", gin.WrapH(http.StripPrefix("/objects", store.Handler())))
+func (s *LocalStore) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+		if err != nil || !s.verify(key, expires, r.URL.Query().Get("sign")) {
+			http.Error(w, "签名不对或者已经过期", http.StatusForbidden)
+			return
+		}
+		data, err := s.Get(r.Context(), key)
+		switch {
+		case errors.Is(err, ErrObjectNotFound):
+			http.NotFound(w, r)
+		case err != nil:
+			http.Error(w, "系统错误", http.StatusInternalServerError)
+		default:
+			w.Header().Set("Content-Type", s.contentType(key, data))
+			_, _ = w.Write(data)
+		}
+	})
+}
+
+func (s *LocalStore) verify(key string, expires int64, sign string) bool {
+	if s.now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(sign), []byte(s.sign(key, expires)))
+}
+
+func (s *LocalStore) sign(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path 把 key 转换成文件路径，不允许通过 .. 之类的跑到 dir 外面去
+func (s *LocalStore) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." ||
+			strings.HasPrefix(seg, ".tmp-") || strings.HasPrefix(seg, ".type-") {
+			return "", ErrInvalidKey
+		}
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// typePath 记录 Content-Type 的文件，以 .type- 开头的 key 是非法的，所以不会和对象冲突
+func typePath(path string) string {
+	return filepath.Join(filepath.Dir(path), ".type-"+filepath.Base(path))
+}
+
+func escapeKey(key string) string {
+	segs := strings.Split(key, "/")
+	for i, seg := range segs {
+		segs[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segs, "/")
+}
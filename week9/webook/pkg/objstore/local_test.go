@@ -0,0 +1,89 @@
+package objstore
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "http://localhost/objects", []byte("secret"))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = store.Get(ctx, "1/100")
+	assert.Equal(t, ErrObjectNotFound, err)
+
+	require.NoError(t, store.Put(ctx, "1/100", []byte("内容"), "text/plain"))
+	data, err := store.Get(ctx, "1/100")
+	require.NoError(t, err)
+	assert.Equal(t, "内容", string(data))
+
+	// 覆盖写
+	require.NoError(t, store.Put(ctx, "1/100", []byte("新的内容"), "text/plain"))
+	data, err = store.Get(ctx, "1/100")
+	require.NoError(t, err)
+	assert.Equal(t, "新的内容", string(data))
+
+	require.NoError(t, store.Delete(ctx, "1/100"))
+	_, err = store.Get(ctx, "1/100")
+	assert.Equal(t, ErrObjectNotFound, err)
+	// 删除不存在的对象不报错
+	assert.NoError(t, store.Delete(ctx, "1/100"))
+}
+
+func TestLocalStore_InvalidKey(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "http://localhost/objects", []byte("secret"))
+	require.NoError(t, err)
+	ctx := context.Background()
+	keys := []string{"", "/etc/passwd", "../a", "a/../../b", "a//b", "a/.tmp-1", "a/.type-1"}
+	for _, key := range keys {
+		assert.Equal(t, ErrInvalidKey, store.Put(ctx, key, []byte("x"), "text/plain"), key)
+		_, err = store.Get(ctx, key)
+		assert.Equal(t, ErrInvalidKey, err, key)
+	}
+}
+
+func TestLocalStore_PresignedURL(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir(), "http://localhost/objects", []byte("secret"))
+	require.NoError(t, err)
+	now := time.UnixMilli(1700000000000)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+	require.NoError(t, store.Put(ctx, "1/100", []byte("内容"), "text/plain;charset=utf-8"))
+
+	link, err := store.PresignedURL(ctx, "1/100", time.Minute)
+	require.NoError(t, err)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	assert.Equal(t, "/objects/1/100", u.Path)
+
+	handler := http.StripPrefix("/objects", store.Handler())
+	serve := func(target string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, target, nil))
+		return resp
+	}
+
+	resp := serve(u.RequestURI())
+	assert.Equal(t, http.StatusOK, resp.Code)
+	// 上传的时候给的 Content-Type
+	assert.Equal(t, "text/plain;charset=utf-8", resp.Header().Get("Content-Type"))
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "内容", string(body))
+
+	// 换一个 key，签名就对不上了
+	resp = serve("/objects/1/101?" + u.RawQuery)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	// 过期了
+	now = now.Add(time.Minute + time.Second)
+	resp = serve(u.RequestURI())
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
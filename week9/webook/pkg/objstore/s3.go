@@ -0,0 +1,70 @@
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/ecodeclub/ekit"
+	"io"
+	"time"
+)
+
+// S3Store 基于 S3 协议的实现，腾讯云的 COS 和阿里云的 OSS 都兼容这个协议
+type S3Store struct {
+	client *s3.S3
+	bucket *string
+}
+
+func NewS3Store(client *s3.S3, bucket string) *S3Store {
+	return &S3Store{
+		client: client,
+		bucket: ekit.ToPtr[string](bucket),
+	}
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	_, err := s.client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+		Bucket:      s.bucket,
+		Key:         ekit.ToPtr[string](key),
+		Body:        bytes.NewReader(data),
+		ContentType: ekit.ToPtr[string](contentType),
+	})
+	return err
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	res, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: s.bucket,
+		Key:    ekit.ToPtr[string](key),
+	})
+	if err != nil {
+		var aerr awserr.Error
+		if errors.As(err, &aerr) && aerr.Code() == s3.ErrCodeNoSuchKey {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	defer res.Body.Close()
+	return io.ReadAll(res.Body)
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	// S3 删除不存在的对象也是成功的
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: s.bucket,
+		Key:    ekit.ToPtr[string](key),
+	})
+	return err
+}
+
+func (s *S3Store) PresignedURL(ctx context.Context, key string, expire time.Duration) (string, error) {
+	// 签名是在本地计算的，不会发请求
+	req, _ := s.client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: s.bucket,
+		Key:    ekit.ToPtr[string](key),
+	})
+	req.SetContext(ctx)
+	return req.Presign(expire)
+}
@@ -0,0 +1,22 @@
+package objstore
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrObjectNotFound 对象不存在
+var ErrObjectNotFound = errors.New("对象不存在")
+
+// ObjectStore 对象存储的抽象，可以是 S3（以及兼容 S3 的 COS、OSS），也可以是本地文件系统
+type ObjectStore interface {
+	// Put 覆盖写入，写入要么完整成功，要么对读者不可见
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get 对象不存在的时候返回 ErrObjectNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete 对象不存在也不会报错
+	Delete(ctx context.Context, key string) error
+	// PresignedURL 生成一个在 expire 之内有效的下载链接，前端可以直接下载，不需要经过我们的服务器
+	PresignedURL(ctx context.Context, key string, expire time.Duration) (string, error)
+}
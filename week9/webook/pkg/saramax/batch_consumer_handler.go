@@ -15,7 +15,12 @@ type BatchHandler[T any] struct {
 	// 用 option 模式来设置这个 batchSize 和 duration
 	batchSize     int
 	batchDuration time.Duration
-	counter       prometheus.Counter
+	// retryInterval 一批处理失败之后，隔多久重试
+	retryInterval time.Duration
+	// maxRetries 最多重试几次，还是失败就放弃这一批，不然一条坏消息会卡住整个分区
+	maxRetries int
+	counter    prometheus.Counter
+	idempotency[T]
 }
 
 func NewBatchHandler[T any](l logger.LoggerV1, fn func(msgs []*sarama.ConsumerMessage, ts []T) error) *BatchHandler[T] {
@@ -24,13 +29,15 @@ func NewBatchHandler[T any](l logger.LoggerV1, fn func(msgs []*sarama.ConsumerMe
 		fn:            fn,
 		batchDuration: time.Second,
 		batchSize:     10,
-		counter: (&CounterBuilder{
-			Namespace:  "geekbang-gevin",
-			Subsystem:  "webook",
-			Name:       "sarama_consume_msg",
-			Help:       "统计批量消费错误次数",
-			InstanceID: "my-instance-1",
-		}).Build()}
+		retryInterval: time.Second,
+		maxRetries:    3,
+		counter:       batchConsumeErrCounter()}
+}
+
+// WithIdempotency 开启幂等消费，一批里面处理过的消息会被过滤掉
+func (b *BatchHandler[T]) WithIdempotency(store IdempotencyStore, keyFn KeyFunc[T]) *BatchHandler[T] {
+	b.idempotency = idempotency[T]{store: store, keyFn: keyFn}
+	return b
 }
 
 func (b *BatchHandler[T]) Setup(session sarama.ConsumerGroupSession) error {
@@ -77,18 +84,72 @@ func (b *BatchHandler[T]) ConsumeClaim(session sarama.ConsumerGroupSession, clai
 		if len(msgs) == 0 {
 			continue
 		}
-		err := b.fn(msgs, ts)
-		if err != nil {
-			b.l.Error("调用业务批量接口失败",
-				logger.Error(err))
+		// 失败了先重试，rebalance 的时候退出，没有提交的这一批会重新投递。
+		// 重试了 maxRetries 次还是失败，多半是数据本身有问题，记下来然后跳过
+		for i := 0; ; i++ {
+			err := b.consume(session.Context(), msgs, ts)
+			if err == nil {
+				break
+			}
 			b.counter.Inc()
-			// 你这里整个批次都要记下来
-
-			// 还要继续往前消费
+			if i >= b.maxRetries {
+				b.l.Error("调用业务批量接口失败，放弃这一批",
+					logger.Error(err),
+					logger.String("topic", msgs[0].Topic),
+					logger.Int64("partition", int64(msgs[0].Partition)),
+					logger.Int64("from", msgs[0].Offset),
+					logger.Int64("to", msgs[len(msgs)-1].Offset))
+				break
+			}
+			b.l.Error("调用业务批量接口失败，稍后重试",
+				logger.Error(err),
+				logger.String("topic", msgs[0].Topic),
+				logger.Int64("partition", int64(msgs[0].Partition)),
+				logger.Int64("offset", msgs[0].Offset))
+			select {
+			case <-session.Context().Done():
+				return nil
+			case <-time.After(b.retryInterval):
+			}
 		}
 		for _, msg := range msgs {
-			// 这样，万无一失
 			session.MarkMessage(msg, "")
 		}
 	}
 }
+
+// consume 去掉重复的消息之后调用业务方法
+func (b *BatchHandler[T]) consume(ctx context.Context,
+	msgs []*sarama.ConsumerMessage, ts []T) error {
+	idxs, claimed, err := b.claim(ctx, msgs, ts)
+	if err != nil {
+		b.l.Error("幂等检查失败，整批继续处理", logger.Error(err))
+	}
+	if len(idxs) == 0 {
+		return nil
+	}
+	if len(idxs) < len(msgs) {
+		b.l.Debug("过滤重复的消息",
+			logger.Int32("cnt", int32(len(msgs)-len(idxs))))
+		filteredMsgs := make([]*sarama.ConsumerMessage, 0, len(idxs))
+		filteredTs := make([]T, 0, len(idxs))
+		for _, idx := range idxs {
+			filteredMsgs = append(filteredMsgs, msgs[idx])
+			filteredTs = append(filteredTs, ts[idx])
+		}
+		msgs, ts = filteredMsgs, filteredTs
+	}
+	err = b.fn(msgs, ts)
+	if err != nil {
+		// 让重试的时候还可以再处理
+		if er := b.release(ctx, claimed); er != nil {
+			b.l.Error("释放幂等 key 失败", logger.Error(er))
+		}
+		return err
+	}
+	if er := b.done(ctx, claimed); er != nil {
+		// 处理中的状态过期之后，重复投递的消息会再处理一遍
+		b.l.Error("标记幂等 key 失败", logger.Error(er))
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package saramax
+
+import (
+	"context"
+	"errors"
+	"github.com/IBM/sarama"
+	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// fakeSession 只记录提交了哪些消息
+type fakeSession struct {
+	sarama.ConsumerGroupSession
+	ctx    context.Context
+	marked []int64
+}
+
+func (s *fakeSession) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.marked = append(s.marked, msg.Offset)
+}
+
+type fakeClaim struct {
+	sarama.ConsumerGroupClaim
+	msgs chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage {
+	return c.msgs
+}
+
+type testEvent struct {
+	Aid int64 `json:"aid"`
+}
+
+func TestBatchHandler_ConsumeClaim(t *testing.T) {
+	testCases := []struct {
+		name string
+		// 第几次调用之前都返回错误
+		failTimes int
+
+		wantCalls int
+	}{
+		{
+			name:      "重试之后成功",
+			failTimes: 2,
+			wantCalls: 3,
+		},
+		{
+			// 一直失败也不能卡住整个分区，放弃这一批，照样提交
+			name:      "重试次数用完了",
+			failTimes: 100,
+			wantCalls: 4,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			h := NewBatchHandler[testEvent](&logger.NopLogger{},
+				func(msgs []*sarama.ConsumerMessage, ts []testEvent) error {
+					calls++
+					if calls <= tc.failTimes {
+						return errors.New("mock error")
+					}
+					return nil
+				})
+			h.batchDuration = time.Millisecond * 10
+			h.retryInterval = time.Millisecond
+
+			claim := &fakeClaim{msgs: make(chan *sarama.ConsumerMessage, 2)}
+			claim.msgs <- &sarama.ConsumerMessage{Offset: 1, Value: []byte(`{"aid":1}`)}
+			claim.msgs <- &sarama.ConsumerMessage{Offset: 2, Value: []byte(`{"aid":2}`)}
+			session := &fakeSession{ctx: context.Background()}
+			go func() {
+				time.Sleep(time.Millisecond * 100)
+				close(claim.msgs)
+			}()
+			err := h.ConsumeClaim(session, claim)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantCalls, calls)
+			assert.Equal(t, []int64{1, 2}, session.marked)
+		})
+	}
+}
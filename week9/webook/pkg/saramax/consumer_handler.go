@@ -5,6 +5,7 @@ import (
 	"github.com/IBM/sarama"
 	"github.com/gevinzone/basic-go/week9/webook/pkg/logger"
 	"github.com/prometheus/client_golang/prometheus"
+	"sync"
 )
 
 type HandlerV1[T any] func(msg *sarama.ConsumerMessage, t T) error
@@ -13,22 +14,23 @@ type Handler[T any] struct {
 	l       logger.LoggerV1
 	fn      func(msg *sarama.ConsumerMessage, t T) error
 	counter prometheus.Counter
+	idempotency[T]
 }
 
 func NewHandler[T any](l logger.LoggerV1, fn func(msg *sarama.ConsumerMessage, t T) error) *Handler[T] {
 	return &Handler[T]{
-		l:  l,
-		fn: fn,
-		counter: (&CounterBuilder{
-			Namespace:  "geekbang-gevin",
-			Subsystem:  "webook",
-			Name:       "sarama_consume_msg",
-			Help:       "统计消费错误次数",
-			InstanceID: "my-instance-1",
-		}).Build(),
+		l:       l,
+		fn:      fn,
+		counter: consumeErrCounter(),
 	}
 }
 
+// WithIdempotency 开启幂等消费，处理过的消息直接跳过
+func (h *Handler[T]) WithIdempotency(store IdempotencyStore, keyFn KeyFunc[T]) *Handler[T] {
+	h.idempotency = idempotency[T]{store: store, keyFn: keyFn}
+	return h
+}
+
 func (h Handler[T]) Setup(session sarama.ConsumerGroupSession) error {
 	return nil
 }
@@ -50,6 +52,20 @@ func (h Handler[T]) ConsumeClaim(session sarama.ConsumerGroupSession, claim sara
 				logger.Int64("offset", msg.Offset))
 			continue
 		}
+		idxs, claimed, err := h.claim(session.Context(),
+			[]*sarama.ConsumerMessage{msg}, []T{t})
+		if err != nil {
+			h.l.Error("幂等检查失败，继续处理",
+				logger.Error(err),
+				logger.String("topic", msg.Topic),
+				logger.Int64("partition", int64(msg.Partition)),
+				logger.Int64("offset", msg.Offset))
+		}
+		if len(idxs) == 0 {
+			// 重复的消息
+			session.MarkMessage(msg, "")
+			continue
+		}
 		// 在这里执行重试
 		for i := 0; i < 3; i++ {
 			err = h.fn(msg, t)
@@ -70,7 +86,15 @@ func (h Handler[T]) ConsumeClaim(session sarama.ConsumerGroupSession, claim sara
 				logger.Int64("partition", int64(msg.Partition)),
 				logger.Int64("offset", msg.Offset))
 			h.counter.Inc()
+			// 让重新投递的消息还可以再处理
+			if er := h.release(session.Context(), claimed); er != nil {
+				h.l.Error("释放幂等 key 失败", logger.Error(er))
+			}
 		} else {
+			if er := h.done(session.Context(), claimed); er != nil {
+				// 处理中的状态过期之后，重复投递的消息会再处理一遍
+				h.l.Error("标记幂等 key 失败", logger.Error(er))
+			}
 			session.MarkMessage(msg, "")
 		}
 	}
@@ -98,3 +122,39 @@ func (c *CounterBuilder) Build() prometheus.Counter {
 	prometheus.MustRegister(counter)
 	return counter
 }
+
+var (
+	consumeErrCounterOnce      sync.Once
+	consumeErrCounterVal       prometheus.Counter
+	batchConsumeErrCounterOnce sync.Once
+	batchConsumeErrCounterVal  prometheus.Counter
+)
+
+// consumeErrCounter 所有的 Handler 共用一个指标，只能注册一次
+func consumeErrCounter() prometheus.Counter {
+	consumeErrCounterOnce.Do(func() {
+		consumeErrCounterVal = (&CounterBuilder{
+			Namespace:  "geekbang_daming",
+			Subsystem:  "webook",
+			Name:       "sarama_consume_msg",
+			Help:       "统计消费错误次数",
+			InstanceID: "my-instance-1",
+		}).Build()
+	})
+	return consumeErrCounterVal
+}
+
+// batchConsumeErrCounter 所有的 BatchHandler 共用一个指标，
+// 名字不能和 Handler 的一样
+func batchConsumeErrCounter() prometheus.Counter {
+	batchConsumeErrCounterOnce.Do(func() {
+		batchConsumeErrCounterVal = (&CounterBuilder{
+			Namespace:  "geekbang_daming",
+			Subsystem:  "webook",
+			Name:       "sarama_batch_consume_msg",
+			Help:       "统计批量消费错误次数",
+			InstanceID: "my-instance-1",
+		}).Build()
+	})
+	return batchConsumeErrCounterVal
+}
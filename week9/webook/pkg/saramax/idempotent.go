@@ -0,0 +1,233 @@
+package saramax
+
+import (
+	"context"
+	"github.com/IBM/sarama"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// IdempotencyStore 记录处理过的消息，用来去掉重复投递的消息。
+// 比如说 rebalance 之后，还没有提交的消息会再来一遍。
+// 一个 key 先被 Claim 成处理中，业务成功之后 Done 成处理过了，失败了就 Release，
+// 中间崩溃了的话，处理中的状态很快过期，重新投递的消息还可以再处理
+type IdempotencyStore interface {
+	// Claim 把这些 key 占住，标记成处理中，返回的结果和 keys 一一对应
+	Claim(ctx context.Context, keys ...string) ([]ClaimStatus, error)
+	// Done 业务处理成功之后调用，之后重复投递的消息都会被跳过
+	Done(ctx context.Context, keys ...string) error
+	// Release 业务处理失败的时候释放，重新投递的时候还可以再处理
+	Release(ctx context.Context, keys ...string) error
+}
+
+type ClaimStatus uint8
+
+const (
+	// ClaimStatusClaimed 占住了，可以处理
+	ClaimStatusClaimed ClaimStatus = iota
+	// ClaimStatusDone 已经处理过了
+	ClaimStatusDone
+	// ClaimStatusBusy 别的消费者正在处理，比如说 rebalance 之前的消费者还没处理完
+	ClaimStatusBusy
+)
+
+// KeyFunc 从消息里面拿到去重用的 key，返回空字符串的消息不去重
+type KeyFunc[T any] func(msg *sarama.ConsumerMessage, t T) string
+
+// MessageKey 用 Kafka 消息本身的 key 去重
+func MessageKey[T any](msg *sarama.ConsumerMessage, t T) string {
+	return string(msg.Key)
+}
+
+const (
+	valProcessing = "processing"
+	valDone       = "done"
+)
+
+// RedisIdempotencyStore 用 SETNX 占住 key。
+// processingTTL 要比处理一条消息（一批消息）的时间长，
+// ttl 要比消息可能重复投递的时间窗口长
+type RedisIdempotencyStore struct {
+	client        redis.Cmdable
+	prefix        string
+	processingTTL time.Duration
+	ttl           time.Duration
+}
+
+func NewRedisIdempotencyStore(client redis.Cmdable,
+	prefix string, processingTTL, ttl time.Duration) IdempotencyStore {
+	return &RedisIdempotencyStore{
+		client:        client,
+		prefix:        prefix,
+		processingTTL: processingTTL,
+		ttl:           ttl,
+	}
+}
+
+func (r *RedisIdempotencyStore) Claim(ctx context.Context, keys ...string) ([]ClaimStatus, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	pipe := r.client.Pipeline()
+	cmds := make([]*redis.BoolCmd, 0, len(keys))
+	for _, key := range keys {
+		cmds = append(cmds, pipe.SetNX(ctx, r.prefix+key, valProcessing, r.processingTTL))
+	}
+	_, err := pipe.Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]ClaimStatus, len(keys))
+	// 没占住的，再看看是处理过了还是正在处理
+	pipe = r.client.Pipeline()
+	gets := make(map[int]*redis.StringCmd, len(keys))
+	for i, cmd := range cmds {
+		if !cmd.Val() {
+			gets[i] = pipe.Get(ctx, r.prefix+keys[i])
+		}
+	}
+	if len(gets) == 0 {
+		return res, nil
+	}
+	_, err = pipe.Exec(ctx)
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	for i, cmd := range gets {
+		if cmd.Val() == valDone {
+			res[i] = ClaimStatusDone
+		} else {
+			// 包括刚好过期了的，下一次 Claim 就能占住
+			res[i] = ClaimStatusBusy
+		}
+	}
+	return res, nil
+}
+
+func (r *RedisIdempotencyStore) Done(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	pipe := r.client.Pipeline()
+	for _, key := range keys {
+		pipe.Set(ctx, r.prefix+key, valDone, r.ttl)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisIdempotencyStore) Release(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	fullKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		fullKeys = append(fullKeys, r.prefix+key)
+	}
+	return r.client.Del(ctx, fullKeys...).Err()
+}
+
+// busyRetryInterval 别的消费者正在处理的时候，隔多久再试一次
+var busyRetryInterval = time.Second
+
+// idempotency Handler 和 BatchHandler 共用的去重逻辑，store 为 nil 的时候不去重
+type idempotency[T any] struct {
+	store IdempotencyStore
+	keyFn KeyFunc[T]
+}
+
+// claim 返回没有处理过的消息的下标，以及这一次占住的 key，
+// 同一批里面重复的消息只处理第一条。
+// 别的消费者正在处理的 key 会一直等到它处理完或者过期，
+// store 出错的时候返回全部消息，宁可重复处理也不要丢消息
+func (i idempotency[T]) claim(ctx context.Context,
+	msgs []*sarama.ConsumerMessage, ts []T) ([]int, []string, error) {
+	res := make([]int, 0, len(msgs))
+	if i.store == nil {
+		for idx := range msgs {
+			res = append(res, idx)
+		}
+		return res, nil, nil
+	}
+	keys := make([]string, len(msgs))
+	// 同一批里面重复的
+	dup := make([]bool, len(msgs))
+	seen := make(map[string]struct{}, len(msgs))
+	uniq := make([]string, 0, len(msgs))
+	for idx, msg := range msgs {
+		key := i.keyFn(msg, ts[idx])
+		if key == "" {
+			continue
+		}
+		if _, ok := seen[key]; ok {
+			dup[idx] = true
+			continue
+		}
+		seen[key] = struct{}{}
+		keys[idx] = key
+		uniq = append(uniq, key)
+	}
+	status, err := i.claimKeys(ctx, uniq)
+	claimed := make([]string, 0, len(uniq))
+	for idx, key := range keys {
+		st, ok := status[key]
+		switch {
+		case dup[idx]:
+			// 第一条处理了就可以了
+		case key == "":
+			res = append(res, idx)
+		case ok && st == ClaimStatusClaimed:
+			res = append(res, idx)
+			claimed = append(claimed, key)
+		case !ok && err != nil:
+			res = append(res, idx)
+		}
+	}
+	return res, claimed, err
+}
+
+// claimKeys 占住 keys，返回的结果里面没有 ClaimStatusBusy，
+// 出错的时候返回已经有结果的部分
+func (i idempotency[T]) claimKeys(ctx context.Context,
+	keys []string) (map[string]ClaimStatus, error) {
+	res := make(map[string]ClaimStatus, len(keys))
+	for len(keys) > 0 {
+		status, err := i.store.Claim(ctx, keys...)
+		if err != nil {
+			return res, err
+		}
+		busy := make([]string, 0, len(keys))
+		for j, key := range keys {
+			if status[j] == ClaimStatusBusy {
+				busy = append(busy, key)
+				continue
+			}
+			res[key] = status[j]
+		}
+		keys = busy
+		if len(keys) == 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return res, ctx.Err()
+		case <-time.After(busyRetryInterval):
+		}
+	}
+	return res, nil
+}
+
+// done 业务处理成功之后标记处理过了
+func (i idempotency[T]) done(ctx context.Context, keys []string) error {
+	if i.store == nil || len(keys) == 0 {
+		return nil
+	}
+	return i.store.Done(ctx, keys...)
+}
+
+func (i idempotency[T]) release(ctx context.Context, keys []string) error {
+	if i.store == nil || len(keys) == 0 {
+		return nil
+	}
+	return i.store.Release(ctx, keys...)
+}
@@ -0,0 +1,118 @@
+package saramax
+
+import (
+	"context"
+	"errors"
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// memoryStore 测试用，err 不为 nil 的时候 Claim 直接返回错误。
+// busy 里面的 key 在别的消费者手里，Claim 一次减一，减到 0 就算过期了
+type memoryStore struct {
+	keys map[string]ClaimStatus
+	busy map[string]int
+	err  error
+}
+
+func (m *memoryStore) Claim(ctx context.Context, keys ...string) ([]ClaimStatus, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	res := make([]ClaimStatus, 0, len(keys))
+	for _, key := range keys {
+		if m.busy[key] > 0 {
+			m.busy[key]--
+			res = append(res, ClaimStatusBusy)
+			continue
+		}
+		st, ok := m.keys[key]
+		switch {
+		case !ok:
+			m.keys[key] = ClaimStatusBusy
+			res = append(res, ClaimStatusClaimed)
+		case st == ClaimStatusDone:
+			res = append(res, ClaimStatusDone)
+		default:
+			res = append(res, ClaimStatusBusy)
+		}
+	}
+	return res, nil
+}
+
+func (m *memoryStore) Done(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		m.keys[key] = ClaimStatusDone
+	}
+	return nil
+}
+
+func (m *memoryStore) Release(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		delete(m.keys, key)
+	}
+	return nil
+}
+
+func TestIdempotency_Claim(t *testing.T) {
+	busyRetryInterval = time.Millisecond
+	msgs := []*sarama.ConsumerMessage{
+		{Key: []byte("a")},
+		{Key: []byte("b")},
+		// 没有 key 的不去重
+		{},
+		// 同一批里面重复的
+		{Key: []byte("a")},
+	}
+	ts := make([]int, len(msgs))
+	ctx := context.Background()
+
+	store := &memoryStore{keys: map[string]ClaimStatus{"b": ClaimStatusDone}}
+	i := idempotency[int]{store: store, keyFn: MessageKey[int]}
+	idxs, claimed, err := i.claim(ctx, msgs, ts)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 2}, idxs)
+	assert.Equal(t, []string{"a"}, claimed)
+
+	// 失败释放之后可以再处理
+	assert.NoError(t, i.release(ctx, claimed))
+	idxs, claimed, err = i.claim(ctx, msgs[:1], ts[:1])
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0}, idxs)
+
+	// 成功之后就是重复的了
+	assert.NoError(t, i.done(ctx, claimed))
+	idxs, _, err = i.claim(ctx, msgs[:1], ts[:1])
+	assert.NoError(t, err)
+	assert.Empty(t, idxs)
+
+	// 别的消费者正在处理，等它过期
+	store.busy = map[string]int{"c": 2}
+	idxs, claimed, err = i.claim(ctx, []*sarama.ConsumerMessage{{Key: []byte("c")}}, ts[:1])
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0}, idxs)
+	assert.Equal(t, []string{"c"}, claimed)
+
+	// 一直在处理，等到超时
+	store.busy = map[string]int{"d": 1000}
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer cancel()
+	idxs, claimed, err = i.claim(timeoutCtx, []*sarama.ConsumerMessage{{Key: []byte("d")}}, ts[:1])
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, []int{0}, idxs)
+	assert.Empty(t, claimed)
+
+	// store 出错的时候全部处理
+	store.err = errors.New("mock redis error")
+	idxs, claimed, err = i.claim(ctx, msgs, ts)
+	assert.Equal(t, store.err, err)
+	assert.Equal(t, []int{0, 1, 2}, idxs)
+	assert.Empty(t, claimed)
+
+	// 没有开启幂等
+	idxs, _, err = idempotency[int]{}.claim(ctx, msgs, ts)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3}, idxs)
+}
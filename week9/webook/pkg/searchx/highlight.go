@@ -0,0 +1,109 @@
+package searchx
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Highlight 从 text 里面截取一段不超过 width 个字的片段，
+// 并且用 pre 和 post 把命中查询的部分包起来。
+// 片段本身会做 HTML 转义，pre 和 post 不会
+func Highlight(text, query, pre, post string, width int) string {
+	rs := []rune(text)
+	// 逐个字符转小写，保证下标和原文一一对应
+	lower := make([]rune, len(rs))
+	for i, r := range rs {
+		lower[i] = unicode.ToLower(r)
+	}
+	spans := matchSpans(lower, dedup(TokenizeQuery(query)))
+
+	start := 0
+	if len(spans) > 0 {
+		// 第一个命中的位置前面留一点上下文
+		start = spans[0][0] - width/4
+		if start < 0 {
+			start = 0
+		}
+	}
+	end := start + width
+	if end > len(rs) {
+		end = len(rs)
+		start = end - width
+		if start < 0 {
+			start = 0
+		}
+	}
+
+	var sb strings.Builder
+	if start > 0 {
+		sb.WriteString("...")
+	}
+	cur := start
+	for _, span := range spans {
+		s, e := span[0], span[1]
+		if e <= cur || s >= end {
+			continue
+		}
+		if s < cur {
+			s = cur
+		}
+		if e > end {
+			e = end
+		}
+		sb.WriteString(html.EscapeString(string(rs[cur:s])))
+		sb.WriteString(pre)
+		sb.WriteString(html.EscapeString(string(rs[s:e])))
+		sb.WriteString(post)
+		cur = e
+	}
+	sb.WriteString(html.EscapeString(string(rs[cur:end])))
+	if end < len(rs) {
+		sb.WriteString("...")
+	}
+	return sb.String()
+}
+
+// matchSpans 找到所有命中的区间，按照开始位置排序并且合并重叠的部分
+func matchSpans(text []rune, terms []string) [][2]int {
+	var spans [][2]int
+	for _, term := range terms {
+		tr := []rune(term)
+		if len(tr) == 0 {
+			continue
+		}
+		for i := 0; i+len(tr) <= len(text); i++ {
+			if hasPrefix(text[i:], tr) {
+				spans = append(spans, [2]int{i, i + len(tr)})
+			}
+		}
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i][0] < spans[j][0]
+	})
+	res := spans[:1]
+	for _, span := range spans[1:] {
+		last := &res[len(res)-1]
+		if span[0] <= last[1] {
+			if span[1] > last[1] {
+				last[1] = span[1]
+			}
+			continue
+		}
+		res = append(res, span)
+	}
+	return res
+}
+
+func hasPrefix(text, prefix []rune) bool {
+	for i, r := range prefix {
+		if text[i] != r {
+			return false
+		}
+	}
+	return true
+}
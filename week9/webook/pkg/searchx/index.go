@@ -0,0 +1,211 @@
+package searchx
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// BM25 的两个参数，取的是常见的默认值
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Doc 被索引的文档
+type Doc struct {
+	Id int64
+	// Fields 参与检索的字段，字段名要和 NewIndex 的权重对应上
+	Fields map[string]string
+	// Data 原样保存，检索命中的时候原样返回，索引本身不关心
+	Data any
+}
+
+// Hit 一条命中的结果
+type Hit struct {
+	Doc   Doc
+	Score float64
+}
+
+// Index 进程内的倒排索引，并发安全。
+// 打分用的是 BM25，不同字段的词频按照权重叠加之后再计算
+type Index struct {
+	mu      sync.RWMutex
+	weights map[string]float64
+	// 词 => 文档 => 加权之后的词频
+	postings map[string]map[int64]float64
+	docs     map[int64]indexedDoc
+	// 所有文档加权之后的总长度，用来计算平均长度
+	totalLen float64
+}
+
+type indexedDoc struct {
+	doc Doc
+	// 删除的时候要知道这个文档出现在哪些词里面
+	terms []string
+	len   float64
+}
+
+// NewIndex 创建索引，weights 是每个字段的权重，不在里面的字段不会被索引
+func NewIndex(weights map[string]float64) *Index {
+	return &Index{
+		weights:  weights,
+		postings: make(map[string]map[int64]float64),
+		docs:     make(map[int64]indexedDoc),
+	}
+}
+
+// Put 插入或者覆盖文档
+func (idx *Index) Put(doc Doc) {
+	tfs, l := idx.analyze(doc)
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(doc.Id)
+	idx.add(doc, tfs, l)
+}
+
+// Delete 删除文档，文档不存在也不会报错
+func (idx *Index) Delete(id int64) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+}
+
+// Replace 用 docs 替换掉索引里面的全部文档。
+// 新的索引在锁外面构建好再一次性换上去，所以重建期间不影响查询
+func (idx *Index) Replace(docs []Doc) {
+	fresh := NewIndex(idx.weights)
+	for _, doc := range docs {
+		tfs, l := fresh.analyze(doc)
+		fresh.remove(doc.Id)
+		fresh.add(doc, tfs, l)
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.postings = fresh.postings
+	idx.docs = fresh.docs
+	idx.totalLen = fresh.totalLen
+}
+
+// Len 文档数量
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Search 按照相关度从高到低返回 [offset, offset+limit) 的结果，以及命中的总数。
+// offset 小于 0 的时候当成 0，limit 不是正数的时候只返回总数
+func (idx *Index) Search(query string, offset, limit int) ([]Hit, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit < 0 {
+		limit = 0
+	}
+	terms := dedup(TokenizeQuery(query))
+	if len(terms) == 0 {
+		return nil, 0
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	n := float64(len(idx.docs))
+	if n == 0 {
+		return nil, 0
+	}
+	avgLen := idx.totalLen / n
+	scores := make(map[int64]float64)
+	for _, term := range terms {
+		posting := idx.postings[term]
+		if len(posting) == 0 {
+			continue
+		}
+		df := float64(len(posting))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, tf := range posting {
+			dl := idx.docs[id].len
+			scores[id] += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*dl/avgLen))
+		}
+	}
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{Doc: idx.docs[id].doc, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		// 分数一样的时候，新的文档排前面，保证分页稳定
+		return hits[i].Doc.Id > hits[j].Doc.Id
+	})
+	total := len(hits)
+	if offset >= total {
+		return nil, total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return hits[offset:end], total
+}
+
+func (idx *Index) analyze(doc Doc) (map[string]float64, float64) {
+	tfs := make(map[string]float64)
+	var l float64
+	for field, text := range doc.Fields {
+		w, ok := idx.weights[field]
+		if !ok {
+			continue
+		}
+		for _, term := range Tokenize(text) {
+			tfs[term] += w
+			l += w
+		}
+	}
+	return tfs, l
+}
+
+// add 和 remove 都要求调用者持有写锁
+func (idx *Index) add(doc Doc, tfs map[string]float64, l float64) {
+	terms := make([]string, 0, len(tfs))
+	for term, tf := range tfs {
+		posting, ok := idx.postings[term]
+		if !ok {
+			posting = make(map[int64]float64)
+			idx.postings[term] = posting
+		}
+		posting[doc.Id] = tf
+		terms = append(terms, term)
+	}
+	idx.docs[doc.Id] = indexedDoc{doc: doc, terms: terms, len: l}
+	idx.totalLen += l
+}
+
+func (idx *Index) remove(id int64) {
+	old, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for _, term := range old.terms {
+		posting := idx.postings[term]
+		delete(posting, id)
+		if len(posting) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	idx.totalLen -= old.len
+	delete(idx.docs, id)
+}
+
+func dedup(terms []string) []string {
+	seen := make(map[string]struct{}, len(terms))
+	res := terms[:0]
+	for _, term := range terms {
+		if _, ok := seen[term]; ok {
+			continue
+		}
+		seen[term] = struct{}{}
+		res = append(res, term)
+	}
+	return res
+}
@@ -0,0 +1,154 @@
+package searchx
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	testCases := []struct {
+		name  string
+		text  string
+		query bool
+		want  []string
+	}{
+		{
+			name: "英文",
+			text: "Hello, Go-Lang 2023!",
+			want: []string{"hello", "go", "lang", "2023"},
+		},
+		{
+			name: "中文建索引",
+			text: "微服务",
+			want: []string{"微", "服", "务", "微服", "服务"},
+		},
+		{
+			name:  "中文查询",
+			text:  "微服务",
+			query: true,
+			want:  []string{"微服", "服务"},
+		},
+		{
+			name:  "中文单字查询",
+			text:  "微",
+			query: true,
+			want:  []string{"微"},
+		},
+		{
+			name: "中英混合",
+			text: "用Go写微服务",
+			want: []string{"用", "go", "写", "微", "服", "务", "写微", "微服", "服务"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := analyze(tc.text, tc.query)
+			assert.Equal(t, tc.want, res)
+		})
+	}
+}
+
+func TestIndex_Search(t *testing.T) {
+	idx := NewIndex(map[string]float64{"title": 3, "content": 1})
+	idx.Put(Doc{Id: 1, Fields: map[string]string{
+		"title":   "Go 语言入门",
+		"content": "介绍一下 Go 的基本语法",
+	}})
+	idx.Put(Doc{Id: 2, Fields: map[string]string{
+		"title":   "MySQL 索引",
+		"content": "在 Go 里面使用 MySQL",
+	}})
+	idx.Put(Doc{Id: 3, Fields: map[string]string{
+		"title":   "Redis",
+		"content": "缓存",
+	}})
+
+	// 标题命中的权重更高
+	hits, total := idx.Search("go", 0, 10)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, []int64{1, 2}, ids(hits))
+
+	hits, total = idx.Search("mysql 索引", 0, 10)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, []int64{2}, ids(hits))
+
+	// 分页
+	hits, total = idx.Search("go", 1, 10)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, []int64{2}, ids(hits))
+
+	// 负数的 offset 和 limit 不能 panic
+	hits, total = idx.Search("go", -1, 10)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, []int64{1, 2}, ids(hits))
+	hits, total = idx.Search("go", 0, -1)
+	assert.Equal(t, 2, total)
+	assert.Empty(t, hits)
+
+	// 覆盖之后，旧的词不再命中
+	idx.Put(Doc{Id: 1, Fields: map[string]string{"title": "Kafka"}})
+	hits, _ = idx.Search("go", 0, 10)
+	assert.Equal(t, []int64{2}, ids(hits))
+
+	idx.Delete(2)
+	_, total = idx.Search("go", 0, 10)
+	assert.Equal(t, 0, total)
+
+	idx.Replace([]Doc{{Id: 4, Fields: map[string]string{"title": "Go"}}})
+	assert.Equal(t, 1, idx.Len())
+	hits, _ = idx.Search("go", 0, 10)
+	assert.Equal(t, []int64{4}, ids(hits))
+}
+
+func TestHighlight(t *testing.T) {
+	testCases := []struct {
+		name  string
+		text  string
+		query string
+		width int
+		want  string
+	}{
+		{
+			name:  "英文不区分大小写",
+			text:  "Learn GO fast",
+			query: "go",
+			width: 100,
+			want:  "Learn <em>GO</em> fast",
+		},
+		{
+			name:  "中文相邻的 bigram 合并",
+			text:  "如何设计微服务架构",
+			query: "微服务",
+			width: 100,
+			want:  "如何设计<em>微服务</em>架构",
+		},
+		{
+			name:  "截断并且转义",
+			text:  "<b>0123456789</b> go 0123456789",
+			query: "go",
+			width: 8,
+			want:  "...&gt; <em>go</em> 012...",
+		},
+		{
+			name:  "没有命中",
+			text:  "0123456789",
+			query: "go",
+			width: 4,
+			want:  "0123...",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := Highlight(tc.text, tc.query, "<em>", "</em>", tc.width)
+			assert.Equal(t, tc.want, res)
+		})
+	}
+}
+
+func ids(hits []Hit) []int64 {
+	res := make([]int64, 0, len(hits))
+	for _, hit := range hits {
+		res = append(res, hit.Doc.Id)
+	}
+	return res
+}
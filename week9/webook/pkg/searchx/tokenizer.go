@@ -0,0 +1,72 @@
+package searchx
+
+import "unicode"
+
+// Tokenize 切分用于建索引的文本。
+// 英文和数字按照单词切分，统一转成小写；
+// 中日韩文字之间没有分隔符，所以同时输出单字和相邻两个字（bigram），
+// 单字用来支持只输入一个字的查询，bigram 用来提高多字查询的准确度
+func Tokenize(text string) []string {
+	return analyze(text, false)
+}
+
+// TokenizeQuery 切分查询。和 Tokenize 的区别在于，
+// 连续的中日韩文字只要超过一个字，就只输出 bigram
+func TokenizeQuery(text string) []string {
+	return analyze(text, true)
+}
+
+func analyze(text string, query bool) []string {
+	var (
+		res  []string
+		word []rune
+		cjk  []rune
+	)
+	flushWord := func() {
+		if len(word) > 0 {
+			res = append(res, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 0:
+			return
+		case len(cjk) == 1:
+			res = append(res, string(cjk))
+		default:
+			if !query {
+				for _, r := range cjk {
+					res = append(res, string(r))
+				}
+			}
+			for i := 0; i+1 < len(cjk); i++ {
+				res = append(res, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, unicode.ToLower(r))
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return res
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) ||
+		unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r)
+}
@@ -4,11 +4,14 @@ package main
 
 import (
 	"github.com/gevinzone/basic-go/week9/webook/internal/events/article"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/feed"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/follow"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/interactive"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/notification"
+	"github.com/gevinzone/basic-go/week9/webook/internal/job"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
-	article2 "github.com/gevinzone/basic-go/week9/webook/internal/repository/article"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository/dao"
-	article3 "github.com/gevinzone/basic-go/week9/webook/internal/repository/dao/article"
 	"github.com/gevinzone/basic-go/week9/webook/internal/service"
 	"github.com/gevinzone/basic-go/week9/webook/internal/web"
 	ijwt "github.com/gevinzone/basic-go/week9/webook/internal/web/jwt"
@@ -21,46 +24,124 @@ var interactiveSvcProvider = wire.NewSet(
 	repository.NewCachedInteractiveRepository,
 	dao.NewGORMInteractiveDAO,
 	cache.NewRedisInteractiveCache,
+	repository.NewCollectionDBRepository,
+	dao.NewGORMCollectionDAO,
+	interactive.NewKafkaProducer,
+)
+
+var followSvcProvider = wire.NewSet(
+	dao.NewGORMFollowDAO,
+	cache.NewRedisFollowCache,
+	repository.NewCachedFollowRepository,
+	follow.NewKafkaProducer,
+	service.NewFollowService,
+)
+
+var feedSvcProvider = wire.NewSet(
+	cache.NewRedisFeedCache,
+	repository.NewCachedFeedRepository,
+	service.NewFeedService,
+)
+
+var interactiveLiveSvcProvider = wire.NewSet(
+	ioc.InitRedisPubSubClient,
+	cache.NewRedisInteractiveChangeSubscriber,
+	repository.NewCachedInteractiveChangeRepository,
+	service.NewLiveInteractiveService,
+)
+
+var notificationSvcProvider = wire.NewSet(
+	dao.NewGORMNotificationDAO,
+	cache.NewRedisNotificationCache,
+	repository.NewCachedNotificationRepository,
+	ioc.InitNotificationService,
+)
+
+var jobProviderSet = wire.NewSet(
+	dao.NewGORMJobDAO,
+	repository.NewPreemptCronJobRepository,
+	service.NewCronJobService,
+	job.NewArticlePublishExecutor,
+	job.NewArticlePurgeExecutor,
+	job.NewReadCntFlushExecutor,
+	job.NewCntReconcileExecutor,
+	job.NewUVRollupExecutor,
+	ioc.InitLocalFuncExecutor,
+	ioc.InitScheduler,
+)
+
+var commentSvcProvider = wire.NewSet(
+	dao.NewGORMCommentDAO,
+	repository.NewCachedCommentRepository,
+	ioc.InitCommentService,
+)
+
+var historySvcProvider = wire.NewSet(
+	dao.NewGORMHistoryDAO,
+	repository.NewHistoryDBRepository,
+	service.NewHistoryService,
 )
 
 var rankingServiceSet = wire.NewSet(
 	repository.NewCachedRankingRepository,
 	cache.NewRankingRedisCache,
-	service.NewBatchRankingService,
+	cache.NewRankingLocalCache,
+	ioc.InitRankingService,
+	wire.Bind(new(service.RankingService), new(*service.BatchRankingService)),
+	ioc.NewRankingConfigReloader,
 )
 
 func InitWebServer() *App {
 	wire.Build(
 		// 最基础的第三方依赖
 		ioc.InitDB, ioc.InitRedis,
+		ioc.InitRLockClient,
 		ioc.InitLogger,
 		ioc.InitKafka,
 		ioc.NewConsumers,
 		ioc.NewSyncProducer,
 
 		interactiveSvcProvider,
+		interactiveLiveSvcProvider,
+		commentSvcProvider,
+		historySvcProvider,
+		followSvcProvider,
+		feedSvcProvider,
+		notificationSvcProvider,
+		service.NewCollectionService,
 		rankingServiceSet,
 		ioc.InitJobs,
 		ioc.InitRankingJob,
+		jobProviderSet,
 
 		// consumer
 		article.NewInteractiveReadEventBatchConsumer,
+		ioc.InitIdempotencyStore,
+		article.NewHistoryReadEventConsumer,
+		article.NewInteractiveUVEventBatchConsumer,
+		feed.NewPublishEventConsumer,
+		notification.NewInteractiveEventConsumer,
+		notification.NewFollowEventConsumer,
 		article.NewKafkaProducer,
 
 		// 初始化 DAO
 		dao.NewUserDAO,
-		article3.NewGORMArticleDAO,
+		ioc.InitArticleDAO,
 
 		cache.NewUserCache,
 		cache.NewCodeCache,
+		cache.NewRedisArticleCache,
 
 		repository.NewUserRepository,
 		repository.NewCodeRepository,
-		article2.NewArticleRepository,
+		repository.NewInMemorySearchRepository,
+		ioc.InitArticleRepository,
 
 		service.NewUserService,
 		service.NewCodeService,
 		service.NewArticleService,
+		ioc.InitSearchService,
+		ioc.InitResourceService,
 
 		// 直接基于内存实现
 		ioc.InitSMSService,
@@ -68,6 +149,14 @@ func InitWebServer() *App {
 
 		web.NewUserHandler,
 		web.NewArticleHandler,
+		web.NewSearchHandler,
+		web.NewCommentHandler,
+		web.NewHistoryHandler,
+		web.NewCollectionHandler,
+		web.NewUserInteractiveHandler,
+		web.NewFollowHandler,
+		web.NewFeedHandler,
+		web.NewNotificationHandler,
 		web.NewOAuth2WechatHandler,
 		//ioc.NewWechatHandlerConfig,
 		ijwt.NewRedisJWTHandler,
@@ -8,11 +8,14 @@ package main
 
 import (
 	article3 "github.com/gevinzone/basic-go/week9/webook/internal/events/article"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/feed"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/follow"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/interactive"
+	"github.com/gevinzone/basic-go/week9/webook/internal/events/notification"
+	"github.com/gevinzone/basic-go/week9/webook/internal/job"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository"
-	article2 "github.com/gevinzone/basic-go/week9/webook/internal/repository/article"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository/cache"
 	"github.com/gevinzone/basic-go/week9/webook/internal/repository/dao"
-	"github.com/gevinzone/basic-go/week9/webook/internal/repository/dao/article"
 	"github.com/gevinzone/basic-go/week9/webook/internal/service"
 	"github.com/gevinzone/basic-go/week9/webook/internal/web"
 	"github.com/gevinzone/basic-go/week9/webook/internal/web/jwt"
@@ -40,36 +43,111 @@ func InitWebServer() *App {
 	codeRepository := repository.NewCodeRepository(codeCache)
 	smsService := ioc.InitSMSService(cmdable)
 	codeService := service.NewCodeService(codeRepository, smsService)
-	userHandler := web.NewUserHandler(userService, codeService, handler)
-	wechatService := ioc.InitWechatService(loggerV1)
-	oAuth2WechatHandler := web.NewOAuth2WechatHandler(wechatService, userService, handler)
-	articleDAO := article.NewGORMArticleDAO(db)
-	articleRepository := article2.NewArticleRepository(articleDAO, loggerV1)
+	followDAO := dao.NewGORMFollowDAO(db)
+	followCache := cache.NewRedisFollowCache(cmdable)
+	followRepository := repository.NewCachedFollowRepository(followDAO, followCache, loggerV1)
 	client := ioc.InitKafka()
 	syncProducer := ioc.NewSyncProducer(client)
+	followProducer := follow.NewKafkaProducer(syncProducer)
+	followService := service.NewFollowService(followRepository, userRepository, followProducer, loggerV1)
+	userHandler := web.NewUserHandler(userService, codeService, followService, handler)
+	wechatService := ioc.InitWechatService(loggerV1)
+	oAuth2WechatHandler := web.NewOAuth2WechatHandler(wechatService, userService, handler)
+	articleDAO := ioc.InitArticleDAO(db)
+	articleCache := cache.NewRedisArticleCache(cmdable)
+	searchRepository := repository.NewInMemorySearchRepository()
+	articleRepository := ioc.InitArticleRepository(articleDAO, userRepository, articleCache, searchRepository, loggerV1)
 	producer := article3.NewKafkaProducer(syncProducer)
-	articleService := service.NewArticleService(articleRepository, loggerV1, producer)
-	articleHandler := web.NewArticleHandler(articleService, loggerV1)
-	engine := ioc.InitWebServer(v, userHandler, oAuth2WechatHandler, articleHandler)
-	interactiveDAO := dao.NewGORMInteractiveDAO(db)
+	jobDAO := dao.NewGORMJobDAO(db)
+	jobRepository := repository.NewPreemptCronJobRepository(jobDAO)
+	jobService := service.NewCronJobService(jobRepository, loggerV1)
+	articleService := service.NewArticleService(articleRepository, loggerV1, producer, jobService)
+	searchService := ioc.InitSearchService(articleRepository, searchRepository, loggerV1)
+	searchHandler := web.NewSearchHandler(searchService)
+	commentDAO := dao.NewGORMCommentDAO(db)
 	interactiveCache := cache.NewRedisInteractiveCache(cmdable)
+	commentRepository := repository.NewCachedCommentRepository(commentDAO, interactiveCache, loggerV1)
+	interactiveProducer := interactive.NewKafkaProducer(syncProducer)
+	commentService := ioc.InitCommentService(commentRepository, articleService, interactiveProducer, loggerV1)
+	commentHandler := web.NewCommentHandler(commentService)
+	historyDAO := dao.NewGORMHistoryDAO(db)
+	historyRepository := repository.NewHistoryDBRepository(historyDAO)
+	historyService := service.NewHistoryService(historyRepository)
+	historyHandler := web.NewHistoryHandler(historyService)
+	collectionDAO := dao.NewGORMCollectionDAO(db)
+	collectionRepository := repository.NewCollectionDBRepository(collectionDAO)
+	collectionService := service.NewCollectionService(collectionRepository)
+	interactiveDAO := dao.NewGORMInteractiveDAO(db)
 	interactiveRepository := repository.NewCachedInteractiveRepository(interactiveDAO, interactiveCache, loggerV1)
-	interactiveReadEventBatchConsumer := article3.NewInteractiveReadEventBatchConsumer(client, interactiveRepository, loggerV1)
-	v2 := ioc.NewConsumers(interactiveReadEventBatchConsumer)
-	interactiveService := service.NewInteractiveService(interactiveRepository, loggerV1)
-	rankingService := service.NewBatchRankingService(articleService, interactiveService)
-	rankingJob := ioc.InitRankingJob(rankingService)
-	cron := ioc.InitJobs(loggerV1, rankingJob)
+	interactiveService := service.NewInteractiveService(interactiveRepository, collectionRepository, interactiveProducer, loggerV1)
+	universalClient := ioc.InitRedisPubSubClient(cmdable)
+	interactiveChangeSubscriber := cache.NewRedisInteractiveChangeSubscriber(universalClient)
+	interactiveChangeRepository := repository.NewCachedInteractiveChangeRepository(interactiveChangeSubscriber)
+	interactiveLiveService := service.NewLiveInteractiveService(interactiveRepository, interactiveChangeRepository, loggerV1)
+	articleHandler := web.NewArticleHandler(articleService, interactiveService, followService, interactiveLiveService, loggerV1)
+	collectionHandler := web.NewCollectionHandler(collectionService, interactiveService, articleService)
+	resourceService := ioc.InitResourceService(articleService, loggerV1)
+	userInteractiveHandler := web.NewUserInteractiveHandler(interactiveService, collectionService, resourceService)
+	followHandler := web.NewFollowHandler(followService)
+	feedCache := cache.NewRedisFeedCache(cmdable)
+	feedRepository := repository.NewCachedFeedRepository(feedCache)
+	feedService := service.NewFeedService(feedRepository, followRepository, articleService, loggerV1)
+	feedHandler := web.NewFeedHandler(feedService)
+	notificationDAO := dao.NewGORMNotificationDAO(db)
+	notificationCache := cache.NewRedisNotificationCache(cmdable)
+	notificationRepository := repository.NewCachedNotificationRepository(notificationDAO, notificationCache, loggerV1)
+	notificationService := ioc.InitNotificationService(notificationRepository, articleService, loggerV1)
+	notificationHandler := web.NewNotificationHandler(notificationService)
+	engine := ioc.InitWebServer(v, userHandler, oAuth2WechatHandler, articleHandler, searchHandler, commentHandler, historyHandler, collectionHandler, userInteractiveHandler, followHandler, feedHandler, notificationHandler)
+	idempotencyStore := ioc.InitIdempotencyStore(cmdable)
+	interactiveReadEventBatchConsumer := article3.NewInteractiveReadEventBatchConsumer(client, interactiveRepository, loggerV1, idempotencyStore)
+	historyReadEventConsumer := article3.NewHistoryReadEventConsumer(client, loggerV1, historyRepository)
+	interactiveUVEventBatchConsumer := article3.NewInteractiveUVEventBatchConsumer(client, interactiveRepository, loggerV1)
+	publishEventConsumer := feed.NewPublishEventConsumer(client, feedService, loggerV1)
+	interactiveEventConsumer := notification.NewInteractiveEventConsumer(client, notificationService, loggerV1, idempotencyStore)
+	followEventConsumer := notification.NewFollowEventConsumer(client, notificationService, loggerV1, idempotencyStore)
+	v2 := ioc.NewConsumers(interactiveReadEventBatchConsumer, historyReadEventConsumer, interactiveUVEventBatchConsumer, publishEventConsumer, interactiveEventConsumer, followEventConsumer)
+	rankingRedisCache := cache.NewRankingRedisCache(cmdable)
+	rankingLocalCache := cache.NewRankingLocalCache()
+	rankingRepository := repository.NewCachedRankingRepository(rankingRedisCache, rankingLocalCache)
+	batchRankingService := ioc.InitRankingService(articleService, interactiveService, rankingRepository)
+	rlockClient := ioc.InitRLockClient(cmdable)
+	rankingJob := ioc.InitRankingJob(batchRankingService, rlockClient, loggerV1)
+	cron := ioc.InitJobs(loggerV1, rankingJob, batchRankingService, rlockClient)
+	localFuncExecutor := ioc.InitLocalFuncExecutor(batchRankingService, searchService)
+	articlePublishExecutor := job.NewArticlePublishExecutor(articleService)
+	articlePurgeExecutor := job.NewArticlePurgeExecutor(articleService, interactiveService, loggerV1)
+	readCntFlushExecutor := job.NewReadCntFlushExecutor(interactiveService, loggerV1)
+	cntReconcileExecutor := job.NewCntReconcileExecutor(interactiveService, loggerV1)
+	uvRollupExecutor := job.NewUVRollupExecutor(interactiveService, loggerV1)
+	scheduler := ioc.InitScheduler(loggerV1, localFuncExecutor, articlePublishExecutor, articlePurgeExecutor, readCntFlushExecutor, cntReconcileExecutor, uvRollupExecutor, jobService)
+	rankingConfigReloader := ioc.NewRankingConfigReloader(batchRankingService, loggerV1)
 	app := &App{
-		web:       engine,
-		consumers: v2,
-		cron:      cron,
+		web:             engine,
+		consumers:       v2,
+		cron:            cron,
+		scheduler:       scheduler,
+		rankingReloader: rankingConfigReloader,
 	}
 	return app
 }
 
 // wire.go:
 
-var interactiveSvcProvider = wire.NewSet(service.NewInteractiveService, repository.NewCachedInteractiveRepository, dao.NewGORMInteractiveDAO, cache.NewRedisInteractiveCache)
+var interactiveSvcProvider = wire.NewSet(service.NewInteractiveService, repository.NewCachedInteractiveRepository, dao.NewGORMInteractiveDAO, cache.NewRedisInteractiveCache, repository.NewCollectionDBRepository, dao.NewGORMCollectionDAO, interactive.NewKafkaProducer)
+
+var followSvcProvider = wire.NewSet(dao.NewGORMFollowDAO, cache.NewRedisFollowCache, repository.NewCachedFollowRepository, follow.NewKafkaProducer, service.NewFollowService)
+
+var feedSvcProvider = wire.NewSet(cache.NewRedisFeedCache, repository.NewCachedFeedRepository, service.NewFeedService)
+
+var interactiveLiveSvcProvider = wire.NewSet(ioc.InitRedisPubSubClient, cache.NewRedisInteractiveChangeSubscriber, repository.NewCachedInteractiveChangeRepository, service.NewLiveInteractiveService)
+
+var notificationSvcProvider = wire.NewSet(dao.NewGORMNotificationDAO, cache.NewRedisNotificationCache, repository.NewCachedNotificationRepository, ioc.InitNotificationService)
+
+var jobProviderSet = wire.NewSet(dao.NewGORMJobDAO, repository.NewPreemptCronJobRepository, service.NewCronJobService, job.NewArticlePublishExecutor, job.NewArticlePurgeExecutor, job.NewReadCntFlushExecutor, job.NewCntReconcileExecutor, job.NewUVRollupExecutor, ioc.InitLocalFuncExecutor, ioc.InitScheduler)
+
+var commentSvcProvider = wire.NewSet(dao.NewGORMCommentDAO, repository.NewCachedCommentRepository, ioc.InitCommentService)
+
+var historySvcProvider = wire.NewSet(dao.NewGORMHistoryDAO, repository.NewHistoryDBRepository, service.NewHistoryService)
 
-var rankingServiceSet = wire.NewSet(repository.NewCachedRankingRepository, cache.NewRankingRedisCache, service.NewBatchRankingService)
+var rankingServiceSet = wire.NewSet(repository.NewCachedRankingRepository, cache.NewRankingRedisCache, cache.NewRankingLocalCache, ioc.InitRankingService, wire.Bind(new(service.RankingService), new(*service.BatchRankingService)), ioc.NewRankingConfigReloader)